@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,10 +19,14 @@ import (
 	_ "github.com/libopenstorage/stork/drivers/volume/portworx"
 	"github.com/libopenstorage/stork/pkg/apis"
 	"github.com/libopenstorage/stork/pkg/applicationmanager"
+	"github.com/libopenstorage/stork/pkg/audit"
 	"github.com/libopenstorage/stork/pkg/clusterdomains"
+	"github.com/libopenstorage/stork/pkg/controllers"
 	"github.com/libopenstorage/stork/pkg/dbg"
 	"github.com/libopenstorage/stork/pkg/extender"
 	"github.com/libopenstorage/stork/pkg/groupsnapshot"
+	groupsnapshotcontrollers "github.com/libopenstorage/stork/pkg/groupsnapshot/controllers"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/metrics"
 	"github.com/libopenstorage/stork/pkg/migration"
 	"github.com/libopenstorage/stork/pkg/monitor"
@@ -48,11 +53,12 @@ import (
 )
 
 const (
-	defaultLockObjectName      = "stork"
-	defaultLockObjectNamespace = "kube-system"
-	defaultAdminNamespace      = "kube-system"
-	eventComponentName         = "stork"
-	debugFilePath              = "/var/cores"
+	defaultLockObjectName        = "stork"
+	defaultLockObjectNamespace   = "kube-system"
+	defaultAdminNamespace        = "kube-system"
+	defaultOptionalResourceKinds = "Job,APIService"
+	defaultEventComponentName    = "stork"
+	debugFilePath                = "/var/cores"
 )
 
 var ext *extender.Extender
@@ -127,7 +133,7 @@ func main() {
 		cli.StringFlag{
 			Name:  "admin-namespace",
 			Value: defaultAdminNamespace,
-			Usage: "Namespace to be used by a cluster admin which can migrate and backup all other namespaces",
+			Usage: "Namespace to be used by a cluster admin which can migrate and backup all other namespaces. BackupLocations in this namespace can also be used from all other namespaces",
 		},
 		cli.StringFlag{
 			Name:  "migration-admin-namespace",
@@ -159,6 +165,44 @@ func main() {
 			Value: 10,
 			Usage: "The interval in seconds to sync reconcilers (default: 10 seconds)",
 		},
+		cli.StringFlag{
+			Name:  "optional-resource-kinds",
+			Value: defaultOptionalResourceKinds,
+			Usage: "Comma separated list of resource kinds that are only backed up, restored and migrated if they are included in the optional resource types",
+		},
+		cli.StringFlag{
+			Name:  "event-component-name",
+			Value: defaultEventComponentName,
+			Usage: "Component name used as the source of events recorded by stork. Can be used to distinguish between multiple stork deployments",
+		},
+		cli.StringFlag{
+			Name:  "event-source-host",
+			Usage: "Host name used as the source of events recorded by stork",
+		},
+		cli.Float64Flag{
+			Name:  "group-snapshot-poll-jitter",
+			Value: groupsnapshotcontrollers.DefaultStatusPollJitter,
+			Usage: "Jitter factor for the interval at which the status of group snapshots in progress is checked. Set to 0 to disable",
+		},
+		cli.DurationFlag{
+			Name:  "requeue-interval",
+			Value: controllers.DefaultRequeue,
+			Usage: "The interval at which resources are reconciled by the controllers",
+		},
+		cli.DurationFlag{
+			Name:  "requeue-error-interval",
+			Value: controllers.DefaultRequeueError,
+			Usage: "The interval at which resources are reconciled by the controllers after an error",
+		},
+		cli.DurationFlag{
+			Name:  "volume-requeue-interval",
+			Value: controllers.VolumeRequeue,
+			Usage: "The interval at which application restores are reconciled while their volumes are being restored",
+		},
+		cli.StringFlag{
+			Name:  "audit-sink",
+			Usage: "Webhook URL or namespace/name of a BackupLocation to write audit records for application backups and restores to",
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -177,6 +221,10 @@ func run(c *cli.Context) {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	controllers.DefaultRequeue = c.Duration("requeue-interval")
+	controllers.DefaultRequeueError = c.Duration("requeue-error-interval")
+	controllers.VolumeRequeue = c.Duration("volume-requeue-interval")
+
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		log.Fatalf("Error getting cluster config: %v", err)
@@ -189,7 +237,12 @@ func run(c *cli.Context) {
 
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartRecordingToSink(&core_v1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
-	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, api_v1.EventSource{Component: eventComponentName})
+	eventSource := api_v1.EventSource{
+		Component: c.String("event-component-name"),
+		Host:      c.String("event-source-host"),
+	}
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, eventSource)
+	rule.SetEventSource(eventSource)
 
 	var d volume.Driver
 	if driverName != "" {
@@ -305,6 +358,7 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 		log.Fatalf("Error initializing rule: %v", err)
 	}
 
+	resourcecollector.SetOptionalResourceKinds(getOptionalResourceKinds(c.String("optional-resource-kinds")))
 	resourceCollector := resourcecollector.ResourceCollector{
 		Driver: d,
 	}
@@ -315,6 +369,7 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 	if adminNamespace == "" {
 		adminNamespace = c.String("migration-admin-namespace")
 	}
+	k8sutils.SetAdminNamespace(adminNamespace)
 
 	monitor := &monitor.Monitor{
 		Driver:      d,
@@ -341,8 +396,10 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 			}
 
 			groupsnapshotInst := groupsnapshot.GroupSnapshot{
-				Driver:   d,
-				Recorder: recorder,
+				Driver:           d,
+				Recorder:         recorder,
+				StatusPollJitter: c.Float64("group-snapshot-poll-jitter"),
+				AdminNamespace:   adminNamespace,
 			}
 			if err := groupsnapshotInst.Init(mgr); err != nil {
 				log.Fatalf("Error initializing groupsnapshot controller: %v", err)
@@ -378,11 +435,16 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 	}
 
 	if c.Bool("application-controller") {
+		auditSink, err := audit.NewSink(c.String("audit-sink"))
+		if err != nil {
+			log.Fatalf("Error initializing audit sink: %v", err)
+		}
 		appManager := applicationmanager.ApplicationManager{
 			Driver:            d,
 			Recorder:          recorder,
 			ResourceCollector: resourceCollector,
 			RsyncTime:         c.Int64("application-backup-sync-interval"),
+			AuditSink:         auditSink,
 		}
 		if err := appManager.Init(mgr, adminNamespace, signalChan); err != nil {
 			log.Fatalf("Error initializing application manager: %v", err)
@@ -427,3 +489,13 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 	}
 	os.Exit(0)
 }
+
+func getOptionalResourceKinds(kinds string) []string {
+	optionalKinds := make([]string, 0)
+	for _, kind := range strings.Split(kinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			optionalKinds = append(optionalKinds, kind)
+		}
+	}
+	return optionalKinds
+}
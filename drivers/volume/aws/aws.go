@@ -71,6 +71,9 @@ type aws struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.StagedRestoreNotSupported
+	storkvolume.RestoreCapacityNotSupported
+	storkvolume.RestoreVerifyNotSupported
 }
 
 func (a *aws) Init(_ interface{}) error {
@@ -462,8 +465,12 @@ func (a *aws) StartRestore(
 		// operation
 		if output, err := a.getEBSVolume("", tags); err == nil {
 			volumeInfo.RestoreVolume = *output.VolumeId
+			if backupVolumeInfo.Options[storkvolume.RestoreZonesOption] != "" {
+				volumeInfo.PlacedZones = []string{*output.AvailabilityZone}
+			}
 		} else {
-			if len(backupVolumeInfo.Zones) == 0 {
+			zones := storkvolume.GetRestoreZones(backupVolumeInfo)
+			if len(zones) == 0 {
 				return nil, fmt.Errorf("zone missing in backup for volume (%v) %v", backupVolumeInfo.Namespace, backupVolumeInfo.PersistentVolumeClaim)
 			}
 			ebsSnapshot, err := a.getEBSSnapshot(backupVolumeInfo.BackupID, nil)
@@ -473,7 +480,7 @@ func (a *aws) StartRestore(
 
 			input := &ec2.CreateVolumeInput{
 				SnapshotId:       aws_sdk.String(backupVolumeInfo.BackupID),
-				AvailabilityZone: aws_sdk.String(backupVolumeInfo.Zones[0]),
+				AvailabilityZone: aws_sdk.String(zones[0]),
 				TagSpecifications: []*ec2.TagSpecification{
 					{
 						ResourceType: aws_sdk.String(ec2.ResourceTypeVolume),
@@ -503,6 +510,10 @@ func (a *aws) StartRestore(
 				return nil, err
 			}
 			volumeInfo.RestoreVolume = *output.VolumeId
+			// Volumes can only be created in a single zone
+			if backupVolumeInfo.Options[storkvolume.RestoreZonesOption] != "" {
+				volumeInfo.PlacedZones = []string{zones[0]}
+			}
 		}
 	}
 	return volumeInfos, nil
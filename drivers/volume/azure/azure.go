@@ -59,6 +59,9 @@ type azure struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.StagedRestoreNotSupported
+	storkvolume.RestoreCapacityNotSupported
+	storkvolume.RestoreVerifyNotSupported
 }
 
 func (a *azure) Init(_ interface{}) error {
@@ -15,8 +15,10 @@ import (
 	storkvolume "github.com/libopenstorage/stork/drivers/volume"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
+	"github.com/libopenstorage/stork/pkg/compression"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/portworx/sched-ops/k8s/core"
@@ -25,6 +27,7 @@ import (
 	"gocloud.dev/gcerrors"
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -117,6 +120,7 @@ func (cbo *csiBackupObject) GetVolumeSnapshotClass(snapshotID string) (*kSnapsho
 
 type csi struct {
 	snapshotClient *kSnapshotClient.Clientset
+	k8sClient      clientset.Interface
 
 	storkvolume.ClusterPairNotSupported
 	storkvolume.MigrationNotSupported
@@ -124,6 +128,9 @@ type csi struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.StagedRestoreNotSupported
+	storkvolume.RestoreCapacityNotSupported
+	storkvolume.RestoreVerifyNotSupported
 }
 
 func (c *csi) Init(_ interface{}) error {
@@ -138,6 +145,11 @@ func (c *csi) Init(_ interface{}) error {
 	}
 	c.snapshotClient = cs
 
+	c.k8sClient, err = clientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
 	if c.isCSIInstalled() {
 		logrus.Infof("Creating default CSI SnapshotClasses")
 		err = c.createDefaultSnapshotClasses()
@@ -243,7 +255,11 @@ func (c *csi) getDefaultSnapshotClassName(driverName string) string {
 func (c *csi) getSnapshotClassName(
 	backup *storkapi.ApplicationBackup,
 	driverName string,
+	storageClassName string,
 ) string {
+	if snapshotClassName, ok := backup.Spec.SnapshotClassMapping[storageClassName]; ok {
+		return snapshotClassName
+	}
 	if snapshotClassName, ok := backup.Spec.Options[optCSISnapshotClassName]; ok {
 		return snapshotClassName
 	}
@@ -358,13 +374,34 @@ func (c *csi) StartBackup(
 		}
 		csiDriverName := pv.Spec.CSI.Driver
 		volumeInfo.Options[optCSIDriverName] = csiDriverName
-		snapshotClassName := c.getSnapshotClassName(backup, csiDriverName)
 
-		// ensure volumesnapshotclass is created for this driver
-		snapshotClassCreatedForDriver, err = c.ensureVolumeSnapshotClassCreated(snapshotClassCreatedForDriver, csiDriverName, snapshotClassName)
+		sc, err := core.Instance().GetStorageClassForPVC(&pvc)
 		if err != nil {
 			c.cancelBackupDuringStartFailure(backup, volumeInfos)
-			return nil, fmt.Errorf("failed to ensure volumesnapshotclass was created: %v", err)
+			return nil, fmt.Errorf("failed to get storage class for PVC %s: %v", pvc.Name, err)
+		}
+		snapshotClassName := c.getSnapshotClassName(backup, csiDriverName, sc.Name)
+
+		if _, ok := backup.Spec.SnapshotClassMapping[sc.Name]; ok {
+			// Snapshot classes from the mapping need to have been created
+			// for the driver since they could require specific parameters
+			vsClass, err := c.getVolumeSnapshotClass(snapshotClassName)
+			if err != nil {
+				c.cancelBackupDuringStartFailure(backup, volumeInfos)
+				return nil, fmt.Errorf("failed to get volumesnapshotclass %v for storage class %v: %v", snapshotClassName, sc.Name, err)
+			}
+			if vsClass.Driver != csiDriverName {
+				c.cancelBackupDuringStartFailure(backup, volumeInfos)
+				return nil, fmt.Errorf("volumesnapshotclass %v for storage class %v is for driver %v instead of %v",
+					snapshotClassName, sc.Name, vsClass.Driver, csiDriverName)
+			}
+		} else {
+			// ensure volumesnapshotclass is created for this driver
+			snapshotClassCreatedForDriver, err = c.ensureVolumeSnapshotClassCreated(snapshotClassCreatedForDriver, csiDriverName, snapshotClassName)
+			if err != nil {
+				c.cancelBackupDuringStartFailure(backup, volumeInfos)
+				return nil, fmt.Errorf("failed to ensure volumesnapshotclass was created: %v", err)
+			}
 		}
 
 		// Create CSI volume snapshot
@@ -389,12 +426,6 @@ func (c *csi) StartBackup(
 		}
 		volumeInfo.BackupID = string(vsName)
 
-		sc, err := core.Instance().GetStorageClassForPVC(&pvc)
-		if err != nil {
-			c.cancelBackupDuringStartFailure(backup, volumeInfos)
-			return nil, fmt.Errorf("failed to get storage class for PVC %s: %v", pvc.Name, err)
-		}
-
 		// only add one instance of a storageclass
 		if !storageClassAdded[sc.Name] {
 			sc.Kind = "StorageClass"
@@ -433,7 +464,7 @@ func (c *csi) uploadObject(
 	objectName string,
 	data []byte,
 ) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := k8sutils.GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
 		return err
 	}
@@ -442,6 +473,9 @@ func (c *csi) uploadObject(
 		return err
 	}
 
+	if data, err = compression.Compress(data, backupLocation.Location.Compression); err != nil {
+		return err
+	}
 	if backupLocation.Location.EncryptionKey != "" {
 		if data, err = crypto.Encrypt(data, backupLocation.Location.EncryptionKey); err != nil {
 			return err
@@ -449,7 +483,9 @@ func (c *csi) uploadObject(
 	}
 
 	objectPath := controllers.GetObjectPath(backup)
-	writer, err := bucket.NewWriter(context.TODO(), filepath.Join(objectPath, objectName), nil)
+	ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestWrite)
+	defer cancel()
+	writer, err := bucket.NewWriter(ctx, filepath.Join(objectPath, objectName), nil)
 	if err != nil {
 		return err
 	}
@@ -498,6 +534,118 @@ func (c *csi) uploadCSIBackupObject(
 	return nil
 }
 
+// getCSIDriverAPIVersion returns the group version of the CSIDriver API served
+// by the cluster. Returns an empty string if it isn't served.
+func (c *csi) getCSIDriverAPIVersion() (string, error) {
+	for _, groupVersion := range []string{storagev1.SchemeGroupVersion.String(), storagev1beta1.SchemeGroupVersion.String()} {
+		resources, err := c.k8sClient.Discovery().ServerResourcesForGroupVersion(groupVersion)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		for _, resource := range resources.APIResources {
+			if resource.Kind == "CSIDriver" {
+				return groupVersion, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func (c *csi) getCSIDriver(groupVersion string, name string) (*storagev1.CSIDriver, error) {
+	if groupVersion == storagev1.SchemeGroupVersion.String() {
+		return c.k8sClient.StorageV1().CSIDrivers().Get(context.TODO(), name, metav1.GetOptions{})
+	}
+	driver, err := c.k8sClient.StorageV1beta1().CSIDrivers().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// The spec is the same in both versions
+	driverBytes, err := json.Marshal(driver)
+	if err != nil {
+		return nil, err
+	}
+	var driverV1 storagev1.CSIDriver
+	if err := json.Unmarshal(driverBytes, &driverV1); err != nil {
+		return nil, err
+	}
+	return &driverV1, nil
+}
+
+// driverRequiresAttach returns true if the CSIDriver object registered for
+// the driver requires volumes to be attached through VolumeAttachments.
+// Drivers without a CSIDriver object aren't known to the cluster, so false is
+// returned for them.
+func (c *csi) driverRequiresAttach(driverName string) (bool, error) {
+	groupVersion, err := c.getCSIDriverAPIVersion()
+	if err != nil || groupVersion == "" {
+		return false, err
+	}
+	driver, err := c.getCSIDriver(groupVersion, driverName)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get CSIDriver %v: %v", driverName, err)
+	}
+	return driver.Spec.AttachRequired == nil || *driver.Spec.AttachRequired, nil
+}
+
+// retainRestoreSnapshots keeps the VolumeSnapshots and VolumeSnapshotContents
+// that volumes were restored from if their driver requires the volumes to be
+// attached. The restore label is removed from them so that they aren't
+// cleaned up with the other snapshots of the restore.
+func (c *csi) retainRestoreSnapshots(restore *storkapi.ApplicationRestore) error {
+	requiresAttach := make(map[string]bool)
+	for _, vrInfo := range restore.Status.Volumes {
+		if vrInfo.VolumeSnapshotContent == "" {
+			continue
+		}
+		vsc, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshotContents().Get(context.TODO(), vrInfo.VolumeSnapshotContent, metav1.GetOptions{})
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				vrInfo.VolumeSnapshotContent = ""
+				continue
+			}
+			return err
+		}
+		retain, ok := requiresAttach[vsc.Spec.Driver]
+		if !ok {
+			retain, err = c.driverRequiresAttach(vsc.Spec.Driver)
+			if err != nil {
+				return err
+			}
+			requiresAttach[vsc.Spec.Driver] = retain
+		}
+		if !retain {
+			vrInfo.VolumeSnapshotContent = ""
+			continue
+		}
+
+		vs, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(vsc.Spec.VolumeSnapshotRef.Namespace).Get(context.TODO(), vsc.Spec.VolumeSnapshotRef.Name, metav1.GetOptions{})
+		if err == nil {
+			if _, ok := vs.Labels[restoreUIDLabel]; ok {
+				delete(vs.Labels, restoreUIDLabel)
+				if _, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(vs.Namespace).Update(context.TODO(), vs, metav1.UpdateOptions{}); err != nil {
+					return err
+				}
+			}
+		} else if !k8s_errors.IsNotFound(err) {
+			return err
+		}
+		if _, ok := vsc.Labels[restoreUIDLabel]; ok {
+			delete(vsc.Labels, restoreUIDLabel)
+			if _, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshotContents().Update(context.TODO(), vsc, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+		log.ApplicationRestoreLog(restore).Debugf("retained vsc %v for pvc %v", vsc.Name, vrInfo.PersistentVolumeClaim)
+	}
+	return nil
+}
+
 func (c *csi) getRestoreUIDLabelSelector(restore *storkapi.ApplicationRestore) string {
 	return fmt.Sprintf("%s=%s", restoreUIDLabel, string(restore.GetUID()))
 }
@@ -736,7 +884,9 @@ func (c *csi) recreateSnapshotForDeletion(
 ) error {
 	var err error
 	driverName := c.getVolumeCSIDriver(vbInfo)
-	snapshotClassName := c.getSnapshotClassName(backup, driverName)
+	// The class from the mapping isn't needed since the snapshot class from
+	// the backup is restored below
+	snapshotClassName := c.getSnapshotClassName(backup, driverName, "")
 
 	// make sure snapshot class is created for this object.
 	// if we have already created it in this batch, do not check if created already.
@@ -827,7 +977,7 @@ func (c *csi) CancelBackup(backup *storkapi.ApplicationBackup) error {
 }
 
 func (c *csi) cleanupBackupLocation(backup *storkapi.ApplicationBackup) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := k8sutils.GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
 		// Can't do anything if the backup location is deleted
 		if k8s_errors.IsNotFound(err) {
@@ -842,10 +992,12 @@ func (c *csi) cleanupBackupLocation(backup *storkapi.ApplicationBackup) error {
 
 	objectPath := backup.Status.BackupPath
 	if objectPath != "" {
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, snapshotObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestWrite)
+		defer cancel()
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, snapshotObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting resources for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, storageClassesObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, storageClassesObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting resources for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
 	}
@@ -960,7 +1112,7 @@ func (c *csi) downloadObject(
 	namespace string,
 	objectName string,
 ) ([]byte, error) {
-	restoreLocation, err := storkops.Instance().GetBackupLocation(backupLocation, namespace)
+	restoreLocation, err := k8sutils.GetBackupLocation(backupLocation, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -970,12 +1122,16 @@ func (c *csi) downloadObject(
 	}
 
 	objectPath := backup.Status.BackupPath
-	exists, err := bucket.Exists(context.TODO(), filepath.Join(objectPath, objectName))
+	existsCtx, cancel := objectstore.GetContext(restoreLocation, objectstore.RequestExists)
+	defer cancel()
+	exists, err := bucket.Exists(existsCtx, filepath.Join(objectPath, objectName))
 	if err != nil || !exists {
 		return nil, nil
 	}
 
-	data, err := bucket.ReadAll(context.TODO(), filepath.Join(objectPath, objectName))
+	readCtx, cancel := objectstore.GetContext(restoreLocation, objectstore.RequestRead)
+	defer cancel()
+	data, err := bucket.ReadAll(readCtx, filepath.Join(objectPath, objectName))
 	if err != nil {
 		return nil, err
 	}
@@ -985,7 +1141,7 @@ func (c *csi) downloadObject(
 		}
 	}
 
-	return data, nil
+	return compression.Decompress(data)
 }
 
 // getRestoreSnapshotsAndContent retrieves the volumeSnapshots and
@@ -1428,6 +1584,9 @@ func (c *csi) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 		var vscError string
 		if vsContentName != "" {
 			vscError = c.getSnapshotContentError(vsContentName)
+			if restore.Spec.RetainVolumeSnapshots {
+				vrInfo.VolumeSnapshotContent = vsContentName
+			}
 		}
 
 		// Use PVC size by default, but replace with restoreSize once it is ready
@@ -1461,6 +1620,11 @@ func (c *csi) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 
 	// If none are in progress, we can safely cleanup our volumesnapshot objects
 	if !anyInProgress {
+		if restore.Spec.RetainVolumeSnapshots && !anyFailed {
+			if err := c.retainRestoreSnapshots(restore); err != nil {
+				return nil, fmt.Errorf("failed to retain CSI snapshots: %v", err)
+			}
+		}
 		err := c.cleanupSnapshotsForRestore(restore, true)
 		if err != nil {
 			return nil, fmt.Errorf("failed to clean CSI snapshots: %v", err)
@@ -54,6 +54,9 @@ type gcp struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.StagedRestoreNotSupported
+	storkvolume.RestoreCapacityNotSupported
+	storkvolume.RestoreVerifyNotSupported
 }
 
 func (g *gcp) Init(_ interface{}) error {
@@ -414,12 +417,16 @@ func (g *gcp) StartRestore(
 
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, backupVolumeInfo := range volumeBackupInfos {
+		zones := storkvolume.GetRestoreZones(backupVolumeInfo)
 		volumeInfo := &storkapi.ApplicationRestoreVolumeInfo{
 			PersistentVolumeClaim: backupVolumeInfo.PersistentVolumeClaim,
 			SourceNamespace:       backupVolumeInfo.Namespace,
 			SourceVolume:          backupVolumeInfo.Volume,
 			DriverName:            driverName,
-			Zones:                 backupVolumeInfo.Zones,
+			Zones:                 zones,
+		}
+		if backupVolumeInfo.Options[storkvolume.RestoreZonesOption] != "" {
+			volumeInfo.PlacedZones = zones
 		}
 		volumeInfos = append(volumeInfos, volumeInfo)
 		labels := storkvolume.GetApplicationRestoreLabels(restore, volumeInfo)
@@ -429,17 +436,17 @@ func (g *gcp) StartRestore(
 			SourceSnapshot: g.getSnapshotResourceName(backupVolumeInfo),
 			Labels:         labels,
 		}
-		if len(backupVolumeInfo.Zones) == 0 {
+		if len(zones) == 0 {
 			return nil, fmt.Errorf("zones missing for backup volume %v/%v",
 				backupVolumeInfo.Namespace,
 				backupVolumeInfo.PersistentVolumeClaim,
 			)
-		} else if len(backupVolumeInfo.Zones) > 1 {
-			disk.ReplicaZones, err = g.getZoneURLs(backupVolumeInfo.Zones)
+		} else if len(zones) > 1 {
+			disk.ReplicaZones, err = g.getZoneURLs(zones)
 			if err != nil {
 				return nil, err
 			}
-			region, err := g.getRegion(backupVolumeInfo.Zones[0])
+			region, err := g.getRegion(zones[0])
 			if err != nil {
 				return nil, err
 			}
@@ -456,10 +463,10 @@ func (g *gcp) StartRestore(
 			}
 		} else {
 			// First check if the disk has already been created with the same labels
-			if disks, err := g.service.Disks.List(g.projectID, backupVolumeInfo.Zones[0]).Filter(filter).Do(); err == nil && len(disks.Items) == 1 {
+			if disks, err := g.service.Disks.List(g.projectID, zones[0]).Filter(filter).Do(); err == nil && len(disks.Items) == 1 {
 				volumeInfo.RestoreVolume = disks.Items[0].Name
 			} else {
-				_, err := g.service.Disks.Insert(g.projectID, backupVolumeInfo.Zones[0], disk).Do()
+				_, err := g.service.Disks.Insert(g.projectID, zones[0], disk).Do()
 				if err != nil {
 					return nil, err
 				}
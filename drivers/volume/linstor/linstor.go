@@ -50,6 +50,9 @@ type linstor struct {
 	storkvolume.BackupRestoreNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.StagedRestoreNotSupported
+	storkvolume.RestoreCapacityNotSupported
+	storkvolume.RestoreVerifyNotSupported
 }
 
 func (l *linstor) linstorClient() (*lclient.Client, error) {
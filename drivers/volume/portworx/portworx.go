@@ -212,6 +212,7 @@ var restoreStateCallBackoff = wait.Backoff{
 }
 
 type portworx struct {
+	storkvolume.StagedRestoreNotSupported
 	store           cache.Store
 	stopChannel     chan struct{}
 	sdkConn         *portworxGrpcConnection
@@ -2279,6 +2280,11 @@ func (p *portworx) getBackupRestoreTaskID(operationUID types.UID, namespace stri
 }
 
 func (p *portworx) getCredID(backupLocation string, namespace string) string {
+	// The BackupLocation could be from the admin namespace, so use the
+	// namespace that it was found in
+	if bl, err := k8sutils.GetBackupLocation(backupLocation, namespace); err == nil {
+		namespace = bl.Namespace
+	}
 	return "k8s/" + namespace + "/" + backupLocation
 }
 
@@ -2405,7 +2411,7 @@ func (p *portworx) CreateGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) (
 		return nil, err
 	}
 
-	volNames, err := k8sutils.GetVolumeNamesFromLabelSelector(snap.Namespace, snap.Spec.PVCSelector.MatchLabels)
+	volNames, err := k8sutils.GetVolumeNamesFromLabelSelector(k8sutils.GetGroupSnapshotNamespaces(snap), snap.Spec.PVCSelector.MatchLabels)
 	if err != nil {
 		return nil, err
 	}
@@ -2463,7 +2469,11 @@ func (p *portworx) DeleteGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) error
 			continue
 		}
 
-		err := k8sextops.Instance().DeleteSnapshot(vs.VolumeSnapshotName, snap.Namespace)
+		namespace := vs.VolumeSnapshotNamespace
+		if namespace == "" {
+			namespace = snap.Namespace
+		}
+		err := k8sextops.Instance().DeleteSnapshot(vs.VolumeSnapshotName, namespace)
 		if err != nil {
 			if !k8s_errors.IsNotFound(err) {
 				log.GroupSnapshotLog(snap).Errorf("failed to delete snapshot due to: %v", err)
@@ -3051,6 +3061,51 @@ func (p *portworx) GetPreRestoreResources(
 	return objectsToRestore, nil
 }
 
+// CheckRestoreCapacity checks that the storage pools on the online nodes have
+// enough free capacity for the volumes being restored. The replication factor
+// of the volumes isn't known before they are restored, so this only catches
+// clusters that can't hold even a single replica of the volumes.
+func (p *portworx) CheckRestoreCapacity(
+	restore *storkapi.ApplicationRestore,
+	size uint64,
+) error {
+	if !p.initDone {
+		if err := p.initPortworxClients(); err != nil {
+			return err
+		}
+	}
+
+	clusterManager, err := p.getClusterManagerClient()
+	if err != nil {
+		return fmt.Errorf("cannot get cluster manager, err: %s", err.Error())
+	}
+	cluster, err := clusterManager.Enumerate()
+	if err != nil {
+		return &ErrFailedToGetNodes{
+			Cause: err.Error(),
+		}
+	}
+
+	var available uint64
+	for _, n := range cluster.Nodes {
+		if n.Status != api.Status_STATUS_OK {
+			continue
+		}
+		for _, pool := range n.Pools {
+			if pool.TotalSize > pool.Used {
+				available += pool.TotalSize - pool.Used
+			}
+		}
+	}
+	if available < size {
+		return &errors.ErrInsufficientCapacity{
+			Required:  size,
+			Available: available,
+		}
+	}
+	return nil
+}
+
 func (p *portworx) StartRestore(
 	restore *storkapi.ApplicationRestore,
 	volumeBackupInfos []*storkapi.ApplicationBackupVolumeInfo,
@@ -3141,6 +3196,13 @@ func (p *portworx) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*st
 			vInfo.TotalSize = csStatus.bytesDone
 			vInfo.Status = storkapi.ApplicationRestoreStatusSuccessful
 			vInfo.Reason = "Restore successful for volume"
+			vols, err := volDriver.Inspect([]string{vInfo.RestoreVolume})
+			if err != nil {
+				return nil, fmt.Errorf("error inspecting restored volume %v: %v", vInfo.RestoreVolume, err)
+			}
+			if len(vols) == 1 {
+				vInfo.Warnings = getRestoredVolumeWarnings(vols[0])
+			}
 		}
 		volumeInfos = append(volumeInfos, vInfo)
 	}
@@ -3148,6 +3210,70 @@ func (p *portworx) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*st
 	return volumeInfos, nil
 }
 
+// getRestoredVolumeWarnings returns warnings for a restored volume that isn't
+// healthy or has fewer replicas than its HA level
+func getRestoredVolumeWarnings(vol *api.Volume) []string {
+	var warnings []string
+	if vol.Status != api.VolumeStatus_VOLUME_STATUS_UP {
+		warnings = append(warnings, fmt.Sprintf("Volume status is %v", vol.Status))
+	}
+	if vol.Spec != nil && len(vol.ReplicaSets) > 0 {
+		replicas := int64(len(vol.ReplicaSets[0].Nodes))
+		if replicas < vol.Spec.HaLevel {
+			warnings = append(warnings, fmt.Sprintf("Volume has %v of %v replicas", replicas, vol.Spec.HaLevel))
+		}
+	}
+	return warnings
+}
+
+// VerifyRestore checks that the cloudsnap for a volume restored all of its
+// data and that the restored volume isn't down
+func (p *portworx) VerifyRestore(restore *storkapi.ApplicationRestore, vInfo *storkapi.ApplicationRestoreVolumeInfo) error {
+	if !p.initDone {
+		if err := p.initPortworxClients(); err != nil {
+			return err
+		}
+	}
+
+	volDriver, err := p.getUserVolDriver(restore.Annotations)
+	if err != nil {
+		return err
+	}
+	taskID := p.getBackupRestoreTaskID(restore.UID, vInfo.SourceNamespace, vInfo.PersistentVolumeClaim)
+	csStatus := p.getCloudSnapStatus(volDriver, api.CloudRestoreOp, taskID)
+	if csStatus.status != api.CloudBackupStatusDone {
+		return &errors.ErrVerificationFailed{
+			Volume: vInfo.RestoreVolume,
+			Reason: fmt.Sprintf("restore isn't done: %v", csStatus.msg),
+		}
+	}
+	if csStatus.bytesDone < csStatus.bytesTotal {
+		return &errors.ErrVerificationFailed{
+			Volume: vInfo.RestoreVolume,
+			Reason: fmt.Sprintf("only %v of %v bytes were restored", csStatus.bytesDone, csStatus.bytesTotal),
+		}
+	}
+
+	vols, err := volDriver.Inspect([]string{vInfo.RestoreVolume})
+	if err != nil {
+		return err
+	}
+	if len(vols) != 1 {
+		return &errors.ErrVerificationFailed{
+			Volume: vInfo.RestoreVolume,
+			Reason: "restored volume doesn't exist",
+		}
+	}
+	if vols[0].Status == api.VolumeStatus_VOLUME_STATUS_DOWN ||
+		vols[0].Status == api.VolumeStatus_VOLUME_STATUS_NOT_PRESENT {
+		return &errors.ErrVerificationFailed{
+			Volume: vInfo.RestoreVolume,
+			Reason: fmt.Sprintf("restored volume status is %v", vols[0].Status),
+		}
+	}
+	return nil
+}
+
 func (p *portworx) CancelRestore(restore *storkapi.ApplicationRestore) error {
 	if !p.initDone {
 		if err := p.initPortworxClients(); err != nil {
@@ -19,6 +19,15 @@ const (
 	// Default snapshot type if drivers don't support different types or can't
 	// find driver
 	defaultSnapType = "Local"
+
+	// PVCDriverAnnotation can be set on a PVC to pick the driver that backs
+	// it up, for PVCs that could be owned by more than one driver
+	PVCDriverAnnotation = "stork.libopenstorage.org/driver"
+
+	// RestoreZonesOption is set in the options of volumes being restored
+	// to a comma separated list of the zones that they should be restored to.
+	// Drivers that use the zones set PlacedZones in the restore volume info
+	RestoreZonesOption = "restoreZones"
 )
 
 // Driver defines an external volume driver interface.
@@ -80,6 +89,12 @@ type Driver interface {
 	ClonePluginInterface
 	// SnapshotRestorePluginInterface Interface to do in-place restore of volumes
 	SnapshotRestorePluginInterface
+	// StagedRestorePluginInterface Interface to restore volumes in two phases
+	StagedRestorePluginInterface
+	// RestoreCapacityPluginInterface Interface to check capacity for restores
+	RestoreCapacityPluginInterface
+	// RestoreVerifyPluginInterface Interface to verify restored volumes
+	RestoreVerifyPluginInterface
 }
 
 // GroupSnapshotCreateResponse is the response for the group snapshot operation
@@ -154,6 +169,34 @@ type BackupRestorePluginInterface interface {
 	CancelRestore(*storkapi.ApplicationRestore) error
 }
 
+// StagedRestorePluginInterface Interface to restore volumes in two phases. The
+// bulk of the data is copied in advance and a final sync is done at cutover.
+type StagedRestorePluginInterface interface {
+	// Start staging the data for the volumes specified by the spec. Progress
+	// is tracked using GetRestoreStatus
+	StageRestore(*storkapi.ApplicationRestore, []*storkapi.ApplicationBackupVolumeInfo) ([]*storkapi.ApplicationRestoreVolumeInfo, error)
+	// Start the final sync for the volumes that were staged. Progress is
+	// tracked using GetRestoreStatus
+	FinalizeRestore(*storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error)
+}
+
+// RestoreCapacityPluginInterface Interface to check that there is enough
+// capacity to restore volumes
+type RestoreCapacityPluginInterface interface {
+	// CheckRestoreCapacity checks that the storage has enough free capacity
+	// for the restore of volumes with the given total size in bytes. Returns
+	// ErrInsufficientCapacity if it doesn't
+	CheckRestoreCapacity(*storkapi.ApplicationRestore, uint64) error
+}
+
+// RestoreVerifyPluginInterface Interface to verify the integrity of the data
+// in volumes once they have been restored
+type RestoreVerifyPluginInterface interface {
+	// VerifyRestore checks the integrity of a volume that has been restored
+	// successfully. Returns ErrVerificationFailed if the data is corrupt
+	VerifyRestore(*storkapi.ApplicationRestore, *storkapi.ApplicationRestoreVolumeInfo) error
+}
+
 // SnapshotRestorePluginInterface Interface to perform in place restore of volume
 type SnapshotRestorePluginInterface interface {
 	// StartVolumeSnapshotRestore will prepare volume for restore
@@ -271,6 +314,20 @@ func GetPVCDriver(coreOps core.Ops, pvc *v1.PersistentVolumeClaim) (string, erro
 	}
 }
 
+// GetPVCDriverForBackup gets the driver used to backup a PVC. The driver set
+// in PVCDriverAnnotation is used if the PVC has it, and ErrNotFound is
+// returned if that driver isn't available. Otherwise the driver that owns the
+// PVC is used.
+func GetPVCDriverForBackup(coreOps core.Ops, pvc *v1.PersistentVolumeClaim) (string, error) {
+	if driverName, ok := pvc.Annotations[PVCDriverAnnotation]; ok {
+		if _, err := Get(driverName); err != nil {
+			return "", err
+		}
+		return driverName, nil
+	}
+	return GetPVCDriver(coreOps, pvc)
+}
+
 // GetPVDriver gets the driver associated with a PV. Returns ErrNotFound if the PV is
 // not owned by any available driver
 func GetPVDriver(pv *v1.PersistentVolume) (string, error) {
@@ -411,6 +468,41 @@ func (b *BackupRestoreNotSupported) CancelRestore(*storkapi.ApplicationRestore)
 	return &errors.ErrNotSupported{}
 }
 
+// StagedRestoreNotSupported to be used by drivers that don't support staged
+// restores
+type StagedRestoreNotSupported struct{}
+
+// StageRestore returns ErrNotSupported
+func (s *StagedRestoreNotSupported) StageRestore(
+	*storkapi.ApplicationRestore,
+	[]*storkapi.ApplicationBackupVolumeInfo,
+) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+// FinalizeRestore returns ErrNotSupported
+func (s *StagedRestoreNotSupported) FinalizeRestore(*storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+// RestoreCapacityNotSupported to be used by drivers that can't check the
+// capacity for restores
+type RestoreCapacityNotSupported struct{}
+
+// CheckRestoreCapacity returns ErrNotSupported
+func (r *RestoreCapacityNotSupported) CheckRestoreCapacity(*storkapi.ApplicationRestore, uint64) error {
+	return &errors.ErrNotSupported{}
+}
+
+// RestoreVerifyNotSupported to be used by drivers that can't verify restored
+// volumes
+type RestoreVerifyNotSupported struct{}
+
+// VerifyRestore returns ErrNotSupported
+func (r *RestoreVerifyNotSupported) VerifyRestore(*storkapi.ApplicationRestore, *storkapi.ApplicationRestoreVolumeInfo) error {
+	return &errors.ErrNotSupported{}
+}
+
 // CloneNotSupported to be used by drivers that don't support volume clone
 type CloneNotSupported struct{}
 
@@ -569,6 +661,16 @@ func GetApplicationBackupLabels(
 	}
 }
 
+// GetRestoreZones returns the zones that a volume should be restored to. The
+// zones from the restore options are used if they are set, otherwise the
+// zones that the volume was backed up from.
+func GetRestoreZones(backupVolumeInfo *storkapi.ApplicationBackupVolumeInfo) []string {
+	if zones := backupVolumeInfo.Options[RestoreZonesOption]; zones != "" {
+		return strings.Split(zones, ",")
+	}
+	return backupVolumeInfo.Zones
+}
+
 // GetApplicationRestoreLabels Gets the labels that need to be applied to a
 // volume when restoring from a backup
 func GetApplicationRestoreLabels(
@@ -28,6 +28,7 @@ require (
 	github.com/hashicorp/vault/sdk v0.1.14-0.20200429182704-29fce8f27ce4 // indirect
 	github.com/heptio/ark v1.0.0
 	github.com/heptio/velero v1.0.0 // indirect
+	github.com/klauspost/compress v1.11.7
 	github.com/kubernetes-csi/external-snapshotter/client/v4 v4.0.0
 	github.com/kubernetes-incubator/external-storage v0.20.4-openstorage-rc2
 	github.com/kubernetes-sigs/aws-ebs-csi-driver v0.9.0
@@ -37,7 +38,7 @@ require (
 	github.com/openshift/api v0.0.0-20210105115604-44119421ec6b
 	github.com/openshift/client-go v0.0.0-20210112165513-ebc401615f47
 	github.com/pborman/uuid v1.2.0
-	github.com/pierrec/lz4 v2.5.2+incompatible // indirect
+	github.com/pierrec/lz4 v2.5.2+incompatible
 	github.com/portworx/sched-ops v1.20.4-rc1.0.20210503012502-d83d87d42ead
 	github.com/portworx/torpedo v0.20.4-rc1.0.20210325154352-eb81b0cdd145
 	github.com/prometheus/client_golang v1.9.0
@@ -34,6 +34,17 @@ type ApplicationBackupSpec struct {
 	Options          map[string]string `json:"options"`
 	IncludeResources []ObjectInfo      `json:"includeResources"`
 	ResourceTypes    []string          `json:"resourceTypes"`
+	// SnapshotClassMapping is a map of storage class names to the
+	// VolumeSnapshotClass used for CSI snapshots of PVCs from that storage
+	// class. PVCs from storage classes that aren't in the map use the
+	// snapshot class from the options or the default class for the driver
+	SnapshotClassMapping map[string]string `json:"snapshotClassMapping"`
+	// IncludeReferencedResources also backs up the cluster scoped resources
+	// referenced by the namespaced resources, like the StorageClasses of
+	// PVCs and the ClusterRoles of RoleBindings. They are only created on
+	// restore if they don't exist. Only allowed for backups in the admin
+	// namespace
+	IncludeReferencedResources bool `json:"includeReferencedResources"`
 }
 
 // ApplicationBackupReclaimPolicyType is the reclaim policy for the application backup
@@ -60,6 +71,10 @@ type ApplicationBackupStatus struct {
 	LastUpdateTimestamp metav1.Time                      `json:"lastUpdateTimestamp"`
 	FinishTimestamp     metav1.Time                      `json:"finishTimestamp"`
 	TotalSize           uint64                           `json:"totalSize"`
+	// UploadedObjects are the names of the objects for the resources that
+	// have been uploaded to the backup location. They aren't uploaded again
+	// if the backup is resumed, for example after stork is restarted
+	UploadedObjects []string `json:"uploadedObjects,omitempty"`
 }
 
 // ObjectInfo contains info about an object being backed up or restored
@@ -2,6 +2,7 @@ package v1alpha1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 const (
@@ -9,6 +10,9 @@ const (
 	ApplicationRestoreResourceName = "applicationrestore"
 	// ApplicationRestoreResourcePlural is plural for "applicationrestore" resource
 	ApplicationRestoreResourcePlural = "applicationrestores"
+	// CreatedByAnnotation is the annotation set by the admission webhook with
+	// the name of the user that created the object
+	CreatedByAnnotation = "stork.libopenstorage.org/created-by"
 )
 
 // +genclient
@@ -30,6 +34,347 @@ type ApplicationRestoreSpec struct {
 	ReplacePolicy                ApplicationRestoreReplacePolicyType `json:"replacePolicy"`
 	IncludeOptionalResourceTypes []string                            `json:"includeOptionalResourceTypes"`
 	IncludeResources             []ObjectInfo                        `json:"includeResources"`
+	// NamespaceTemplate is the name of a ConfigMap in the namespace of the
+	// restore with labels, annotations and default objects to be applied to
+	// the namespaces created by the restore
+	NamespaceTemplate string `json:"namespaceTemplate"`
+	// DeleteConcurrency is the number of existing resources deleted in
+	// parallel when ReplacePolicy is set to Delete
+	DeleteConcurrency int `json:"deleteConcurrency"`
+	// DeleteBatchSize is the number of existing resources deleted before
+	// waiting for them to be removed when ReplacePolicy is set to Delete
+	DeleteBatchSize int `json:"deleteBatchSize"`
+	// DeleteGracePeriodSeconds is the grace period used when deleting
+	// existing resources when ReplacePolicy is set to Delete. The restore
+	// waits for the grace period, along with the default wait, for the
+	// resources to be removed before creating them again. The default grace
+	// period for each resource is used if it isn't set
+	DeleteGracePeriodSeconds *int64 `json:"deleteGracePeriodSeconds,omitempty"`
+	// StartWorkloadsPaused restores workloads with their replicas set to 0
+	// and CronJobs suspended. The original replica counts are stored in an
+	// annotation on the workloads.
+	StartWorkloadsPaused bool `json:"startWorkloadsPaused"`
+	// StagedRestore restores the volumes in two phases. The bulk of the data
+	// is copied first and the restore waits in the Staged status until
+	// FinalizeStagedRestore is set
+	StagedRestore bool `json:"stagedRestore"`
+	// FinalizeStagedRestore starts the final sync of the volumes for a
+	// staged restore and then restores the resources
+	FinalizeStagedRestore bool `json:"finalizeStagedRestore"`
+	// ChangedSince restores only resources that were modified in the source
+	// after this time. All resources are restored if it isn't set
+	ChangedSince metav1.Time `json:"changedSince"`
+	// PVCDataSourcePolicy is the policy for PVCs that were created from a
+	// data source. Defaults to Clear
+	PVCDataSourcePolicy ApplicationRestorePVCDataSourcePolicyType `json:"pvcDataSourcePolicy"`
+	// SelectorLabelKey is the key for a label, with the name of the restore
+	// as the value, that is added to the selectors and pod templates of
+	// restored workloads and the selectors of restored services. This keeps
+	// restored pods from being selected by existing services. Names longer
+	// than 63 characters are truncated and a hash of the name is added
+	SelectorLabelKey string `json:"selectorLabelKey"`
+	// StripMeshSidecars removes the sidecar containers, volumes and injection
+	// annotations added by the given service meshes from the pod templates of
+	// restored workloads so that the mesh on the destination cluster can
+	// inject them again
+	StripMeshSidecars []ApplicationRestoreMeshType `json:"stripMeshSidecars"`
+	// DependsOn is a list of names of restores in the same namespace that
+	// need to be successful before this restore is started
+	DependsOn []string `json:"dependsOn"`
+	// AdoptExistingVolumes skips restoring the data for volumes whose PVC
+	// already exists and is bound in the destination namespace. The existing
+	// PVCs and PVs are retained and only the other resources are restored
+	AdoptExistingVolumes bool `json:"adoptExistingVolumes"`
+	// ClusterPair is the name of a ClusterPair in the namespace of the
+	// restore. If set, the resources are restored to the remote cluster from
+	// the pair instead of the cluster that the restore was created on
+	ClusterPair string `json:"clusterPair"`
+	// DryRun compares the resources in the backup to the objects in the
+	// cluster and records the differences in the status of each resource
+	// without restoring any volumes or resources
+	DryRun bool `json:"dryRun"`
+	// ServerDryRun also validates each resource against the destination
+	// apiserver during a DryRun, using a server-side apply dry run. Resources
+	// that are rejected by validation or admission are marked as failed with
+	// the error from the apiserver. Nothing is persisted
+	ServerDryRun bool `json:"serverDryRun"`
+	// NamespaceBatchSize is the maximum number of namespaces created in each
+	// pass of the restore. The remaining namespaces are created in the
+	// following passes. All namespaces are created at once if it isn't set
+	NamespaceBatchSize int `json:"namespaceBatchSize"`
+	// ServiceAnnotationMapping is a map of annotations that are set on
+	// restored LoadBalancer Services, replacing the values from the backup
+	ServiceAnnotationMapping map[string]string `json:"serviceAnnotationMapping"`
+	// StripServiceAnnotations is a list of annotations that are removed from
+	// restored LoadBalancer Services. Entries ending with * remove all
+	// annotations with that prefix
+	StripServiceAnnotations []string `json:"stripServiceAnnotations"`
+	// RelaxPodSecurity sets the Pod Security level enforced on destination
+	// namespaces to privileged if restored pods would violate it. The
+	// original level is set again a while after the restore completes
+	RelaxPodSecurity bool `json:"relaxPodSecurity"`
+	// ExportType writes the resources that would be applied, after they have
+	// been prepared for the destination, as a multi-document YAML bundle
+	// instead of restoring them. No volumes are restored either. The location
+	// of the bundle is recorded in Status.ExportPath
+	ExportType ApplicationRestoreExportType `json:"exportType"`
+	// VolumesOnly restores the volumes along with their PVCs and PVs, but
+	// skips all other resources from the backup
+	VolumesOnly bool `json:"volumesOnly"`
+	// StripCNIAnnotations is a list of annotations that are removed from
+	// restored Pods and the pod templates of restored workloads. These are
+	// usually set by the CNI on the source cluster and aren't valid with the
+	// CNI on the destination. Entries ending with * remove all annotations
+	// with that prefix
+	StripCNIAnnotations []string `json:"stripCNIAnnotations"`
+	// StripCNINodeAffinityKeys is a list of node label keys that are removed
+	// from the node selector and node affinity of restored Pods and pod
+	// templates. Entries ending with * remove all keys with that prefix
+	StripCNINodeAffinityKeys []string `json:"stripCNINodeAffinityKeys"`
+	// SkipValidation skips the optional checks done before and during the
+	// restore to speed it up for backups that are trusted. The checks that
+	// were skipped are recorded in Status.SkippedValidations
+	SkipValidation bool `json:"skipValidation"`
+	// MaxReconcileRetries is the number of consecutive passes of the restore
+	// that can fail before it is marked as Failed. Failed passes are retried
+	// indefinitely if it isn't set
+	MaxReconcileRetries int `json:"maxReconcileRetries"`
+	// StripAnnotations is a list of annotations that are removed from all
+	// restored resources, like the ones that trigger controllers such as
+	// external-dns and cert-manager to make changes outside the cluster.
+	// Entries ending with * remove all annotations with that prefix
+	StripAnnotations []string `json:"stripAnnotations"`
+	// KeepAnnotations is a list of annotations that are kept on restored
+	// resources even if they match an entry in StripAnnotations. Entries
+	// ending with * keep all annotations with that prefix
+	KeepAnnotations []string `json:"keepAnnotations"`
+	// UseDefaultStorageClassOnMissing updates restored PVCs, and the volume
+	// claim templates of StatefulSets, that use a storage class that doesn't
+	// exist on the destination to use the default storage class instead.
+	// Otherwise only a warning is reported for them
+	UseDefaultStorageClassOnMissing bool `json:"useDefaultStorageClassOnMissing"`
+	// NotificationWebhooks are URLs that a notification is posted to when
+	// the stage or status of the restore changes. Failures to notify the
+	// webhooks don't affect the restore. The URLs have to be for services in
+	// the namespace of the restore, unless it is in the admin namespace, and
+	// notifications are posted to them in order
+	NotificationWebhooks []string `json:"notificationWebhooks"`
+	// ResourceSelectors select the resources from the backup that are
+	// restored. A resource is restored if it matches any of the selectors.
+	// All resources are restored if none are specified. The volumes that are
+	// restored aren't affected by the selectors
+	ResourceSelectors []ApplicationRestoreResourceSelector `json:"resourceSelectors"`
+	// SingleResource restores only the given object from the backup. It is
+	// used as the only entry in IncludeResources, so they can't both be set.
+	// The restore fails if the object isn't found in the backup
+	SingleResource *ObjectInfo `json:"singleResource,omitempty"`
+	// IngressClassMapping is a map of ingress classes from the source to the
+	// ingress classes on the destination. It is applied to the
+	// ingressClassName and the kubernetes.io/ingress.class annotation of
+	// restored Ingresses
+	IngressClassMapping map[string]string `json:"ingressClassMapping"`
+	// StripIngressAnnotations is a list of annotations that are removed from
+	// restored Ingresses, like the ones specific to the ingress controller on
+	// the source cluster. Entries ending with * remove all annotations with
+	// that prefix
+	StripIngressAnnotations []string `json:"stripIngressAnnotations"`
+	// KeepIngressAnnotations is a list of annotations that are kept on
+	// restored Ingresses even if they match an entry in
+	// StripIngressAnnotations. Entries ending with * keep all annotations
+	// with that prefix
+	KeepIngressAnnotations []string `json:"keepIngressAnnotations"`
+	// SettleDelay is the time to wait after the resources are applied before
+	// the restore is marked as successful. If restored pods are crash looping
+	// or restored PVCs lose their volumes during that time the restore is
+	// marked as PartialSuccess instead
+	SettleDelay metav1.Duration `json:"settleDelay"`
+	// RelaxWebhookFailurePolicy sets the failurePolicy of the webhooks in
+	// restored webhook configurations to Ignore, so that requests aren't
+	// rejected while the webhook backends are starting up. The original
+	// policies are set again a while after the restore completes
+	RelaxWebhookFailurePolicy bool `json:"relaxWebhookFailurePolicy"`
+	// WaitAfterVolumes stops the restore once the volumes have been restored
+	// so that they can be validated. The resources are restored once the
+	// stork.libopenstorage.org/restore-resources annotation is set to true on
+	// the restore
+	WaitAfterVolumes bool `json:"waitAfterVolumes"`
+	// NamespaceCollisionPolicy is the policy for objects with the same name
+	// from different source namespaces that are mapped to the same
+	// destination namespace. Defaults to Fail
+	NamespaceCollisionPolicy ApplicationRestoreNamespaceCollisionPolicyType `json:"namespaceCollisionPolicy"`
+	// VerifyOnComplete asks the drivers to verify the integrity of each
+	// volume once it has been restored. The restore fails if a volume fails
+	// verification. Volumes from drivers that can't verify them are only
+	// reported with a warning
+	VerifyOnComplete bool `json:"verifyOnComplete"`
+	// UseGenerateName restores resources that have generateName set with a
+	// generated name instead of their name from the backup. Resources with
+	// generateName are also restored with a generated name if a resource with
+	// their name still exists after it was deleted for the Delete
+	// ReplacePolicy. PVCs and PVs are always restored with their names. The
+	// generated names are recorded in the status of the resources and reused
+	// if the restore applies the resources again
+	UseGenerateName bool `json:"useGenerateName"`
+	// MarkRestoredResources adds the stork.libopenstorage.org/restored-by
+	// label, with the name of the restore, and the
+	// stork.libopenstorage.org/restore-timestamp annotation to the restored
+	// resources so that they can be found without going through the status
+	// of the restore
+	MarkRestoredResources bool `json:"markRestoredResources"`
+	// Substitutions are values that are substituted into the data of
+	// restored ConfigMaps using Go templates, for example {{ .Region }} is
+	// replaced with the value for Region. Values that aren't valid templates
+	// are restored as is
+	Substitutions map[string]string `json:"substitutions"`
+	// SubstituteSecrets also applies the Substitutions to the data and
+	// stringData of restored Secrets
+	SubstituteSecrets bool `json:"substituteSecrets"`
+	// RollbackOnCancel deletes the resources that were created by the
+	// restore if it is deleted before it completes, or if it fails.
+	// Resources that already existed and were retained or merged are kept
+	RollbackOnCancel bool `json:"rollbackOnCancel"`
+	// GroupMapping is a map of API groups from the source to the API groups
+	// on the destination. It is applied to the apiVersion of restored
+	// resources, so custom resources can be restored if the group of their
+	// CRD was renamed
+	GroupMapping map[string]string `json:"groupMapping"`
+	// PruneExtraneous deletes the objects in the destination namespaces that
+	// aren't in the backup once the resources have been applied, so that
+	// the namespaces match the backup. Only the types of resources that are
+	// backed up are pruned, and objects owned by other objects are skipped
+	PruneExtraneous bool `json:"pruneExtraneous"`
+	// PruneProtectedKinds are the kinds of resources that are never deleted
+	// when PruneExtraneous is set
+	PruneProtectedKinds []string `json:"pruneProtectedKinds"`
+	// SuspendCronJobs restores CronJobs suspended so that they don't start
+	// jobs as soon as they are created. The suspend setting from the backup
+	// is kept in an annotation on the CronJob
+	SuspendCronJobs bool `json:"suspendCronJobs"`
+	// RestoreCompletedJobs restores Jobs that had completed or failed when
+	// they were backed up. By default they are skipped so that they don't
+	// run again, and are reported as Skipped in the status
+	RestoreCompletedJobs bool `json:"restoreCompletedJobs"`
+	// PreservePVUID records the UIDs of the backed up PVs for the restored
+	// volumes. The apiserver always generates a new UID for the restored
+	// PVs, so the original UID is added to the PVs in an annotation and the
+	// mapping is recorded in the status of each volume
+	PreservePVUID bool `json:"preservePVUID"`
+	// RetainVolumeSnapshots keeps the VolumeSnapshots and
+	// VolumeSnapshotContents that CSI volumes were restored from once the
+	// volumes are restored, for drivers that need them to attach the
+	// restored volumes. They are only kept for drivers that are registered
+	// with a CSIDriver object that requires attachment, and are deleted
+	// otherwise
+	RetainVolumeSnapshots bool `json:"retainVolumeSnapshots"`
+	// ImagePullSecretMapping is a map of the names of image pull secrets
+	// from the source to the names of the secrets on the destination. It is
+	// applied to the image pull secrets of restored ServiceAccounts and the
+	// pod templates of restored workloads
+	ImagePullSecretMapping map[string]string `json:"imagePullSecretMapping"`
+	// ApplyHooks are webhooks that restored resources are sent to before
+	// they are applied. The object returned by the webhook is applied
+	// instead of the one from the backup
+	ApplyHooks []ApplicationRestoreApplyHook `json:"applyHooks"`
+	// FailOnProvisionerMismatch fails the restore before the volumes are
+	// restored if the storage class used by a PVC on the destination has a
+	// different provisioner than the one that provisioned the volume in the
+	// source. Only a warning is reported otherwise
+	FailOnProvisionerMismatch bool `json:"failOnProvisionerMismatch"`
+	// PriorityClassMapping is a map of the names of PriorityClasses from the
+	// source to the names of the PriorityClasses on the destination. It is
+	// applied to the pod templates of restored workloads
+	PriorityClassMapping map[string]string `json:"priorityClassMapping"`
+	// ReportChanges compares the resources in the backup to the ones
+	// restored by the last completed restore to the same namespaces and
+	// records the objects that were added, removed or modified since then
+	// in Status.Changes
+	ReportChanges bool `json:"reportChanges"`
+	// StripFinalizers is a list of finalizers that are removed from all
+	// restored resources, like the ones added by operators that aren't
+	// installed on the destination, which would otherwise keep the objects
+	// from being deleted. Entries ending with * remove all finalizers with
+	// that prefix
+	StripFinalizers []string `json:"stripFinalizers"`
+	// VolumePlacement are hints for the zones that restored volumes are
+	// placed in. They are passed to the volume drivers, and the node affinity
+	// of the restored PVs is set to the zones that the drivers placed the
+	// volumes in. Drivers that don't support placement ignore them. The
+	// first entry that matches a volume is used
+	VolumePlacement []ApplicationRestoreVolumePlacement `json:"volumePlacement"`
+	// SanitizeNames renames restored resources whose names aren't valid on
+	// the destination, for example after they have been prefixed with their
+	// source namespace. Invalid characters are replaced and long names are
+	// truncated, with a hash of the original name added so that the new
+	// name is stable. Names are validated the same way as the apiserver does
+	// for their kind. References to renamed resources from pod specs,
+	// StatefulSets, ServiceAccounts, role bindings, Ingresses and
+	// HorizontalPodAutoscalers are updated. The renamed resources are
+	// recorded in Status.SanitizedNames and a warning is added to their
+	// status
+	SanitizeNames bool `json:"sanitizeNames"`
+	// StripLabels is a list of labels that are removed from all restored
+	// resources, like the ones watched by operators on the destination that
+	// would otherwise adopt or modify the restored objects. Only the labels
+	// of the objects themselves are removed, not the ones in selectors or
+	// pod templates. Entries ending with * remove all labels with that
+	// prefix
+	StripLabels []string `json:"stripLabels"`
+}
+
+// ApplicationRestoreVolumePlacement is where the volumes for a set of PVCs
+// are restored to
+type ApplicationRestoreVolumePlacement struct {
+	// PersistentVolumeClaims are the PVCs that the placement is used for, as
+	// namespace/name in the source. All volumes match if it is empty
+	PersistentVolumeClaims []string `json:"persistentVolumeClaims"`
+	// Zones that the volumes are restored to. Drivers that can only restore
+	// a volume to a single zone use the first one
+	Zones []string `json:"zones"`
+}
+
+// ApplicationRestoreApplyHook is a webhook that restored resources of a type
+// are posted to before they are applied. The webhook responds with the object
+// to be applied, which can't change the apiVersion, kind, name or namespace of
+// the object
+type ApplicationRestoreApplyHook struct {
+	// GroupVersionKind of the resources sent to the webhook. Resources of
+	// any group or version match if they aren't set
+	metav1.GroupVersionKind `json:",inline"`
+	// URL that the resources are posted to. It has to be for a service in
+	// the namespace of the restore, ie http(s)://<service>.<namespace>.svc,
+	// unless the restore is in the admin namespace
+	URL string `json:"url"`
+	// AllowSecrets allows Secrets to be sent to the webhook. They are
+	// never sent otherwise
+	AllowSecrets bool `json:"allowSecrets"`
+	// TimeoutSeconds is the time to wait for a response from the webhook.
+	// Defaults to 10 seconds
+	TimeoutSeconds int64 `json:"timeoutSeconds"`
+	// IgnoreFailure applies the resource from the backup if the webhook
+	// can't be called. The restore fails otherwise
+	IgnoreFailure bool `json:"ignoreFailure"`
+}
+
+// ApplicationRestoreResourceSelector selects resources from a backup. A
+// resource matches if it matches all the conditions in the selector
+type ApplicationRestoreResourceSelector struct {
+	// Kind of the resources that are selected. Resources of all kinds are
+	// selected if it isn't set
+	Kind string `json:"kind"`
+	// LabelSelector selects the resources by their labels
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+	// FieldMatchers select the resources by the values of their fields
+	FieldMatchers []ApplicationRestoreFieldMatcher `json:"fieldMatchers"`
+}
+
+// ApplicationRestoreFieldMatcher matches the value of a field in a resource
+type ApplicationRestoreFieldMatcher struct {
+	// Path of the field, for example spec.template.spec.serviceAccountName.
+	// Lists can't be traversed
+	Path string `json:"path"`
+	// Values that the field can have. Matches any value if empty, as long as
+	// the field is set
+	Values []string `json:"values"`
 }
 
 // ApplicationRestoreReplacePolicyType is the replace policy for the application restore
@@ -45,6 +390,91 @@ const (
 	// should retain existing resources that conflict with resources being
 	// restored
 	ApplicationRestoreReplacePolicyRetain ApplicationRestoreReplacePolicyType = "Retain"
+	// ApplicationRestoreReplacePolicyMerge is to specify that the restore
+	// should update existing resources with the fields that are set in the
+	// resources being restored. Fields that are only set on the existing
+	// resources are retained. Resources with fields that are managed by
+	// others on the destination are skipped and marked as a Conflict
+	ApplicationRestoreReplacePolicyMerge ApplicationRestoreReplacePolicyType = "Merge"
+)
+
+// ApplicationRestorePVCDataSourcePolicyType is the policy for the data source
+// of PVCs being restored
+type ApplicationRestorePVCDataSourcePolicyType string
+
+const (
+	// ApplicationRestorePVCDataSourcePolicyClear is to specify that the data
+	// source should be cleared for restored PVCs since the data is restored
+	// by the volume driver
+	ApplicationRestorePVCDataSourcePolicyClear ApplicationRestorePVCDataSourcePolicyType = "Clear"
+	// ApplicationRestorePVCDataSourcePolicyRemap is to specify that the data
+	// source should be updated to point to the restored object, with the name
+	// and namespace that it is restored with, if it is part of the restore.
+	// It is cleared otherwise
+	ApplicationRestorePVCDataSourcePolicyRemap ApplicationRestorePVCDataSourcePolicyType = "Remap"
+)
+
+// ApplicationRestoreNamespaceCollisionPolicyType is the policy for objects
+// from different source namespaces that would be restored with the same name
+type ApplicationRestoreNamespaceCollisionPolicyType string
+
+const (
+	// ApplicationRestoreNamespaceCollisionPolicyFail is to specify that the
+	// restore should fail, listing the objects that collide
+	ApplicationRestoreNamespaceCollisionPolicyFail ApplicationRestoreNamespaceCollisionPolicyType = "Fail"
+	// ApplicationRestoreNamespaceCollisionPolicyPrefix is to specify that the
+	// names of the objects that collide should be prefixed with their source
+	// namespace. References to the objects aren't updated. PVCs can't be
+	// renamed since their volumes are restored with the original names, so
+	// the restore fails if they collide
+	ApplicationRestoreNamespaceCollisionPolicyPrefix ApplicationRestoreNamespaceCollisionPolicyType = "Prefix"
+)
+
+// ApplicationRestoreExportType is where the resources for a restore are
+// exported to
+type ApplicationRestoreExportType string
+
+const (
+	// ApplicationRestoreExportConfigMap exports the resources to a ConfigMap
+	// in the namespace of the restore
+	ApplicationRestoreExportConfigMap ApplicationRestoreExportType = "ConfigMap"
+	// ApplicationRestoreExportBackupLocation exports the resources to the
+	// backup location of the restore
+	ApplicationRestoreExportBackupLocation ApplicationRestoreExportType = "BackupLocation"
+)
+
+// ApplicationRestoreValidationType is a check done for a restore that can be
+// skipped
+type ApplicationRestoreValidationType string
+
+const (
+	// ApplicationRestoreValidationBackupLocation checks that the backup
+	// location can be accessed before starting the restore
+	ApplicationRestoreValidationBackupLocation ApplicationRestoreValidationType = "BackupLocation"
+	// ApplicationRestoreValidationCompleteMarker checks that all the objects
+	// for the backup were uploaded before starting the restore
+	ApplicationRestoreValidationCompleteMarker ApplicationRestoreValidationType = "CompleteMarker"
+	// ApplicationRestoreValidationCRDReady waits for the CRDs from the backup
+	// to be established after they are registered
+	ApplicationRestoreValidationCRDReady ApplicationRestoreValidationType = "CRDReady"
+	// ApplicationRestoreValidationCapacity checks with the drivers that there
+	// is enough capacity for the volumes before starting to restore them
+	ApplicationRestoreValidationCapacity ApplicationRestoreValidationType = "Capacity"
+	// ApplicationRestoreValidationProvisioner checks that the storage
+	// classes used by the PVCs on the destination have the same provisioner
+	// as the volumes in the source
+	ApplicationRestoreValidationProvisioner ApplicationRestoreValidationType = "Provisioner"
+)
+
+// ApplicationRestoreMeshType is the type of service mesh whose sidecars
+// should be removed from restored workloads
+type ApplicationRestoreMeshType string
+
+const (
+	// ApplicationRestoreMeshIstio is the Istio service mesh
+	ApplicationRestoreMeshIstio ApplicationRestoreMeshType = "istio"
+	// ApplicationRestoreMeshLinkerd is the Linkerd service mesh
+	ApplicationRestoreMeshLinkerd ApplicationRestoreMeshType = "linkerd"
 )
 
 // ApplicationRestoreStatus is the status of a application restore operation
@@ -57,13 +487,121 @@ type ApplicationRestoreStatus struct {
 	FinishTimestamp     metav1.Time                       `json:"finishTimestamp"`
 	LastUpdateTimestamp metav1.Time                       `json:"lastUpdateTimestamp"`
 	TotalSize           uint64                            `json:"totalSize"`
+	// CreatedBy is the user that created the restore
+	CreatedBy string `json:"createdBy"`
+	// RestoredNamespaces are the destination namespaces that have already
+	// been created or updated by the restore
+	RestoredNamespaces []string `json:"restoredNamespaces"`
+	// RelaxedNamespaces are the destination namespaces whose Pod Security
+	// level has been relaxed by the restore and still needs to be set back
+	RelaxedNamespaces []string `json:"relaxedNamespaces"`
+	// ExportPath is the name of the ConfigMap or the path in the backup
+	// location that the resources were exported to
+	ExportPath string `json:"exportPath"`
+	// SkippedValidations are the checks that weren't done since
+	// Spec.SkipValidation was set
+	SkippedValidations []ApplicationRestoreValidationType `json:"skippedValidations"`
+	// FailedReconciles is the number of consecutive passes of the restore
+	// that have failed
+	FailedReconciles int `json:"failedReconciles"`
+	// ResourcesAppliedTimestamp is the time that the resources were applied,
+	// which the settle delay is counted from
+	ResourcesAppliedTimestamp metav1.Time `json:"resourcesAppliedTimestamp"`
+	// RelaxedWebhookConfigurations are the restored webhook configurations
+	// whose failure policy has been relaxed by the restore and still needs to
+	// be set back
+	RelaxedWebhookConfigurations []ObjectInfo `json:"relaxedWebhookConfigurations"`
+	// RelaxedCRDConversions are the names of the restored CRDs whose
+	// conversion webhook has been disabled by the restore, since its service
+	// didn't exist yet, and still needs to be set back
+	RelaxedCRDConversions []string `json:"relaxedCRDConversions"`
+	// SanitizedNames are the resources that were restored with a different
+	// name since their name wasn't valid. Only set if SanitizeNames is set
+	SanitizedNames []ApplicationRestoreSanitizedName `json:"sanitizedNames"`
+	// RolledBack is set once the resources created by the restore have been
+	// deleted because it was cancelled or failed
+	RolledBack bool `json:"rolledBack"`
+	// Changes are the differences between the resources restored by this
+	// restore and the previous one. Only set if ReportChanges is set
+	Changes *ApplicationRestoreChanges `json:"changes,omitempty"`
+	// PendingDisruptionBudgets are the PodDisruptionBudgets that haven't been
+	// applied yet since the pods they select weren't ready
+	PendingDisruptionBudgets []ApplicationRestorePendingDisruptionBudget `json:"pendingDisruptionBudgets,omitempty"`
+}
+
+// ApplicationRestorePendingDisruptionBudget is a PodDisruptionBudget that is
+// applied once the pods that it selects are ready
+type ApplicationRestorePendingDisruptionBudget struct {
+	ObjectInfo `json:",inline"`
+	// Object is the PodDisruptionBudget as it will be applied
+	Object runtime.RawExtension `json:"object"`
+	// DeferredTimestamp is the time that applying it was deferred. It is
+	// applied anyway once the pods haven't been ready for a while
+	DeferredTimestamp metav1.Time `json:"deferredTimestamp"`
+	// Existed is set if the PodDisruptionBudget existed before the restore
+	// started, so that it isn't deleted if the restore is rolled back
+	Existed bool `json:"existed,omitempty"`
+}
+
+// ApplicationRestoreSanitizedName is a resource that was restored with a
+// sanitized name
+type ApplicationRestoreSanitizedName struct {
+	// ObjectInfo is the resource with its original name
+	ObjectInfo `json:",inline"`
+	// SanitizedName is the name that the resource was restored with
+	SanitizedName string `json:"sanitizedName"`
+}
+
+// ApplicationRestoreChanges are the objects that changed between the backup
+// restored by a previous restore and the backup being restored
+type ApplicationRestoreChanges struct {
+	// PreviousRestore is the name of the restore that the resources were
+	// compared to
+	PreviousRestore string `json:"previousRestore"`
+	// PreviousBackup is the name of the backup restored by PreviousRestore
+	PreviousBackup string `json:"previousBackup"`
+	// Added are the objects that weren't in the previous backup
+	Added []ObjectInfo `json:"added"`
+	// Removed are the objects that are no longer in the backup
+	Removed []ObjectInfo `json:"removed"`
+	// Modified are the objects whose content changed
+	Modified []ObjectInfo `json:"modified"`
 }
 
 // ApplicationRestoreResourceInfo is the info for the restore of a resource
 type ApplicationRestoreResourceInfo struct {
-	ObjectInfo `json:',inline"`
+	ObjectInfo `json:",inline"`
 	Status     ApplicationRestoreStatusType `json:"status"`
 	Reason     string                       `json:"reason"`
+	// Diff is the difference between the resource in the backup and the
+	// object in the cluster. Only set for a DryRun
+	Diff *ApplicationRestoreResourceDiff `json:"diff,omitempty"`
+	// GeneratedName is the name the resource was restored with if it was
+	// created using its generateName
+	GeneratedName string `json:"generatedName,omitempty"`
+	// Created is set if the resource didn't exist before it was restored.
+	// Only tracked if RollbackOnCancel is set for the restore
+	Created bool `json:"created,omitempty"`
+	// Checksum is the checksum of the resource in the backup. It is used to
+	// find the resources that changed between restores
+	Checksum string `json:"checksum,omitempty"`
+	// Warnings are set for resources that were restored but need attention,
+	// for example if they were restored with a sanitized name
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ApplicationRestoreResourceDiff is the difference between a resource in the
+// backup and the object in the cluster. Fields are listed as paths in the
+// object, for example spec.replicas
+type ApplicationRestoreResourceDiff struct {
+	// Exists is false if the object doesn't exist in the cluster
+	Exists bool `json:"exists"`
+	// Added are the fields that are only set in the backup
+	Added []string `json:"added"`
+	// Removed are the fields that are only set in the cluster
+	Removed []string `json:"removed"`
+	// Changed are the fields that have different values
+	Changed []string `json:"changed"`
 }
 
 // ApplicationRestoreVolumeInfo is the info for the restore of a volume
@@ -77,6 +615,30 @@ type ApplicationRestoreVolumeInfo struct {
 	Status                ApplicationRestoreStatusType `json:"status"`
 	Reason                string                       `json:"reason"`
 	TotalSize             uint64                       `json:"totalSize"`
+	// Warnings are reported by the driver for volumes that were restored
+	// but are in a degraded state, for example with reduced redundancy
+	Warnings []string `json:"warnings,omitempty"`
+	// Verified is set once the driver has verified the restored volume, or
+	// if it can't verify it, when VerifyOnComplete is set for the restore
+	Verified bool `json:"verified,omitempty"`
+	// Static is set for CSI volumes whose PV was statically provisioned.
+	// These aren't restored by the driver, the PV is re-created from the
+	// backup instead and the PVC is bound to it
+	Static bool `json:"static,omitempty"`
+	// SourceVolumeUID is the UID of the backed up PV, recorded if
+	// PreservePVUID is set for the restore
+	SourceVolumeUID string `json:"sourceVolumeUID,omitempty"`
+	// RestoreVolumeUID is the UID of the restored PV, recorded if
+	// PreservePVUID is set for the restore
+	RestoreVolumeUID string `json:"restoreVolumeUID,omitempty"`
+	// PlacedZones are the zones that the driver restored the volume to
+	// using the VolumePlacement of the restore. Only set by drivers that
+	// support placement
+	PlacedZones []string `json:"placedZones,omitempty"`
+	// VolumeSnapshotContent is the VolumeSnapshotContent that a CSI volume
+	// was restored from. Only set if it was kept for the volume because
+	// RetainVolumeSnapshots is set for the restore
+	VolumeSnapshotContent string `json:"volumeSnapshotContent,omitempty"`
 }
 
 // ApplicationRestoreStatusType is the status of the application restore
@@ -95,6 +657,23 @@ const (
 	ApplicationRestoreStatusPartialSuccess ApplicationRestoreStatusType = "PartialSuccess"
 	// ApplicationRestoreStatusRetained for when restore was skipped to retain an already existing resource
 	ApplicationRestoreStatusRetained ApplicationRestoreStatusType = "Retained"
+	// ApplicationRestoreStatusSkipped for when a resource from the backup
+	// wasn't restored because of the restore spec, for example finished Jobs
+	ApplicationRestoreStatusSkipped ApplicationRestoreStatusType = "Skipped"
+	// ApplicationRestoreStatusConflict for when restore was skipped because an
+	// already existing resource conflicts with the one being restored, for
+	// example a PVC bound to a different PV
+	ApplicationRestoreStatusConflict ApplicationRestoreStatusType = "Conflict"
+	// ApplicationRestoreStatusStaged for when the volumes for a staged restore
+	// have been staged and the restore is waiting to be finalized
+	ApplicationRestoreStatusStaged ApplicationRestoreStatusType = "Staged"
+	// ApplicationRestoreStatusVolumesRestored for when the volumes have been
+	// restored and the restore is waiting to be allowed to restore the
+	// resources
+	ApplicationRestoreStatusVolumesRestored ApplicationRestoreStatusType = "VolumesRestored"
+	// ApplicationRestoreStatusVerificationFailed for when a volume was
+	// restored but the driver found that its data is corrupt
+	ApplicationRestoreStatusVerificationFailed ApplicationRestoreStatusType = "VerificationFailed"
 	// ApplicationRestoreStatusSuccessful for when restore has completed successfully
 	ApplicationRestoreStatusSuccessful ApplicationRestoreStatusType = "Successful"
 )
@@ -107,9 +686,15 @@ const (
 	ApplicationRestoreStageInitial ApplicationRestoreStageType = ""
 	// ApplicationRestoreStageVolumes for when volumes are being restored
 	ApplicationRestoreStageVolumes ApplicationRestoreStageType = "Volumes"
+	// ApplicationRestoreStageFinalizeVolumes for when the final sync is being
+	// done for volumes that were staged
+	ApplicationRestoreStageFinalizeVolumes ApplicationRestoreStageType = "FinalizeVolumes"
 	// ApplicationRestoreStageApplications for when applications are being
 	// restored
 	ApplicationRestoreStageApplications ApplicationRestoreStageType = "Applications"
+	// ApplicationRestoreStageSettle for when the restore is waiting for the
+	// restored resources to settle before it is marked as successful
+	ApplicationRestoreStageSettle ApplicationRestoreStageType = "Settle"
 	// ApplicationRestoreStageFinal is the final stage for restore
 	ApplicationRestoreStageFinal ApplicationRestoreStageType = "Final"
 )
@@ -41,6 +41,30 @@ type BackupLocationItem struct {
 	GoogleConfig  *GoogleConfig `json:"googleConfig,omitempty"`
 	SecretConfig  string        `json:"secretConfig"`
 	Sync          bool          `json:"sync"`
+	// EncryptionRequired prevents backups from being written to, or restored
+	// from, the location unless an encryption key is configured for it,
+	// either inline or through the SecretConfig
+	EncryptionRequired bool `json:"encryptionRequired"`
+	// Compression is the codec used to compress the objects written to the
+	// location, either gzip, lz4 or zstd. Objects aren't compressed if it isn't
+	// set. The codec is detected when objects are read, so it can be changed
+	// without affecting existing backups
+	Compression string `json:"compression"`
+	// Timeouts for requests made to the objectstore. Defaults are used for
+	// the operations that don't have a timeout configured
+	Timeouts *ObjectStoreTimeouts `json:"timeouts,omitempty"`
+}
+
+// ObjectStoreTimeouts specifies the timeouts for each type of request made to
+// the objectstore for a backup location
+type ObjectStoreTimeouts struct {
+	// Exists is the timeout for checking if an object exists. It should be
+	// short so that metadata probes fail fast
+	Exists metav1.Duration `json:"exists"`
+	// Read is the timeout for downloading an object
+	Read metav1.Duration `json:"read"`
+	// Write is the timeout for uploading or deleting an object
+	Write metav1.Duration `json:"write"`
 }
 
 // BackupLocationType is the type of the backup location
@@ -40,6 +40,35 @@ type GroupVolumeSnapshotSpec struct {
 	MaxRetries int `json:"maxRetries"`
 	// Options are pass-through parameters that are passed to the driver handling the group snapshot
 	Options map[string]string `json:"options"`
+	// Namespaces is a list of namespaces in which the PVCs are selected. Defaults to the
+	// namespace of the group volumesnapshot. Can only be set for group volumesnapshots in the
+	// admin namespace. The volumesnapshots are created in the namespace of their PVC
+	Namespaces []string `json:"namespaces"`
+	// MaxSnapshots is the number of volumesnapshots kept for each PVC when the
+	// group volumesnapshot completes. Older volumesnapshots created by group
+	// volumesnapshots from the same schedule are deleted. Only enforced for
+	// group volumesnapshots created by a schedule, and not if set to 0
+	MaxSnapshots int `json:"maxSnapshots"`
+	// MaxSnapshotAge is the age after which those volumesnapshots are deleted
+	// when the group volumesnapshot completes. Not enforced if it isn't set
+	MaxSnapshotAge meta.Duration `json:"maxSnapshotAge"`
+	// RuleAssociations are pre and post rules that are run for a subset of
+	// the PVCs in the group, in addition to PreExecRule and PostExecRule.
+	// Each rule is only run on the pods that use the PVCs that it selects
+	RuleAssociations []GroupVolumeSnapshotRuleAssociation `json:"ruleAssociations"`
+}
+
+// GroupVolumeSnapshotRuleAssociation associates pre and post rules with the
+// PVCs from a group snapshot that match a selector
+type GroupVolumeSnapshotRuleAssociation struct {
+	// PVCSelector selects the PVCs from the group that the rules are run for
+	PVCSelector PVCSelectorSpec `json:"pvcSelector"`
+	// PreExecRule is the name of rule applied before taking the snapshot. The rule needs to be
+	// in the same namespace as the group volumesnapshot
+	PreExecRule string `json:"preExecRule"`
+	// PostExecRule is the name of rule applied after taking the snapshot. The rule needs to be
+	// in the same namespace as the group volumesnapshot
+	PostExecRule string `json:"postExecRule"`
 }
 
 // PVCSelectorSpec is the spec to select the PVCs for group snapshot
@@ -63,15 +92,39 @@ type GroupVolumeSnapshotStatus struct {
 	Status          GroupVolumeSnapshotStatusType `json:"status"`
 	NumRetries      int                           `json:"numRetries"`
 	VolumeSnapshots []*VolumeSnapshotStatus       `json:"volumeSnapshots"`
+	// RuleTargets are the rules that are run for the group snapshot and the
+	// pods they are run on. Recorded once the PVCs have been selected, so
+	// that the post rules are run on the same pods as the pre rules
+	RuleTargets []GroupVolumeSnapshotRuleTarget `json:"ruleTargets,omitempty"`
+	// DeleteAttempts is the number of times that deleting the group snapshot
+	// from the driver has failed
+	DeleteAttempts int `json:"deleteAttempts,omitempty"`
+	// LastDeleteAttemptTimestamp is the time of the last failed delete
+	LastDeleteAttemptTimestamp meta.Time `json:"lastDeleteAttemptTimestamp,omitempty"`
+}
+
+// GroupVolumeSnapshotRuleTarget is a pair of pre and post rules along with
+// the pods that they are run on
+type GroupVolumeSnapshotRuleTarget struct {
+	PreExecRule  string `json:"preExecRule"`
+	PostExecRule string `json:"postExecRule"`
+	// Namespace is the namespace of the pods that the rules are run on
+	Namespace string `json:"namespace"`
+	// PersistentVolumeClaims are the PVCs whose pods the rules are run on.
+	// The rules are run on all the pods in the namespace if it is empty
+	PersistentVolumeClaims []string `json:"persistentVolumeClaims"`
 }
 
 // VolumeSnapshotStatus captures the status of a volume snapshot operation
 type VolumeSnapshotStatus struct {
 	VolumeSnapshotName string
-	TaskID             string
-	ParentVolumeID     string
-	DataSource         *crdv1.VolumeSnapshotDataSource
-	Conditions         []crdv1.VolumeSnapshotCondition
+	// VolumeSnapshotNamespace is the namespace of the volumesnapshot. The namespace of the
+	// group volumesnapshot is used if it isn't set
+	VolumeSnapshotNamespace string
+	TaskID                  string
+	ParentVolumeID          string
+	DataSource              *crdv1.VolumeSnapshotDataSource
+	Conditions              []crdv1.VolumeSnapshotCondition
 }
 
 // GroupVolumeSnapshotStatusType is types of statuses of a group snapshot operation
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// GroupVolumeSnapshotScheduleResourceName is name for "groupvolumesnapshotschedule" resource
+	GroupVolumeSnapshotScheduleResourceName = "groupvolumesnapshotschedule"
+	// GroupVolumeSnapshotScheduleResourcePlural is plural for "groupvolumesnapshotschedule" resource
+	GroupVolumeSnapshotScheduleResourcePlural = "groupvolumesnapshotschedules"
+)
+
+// GroupVolumeSnapshotScheduleSpec is the spec used to schedule group volumesnapshots
+type GroupVolumeSnapshotScheduleSpec struct {
+	Template           GroupVolumeSnapshotTemplateSpec `json:"template"`
+	SchedulePolicyName string                          `json:"schedulePolicyName"`
+	Suspend            *bool                           `json:"suspend"`
+	// ReclaimPolicy is used to decide if the group volumesnapshots created
+	// by the schedule are deleted when the schedule is deleted
+	ReclaimPolicy ReclaimPolicyType `json:"reclaimPolicy"`
+}
+
+// GroupVolumeSnapshotTemplateSpec describes the data a GroupVolumeSnapshot
+// should have when created from a template
+type GroupVolumeSnapshotTemplateSpec struct {
+	Spec GroupVolumeSnapshotSpec `json:"spec"`
+}
+
+// GroupVolumeSnapshotScheduleStatus is the status of a group volumesnapshot schedule
+type GroupVolumeSnapshotScheduleStatus struct {
+	Items map[SchedulePolicyType][]*ScheduledGroupVolumeSnapshotStatus `json:"items"`
+}
+
+// ScheduledGroupVolumeSnapshotStatus keeps track of the group volumesnapshot
+// that was triggered by a scheduled policy
+type ScheduledGroupVolumeSnapshotStatus struct {
+	Name              string                        `json:"name"`
+	CreationTimestamp meta.Time                     `json:"creationTimestamp"`
+	FinishTimestamp   meta.Time                     `json:"finishTimestamp"`
+	Status            GroupVolumeSnapshotStatusType `json:"status"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GroupVolumeSnapshotSchedule represents a scheduled group volumesnapshot object
+type GroupVolumeSnapshotSchedule struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            GroupVolumeSnapshotScheduleSpec   `json:"spec"`
+	Status          GroupVolumeSnapshotScheduleStatus `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GroupVolumeSnapshotScheduleList is a list of GroupVolumeSnapshotSchedules
+type GroupVolumeSnapshotScheduleList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []GroupVolumeSnapshotSchedule `json:"items"`
+}
@@ -40,6 +40,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&MigrationScheduleList{},
 		&GroupVolumeSnapshot{},
 		&GroupVolumeSnapshotList{},
+		&GroupVolumeSnapshotSchedule{},
+		&GroupVolumeSnapshotScheduleList{},
 		&SchedulePolicy{},
 		&SchedulePolicyList{},
 		&NamespacedSchedulePolicy{},
@@ -22,7 +22,8 @@ package v1alpha1
 
 import (
 	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -256,6 +257,13 @@ func (in *ApplicationBackupSpec) DeepCopyInto(out *ApplicationBackupSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SnapshotClassMapping != nil {
+		in, out := &in.SnapshotClassMapping, &out.SnapshotClassMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -297,6 +305,11 @@ func (in *ApplicationBackupStatus) DeepCopyInto(out *ApplicationBackupStatus) {
 	in.TriggerTimestamp.DeepCopyInto(&out.TriggerTimestamp)
 	in.LastUpdateTimestamp.DeepCopyInto(&out.LastUpdateTimestamp)
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.UploadedObjects != nil {
+		in, out := &in.UploadedObjects, &out.UploadedObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -626,6 +639,75 @@ func (in *ApplicationRestore) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreApplyHook) DeepCopyInto(out *ApplicationRestoreApplyHook) {
+	*out = *in
+	out.GroupVersionKind = in.GroupVersionKind
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreApplyHook.
+func (in *ApplicationRestoreApplyHook) DeepCopy() *ApplicationRestoreApplyHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreApplyHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreChanges) DeepCopyInto(out *ApplicationRestoreChanges) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]ObjectInfo, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]ObjectInfo, len(*in))
+		copy(*out, *in)
+	}
+	if in.Modified != nil {
+		in, out := &in.Modified, &out.Modified
+		*out = make([]ObjectInfo, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreChanges.
+func (in *ApplicationRestoreChanges) DeepCopy() *ApplicationRestoreChanges {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreChanges)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreFieldMatcher) DeepCopyInto(out *ApplicationRestoreFieldMatcher) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreFieldMatcher.
+func (in *ApplicationRestoreFieldMatcher) DeepCopy() *ApplicationRestoreFieldMatcher {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreFieldMatcher)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationRestoreList) DeepCopyInto(out *ApplicationRestoreList) {
 	*out = *in
@@ -659,10 +741,70 @@ func (in *ApplicationRestoreList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestorePendingDisruptionBudget) DeepCopyInto(out *ApplicationRestorePendingDisruptionBudget) {
+	*out = *in
+	out.ObjectInfo = in.ObjectInfo
+	in.Object.DeepCopyInto(&out.Object)
+	in.DeferredTimestamp.DeepCopyInto(&out.DeferredTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestorePendingDisruptionBudget.
+func (in *ApplicationRestorePendingDisruptionBudget) DeepCopy() *ApplicationRestorePendingDisruptionBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestorePendingDisruptionBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreResourceDiff) DeepCopyInto(out *ApplicationRestoreResourceDiff) {
+	*out = *in
+	if in.Added != nil {
+		in, out := &in.Added, &out.Added
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Removed != nil {
+		in, out := &in.Removed, &out.Removed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changed != nil {
+		in, out := &in.Changed, &out.Changed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreResourceDiff.
+func (in *ApplicationRestoreResourceDiff) DeepCopy() *ApplicationRestoreResourceDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreResourceDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationRestoreResourceInfo) DeepCopyInto(out *ApplicationRestoreResourceInfo) {
 	*out = *in
 	out.ObjectInfo = in.ObjectInfo
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = new(ApplicationRestoreResourceDiff)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -676,6 +818,51 @@ func (in *ApplicationRestoreResourceInfo) DeepCopy() *ApplicationRestoreResource
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreResourceSelector) DeepCopyInto(out *ApplicationRestoreResourceSelector) {
+	*out = *in
+	if in.LabelSelector != nil {
+		in, out := &in.LabelSelector, &out.LabelSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FieldMatchers != nil {
+		in, out := &in.FieldMatchers, &out.FieldMatchers
+		*out = make([]ApplicationRestoreFieldMatcher, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreResourceSelector.
+func (in *ApplicationRestoreResourceSelector) DeepCopy() *ApplicationRestoreResourceSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreResourceSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreSanitizedName) DeepCopyInto(out *ApplicationRestoreSanitizedName) {
+	*out = *in
+	out.ObjectInfo = in.ObjectInfo
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreSanitizedName.
+func (in *ApplicationRestoreSanitizedName) DeepCopy() *ApplicationRestoreSanitizedName {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreSanitizedName)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationRestoreSpec) DeepCopyInto(out *ApplicationRestoreSpec) {
 	*out = *in
@@ -696,6 +883,144 @@ func (in *ApplicationRestoreSpec) DeepCopyInto(out *ApplicationRestoreSpec) {
 		*out = make([]ObjectInfo, len(*in))
 		copy(*out, *in)
 	}
+	if in.DeleteGracePeriodSeconds != nil {
+		in, out := &in.DeleteGracePeriodSeconds, &out.DeleteGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	in.ChangedSince.DeepCopyInto(&out.ChangedSince)
+	if in.StripMeshSidecars != nil {
+		in, out := &in.StripMeshSidecars, &out.StripMeshSidecars
+		*out = make([]ApplicationRestoreMeshType, len(*in))
+		copy(*out, *in)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceAnnotationMapping != nil {
+		in, out := &in.ServiceAnnotationMapping, &out.ServiceAnnotationMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StripServiceAnnotations != nil {
+		in, out := &in.StripServiceAnnotations, &out.StripServiceAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StripCNIAnnotations != nil {
+		in, out := &in.StripCNIAnnotations, &out.StripCNIAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StripCNINodeAffinityKeys != nil {
+		in, out := &in.StripCNINodeAffinityKeys, &out.StripCNINodeAffinityKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StripAnnotations != nil {
+		in, out := &in.StripAnnotations, &out.StripAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeepAnnotations != nil {
+		in, out := &in.KeepAnnotations, &out.KeepAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotificationWebhooks != nil {
+		in, out := &in.NotificationWebhooks, &out.NotificationWebhooks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceSelectors != nil {
+		in, out := &in.ResourceSelectors, &out.ResourceSelectors
+		*out = make([]ApplicationRestoreResourceSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SingleResource != nil {
+		in, out := &in.SingleResource, &out.SingleResource
+		*out = new(ObjectInfo)
+		**out = **in
+	}
+	if in.IngressClassMapping != nil {
+		in, out := &in.IngressClassMapping, &out.IngressClassMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StripIngressAnnotations != nil {
+		in, out := &in.StripIngressAnnotations, &out.StripIngressAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeepIngressAnnotations != nil {
+		in, out := &in.KeepIngressAnnotations, &out.KeepIngressAnnotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.SettleDelay = in.SettleDelay
+	if in.Substitutions != nil {
+		in, out := &in.Substitutions, &out.Substitutions
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.GroupMapping != nil {
+		in, out := &in.GroupMapping, &out.GroupMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PruneProtectedKinds != nil {
+		in, out := &in.PruneProtectedKinds, &out.PruneProtectedKinds
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ImagePullSecretMapping != nil {
+		in, out := &in.ImagePullSecretMapping, &out.ImagePullSecretMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ApplyHooks != nil {
+		in, out := &in.ApplyHooks, &out.ApplyHooks
+		*out = make([]ApplicationRestoreApplyHook, len(*in))
+		copy(*out, *in)
+	}
+	if in.PriorityClassMapping != nil {
+		in, out := &in.PriorityClassMapping, &out.PriorityClassMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StripFinalizers != nil {
+		in, out := &in.StripFinalizers, &out.StripFinalizers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.VolumePlacement != nil {
+		in, out := &in.VolumePlacement, &out.VolumePlacement
+		*out = make([]ApplicationRestoreVolumePlacement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StripLabels != nil {
+		in, out := &in.StripLabels, &out.StripLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -719,7 +1044,7 @@ func (in *ApplicationRestoreStatus) DeepCopyInto(out *ApplicationRestoreStatus)
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(ApplicationRestoreResourceInfo)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -736,6 +1061,49 @@ func (in *ApplicationRestoreStatus) DeepCopyInto(out *ApplicationRestoreStatus)
 	}
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
 	in.LastUpdateTimestamp.DeepCopyInto(&out.LastUpdateTimestamp)
+	if in.RestoredNamespaces != nil {
+		in, out := &in.RestoredNamespaces, &out.RestoredNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RelaxedNamespaces != nil {
+		in, out := &in.RelaxedNamespaces, &out.RelaxedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SkippedValidations != nil {
+		in, out := &in.SkippedValidations, &out.SkippedValidations
+		*out = make([]ApplicationRestoreValidationType, len(*in))
+		copy(*out, *in)
+	}
+	in.ResourcesAppliedTimestamp.DeepCopyInto(&out.ResourcesAppliedTimestamp)
+	if in.RelaxedWebhookConfigurations != nil {
+		in, out := &in.RelaxedWebhookConfigurations, &out.RelaxedWebhookConfigurations
+		*out = make([]ObjectInfo, len(*in))
+		copy(*out, *in)
+	}
+	if in.RelaxedCRDConversions != nil {
+		in, out := &in.RelaxedCRDConversions, &out.RelaxedCRDConversions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SanitizedNames != nil {
+		in, out := &in.SanitizedNames, &out.SanitizedNames
+		*out = make([]ApplicationRestoreSanitizedName, len(*in))
+		copy(*out, *in)
+	}
+	if in.Changes != nil {
+		in, out := &in.Changes, &out.Changes
+		*out = new(ApplicationRestoreChanges)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PendingDisruptionBudgets != nil {
+		in, out := &in.PendingDisruptionBudgets, &out.PendingDisruptionBudgets
+		*out = make([]ApplicationRestorePendingDisruptionBudget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -757,6 +1125,16 @@ func (in *ApplicationRestoreVolumeInfo) DeepCopyInto(out *ApplicationRestoreVolu
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Warnings != nil {
+		in, out := &in.Warnings, &out.Warnings
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PlacedZones != nil {
+		in, out := &in.PlacedZones, &out.PlacedZones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -770,6 +1148,32 @@ func (in *ApplicationRestoreVolumeInfo) DeepCopy() *ApplicationRestoreVolumeInfo
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationRestoreVolumePlacement) DeepCopyInto(out *ApplicationRestoreVolumePlacement) {
+	*out = *in
+	if in.PersistentVolumeClaims != nil {
+		in, out := &in.PersistentVolumeClaims, &out.PersistentVolumeClaims
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationRestoreVolumePlacement.
+func (in *ApplicationRestoreVolumePlacement) DeepCopy() *ApplicationRestoreVolumePlacement {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationRestoreVolumePlacement)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureConfig) DeepCopyInto(out *AzureConfig) {
 	*out = *in
@@ -831,6 +1235,11 @@ func (in *BackupLocationItem) DeepCopyInto(out *BackupLocationItem) {
 		*out = new(GoogleConfig)
 		**out = **in
 	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(ObjectStoreTimeouts)
+		**out = **in
+	}
 	return
 }
 
@@ -1224,7 +1633,7 @@ func (in *DataExportDestination) DeepCopyInto(out *DataExportDestination) {
 	*out = *in
 	if in.PersistentVolumeClaim != nil {
 		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
-		*out = new(v1.PersistentVolumeClaim)
+		*out = new(corev1.PersistentVolumeClaim)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -1278,7 +1687,7 @@ func (in *DataExportSource) DeepCopyInto(out *DataExportSource) {
 	*out = *in
 	if in.PersistentVolumeClaim != nil {
 		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
-		*out = new(v1.PersistentVolumeClaim)
+		*out = new(corev1.PersistentVolumeClaim)
 		(*in).DeepCopyInto(*out)
 	}
 	return
@@ -1405,6 +1814,164 @@ func (in *GroupVolumeSnapshotList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotRuleAssociation) DeepCopyInto(out *GroupVolumeSnapshotRuleAssociation) {
+	*out = *in
+	in.PVCSelector.DeepCopyInto(&out.PVCSelector)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotRuleAssociation.
+func (in *GroupVolumeSnapshotRuleAssociation) DeepCopy() *GroupVolumeSnapshotRuleAssociation {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotRuleAssociation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotRuleTarget) DeepCopyInto(out *GroupVolumeSnapshotRuleTarget) {
+	*out = *in
+	if in.PersistentVolumeClaims != nil {
+		in, out := &in.PersistentVolumeClaims, &out.PersistentVolumeClaims
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotRuleTarget.
+func (in *GroupVolumeSnapshotRuleTarget) DeepCopy() *GroupVolumeSnapshotRuleTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotRuleTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotSchedule) DeepCopyInto(out *GroupVolumeSnapshotSchedule) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotSchedule.
+func (in *GroupVolumeSnapshotSchedule) DeepCopy() *GroupVolumeSnapshotSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GroupVolumeSnapshotSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotScheduleList) DeepCopyInto(out *GroupVolumeSnapshotScheduleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GroupVolumeSnapshotSchedule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotScheduleList.
+func (in *GroupVolumeSnapshotScheduleList) DeepCopy() *GroupVolumeSnapshotScheduleList {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotScheduleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GroupVolumeSnapshotScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotScheduleSpec) DeepCopyInto(out *GroupVolumeSnapshotScheduleSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotScheduleSpec.
+func (in *GroupVolumeSnapshotScheduleSpec) DeepCopy() *GroupVolumeSnapshotScheduleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotScheduleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotScheduleStatus) DeepCopyInto(out *GroupVolumeSnapshotScheduleStatus) {
+	*out = *in
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make(map[SchedulePolicyType][]*ScheduledGroupVolumeSnapshotStatus, len(*in))
+		for key, val := range *in {
+			var outVal []*ScheduledGroupVolumeSnapshotStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]*ScheduledGroupVolumeSnapshotStatus, len(*in))
+				for i := range *in {
+					if (*in)[i] != nil {
+						in, out := &(*in)[i], &(*out)[i]
+						*out = new(ScheduledGroupVolumeSnapshotStatus)
+						(*in).DeepCopyInto(*out)
+					}
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotScheduleStatus.
+func (in *GroupVolumeSnapshotScheduleStatus) DeepCopy() *GroupVolumeSnapshotScheduleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotScheduleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GroupVolumeSnapshotSpec) DeepCopyInto(out *GroupVolumeSnapshotSpec) {
 	*out = *in
@@ -1421,6 +1988,19 @@ func (in *GroupVolumeSnapshotSpec) DeepCopyInto(out *GroupVolumeSnapshotSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.MaxSnapshotAge = in.MaxSnapshotAge
+	if in.RuleAssociations != nil {
+		in, out := &in.RuleAssociations, &out.RuleAssociations
+		*out = make([]GroupVolumeSnapshotRuleAssociation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -1448,6 +2028,14 @@ func (in *GroupVolumeSnapshotStatus) DeepCopyInto(out *GroupVolumeSnapshotStatus
 			}
 		}
 	}
+	if in.RuleTargets != nil {
+		in, out := &in.RuleTargets, &out.RuleTargets
+		*out = make([]GroupVolumeSnapshotRuleTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.LastDeleteAttemptTimestamp.DeepCopyInto(&out.LastDeleteAttemptTimestamp)
 	return
 }
 
@@ -1461,6 +2049,23 @@ func (in *GroupVolumeSnapshotStatus) DeepCopy() *GroupVolumeSnapshotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotTemplateSpec) DeepCopyInto(out *GroupVolumeSnapshotTemplateSpec) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotTemplateSpec.
+func (in *GroupVolumeSnapshotTemplateSpec) DeepCopy() *GroupVolumeSnapshotTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntervalPolicy) DeepCopyInto(out *IntervalPolicy) {
 	*out = *in
@@ -1909,6 +2514,25 @@ func (in *ObjectInfo) DeepCopy() *ObjectInfo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectStoreTimeouts) DeepCopyInto(out *ObjectStoreTimeouts) {
+	*out = *in
+	out.Exists = in.Exists
+	out.Read = in.Read
+	out.Write = in.Write
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectStoreTimeouts.
+func (in *ObjectStoreTimeouts) DeepCopy() *ObjectStoreTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectStoreTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PVCSelectorSpec) DeepCopyInto(out *PVCSelectorSpec) {
 	*out = *in
@@ -2182,6 +2806,24 @@ func (in *ScheduledApplicationBackupStatus) DeepCopy() *ScheduledApplicationBack
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledGroupVolumeSnapshotStatus) DeepCopyInto(out *ScheduledGroupVolumeSnapshotStatus) {
+	*out = *in
+	in.CreationTimestamp.DeepCopyInto(&out.CreationTimestamp)
+	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledGroupVolumeSnapshotStatus.
+func (in *ScheduledGroupVolumeSnapshotStatus) DeepCopy() *ScheduledGroupVolumeSnapshotStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledGroupVolumeSnapshotStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScheduledMigrationStatus) DeepCopyInto(out *ScheduledMigrationStatus) {
 	*out = *in
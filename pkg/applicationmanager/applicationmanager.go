@@ -1,6 +1,7 @@
 package applicationmanager
 
 import (
+	"net/http"
 	"os"
 	"reflect"
 	"time"
@@ -9,38 +10,37 @@ import (
 	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
+	"github.com/libopenstorage/stork/pkg/audit"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/portworx/sched-ops/k8s/apiextensions"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
-const (
-	validateCRDInterval time.Duration = 5 * time.Second
-	validateCRDTimeout  time.Duration = 1 * time.Minute
-)
-
 // ApplicationManager maintains all controllers for application level operations
 type ApplicationManager struct {
 	Driver            volume.Driver
 	Recorder          record.EventRecorder
 	ResourceCollector resourcecollector.ResourceCollector
 	RsyncTime         int64
+	AuditSink         audit.Sink
 }
 
 // Init Initializes the ApplicationManager and any children controller
 func (a *ApplicationManager) Init(mgr manager.Manager, adminNamespace string, stopChannel chan os.Signal) error {
-	if err := a.createCRD(); err != nil {
+	if err := a.createCRD(mgr.GetConfig()); err != nil {
 		return err
 	}
-	backupController := controllers.NewApplicationBackup(mgr, a.Recorder, a.ResourceCollector)
+	backupController := controllers.NewApplicationBackup(mgr, a.Recorder, a.ResourceCollector, a.AuditSink)
 	if err := backupController.Init(mgr, adminNamespace, a.RsyncTime); err != nil {
 		return err
 	}
 
-	restoreController := controllers.NewApplicationRestore(mgr, a.Recorder, a.ResourceCollector)
+	restoreController := controllers.NewApplicationRestore(mgr, a.Recorder, a.ResourceCollector, a.AuditSink)
 	if err := restoreController.Init(mgr, adminNamespace); err != nil {
 		return err
 	}
@@ -66,10 +66,18 @@ func (a *ApplicationManager) Init(mgr manager.Manager, adminNamespace string, st
 	if err := controllers.RegisterDefaultCRDs(); err != nil {
 		return err
 	}
+
+	// Served on the same port as the metrics and the scheduler extender, so
+	// requests have to be authenticated
+	client, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	http.Handle(OperationsPath, &OperationsHandler{Client: client})
 	return nil
 }
 
-func (a *ApplicationManager) createCRD() error {
+func (a *ApplicationManager) createCRD(config *rest.Config) error {
 	resource := apiextensions.CustomResource{
 		Name:    stork_api.BackupLocationResourceName,
 		Plural:  stork_api.BackupLocationResourcePlural,
@@ -78,12 +86,7 @@ func (a *ApplicationManager) createCRD() error {
 		Scope:   apiextensionsv1beta1.NamespaceScoped,
 		Kind:    reflect.TypeOf(stork_api.BackupLocation{}).Name(),
 	}
-	err := apiextensions.Instance().CreateCRD(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-
-	if err := apiextensions.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval); err != nil {
+	if err := k8sutils.CreateCRD(config, resource); err != nil {
 		return err
 	}
 	appReg := apiextensions.CustomResource{
@@ -94,9 +97,5 @@ func (a *ApplicationManager) createCRD() error {
 		Scope:   apiextensionsv1beta1.ClusterScoped,
 		Kind:    reflect.TypeOf(stork_api.ApplicationRegistration{}).Name(),
 	}
-	err = apiextensions.Instance().CreateCRD(appReg)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRD(appReg, validateCRDTimeout, validateCRDInterval)
+	return k8sutils.CreateCRD(config, appReg)
 }
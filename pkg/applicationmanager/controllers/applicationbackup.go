@@ -10,15 +10,19 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-openapi/inflect"
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/audit"
+	"github.com/libopenstorage/stork/pkg/compression"
 	"github.com/libopenstorage/stork/pkg/controllers"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
@@ -36,6 +40,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -50,6 +55,11 @@ const (
 	crdObjectName      = "crds.json"
 	nsObjectName       = "namespaces.json"
 	metadataObjectName = "metadata.json"
+	// Marker object uploaded after all other objects for a backup
+	completeObjectName = "complete"
+	// Annotation set on backups that upload the complete marker, to tell
+	// them apart from backups taken before the marker was added
+	completeMarkerAnnotation = "stork.libopenstorage.org/complete-marker"
 
 	backupCancelBackoffInitialDelay = 5 * time.Second
 	backupCancelBackoffFactor       = 1
@@ -58,6 +68,8 @@ const (
 	allNamespacesSpecifier        = "*"
 	backupVolumeBatchCountEnvVar  = "BACKUP-VOLUME-BATCH-COUNT"
 	defaultBackupVolumeBatchCount = 3
+	// Number of drivers that backups are started for in parallel
+	backupVolumeDriverConcurrency = 4
 	backupResourcesBatchCount     = 15
 	maxRetry                      = 10
 	retrySleep                    = 10 * time.Second
@@ -73,11 +85,12 @@ var (
 )
 
 // NewApplicationBackup creates a new instance of ApplicationBackupController.
-func NewApplicationBackup(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector) *ApplicationBackupController {
+func NewApplicationBackup(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector, auditSink audit.Sink) *ApplicationBackupController {
 	return &ApplicationBackupController{
 		client:            mgr.GetClient(),
 		recorder:          r,
 		resourceCollector: rc,
+		auditSender:       audit.NewSender(auditSink),
 	}
 }
 
@@ -89,11 +102,12 @@ type ApplicationBackupController struct {
 	resourceCollector    resourcecollector.ResourceCollector
 	backupAdminNamespace string
 	reconcileTime        time.Duration
+	auditSender          *audit.Sender
 }
 
 // Init Initialize the application backup controller
 func (a *ApplicationBackupController) Init(mgr manager.Manager, backupAdminNamespace string, syncTime int64) error {
-	err := a.createCRD()
+	err := a.createCRD(mgr.GetConfig())
 	if err != nil {
 		return err
 	}
@@ -199,7 +213,7 @@ func (a *ApplicationBackupController) updateWithAllNamespaces(backup *stork_api.
 // Try to create the backup location path. Ignore errors since this is best
 // effort
 func (a *ApplicationBackupController) createBackupLocationPath(backup *stork_api.ApplicationBackup) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := k8sutils.GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
 		return fmt.Errorf("error getting backup location path: %v", err)
 	}
@@ -209,6 +223,52 @@ func (a *ApplicationBackupController) createBackupLocationPath(backup *stork_api
 	return nil
 }
 
+// validatePVCDriverAnnotations checks that the drivers set on the PVCs being
+// backed up with volume.PVCDriverAnnotation are installed
+func (a *ApplicationBackupController) validatePVCDriverAnnotations(backup *stork_api.ApplicationBackup) error {
+	objectMap := stork_api.CreateObjectsMap(backup.Spec.IncludeResources)
+	info := stork_api.ObjectInfo{
+		GroupVersionKind: metav1.GroupVersionKind{
+			Group:   "core",
+			Version: "v1",
+			Kind:    "PersistentVolumeClaim",
+		},
+	}
+	for _, namespace := range backup.Spec.Namespaces {
+		pvcList, err := core.Instance().GetPersistentVolumeClaims(namespace, backup.Spec.Selectors)
+		if err != nil {
+			return fmt.Errorf("error getting list of volumes to backup: %v", err)
+		}
+		for _, pvc := range pvcList.Items {
+			info.Name = pvc.Name
+			info.Namespace = pvc.Namespace
+			if len(objectMap) != 0 {
+				if val, present := objectMap[info]; !present || !val {
+					continue
+				}
+			}
+			driverName, ok := pvc.Annotations[volume.PVCDriverAnnotation]
+			if !ok {
+				continue
+			}
+			if _, err := volume.Get(driverName); err != nil {
+				return fmt.Errorf("driver %v set for PVC %v/%v is not installed", driverName, pvc.Namespace, pvc.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkBackupLocationEncryption checks that an encryption key is configured
+// for the backup location if it requires encryption
+func (a *ApplicationBackupController) checkBackupLocationEncryption(backup *stork_api.ApplicationBackup) error {
+	backupLocation, err := k8sutils.GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	if err != nil {
+		return fmt.Errorf("error getting backup location: %v", err)
+	}
+	return objectstore.CheckEncryption(backupLocation)
+}
+
 // handle updates for ApplicationBackup objects
 func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_api.ApplicationBackup) error {
 	if backup.DeletionTimestamp != nil {
@@ -238,6 +298,15 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 			err.Error())
 		return nil
 	}
+	if !a.referencedResourcesAllowed(backup) {
+		err := fmt.Errorf("Spec.IncludeReferencedResources is only allowed for backups in the admin namespace")
+		log.ApplicationBackupLog(backup).Errorf(err.Error())
+		a.recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			err.Error())
+		return nil
+	}
 
 	var terminationChannels []chan bool
 	var err error
@@ -286,6 +355,40 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 			}
 		}
 
+		// Fail early for PVCs pinned to a driver that isn't installed, since
+		// their backup can't be started
+		if err := a.validatePVCDriverAnnotations(backup); err != nil {
+			message := fmt.Sprintf("Error validating PVC drivers: %v", err)
+			log.ApplicationBackupLog(backup).Errorf(message)
+			a.recorder.Event(backup,
+				v1.EventTypeWarning,
+				string(stork_api.ApplicationBackupStatusFailed),
+				message)
+			backup.Status.Status = stork_api.ApplicationBackupStatusFailed
+			backup.Status.Reason = message
+			backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+			backup.Status.FinishTimestamp = metav1.Now()
+			backup.Status.LastUpdateTimestamp = metav1.Now()
+			return a.client.Update(context.TODO(), backup)
+		}
+
+		// Don't write anything to the backup location if it requires
+		// encryption and isn't configured for it
+		if err := a.checkBackupLocationEncryption(backup); err != nil {
+			message := fmt.Sprintf("Compliance check failed: %v", err)
+			log.ApplicationBackupLog(backup).Errorf(message)
+			a.recorder.Event(backup,
+				v1.EventTypeWarning,
+				string(stork_api.ApplicationBackupStatusFailed),
+				message)
+			backup.Status.Status = stork_api.ApplicationBackupStatusFailed
+			backup.Status.Reason = message
+			backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+			backup.Status.FinishTimestamp = metav1.Now()
+			backup.Status.LastUpdateTimestamp = metav1.Now()
+			return a.client.Update(context.TODO(), backup)
+		}
+
 		// Try to create the backupLocation path, just log error if it fails
 		err := a.createBackupLocationPath(backup)
 		if err != nil {
@@ -378,7 +481,7 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 		}
 
 	case stork_api.ApplicationBackupStageFinal:
-		// Do Nothing
+		a.recordAudit(backup)
 		return nil
 	default:
 		log.ApplicationBackupLog(backup).Errorf("Invalid stage for backup: %v", backup.Status.Stage)
@@ -387,6 +490,27 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 	return nil
 }
 
+// recordAudit writes the audit record for a backup that has reached a
+// terminal state. The record is written in the background and the backup is
+// annotated once it has been written, so that the backup isn't blocked.
+func (a *ApplicationBackupController) recordAudit(backup *stork_api.ApplicationBackup) {
+	if a.auditSender == nil || audit.IsRecorded(backup) {
+		return
+	}
+	key := runtimeclient.ObjectKeyFromObject(backup)
+	a.auditSender.Send(audit.NewBackupRecord(backup), func() {
+		backup := &stork_api.ApplicationBackup{}
+		if err := a.client.Get(context.TODO(), key, backup); err != nil {
+			logrus.Warnf("Error getting backup %v to update audit annotation: %v", key, err)
+			return
+		}
+		audit.SetRecorded(backup)
+		if err := a.client.Update(context.TODO(), backup); err != nil {
+			log.ApplicationBackupLog(backup).Warnf("Error updating audit annotation: %v", err)
+		}
+	})
+}
+
 func (a *ApplicationBackupController) namespaceBackupAllowed(backup *stork_api.ApplicationBackup) bool {
 	// If the backup is completed it has probably been synced, don't perform
 	// check for those
@@ -405,6 +529,16 @@ func (a *ApplicationBackupController) namespaceBackupAllowed(backup *stork_api.A
 	return true
 }
 
+// referencedResourcesAllowed checks if cluster scoped resources referenced by
+// the namespaced resources can be backed up. These could be shared with other
+// namespaces, so only the admin namespace is allowed to back them up.
+func (a *ApplicationBackupController) referencedResourcesAllowed(backup *stork_api.ApplicationBackup) bool {
+	if !backup.Spec.IncludeReferencedResources || backup.Status.Stage == stork_api.ApplicationBackupStageFinal {
+		return true
+	}
+	return backup.Namespace == a.backupAdminNamespace
+}
+
 func (a *ApplicationBackupController) getDriversForBackup(backup *stork_api.ApplicationBackup) map[string]bool {
 	drivers := make(map[string]bool)
 	for _, volumeInfo := range backup.Status.Volumes {
@@ -459,6 +593,145 @@ func (a *ApplicationBackupController) updateBackupCRWithRetry(
 	return backup, nil
 }
 
+// getStartedVolumeBackups returns the volume infos for the PVCs whose backups
+// have already been started, and removes those PVCs from the mappings so that
+// only the remaining ones are started. Infos for PVCs that are no longer being
+// backed up are dropped.
+func getStartedVolumeBackups(
+	volumeInfos []*stork_api.ApplicationBackupVolumeInfo,
+	pvcMappings map[string][]v1.PersistentVolumeClaim,
+) []*stork_api.ApplicationBackupVolumeInfo {
+	started := make(map[string]*stork_api.ApplicationBackupVolumeInfo)
+	for _, vInfo := range volumeInfos {
+		started[vInfo.Namespace+"/"+vInfo.PersistentVolumeClaim] = vInfo
+	}
+	startedInfos := make([]*stork_api.ApplicationBackupVolumeInfo, 0)
+	for driverName, pvcs := range pvcMappings {
+		remaining := make([]v1.PersistentVolumeClaim, 0)
+		for _, pvc := range pvcs {
+			if vInfo, ok := started[pvc.Namespace+"/"+pvc.Name]; ok {
+				startedInfos = append(startedInfos, vInfo)
+				continue
+			}
+			remaining = append(remaining, pvc)
+		}
+		if len(remaining) == 0 {
+			delete(pvcMappings, driverName)
+			continue
+		}
+		pvcMappings[driverName] = remaining
+	}
+	return startedInfos
+}
+
+// startVolumeBackups starts the backups for the PVCs of each driver. The
+// drivers are backed up in parallel, while the PVCs for each driver are
+// started in batches one after the other. The volume infos returned by the
+// drivers are added to the status of the backup as each batch is started. If
+// starting the backups fails for any driver the backup is marked as Failed.
+func (a *ApplicationBackupController) startVolumeBackups(
+	backup *stork_api.ApplicationBackup,
+	namespacedName types.NamespacedName,
+	pvcMappings map[string][]v1.PersistentVolumeClaim,
+) (*stork_api.ApplicationBackup, error) {
+	var err error
+	batchCount := defaultBackupVolumeBatchCount
+	if len(os.Getenv(backupVolumeBatchCountEnvVar)) != 0 {
+		batchCount, err = strconv.Atoi(os.Getenv(backupVolumeBatchCountEnvVar))
+		if err != nil || batchCount <= 0 {
+			batchCount = defaultBackupVolumeBatchCount
+		}
+	}
+
+	drivers := make(map[string]volume.Driver)
+	for driverName := range pvcMappings {
+		driver, err := volume.Get(driverName)
+		if err != nil {
+			return nil, err
+		}
+		drivers[driverName] = driver
+	}
+
+	// The drivers are passed the backup as it was before the backups were
+	// started, since the latest copy is replaced as the status is updated.
+	// Each driver gets its own copy since drivers can update the status of
+	// the backup they are passed. The volume infos they return are merged
+	// into the status under the lock.
+	startBackup := backup
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var startErr error
+	var updateErr error
+	workers := make(chan struct{}, backupVolumeDriverConcurrency)
+	for driverName, pvcs := range pvcMappings {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(driver volume.Driver, pvcs []v1.PersistentVolumeClaim, startBackup *stork_api.ApplicationBackup) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			for i := 0; i < len(pvcs); i += batchCount {
+				lock.Lock()
+				failed := startErr != nil || updateErr != nil
+				lock.Unlock()
+				if failed {
+					return
+				}
+
+				batch := pvcs[i:min(i+batchCount, len(pvcs))]
+				volumeInfos, err := driver.StartBackup(startBackup, batch)
+
+				lock.Lock()
+				if err != nil {
+					// TODO: If starting backup for a drive fails mark the entire backup
+					// as Cancelling, cancel any other started backups and then mark
+					// it as failed
+					if startErr == nil {
+						startErr = err
+					}
+					lock.Unlock()
+					return
+				}
+				updated, err := a.updateBackupCRWithRetry(
+					namespacedName,
+					stork_api.ApplicationBackupStatusInProgress,
+					stork_api.ApplicationBackupStageVolumes,
+					"Volume backups are in progress",
+					volumeInfos,
+				)
+				if err != nil {
+					updateErr = err
+				} else {
+					backup = updated
+				}
+				lock.Unlock()
+			}
+		}(drivers[driverName], pvcs, startBackup.DeepCopy())
+	}
+	wg.Wait()
+
+	if startErr != nil {
+		message := fmt.Sprintf("Error starting ApplicationBackup for volumes: %v", startErr)
+		log.ApplicationBackupLog(backup).Errorf(message)
+		a.recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			message)
+		return a.updateBackupCRWithRetry(
+			namespacedName,
+			stork_api.ApplicationBackupStatusFailed,
+			stork_api.ApplicationBackupStageFinal,
+			message,
+			nil,
+		)
+	}
+	if updateErr != nil {
+		return nil, updateErr
+	}
+	return backup, nil
+}
+
 func (a *ApplicationBackupController) backupVolumes(backup *stork_api.ApplicationBackup, terminationChannels []chan bool) error {
 	defer func() {
 		for _, channel := range terminationChannels {
@@ -501,7 +774,7 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 			if pvc.Status.Phase != v1.ClaimBound || pvc.DeletionTimestamp != nil {
 				continue
 			}
-			driverName, err := volume.GetPVCDriver(core.Instance(), &pvc)
+			driverName, err := volume.GetPVCDriverForBackup(core.Instance(), &pvc)
 			if err != nil {
 				// Skip unsupported PVCs
 				if _, ok := err.(*errors.ErrNotSupported); ok {
@@ -518,64 +791,23 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 			}
 		}
 	}
-	if backup.Status.Volumes == nil ||
-		(backup.Status.Volumes != nil && len(backup.Status.Volumes) != pvcCount) {
+	if backup.Status.Volumes == nil {
 		backup.Status.Volumes = make([]*stork_api.ApplicationBackupVolumeInfo, 0)
 	}
+	// Backups of volumes that were started before the backup was resumed,
+	// for example after stork was restarted, aren't started again
+	backup.Status.Volumes = getStartedVolumeBackups(backup.Status.Volumes, pvcMappings)
 
 	namespacedName := types.NamespacedName{}
 	namespacedName.Namespace = backup.Namespace
 	namespacedName.Name = backup.Name
 	if len(backup.Status.Volumes) != pvcCount {
-
-		for driverName, pvcs := range pvcMappings {
-			driver, err := volume.Get(driverName)
-			if err != nil {
-				return err
-			}
-			batchCount := defaultBackupVolumeBatchCount
-			if len(os.Getenv(backupVolumeBatchCountEnvVar)) != 0 {
-				batchCount, err = strconv.Atoi(os.Getenv(backupVolumeBatchCountEnvVar))
-				if err != nil {
-					batchCount = defaultBackupVolumeBatchCount
-				}
-			}
-			for i := 0; i < len(pvcs); i += batchCount {
-				batch := pvcs[i:min(i+batchCount, len(pvcs))]
-				volumeInfos, err := driver.StartBackup(backup, batch)
-				if err != nil {
-					// TODO: If starting backup for a drive fails mark the entire backup
-					// as Cancelling, cancel any other started backups and then mark
-					// it as failed
-					message := fmt.Sprintf("Error starting ApplicationBackup for volumes: %v", err)
-					log.ApplicationBackupLog(backup).Errorf(message)
-					a.recorder.Event(backup,
-						v1.EventTypeWarning,
-						string(stork_api.ApplicationBackupStatusFailed),
-						message)
-					_, err = a.updateBackupCRWithRetry(
-						namespacedName,
-						stork_api.ApplicationBackupStatusFailed,
-						stork_api.ApplicationBackupStageFinal,
-						message,
-						nil,
-					)
-					if err != nil {
-						return err
-					}
-					return nil
-				}
-				backup, err = a.updateBackupCRWithRetry(
-					namespacedName,
-					stork_api.ApplicationBackupStatusInProgress,
-					backup.Status.Stage,
-					"Volume backups are in progress",
-					volumeInfos,
-				)
-				if err != nil {
-					return err
-				}
-			}
+		backup, err = a.startVolumeBackups(backup, namespacedName, pvcMappings)
+		if err != nil {
+			return err
+		}
+		if backup.Status.Stage == stork_api.ApplicationBackupStageFinal {
+			return nil
 		}
 
 		// Terminate any background rules that were started
@@ -858,15 +1090,23 @@ func (a *ApplicationBackupController) uploadObject(
 	objectName string,
 	data []byte,
 ) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := k8sutils.GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
 		return err
 	}
+	// The key could have been removed after the backup was started
+	if err := objectstore.CheckEncryption(backupLocation); err != nil {
+		return err
+	}
 	bucket, err := objectstore.GetBucket(backupLocation)
 	if err != nil {
 		return err
 	}
 
+	// Data needs to be compressed before it is encrypted
+	if data, err = compression.Compress(data, backupLocation.Location.Compression); err != nil {
+		return err
+	}
 	if backupLocation.Location.EncryptionKey != "" {
 		if data, err = crypto.Encrypt(data, backupLocation.Location.EncryptionKey); err != nil {
 			return err
@@ -874,12 +1114,14 @@ func (a *ApplicationBackupController) uploadObject(
 	}
 
 	objectPath := GetObjectPath(backup)
-	writer, err := bucket.NewWriter(context.TODO(), filepath.Join(objectPath, objectName), nil)
+	ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestWrite)
+	defer cancel()
+	writer, err := bucket.NewWriter(ctx, filepath.Join(objectPath, objectName), nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = writer.Write(data)
+	_, err = objectstore.NewCountingWriter(writer, backupLocation, objectstore.OperationBackup).Write(data)
 	if err != nil {
 		closeErr := writer.Close()
 		if closeErr != nil {
@@ -895,7 +1137,29 @@ func (a *ApplicationBackupController) uploadObject(
 	return nil
 }
 
-// Convert the list of objects to json and upload to the backup location
+// objectUploaded checks if the object has already been uploaded for the backup
+func objectUploaded(backup *stork_api.ApplicationBackup, objectName string) bool {
+	for _, uploaded := range backup.Status.UploadedObjects {
+		if uploaded == objectName {
+			return true
+		}
+	}
+	return false
+}
+
+// recordUploadedObject records in the status that the object has been
+// uploaded, so that it isn't uploaded again if the backup is resumed
+func (a *ApplicationBackupController) recordUploadedObject(
+	backup *stork_api.ApplicationBackup,
+	objectName string,
+) error {
+	backup.Status.UploadedObjects = append(backup.Status.UploadedObjects, objectName)
+	backup.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), backup)
+}
+
+// Convert the list of objects to json and upload to the backup location.
+// Objects that were uploaded before the backup was resumed are skipped.
 func (a *ApplicationBackupController) uploadResources(
 	backup *stork_api.ApplicationBackup,
 	objects []runtime.Unstructured,
@@ -905,19 +1169,36 @@ func (a *ApplicationBackupController) uploadResources(
 		gvk := obj.GetObjectKind().GroupVersionKind()
 		resKinds[gvk.Kind] = gvk.Version
 	}
-	if err := a.uploadNamespaces(backup); err != nil {
-		return err
+	if !objectUploaded(backup, nsObjectName) {
+		if err := a.uploadNamespaces(backup); err != nil {
+			return err
+		}
+		if err := a.recordUploadedObject(backup, nsObjectName); err != nil {
+			return err
+		}
 	}
 	// upload CRD to backuplocation
-	if err := a.uploadCRDResources(backup, resKinds); err != nil {
-		return err
+	if !objectUploaded(backup, crdObjectName) {
+		if err := a.uploadCRDResources(backup, resKinds); err != nil {
+			return err
+		}
+		if err := a.recordUploadedObject(backup, crdObjectName); err != nil {
+			return err
+		}
+	}
+	if objectUploaded(backup, resourceObjectName) {
+		log.ApplicationBackupLog(backup).Infof("Skipping upload of resources since they were already uploaded")
+		return nil
 	}
 	jsonBytes, err := json.MarshalIndent(objects, "", " ")
 	if err != nil {
 		return err
 	}
 	// TODO: Encrypt if requested
-	return a.uploadObject(backup, resourceObjectName, jsonBytes)
+	if err := a.uploadObject(backup, resourceObjectName, jsonBytes); err != nil {
+		return err
+	}
+	return a.recordUploadedObject(backup, resourceObjectName)
 }
 func (a *ApplicationBackupController) uploadNamespaces(backup *stork_api.ApplicationBackup) error {
 	var namespaces []*v1.Namespace
@@ -1072,6 +1353,14 @@ func (a *ApplicationBackupController) backupResources(
 		resourceMap[metadata.GetUID()] = true
 		updatedAllObjects = append(updatedAllObjects, obj)
 	}
+	if backup.Spec.IncludeReferencedResources {
+		referencedObjects, err := a.resourceCollector.GetReferencedResources(updatedAllObjects)
+		if err != nil {
+			log.ApplicationBackupLog(backup).Errorf("Error getting referenced resources: %v", err)
+			return err
+		}
+		updatedAllObjects = append(updatedAllObjects, referencedObjects...)
+	}
 
 	if backup.Status.Resources == nil {
 		// Save the collected resources infos in the status
@@ -1155,6 +1444,10 @@ func (a *ApplicationBackupController) backupResources(
 	for _, vInfo := range backup.Status.Volumes {
 		backup.Status.TotalSize += vInfo.TotalSize
 	}
+	if backup.Annotations == nil {
+		backup.Annotations = make(map[string]string)
+	}
+	backup.Annotations[completeMarkerAnnotation] = "true"
 	// Upload the metadata for the backup to the backup location
 	if err = a.uploadMetadata(backup); err != nil {
 		a.recorder.Event(backup,
@@ -1164,6 +1457,16 @@ func (a *ApplicationBackupController) backupResources(
 		log.ApplicationBackupLog(backup).Errorf("Error uploading metadata: %v", err)
 		return err
 	}
+	// Upload the marker last so that restores can tell if all the objects
+	// for the backup were uploaded
+	if err = a.uploadObject(backup, completeObjectName, []byte(backup.Status.FinishTimestamp.String())); err != nil {
+		a.recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			fmt.Sprintf("Error uploading complete marker: %v", err))
+		log.ApplicationBackupLog(backup).Errorf("Error uploading complete marker: %v", err)
+		return err
+	}
 
 	backup.Status.LastUpdateTimestamp = metav1.Now()
 
@@ -1201,7 +1504,7 @@ func (a *ApplicationBackupController) deleteBackup(backup *stork_api.Application
 		}
 	}
 
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := k8sutils.GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
 	if err != nil {
 		// Can't do anything if the backup location is deleted
 		if k8s_errors.IsNotFound(err) {
@@ -1216,19 +1519,27 @@ func (a *ApplicationBackupController) deleteBackup(backup *stork_api.Application
 
 	objectPath := backup.Status.BackupPath
 	if objectPath != "" {
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, resourceObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestWrite)
+		defer cancel()
+		// Delete the marker first so that the backup can't be restored once
+		// the other objects start getting deleted
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, completeObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fmt.Errorf("error deleting complete marker for backup %v/%v: %v", backup.Namespace, backup.Name, err)
+		}
+
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, resourceObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting resources for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
 
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, metadataObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, metadataObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting metadata for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
 
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, crdObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, crdObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting crds for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
 
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, nsObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		if err = bucket.Delete(ctx, filepath.Join(objectPath, nsObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 			return fmt.Errorf("error deleting namespaces for backup %v/%v: %v", backup.Namespace, backup.Name, err)
 		}
 	}
@@ -1236,7 +1547,7 @@ func (a *ApplicationBackupController) deleteBackup(backup *stork_api.Application
 	return nil
 }
 
-func (a *ApplicationBackupController) createCRD() error {
+func (a *ApplicationBackupController) createCRD(config *rest.Config) error {
 	resource := apiextensions.CustomResource{
 		Name:    stork_api.ApplicationBackupResourceName,
 		Plural:  stork_api.ApplicationBackupResourcePlural,
@@ -1245,10 +1556,5 @@ func (a *ApplicationBackupController) createCRD() error {
 		Scope:   apiextensionsv1beta1.NamespaceScoped,
 		Kind:    reflect.TypeOf(stork_api.ApplicationBackup{}).Name(),
 	}
-	err := apiextensions.Instance().CreateCRD(resource)
-	if err != nil && !k8s_errors.IsAlreadyExists(err) {
-		return err
-	}
-
-	return apiextensions.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+	return k8sutils.CreateCRD(config, resource)
 }
@@ -0,0 +1,80 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newResumePVC(namespace, name string) v1.PersistentVolumeClaim {
+	return v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+}
+
+func getPVCNames(pvcs []v1.PersistentVolumeClaim) []string {
+	names := make([]string, 0)
+	for _, pvc := range pvcs {
+		names = append(names, pvc.Namespace+"/"+pvc.Name)
+	}
+	return names
+}
+
+func TestGetStartedVolumeBackups(t *testing.T) {
+	tests := []struct {
+		name        string
+		volumeInfos []*stork_api.ApplicationBackupVolumeInfo
+		pvcMappings map[string][]v1.PersistentVolumeClaim
+		started     []string
+		remaining   map[string][]string
+	}{
+		{
+			name: "nothing started",
+			pvcMappings: map[string][]v1.PersistentVolumeClaim{
+				"pxd": {newResumePVC("ns", "pvc1"), newResumePVC("ns", "pvc2")},
+			},
+			started:   []string{},
+			remaining: map[string][]string{"pxd": {"ns/pvc1", "ns/pvc2"}},
+		},
+		{
+			name: "partially started",
+			volumeInfos: []*stork_api.ApplicationBackupVolumeInfo{
+				{Namespace: "ns", PersistentVolumeClaim: "pvc1"},
+			},
+			pvcMappings: map[string][]v1.PersistentVolumeClaim{
+				"pxd": {newResumePVC("ns", "pvc1"), newResumePVC("ns", "pvc2")},
+				"csi": {newResumePVC("other", "pvc1")},
+			},
+			started:   []string{"ns/pvc1"},
+			remaining: map[string][]string{"pxd": {"ns/pvc2"}, "csi": {"other/pvc1"}},
+		},
+		{
+			name: "all started for a driver and removed PVCs dropped",
+			volumeInfos: []*stork_api.ApplicationBackupVolumeInfo{
+				{Namespace: "ns", PersistentVolumeClaim: "pvc1"},
+				{Namespace: "ns", PersistentVolumeClaim: "deleted"},
+			},
+			pvcMappings: map[string][]v1.PersistentVolumeClaim{
+				"pxd": {newResumePVC("ns", "pvc1")},
+				"csi": {newResumePVC("other", "pvc1")},
+			},
+			started:   []string{"ns/pvc1"},
+			remaining: map[string][]string{"csi": {"other/pvc1"}},
+		},
+	}
+	for _, test := range tests {
+		startedInfos := getStartedVolumeBackups(test.volumeInfos, test.pvcMappings)
+		started := make([]string, 0)
+		for _, vInfo := range startedInfos {
+			started = append(started, vInfo.Namespace+"/"+vInfo.PersistentVolumeClaim)
+		}
+		require.ElementsMatch(t, test.started, started, test.name)
+		require.Len(t, test.pvcMappings, len(test.remaining), test.name)
+		for driverName, pvcs := range test.remaining {
+			require.ElementsMatch(t, pvcs, getPVCNames(test.pvcMappings[driverName]), test.name)
+		}
+	}
+}
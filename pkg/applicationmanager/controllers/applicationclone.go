@@ -646,7 +646,8 @@ func (a *ApplicationCloneController) applyResources(
 	if clone.Spec.ReplacePolicy == stork_api.ApplicationCloneReplacePolicyDelete {
 		err := a.resourceCollector.DeleteResources(
 			a.dynamicInterface,
-			objects)
+			objects,
+			nil)
 		if err != nil {
 			return err
 		}
@@ -1,17 +1,34 @@
 package controllers
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/pkg/apis/stork"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/audit"
+	"github.com/libopenstorage/stork/pkg/compression"
 	"github.com/libopenstorage/stork/pkg/controllers"
 	"github.com/libopenstorage/stork/pkg/crypto"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
@@ -20,7 +37,11 @@ import (
 	"github.com/portworx/sched-ops/k8s/core"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
+	"gocloud.dev/blob"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -28,22 +49,162 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/kubernetes/pkg/util/slice"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const (
+	// StorkRestoreReplicasAnnotation is the annotation used to keep track of
+	// the number of replicas for a workload that was restored paused
+	StorkRestoreReplicasAnnotation = "stork.libopenstorage.org/restoreReplicas"
+	// StorkRestoreSuspendAnnotation is the annotation used to keep track of
+	// the suspend setting of a CronJob that was restored suspended
+	StorkRestoreSuspendAnnotation = "stork.libopenstorage.org/restoreSuspend"
+	// StorkRestoreOrderAnnotation is the annotation that can be set on
+	// objects with an integer to control the order in which they are applied
+	// during a restore. Objects with lower values are applied first, and
+	// objects without it are treated as having a value of 0
+	StorkRestoreOrderAnnotation = "stork.libopenstorage.org/restore-order"
+	// StorkRestoreBackupNameAnnotation is set on restored namespaces with the
+	// name of the backup they were restored from
+	StorkRestoreBackupNameAnnotation = "stork.libopenstorage.org/restored-from-backup"
+	// StorkRestoreBackupLocationAnnotation is set on restored namespaces with
+	// the name of the backup location they were restored from
+	StorkRestoreBackupLocationAnnotation = "stork.libopenstorage.org/restored-from-backup-location"
+	// StorkRestoreTimestampAnnotation is set on restored namespaces, and on
+	// restored resources if requested, with the time they were last restored
+	StorkRestoreTimestampAnnotation = "stork.libopenstorage.org/restore-timestamp"
+	// StorkRestoredByLabel is set on restored resources, if requested, with
+	// the name of the restore
+	StorkRestoredByLabel = "stork.libopenstorage.org/restored-by"
+	// StorkRestoreResourcesAnnotation is set to true on a restore with
+	// WaitAfterVolumes to restore the resources once the volumes have been
+	// restored
+	StorkRestoreResourcesAnnotation = "stork.libopenstorage.org/restore-resources"
+
+	// Keys in the namespace template ConfigMap
+	nsTemplateLabelsKey      = "labels"
+	nsTemplateAnnotationsKey = "annotations"
+	nsTemplateLimitRangeKey  = "limitRange"
+	nsTemplateDefaultDenyKey = "defaultDenyNetworkPolicy"
+	// Name used for default objects created from the namespace template
+	nsTemplateObjectName = "stork-namespace-template"
+
+	// Number of namespaces that resources are applied to in parallel
+	namespaceApplyConcurrency = 5
+	// Number of times adding owner references to an object is retried on
+	// conflicts
+	ownerReferenceUpdateRetries = 5
+
+	// Time to wait for the pods selected by a PodDisruptionBudget to be
+	// ready before applying it anyway
+	pdbTargetsTimeout = 5 * time.Minute
+
+	// Time for which the list of objects in a backup is cached
+	backupObjectCacheTimeout = 10 * time.Minute
+
+	// Prefix of the annotations on namespaces with the Pod Security level that
+	// was enforced before it was relaxed by each restore
+	podSecurityEnforceAnnotation = "stork.libopenstorage.org/pod-security-enforce"
+	// Time after a restore completes before the Pod Security level is set
+	// back, to give controllers time to create the pods for the workloads
+	podSecurityRestoreDelay = 5 * time.Minute
+
+	// Annotation on webhook configurations with the failure policies of the
+	// webhooks before they were relaxed by a restore
+	webhookFailurePolicyAnnotation = "stork.libopenstorage.org/webhook-failure-policies"
+	// Time after a restore completes before the failure policies of webhooks
+	// are set back, to give the webhook backends time to start
+	webhookFailurePolicyRestoreDelay = 5 * time.Minute
+
+	// Annotation on CRDs with the conversion that was set before the
+	// conversion webhook was disabled by a restore
+	crdConversionAnnotation = "stork.libopenstorage.org/crd-conversion"
+	// Time after a restore completes before the conversion of CRDs is set
+	// back, to give the conversion webhook backends time to start
+	crdConversionRestoreDelay = 5 * time.Minute
+	// Time after a restore completes after which the restore is failed if
+	// the services for the conversion webhooks of CRDs still don't exist
+	crdConversionRestoreTimeout = 30 * time.Minute
+
+	// Name of the exported bundle in the ConfigMap or backup location
+	exportObjectName = "resources.yaml"
+	// Maximum size of the data in a ConfigMap
+	maxConfigMapDataSize = 1024 * 1024
+
+	// Annotations used to mark the default StorageClass in a cluster
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+
+	// Annotations set on PVCs with the provisioner that provisioned them
+	pvcProvisionerAnnotation     = "volume.kubernetes.io/storage-provisioner"
+	betaPVCProvisionerAnnotation = "volume.beta.kubernetes.io/storage-provisioner"
+
+	// Annotation used to set the class of an Ingress before ingressClassName
+	// was added
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+	// Annotation added to PVs that were dynamically provisioned
+	pvProvisionedByAnnotation = "pv.kubernetes.io/provisioned-by"
+
+	// Timeout for each attempt to post a notification to a webhook
+	notificationWebhookTimeout = 5 * time.Second
+	// Default timeout for responses from apply hooks
+	applyHookDefaultTimeout = 10 * time.Second
+	// Number of objects that are posted to apply hooks in parallel
+	applyHookConcurrency = 10
+	// Time for which the responses from apply hooks are cached
+	applyHookCacheTimeout = 30 * time.Minute
+)
+
+// Backoff for retrying notifications to webhooks. Tried 3 times, waiting for
+// a total of 3 seconds between the attempts.
+var notificationWebhookBackoff = wait.Backoff{
+	Duration: 1 * time.Second,
+	Factor:   2,
+	Steps:    3,
+}
+
+// RestoreNotification is the payload posted to the notification webhooks of a
+// restore when its stage or status changes
+type RestoreNotification struct {
+	Name          string                                `json:"name"`
+	Namespace     string                                `json:"namespace"`
+	UID           string                                `json:"uid"`
+	PreviousStage storkapi.ApplicationRestoreStageType  `json:"previousStage"`
+	Stage         storkapi.ApplicationRestoreStageType  `json:"stage"`
+	Status        storkapi.ApplicationRestoreStatusType `json:"status"`
+	Reason        string                                `json:"reason"`
+	Timestamp     metav1.Time                           `json:"timestamp"`
+}
+
 // NewApplicationRestore creates a new instance of ApplicationRestoreController.
-func NewApplicationRestore(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector) *ApplicationRestoreController {
+func NewApplicationRestore(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector, auditSink audit.Sink) *ApplicationRestoreController {
 	return &ApplicationRestoreController{
 		client:            mgr.GetClient(),
 		recorder:          r,
 		resourceCollector: rc,
+		auditSender:       audit.NewSender(auditSink),
+		webhookClient: &http.Client{
+			Timeout: notificationWebhookTimeout,
+			// Redirects aren't followed since they could be to any endpoint
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
 	}
 }
 
@@ -55,11 +216,60 @@ type ApplicationRestoreController struct {
 	resourceCollector     resourcecollector.ResourceCollector
 	dynamicInterface      dynamic.Interface
 	restoreAdminNamespace string
+	auditSender           *audit.Sender
+	resourceStatusLock    sync.Mutex
+	backupObjectsLock     sync.Mutex
+	backupObjects         map[string]*backupObjectList
+	webhookClient         *http.Client
+	applyHookLock         sync.Mutex
+	applyHookResults      map[string]*applyHookResultList
+	notificationLock      sync.Mutex
+	notificationQueues    map[string][]*pendingNotification
+	targetsLock           sync.Mutex
+	targets               map[string]*cachedRestoreTarget
+}
+
+// cachedRestoreTarget is the target for a ClusterPair, along with the version
+// of the ClusterPair that its clients were created from
+type cachedRestoreTarget struct {
+	resourceVersion string
+	target          *restoreTarget
+}
+
+// pendingNotification is a notification that is waiting to be posted to the
+// webhooks of a restore
+type pendingNotification struct {
+	logger   *logrus.Entry
+	webhooks []string
+	data     []byte
+}
+
+// restoreTarget has the clients for the cluster that resources are restored to
+type restoreTarget struct {
+	config           *rest.Config
+	coreOps          core.Ops
+	client           runtimeclient.Client
+	dynamicInterface dynamic.Interface
+}
+
+// applyHookResultList has the objects returned by the apply hooks for a
+// restore, keyed by the hook and the object that was posted. Resources are
+// applied in more than one pass, so this keeps each hook from being called
+// more than once for an object.
+type applyHookResultList struct {
+	updateTime time.Time
+	results    map[string]map[string]interface{}
+}
+
+// backupObjectList is the list of objects in a backup path
+type backupObjectList struct {
+	listTime time.Time
+	objects  map[string]bool
 }
 
 // Init Initialize the application restore controller
 func (a *ApplicationRestoreController) Init(mgr manager.Manager, restoreAdminNamespace string) error {
-	err := a.createCRD()
+	err := a.createCRD(mgr.GetConfig())
 	if err != nil {
 		return err
 	}
@@ -83,6 +293,18 @@ func (a *ApplicationRestoreController) setDefaults(restore *storkapi.Application
 	if restore.Spec.ReplacePolicy == "" {
 		restore.Spec.ReplacePolicy = storkapi.ApplicationRestoreReplacePolicyRetain
 	}
+	if single := restore.Spec.SingleResource; single != nil {
+		if len(restore.Spec.IncludeResources) == 0 {
+			restore.Spec.IncludeResources = []storkapi.ObjectInfo{*single}
+		} else if len(restore.Spec.IncludeResources) != 1 ||
+			!objectInfoMatches(restore.Spec.IncludeResources[0], *single) {
+			return fmt.Errorf("singleResource can't be specified along with includeResources")
+		}
+	}
+	// Record the user that created the restore, set by the admission webhook
+	if restore.Status.CreatedBy == "" {
+		restore.Status.CreatedBy = restore.Annotations[storkapi.CreatedByAnnotation]
+	}
 	// If no namespaces mappings are provided add mappings for all of them
 	if len(restore.Spec.NamespaceMapping) == 0 {
 		backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
@@ -99,88 +321,328 @@ func (a *ApplicationRestoreController) setDefaults(restore *storkapi.Application
 	return nil
 }
 
-func (a *ApplicationRestoreController) verifyNamespaces(restore *storkapi.ApplicationRestore) error {
+// verifyNamespaces checks that the restore is allowed to restore to the
+// namespaces and creates them. Returns false if there are more namespaces to
+// be created in the next pass.
+func (a *ApplicationRestoreController) verifyNamespaces(restore *storkapi.ApplicationRestore) (bool, error) {
 	// Check whether namespace is allowed to be restored to before each stage
 	// Restrict restores to only the namespace that the object belongs
 	// except for the namespace designated by the admin
 	if !a.namespaceRestoreAllowed(restore) {
-		return fmt.Errorf("Spec.Namespaces should only contain the current namespace")
+		return false, fmt.Errorf("Spec.Namespaces should only contain the current namespace")
 	}
 	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
 	if err != nil {
 		log.ApplicationRestoreLog(restore).Errorf("Error getting backup: %v", err)
-		return err
+		return false, err
+	}
+	// Nothing is created for a dry run or when exporting the resources
+	if restore.Spec.DryRun || restore.Spec.ExportType != "" {
+		return true, nil
+	}
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return false, err
+	}
+	return a.createNamespaces(backup, restore.Spec.BackupLocation, restore, target)
+}
+
+// getRestoreTarget returns the clients for the cluster that the restore is
+// applied to. This is the remote cluster from the ClusterPair if one is
+// specified, and the local cluster otherwise. The clients are cached for each
+// cluster so that they aren't created on every call.
+func (a *ApplicationRestoreController) getRestoreTarget(restore *storkapi.ApplicationRestore) (*restoreTarget, error) {
+	a.targetsLock.Lock()
+	defer a.targetsLock.Unlock()
+	if a.targets == nil {
+		a.targets = make(map[string]*cachedRestoreTarget)
+	}
+
+	if restore.Spec.ClusterPair == "" {
+		if cached, ok := a.targets[""]; ok {
+			return cached.target, nil
+		}
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("error getting cluster config: %v", err)
+		}
+		target := &restoreTarget{
+			config:           config,
+			coreOps:          core.Instance(),
+			client:           a.client,
+			dynamicInterface: a.dynamicInterface,
+		}
+		a.targets[""] = &cachedRestoreTarget{target: target}
+		return target, nil
+	}
+
+	clusterPair, err := storkops.Instance().GetClusterPair(restore.Spec.ClusterPair, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting clusterpair (%v/%v): %v", restore.Namespace, restore.Spec.ClusterPair, err)
+	}
+	if clusterPair.Status.SchedulerStatus != storkapi.ClusterPairStatusReady {
+		return nil, fmt.Errorf("scheduler status for clusterpair %v is %v", clusterPair.Name, clusterPair.Status.SchedulerStatus)
+	}
+	// The clients are created again if the ClusterPair has been updated,
+	// since its config could have changed
+	key := clusterPair.Namespace + "/" + clusterPair.Name
+	if cached, ok := a.targets[key]; ok && cached.resourceVersion == clusterPair.ResourceVersion {
+		return cached.target, nil
+	}
+	config, err := clientcmd.NewNonInteractiveClientConfig(
+		clusterPair.Spec.Config,
+		clusterPair.Spec.Config.CurrentContext,
+		&clientcmd.ConfigOverrides{},
+		clientcmd.NewDefaultClientConfigLoadingRules()).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error getting config for clusterpair %v: %v", clusterPair.Name, err)
+	}
+	coreOps, err := core.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	client, err := runtimeclient.New(config, runtimeclient.Options{})
+	if err != nil {
+		return nil, err
+	}
+	dynamicInterface, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	target := &restoreTarget{
+		config:           config,
+		coreOps:          coreOps,
+		client:           client,
+		dynamicInterface: dynamicInterface,
 	}
-	return a.createNamespaces(backup, restore.Spec.BackupLocation, restore)
+	a.targets[key] = &cachedRestoreTarget{
+		resourceVersion: clusterPair.ResourceVersion,
+		target:          target,
+	}
+	return target, nil
 }
 
+// createNamespaces creates the namespaces that haven't been created by the
+// restore yet, up to Spec.NamespaceBatchSize at a time. The namespaces that
+// are created are recorded in the status so that the next pass resumes from
+// there. Returns false if there are more namespaces to be created.
 func (a *ApplicationRestoreController) createNamespaces(backup *storkapi.ApplicationBackup,
 	backupLocation string,
-	restore *storkapi.ApplicationRestore) error {
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget) (bool, error) {
 	var namespaces []*v1.Namespace
 
+	template, err := a.getNamespaceTemplate(restore)
+	if err != nil {
+		return false, err
+	}
+
 	nsData, err := a.downloadObject(backup, backupLocation, restore.Namespace, nsObjectName, true)
 	if err != nil {
-		return err
+		return false, err
 	}
 	if nsData != nil {
-		if err = json.Unmarshal(nsData, &namespaces); err != nil {
-			return err
+		var backupNamespaces []*v1.Namespace
+		if err = json.Unmarshal(nsData, &backupNamespaces); err != nil {
+			return false, err
 		}
-		for _, ns := range namespaces {
+		for _, ns := range backupNamespaces {
 			if restoreNS, ok := restore.Spec.NamespaceMapping[ns.Name]; ok {
 				ns.Name = restoreNS
 			} else {
 				// Skip namespaces we aren't restoring
 				continue
 			}
-			// create mapped restore namespace with metadata of backed up
-			// namespace
-			_, err := core.Instance().CreateNamespace(&v1.Namespace{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:        ns.Name,
-					Labels:      ns.Labels,
-					Annotations: ns.GetAnnotations(),
-				},
-			})
-			log.ApplicationRestoreLog(restore).Infof("Creating dest namespace %v", ns.Name)
-			if err != nil {
-				if errors.IsAlreadyExists(err) {
-					log.ApplicationRestoreLog(restore).Warnf("Namespace already exists, updating dest namespace %v", ns.Name)
-					// regardless of replace policy we should always update namespace is
-					// its already exist to keep latest annotations/labels
-					_, err = core.Instance().UpdateNamespace(&v1.Namespace{
-						ObjectMeta: metav1.ObjectMeta{
-							Name:        ns.Name,
-							Labels:      ns.Labels,
-							Annotations: ns.GetAnnotations(),
-						},
-					})
-					if err != nil {
-						return err
-					}
-					continue
-				}
-				return err
-			}
+			namespaces = append(namespaces, ns)
 		}
-		return nil
-	}
-	for _, namespace := range restore.Spec.NamespaceMapping {
-		if ns, err := core.Instance().GetNamespace(namespace); err != nil {
-			if errors.IsNotFound(err) {
-				if _, err := core.Instance().CreateNamespace(&v1.Namespace{
+	} else {
+		for _, namespace := range restore.Spec.NamespaceMapping {
+			if _, err := target.coreOps.GetNamespace(namespace); err != nil {
+				if !errors.IsNotFound(err) {
+					return false, err
+				}
+				namespaces = append(namespaces, &v1.Namespace{
 					ObjectMeta: metav1.ObjectMeta{
-						Name:        ns.Name,
-						Labels:      ns.Labels,
-						Annotations: ns.GetAnnotations(),
+						Name: namespace,
 					},
-				}); err != nil {
-					return err
-				}
+				})
 			}
+		}
+	}
+
+	created := 0
+	for _, ns := range namespaces {
+		if slice.ContainsString(restore.Status.RestoredNamespaces, ns.Name, nil) {
+			continue
+		}
+		if restore.Spec.NamespaceBatchSize > 0 && created >= restore.Spec.NamespaceBatchSize {
+			log.ApplicationRestoreLog(restore).Infof("Created %v namespaces, remaining namespaces will be created in the next pass",
+				len(restore.Status.RestoredNamespaces))
+			return false, nil
+		}
+		// create mapped restore namespace with metadata of backed up
+		// namespace
+		if err := a.createNamespace(restore, target, template, ns.Name, ns.Labels, ns.GetAnnotations()); err != nil {
+			return false, err
+		}
+		restore.Status.RestoredNamespaces = append(restore.Status.RestoredNamespaces, ns.Name)
+		created++
+	}
+	return true, nil
+}
+
+// createNamespace creates the namespace with the given metadata merged with
+// the namespace template, along with annotations recording the backup it was
+// restored from. If the namespace already exists it is updated instead. The
+// default objects from the template are created along with the namespace,
+// and the namespace is removed again if that fails so that it isn't left
+// without them.
+func (a *ApplicationRestoreController) createNamespace(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	template *namespaceTemplate,
+	name string,
+	labels map[string]string,
+	annotations map[string]string,
+) error {
+	ns := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+	}
+	template.applyMetadata(ns)
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	ns.Annotations[StorkRestoreBackupNameAnnotation] = restore.Spec.BackupName
+	ns.Annotations[StorkRestoreBackupLocationAnnotation] = restore.Spec.BackupLocation
+	ns.Annotations[StorkRestoreTimestampAnnotation] = time.Now().UTC().Format(time.RFC3339)
+
+	log.ApplicationRestoreLog(restore).Infof("Creating dest namespace %v", ns.Name)
+	_, err := target.coreOps.CreateNamespace(ns)
+	if err != nil {
+		if !errors.IsAlreadyExists(err) {
 			return err
 		}
+		log.ApplicationRestoreLog(restore).Warnf("Namespace already exists, updating dest namespace %v", ns.Name)
+		// regardless of replace policy we should always update namespace is
+		// its already exist to keep latest annotations/labels
+		if _, err = target.coreOps.UpdateNamespace(ns); err != nil {
+			return err
+		}
+		return a.createNamespaceDefaults(target, template, ns.Name)
+	}
+
+	if err := a.createNamespaceDefaults(target, template, ns.Name); err != nil {
+		if deleteErr := target.coreOps.DeleteNamespace(ns.Name); deleteErr != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Error deleting namespace %v after failing to create default objects: %v", ns.Name, deleteErr)
+		}
+		return fmt.Errorf("error creating default objects in namespace %v: %v", ns.Name, err)
+	}
+	return nil
+}
+
+// namespaceTemplate is parsed from the ConfigMap referenced by
+// Spec.NamespaceTemplate. The labels, annotations and limitRange keys are
+// expected to be in JSON
+type namespaceTemplate struct {
+	labels      map[string]string
+	annotations map[string]string
+	limitRange  *v1.LimitRangeSpec
+	defaultDeny bool
+}
+
+func (a *ApplicationRestoreController) getNamespaceTemplate(restore *storkapi.ApplicationRestore) (*namespaceTemplate, error) {
+	if restore.Spec.NamespaceTemplate == "" {
+		return nil, nil
+	}
+	configMap, err := core.Instance().GetConfigMap(restore.Spec.NamespaceTemplate, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting namespace template %v: %v", restore.Spec.NamespaceTemplate, err)
+	}
+	template := &namespaceTemplate{}
+	if data, ok := configMap.Data[nsTemplateLabelsKey]; ok {
+		if err := json.Unmarshal([]byte(data), &template.labels); err != nil {
+			return nil, fmt.Errorf("error parsing labels in namespace template %v: %v", configMap.Name, err)
+		}
+	}
+	if data, ok := configMap.Data[nsTemplateAnnotationsKey]; ok {
+		if err := json.Unmarshal([]byte(data), &template.annotations); err != nil {
+			return nil, fmt.Errorf("error parsing annotations in namespace template %v: %v", configMap.Name, err)
+		}
+	}
+	if data, ok := configMap.Data[nsTemplateLimitRangeKey]; ok {
+		template.limitRange = &v1.LimitRangeSpec{}
+		if err := json.Unmarshal([]byte(data), template.limitRange); err != nil {
+			return nil, fmt.Errorf("error parsing limitRange in namespace template %v: %v", configMap.Name, err)
+		}
+	}
+	if data, ok := configMap.Data[nsTemplateDefaultDenyKey]; ok {
+		if template.defaultDeny, err = strconv.ParseBool(data); err != nil {
+			return nil, fmt.Errorf("error parsing defaultDenyNetworkPolicy in namespace template %v: %v", configMap.Name, err)
+		}
+	}
+	return template, nil
+}
+
+// applyMetadata merges the labels and annotations from the template into the
+// namespace. Values from the template take precedence over the ones from the
+// backup.
+func (t *namespaceTemplate) applyMetadata(ns *v1.Namespace) {
+	if t == nil {
+		return
+	}
+	if len(t.labels) != 0 && ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+	for k, v := range t.labels {
+		ns.Labels[k] = v
+	}
+	if len(t.annotations) != 0 && ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	for k, v := range t.annotations {
+		ns.Annotations[k] = v
+	}
+}
+
+// createNamespaceDefaults creates the default objects from the template in
+// the namespace. Objects that already exist are left as is.
+func (a *ApplicationRestoreController) createNamespaceDefaults(target *restoreTarget, template *namespaceTemplate, namespace string) error {
+	if template == nil {
+		return nil
+	}
+	if template.limitRange != nil {
+		_, err := target.coreOps.CreateLimitRange(&v1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nsTemplateObjectName,
+				Namespace: namespace,
+			},
+			Spec: *template.limitRange,
+		})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error creating LimitRange: %v", err)
+		}
+	}
+	if template.defaultDeny {
+		err := target.client.Create(context.TODO(), &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      nsTemplateObjectName,
+				Namespace: namespace,
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{},
+				PolicyTypes: []networkingv1.PolicyType{
+					networkingv1.PolicyTypeIngress,
+					networkingv1.PolicyTypeEgress,
+				},
+			},
+		})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error creating NetworkPolicy: %v", err)
+		}
 	}
 	return nil
 }
@@ -208,14 +670,70 @@ func (a *ApplicationRestoreController) Reconcile(ctx context.Context, request re
 		return reconcile.Result{Requeue: true}, a.client.Update(context.TODO(), restore)
 	}
 
+	failedReconciles := restore.Status.FailedReconciles
+	previousStage := restore.Status.Stage
+	previousStatus := restore.Status.Status
 	if err = a.handle(context.TODO(), restore); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(a), restore.Namespace, restore.Name, err)
+		if updateErr := a.recordFailedReconcile(restore, err, err.Error()); updateErr != nil {
+			logrus.Errorf("%s: %s/%s: error recording failed reconcile: %s", reflect.TypeOf(a), restore.Namespace, restore.Name, updateErr)
+		} else if restore.Status.Stage != previousStage || restore.Status.Status != previousStatus {
+			a.notifyWebhooks(restore, previousStage)
+		}
 		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
 	}
+	if restore.Status.Stage != previousStage || restore.Status.Status != previousStatus {
+		a.notifyWebhooks(restore, previousStage)
+	}
 
+	// Reset the count once a pass goes through without any failures
+	if failedReconciles != 0 && restore.Status.FailedReconciles == failedReconciles &&
+		restore.DeletionTimestamp == nil {
+		restore.Status.FailedReconciles = 0
+		if err := a.client.Update(context.TODO(), restore); err != nil {
+			return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		}
+	}
+
+	// Volumes take much longer to restore than the other resources, so
+	// check on them less often
+	if restore.Status.Stage == storkapi.ApplicationRestoreStageVolumes &&
+		restore.Status.Status == storkapi.ApplicationRestoreStatusInProgress {
+		return reconcile.Result{RequeueAfter: controllers.VolumeRequeue}, nil
+	}
 	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
 }
 
+// recordFailedReconcile increments the number of consecutive failed passes
+// for the restore. The restore is failed once it reaches the maximum number of
+// retries from the spec. Conflicts are only caused by the restore being
+// updated during the pass, so they aren't counted.
+func (a *ApplicationRestoreController) recordFailedReconcile(
+	restore *storkapi.ApplicationRestore,
+	err error,
+	message string,
+) error {
+	if errors.IsConflict(err) ||
+		restore.DeletionTimestamp != nil || restore.Status.Stage == storkapi.ApplicationRestoreStageFinal {
+		return nil
+	}
+	restore.Status.FailedReconciles++
+	if restore.Spec.MaxReconcileRetries > 0 && restore.Status.FailedReconciles > restore.Spec.MaxReconcileRetries {
+		reason := fmt.Sprintf("Restore failed after %v consecutive failed attempts: %v",
+			restore.Status.FailedReconciles, message)
+		log.ApplicationRestoreLog(restore).Errorf(reason)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusFailed),
+			reason)
+		restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+		restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+		restore.Status.FinishTimestamp = metav1.Now()
+		restore.Status.Reason = reason
+	}
+	return a.client.Update(context.TODO(), restore)
+}
+
 // Handle updates for ApplicationRestore objects
 func (a *ApplicationRestoreController) handle(ctx context.Context, restore *storkapi.ApplicationRestore) error {
 	if restore.DeletionTimestamp != nil {
@@ -243,7 +761,13 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 		return nil
 	}
 
-	err = a.verifyNamespaces(restore)
+	if restore.Status.Stage == storkapi.ApplicationRestoreStageInitial {
+		if ready, err := a.dependenciesReady(restore); err != nil || !ready {
+			return err
+		}
+	}
+
+	namespacesCreated, err := a.verifyNamespaces(restore)
 	if err != nil {
 		log.ApplicationRestoreLog(restore).Errorf(err.Error())
 		a.recorder.Event(restore,
@@ -252,12 +776,140 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 			err.Error())
 		return nil
 	}
+	// Save the progress and continue creating namespaces in the next pass
+	if !namespacesCreated {
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		return a.client.Update(context.TODO(), restore)
+	}
 
 	switch restore.Status.Stage {
 	case storkapi.ApplicationRestoreStageInitial:
+		if restore.Spec.SkipValidation && restore.Status.SkippedValidations == nil {
+			restore.Status.SkippedValidations = []storkapi.ApplicationRestoreValidationType{
+				storkapi.ApplicationRestoreValidationBackupLocation,
+				storkapi.ApplicationRestoreValidationCompleteMarker,
+				storkapi.ApplicationRestoreValidationCRDReady,
+				storkapi.ApplicationRestoreValidationCapacity,
+				storkapi.ApplicationRestoreValidationProvisioner,
+			}
+			message := fmt.Sprintf("Skipping validation for restore: %v", restore.Status.SkippedValidations)
+			log.ApplicationRestoreLog(restore).Warnf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusInProgress),
+				message)
+		}
+		// Make sure the backup location can be accessed before starting
+		if err := a.validateBackupLocation(restore); err != nil {
+			message := fmt.Sprintf("Error validating backup location: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			// Network errors could be transient, so retry those. Fail the
+			// restore for everything else since it needs the location to be
+			// fixed.
+			if validationErr, ok := err.(*objectstore.ValidationError); ok &&
+				validationErr.Type == objectstore.ValidationErrorNetwork {
+				return nil
+			}
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+			restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+			restore.Status.FinishTimestamp = metav1.Now()
+			restore.Status.Reason = message
+			return a.client.Update(context.TODO(), restore)
+		}
+		// Unlike the other checks for the backup location this can't be
+		// skipped since it is required for compliance
+		if err := a.checkBackupLocationEncryption(restore); err != nil {
+			message := fmt.Sprintf("Compliance check failed: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+			restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+			restore.Status.FinishTimestamp = metav1.Now()
+			restore.Status.Reason = message
+			return a.client.Update(context.TODO(), restore)
+		}
+		if err := a.verifyBackupComplete(restore); err != nil {
+			message := fmt.Sprintf("Error verifying backup: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+			restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+			restore.Status.FinishTimestamp = metav1.Now()
+			restore.Status.Reason = message
+			return a.client.Update(context.TODO(), restore)
+		}
+		if err := a.verifySingleResource(restore); err != nil {
+			message := fmt.Sprintf("Error verifying resource: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+			restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+			restore.Status.FinishTimestamp = metav1.Now()
+			restore.Status.Reason = message
+			return a.client.Update(context.TODO(), restore)
+		}
+		if err := a.checkNamespaceCollisions(restore); err != nil {
+			message := fmt.Sprintf("Error checking namespace mapping: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+			restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+			restore.Status.FinishTimestamp = metav1.Now()
+			restore.Status.Reason = message
+			return a.client.Update(context.TODO(), restore)
+		}
+		if restore.Spec.DryRun {
+			if err := a.previewResources(restore); err != nil {
+				message := fmt.Sprintf("Error comparing resources: %v", err)
+				log.ApplicationRestoreLog(restore).Errorf(message)
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+				restore.Status.FinishTimestamp = metav1.Now()
+				restore.Status.Reason = message
+				return a.client.Update(context.TODO(), restore)
+			}
+			return nil
+		}
+		if restore.Spec.ExportType != "" {
+			if err := a.exportResources(restore); err != nil {
+				message := fmt.Sprintf("Error exporting resources: %v", err)
+				log.ApplicationRestoreLog(restore).Errorf(message)
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+				restore.Status.FinishTimestamp = metav1.Now()
+				restore.Status.Reason = message
+				return a.client.Update(context.TODO(), restore)
+			}
+			return nil
+		}
 		// Make sure the namespaces exist
 		fallthrough
-	case storkapi.ApplicationRestoreStageVolumes:
+	case storkapi.ApplicationRestoreStageVolumes,
+		storkapi.ApplicationRestoreStageFinalizeVolumes:
 		err := a.restoreVolumes(restore)
 		if err != nil {
 			message := fmt.Sprintf("Error restoring volumes: %v", err)
@@ -266,7 +918,7 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 				v1.EventTypeWarning,
 				string(storkapi.ApplicationRestoreStatusFailed),
 				message)
-			return nil
+			return a.recordFailedReconcile(restore, err, message)
 		}
 	case storkapi.ApplicationRestoreStageApplications:
 		err := a.restoreResources(restore)
@@ -277,224 +929,1558 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 				v1.EventTypeWarning,
 				string(storkapi.ApplicationRestoreStatusFailed),
 				message)
-			return nil
+			return a.recordFailedReconcile(restore, err, message)
 		}
 
-	case storkapi.ApplicationRestoreStageFinal:
-		// Do Nothing
-		return nil
-	default:
-		log.ApplicationRestoreLog(restore).Errorf("Invalid stage for restore: %v", restore.Status.Stage)
-	}
-
-	return nil
-}
+	case storkapi.ApplicationRestoreStageSettle:
+		err := a.settleResources(restore)
+		if err != nil {
+			message := fmt.Sprintf("Error checking restored resources: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			return a.recordFailedReconcile(restore, err, message)
+		}
 
-func (a *ApplicationRestoreController) namespaceRestoreAllowed(restore *storkapi.ApplicationRestore) bool {
-	// Restrict restores to only the namespace that the object belongs
-	// except for the namespace designated by the admin
-	if restore.Namespace != a.restoreAdminNamespace {
-		for _, ns := range restore.Spec.NamespaceMapping {
-			if ns != restore.Namespace {
-				return false
+	case storkapi.ApplicationRestoreStageFinal:
+		a.recordAudit(restore)
+		updated, err := a.applyPendingDisruptionBudgets(restore)
+		if err != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Error applying PodDisruptionBudgets: %v", err)
+		}
+		if len(restore.Status.RelaxedNamespaces) != 0 &&
+			time.Since(restore.Status.FinishTimestamp.Time) > podSecurityRestoreDelay {
+			if err := a.restorePodSecurity(restore); err != nil {
+				log.ApplicationRestoreLog(restore).Warnf("Error setting Pod Security level back for namespaces: %v", err)
+				return nil
+			}
+			updated = true
+		}
+		if len(restore.Status.RelaxedWebhookConfigurations) != 0 &&
+			time.Since(restore.Status.FinishTimestamp.Time) > webhookFailurePolicyRestoreDelay {
+			if err := a.restoreWebhookFailurePolicies(restore); err != nil {
+				log.ApplicationRestoreLog(restore).Warnf("Error setting failure policies back for webhooks: %v", err)
+			} else {
+				updated = true
+			}
+		}
+		if len(restore.Status.RelaxedCRDConversions) != 0 &&
+			time.Since(restore.Status.FinishTimestamp.Time) > crdConversionRestoreDelay {
+			if err := a.restoreCRDConversions(restore, false); err == nil {
+				updated = true
+			} else if time.Since(restore.Status.FinishTimestamp.Time) > crdConversionRestoreTimeout {
+				// Set the conversions back anyway so that the CRDs are
+				// left as they were in the backup
+				message := fmt.Sprintf("Error setting conversion back for CRDs after %v: %v", crdConversionRestoreTimeout, err)
+				log.ApplicationRestoreLog(restore).Errorf(message)
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				if err := a.restoreCRDConversions(restore, true); err != nil {
+					log.ApplicationRestoreLog(restore).Warnf("Error setting conversion back for CRDs: %v", err)
+				}
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				restore.Status.Reason = message
+				updated = true
+			} else {
+				log.ApplicationRestoreLog(restore).Warnf("Error setting conversion back for CRDs: %v", err)
+			}
+		}
+		if restore.Spec.RollbackOnCancel && !restore.Status.RolledBack &&
+			restore.Status.Status == storkapi.ApplicationRestoreStatusFailed {
+			if err := a.rollbackResources(restore); err != nil {
+				log.ApplicationRestoreLog(restore).Warnf("Error rolling back resources for failed restore: %v", err)
+			} else {
+				updated = true
 			}
 		}
+		if updated {
+			return a.client.Update(context.TODO(), restore)
+		}
+		return nil
+	default:
+		log.ApplicationRestoreLog(restore).Errorf("Invalid stage for restore: %v", restore.Status.Stage)
 	}
-	return true
-}
 
-func (a *ApplicationRestoreController) getDriversForRestore(restore *storkapi.ApplicationRestore) map[string]bool {
-	drivers := make(map[string]bool)
-	for _, volumeInfo := range restore.Status.Volumes {
-		drivers[volumeInfo.DriverName] = true
-	}
-	return drivers
+	return nil
 }
 
-func (a *ApplicationRestoreController) getNamespacedObjectsToDelete(restore *storkapi.ApplicationRestore, objects []runtime.Unstructured) ([]runtime.Unstructured, error) {
-	tempObjects := make([]runtime.Unstructured, 0)
-	for _, o := range objects {
-		objectType, err := meta.TypeAccessor(o)
-		if err != nil {
-			return nil, err
+// dependenciesReady checks if the restores that the restore depends on have
+// been successful. The restore is kept pending until they are, and is failed if
+// any of them don't succeed.
+func (a *ApplicationRestoreController) dependenciesReady(restore *storkapi.ApplicationRestore) (bool, error) {
+	for _, name := range restore.Spec.DependsOn {
+		dependency, err := storkops.Instance().GetApplicationRestore(name, restore.Namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		message := ""
+		if errors.IsNotFound(err) {
+			message = fmt.Sprintf("Waiting for restore %v that it depends on to be created", name)
+		} else if dependency.Status.Status == storkapi.ApplicationRestoreStatusSuccessful {
+			continue
+		} else if dependency.Status.Stage == storkapi.ApplicationRestoreStageFinal {
+			message = fmt.Sprintf("Restore %v that it depends on finished with status %v", name, dependency.Status.Status)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				message)
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+			restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+			restore.Status.FinishTimestamp = metav1.Now()
+			restore.Status.Reason = message
+			return false, a.client.Update(context.TODO(), restore)
+		} else {
+			message = fmt.Sprintf("Waiting for restore %v that it depends on to complete", name)
 		}
 
-		// Skip PVs, we will let the PVC handle PV deletion where needed
-		if objectType.GetKind() != "PersistentVolume" {
-			tempObjects = append(tempObjects, o)
+		if restore.Status.Status == storkapi.ApplicationRestoreStatusPending &&
+			restore.Status.Reason == message {
+			return false, nil
 		}
+		log.ApplicationRestoreLog(restore).Infof(message)
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusPending),
+			message)
+		restore.Status.Status = storkapi.ApplicationRestoreStatusPending
+		restore.Status.Reason = message
+		return false, a.client.Update(context.TODO(), restore)
 	}
+	return true, nil
+}
 
-	return tempObjects, nil
+// failRestoreForMissingDriver marks the restore as failed since a volume
+// driver that it needs isn't installed
+func (a *ApplicationRestoreController) failRestoreForMissingDriver(
+	restore *storkapi.ApplicationRestore,
+	driverName string,
+) error {
+	message := fmt.Sprintf("Volume driver %v is not installed on the cluster", driverName)
+	log.ApplicationRestoreLog(restore).Errorf(message)
+	a.recorder.Event(restore,
+		v1.EventTypeWarning,
+		string(storkapi.ApplicationRestoreStatusFailed),
+		message)
+	restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+	restore.Status.FinishTimestamp = metav1.Now()
+	restore.Status.Reason = message
+	return a.client.Update(context.TODO(), restore)
 }
 
-func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.ApplicationRestore) error {
-	restore.Status.Stage = storkapi.ApplicationRestoreStageVolumes
-	if restore.Status.Volumes == nil || len(restore.Status.Volumes) == 0 {
-		backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+// checkRestoreCapacity checks with each driver that there is enough capacity
+// for the volumes it will restore. Drivers that can't check the capacity are
+// skipped.
+func (a *ApplicationRestoreController) checkRestoreCapacity(
+	restore *storkapi.ApplicationRestore,
+	backupVolumeInfoMappings map[string][]*storkapi.ApplicationBackupVolumeInfo,
+) error {
+	for driverName, vInfos := range backupVolumeInfoMappings {
+		driver, err := volume.Get(driverName)
 		if err != nil {
-			return fmt.Errorf("error getting backup spec for restore: %v", err)
+			return err
 		}
-		backupVolumeInfoMappings := make(map[string][]*storkapi.ApplicationBackupVolumeInfo)
-		objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
-		info := storkapi.ObjectInfo{
-			GroupVersionKind: metav1.GroupVersionKind{
-				Group:   "core",
-				Version: "v1",
-				Kind:    "PersistentVolumeClaim",
-			},
+		var size uint64
+		for _, vInfo := range vInfos {
+			size += vInfo.TotalSize
 		}
-
-		for _, namespace := range backup.Spec.Namespaces {
-			if _, ok := restore.Spec.NamespaceMapping[namespace]; !ok {
+		if err := driver.CheckRestoreCapacity(restore, size); err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); ok {
 				continue
 			}
-			for _, volumeBackup := range backup.Status.Volumes {
-				if volumeBackup.Namespace != namespace {
-					continue
-				}
-				// If a list of resources was specified during restore check if
-				// this PVC was included
-				info.Name = volumeBackup.PersistentVolumeClaim
-				info.Namespace = volumeBackup.Namespace
-				if len(objectMap) != 0 {
-					if val, present := objectMap[info]; !present || !val {
-						continue
-					}
-				}
-
-				if volumeBackup.DriverName == "" {
-					volumeBackup.DriverName = volume.GetDefaultDriverName()
-				}
-				if backupVolumeInfoMappings[volumeBackup.DriverName] == nil {
-					backupVolumeInfoMappings[volumeBackup.DriverName] = make([]*storkapi.ApplicationBackupVolumeInfo, 0)
-				}
-				backupVolumeInfoMappings[volumeBackup.DriverName] = append(backupVolumeInfoMappings[volumeBackup.DriverName], volumeBackup)
+			if capacityErr, ok := err.(*storkerrors.ErrInsufficientCapacity); ok {
+				return capacityErr
 			}
+			return fmt.Errorf("error checking capacity for driver %v: %v", driverName, err)
 		}
+	}
+	return nil
+}
 
-		for driverName, vInfos := range backupVolumeInfoMappings {
-			driver, err := volume.Get(driverName)
-			if err != nil {
-				return err
-			}
-
-			// For each driver, check if it needs any additional resources to be
-			// restored before starting the volume restore
-			objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.Namespace)
-			if err != nil {
-				log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
-				return err
-			}
-
-			preRestoreObjects, err := driver.GetPreRestoreResources(backup, objects)
-			if err != nil {
-				log.ApplicationRestoreLog(restore).Errorf("Error getting PreRestore Resources: %v", err)
-				return err
-			}
-			if err := a.applyResources(restore, preRestoreObjects); err != nil {
-				return err
-			}
-
-			// Pre-delete resources for CSI driver
-			if driverName == "csi" && restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
-				objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
-				objectBasedOnIncludeResources := make([]runtime.Unstructured, 0)
-				for _, o := range objects {
-					skip, err := a.resourceCollector.PrepareResourceForApply(
-						o,
-						objects,
-						objectMap,
-						restore.Spec.NamespaceMapping,
-						nil,
-						restore.Spec.IncludeOptionalResourceTypes,
-					)
-					if err != nil {
-						return err
-					}
-					if !skip {
-						objectBasedOnIncludeResources = append(
-							objectBasedOnIncludeResources,
-							o,
-						)
-					}
-				}
-				tempObjects, err := a.getNamespacedObjectsToDelete(
-					restore,
-					objectBasedOnIncludeResources,
-				)
-				if err != nil {
-					return err
-				}
-				err = a.resourceCollector.DeleteResources(
-					a.dynamicInterface,
-					tempObjects)
-				if err != nil {
-					return err
-				}
-			}
-
-			restoreVolumeInfos, err := driver.StartRestore(restore, vInfos)
-			if err != nil {
-				message := fmt.Sprintf("Error starting Application Restore for volumes: %v", err)
-				log.ApplicationRestoreLog(restore).Errorf(message)
-				a.recorder.Event(restore,
-					v1.EventTypeWarning,
-					string(storkapi.ApplicationRestoreStatusFailed),
-					message)
-				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
-				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
-				restore.Status.Reason = message
-				err = a.client.Update(context.TODO(), restore)
-				if err != nil {
-					return err
-				}
-
-				return nil
-			}
-			restore.Status.Volumes = append(restore.Status.Volumes, restoreVolumeInfos...)
+// checkProvisioners compares the provisioner that provisioned each PVC being
+// restored with the provisioner of the storage class it will use on the
+// destination. Returns a description of each PVC whose provisioners don't
+// match. PVCs whose storage class doesn't exist on the destination are
+// reported when the resources are restored instead.
+func (a *ApplicationRestoreController) checkProvisioners(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	backup *storkapi.ApplicationBackup,
+	backupVolumeInfoMappings map[string][]*storkapi.ApplicationBackupVolumeInfo,
+) ([]string, error) {
+	pvcs := make(map[string]bool)
+	for _, vInfos := range backupVolumeInfoMappings {
+		for _, vInfo := range vInfos {
+			pvcs[vInfo.Namespace+"/"+vInfo.PersistentVolumeClaim] = true
 		}
-		restore.Status.Status = storkapi.ApplicationRestoreStatusInProgress
-		restore.Status.LastUpdateTimestamp = metav1.Now()
-		err = a.client.Update(context.TODO(), restore)
-		if err != nil {
-			return err
+	}
+	objects, err := a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading resources: %v", err)
+	}
+	storageClasses := &storagev1.StorageClassList{}
+	if err := target.client.List(context.TODO(), storageClasses); err != nil {
+		return nil, fmt.Errorf("error listing storage classes: %v", err)
+	}
+	provisioners := make(map[string]string)
+	defaultClass := ""
+	for _, storageClass := range storageClasses.Items {
+		provisioners[storageClass.Name] = storageClass.Provisioner
+		if storageClass.Annotations[defaultStorageClassAnnotation] == "true" ||
+			storageClass.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+			defaultClass = storageClass.Name
 		}
 	}
 
-	inProgress := false
-	// Skip checking status if no volumes are being restored
-	if len(restore.Status.Volumes) != 0 {
-		drivers := a.getDriversForRestore(restore)
-		volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
-
-		var err error
-		for driverName := range drivers {
-			driver, err := volume.Get(driverName)
-			if err != nil {
-				return err
-			}
+	mismatches := make([]string, 0)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolumeClaim" {
+			continue
+		}
+		var pvc v1.PersistentVolumeClaim
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pvc); err != nil {
+			return nil, fmt.Errorf("error converting PVC: %v", err)
+		}
+		if !pvcs[pvc.Namespace+"/"+pvc.Name] {
+			continue
+		}
+		sourceProvisioner := pvc.Annotations[pvcProvisionerAnnotation]
+		if sourceProvisioner == "" {
+			sourceProvisioner = pvc.Annotations[betaPVCProvisionerAnnotation]
+		}
+		if sourceProvisioner == "" {
+			continue
+		}
+		className := pvc.Annotations[v1.BetaStorageClassAnnotation]
+		if className == "" && pvc.Spec.StorageClassName != nil {
+			className = *pvc.Spec.StorageClassName
+		}
+		if className == "" || (restore.Spec.UseDefaultStorageClassOnMissing && provisioners[className] == "") {
+			className = defaultClass
+		}
+		targetProvisioner, ok := provisioners[className]
+		if !ok || targetProvisioner == sourceProvisioner {
+			continue
+		}
+		mismatches = append(mismatches, fmt.Sprintf("PVC %v/%v was provisioned by %v but storage class %v uses %v",
+			pvc.Namespace, pvc.Name, sourceProvisioner, className, targetProvisioner))
+	}
+	return mismatches, nil
+}
 
-			status, err := driver.GetRestoreStatus(restore)
-			if err != nil {
-				return fmt.Errorf("error getting restore status for driver %v: %v", driverName, err)
-			}
-			volumeInfos = append(volumeInfos, status...)
+// validationSkipped checks if the given validation should be skipped for the
+// restore
+func validationSkipped(
+	restore *storkapi.ApplicationRestore,
+	validation storkapi.ApplicationRestoreValidationType,
+) bool {
+	for _, skipped := range restore.Status.SkippedValidations {
+		if skipped == validation {
+			return true
 		}
+	}
+	return false
+}
 
-		restore.Status.Volumes = volumeInfos
-		restore.Status.LastUpdateTimestamp = metav1.Now()
-		// Store the new status
-		err = a.client.Update(context.TODO(), restore)
+func (a *ApplicationRestoreController) validateBackupLocation(restore *storkapi.ApplicationRestore) error {
+	if validationSkipped(restore, storkapi.ApplicationRestoreValidationBackupLocation) {
+		return nil
+	}
+	backupLocation, err := k8sutils.GetBackupLocation(restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	return objectstore.Validate(backupLocation)
+}
+
+// verifySingleResource checks that the object being restored in the single
+// resource mode is present in the backup, so that the restore doesn't
+// complete without restoring anything
+func (a *ApplicationRestoreController) verifySingleResource(restore *storkapi.ApplicationRestore) error {
+	single := restore.Spec.SingleResource
+	if single == nil {
+		return nil
+	}
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	objects, err := a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
 		if err != nil {
 			return err
 		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		info := storkapi.ObjectInfo{
+			Name:      metadata.GetName(),
+			Namespace: metadata.GetNamespace(),
+			GroupVersionKind: metav1.GroupVersionKind{
+				Group:   gvk.Group,
+				Version: gvk.Version,
+				Kind:    gvk.Kind,
+			},
+		}
+		if objectInfoMatches(info, *single) {
+			return nil
+		}
+	}
+	return fmt.Errorf("object not found in backup %v: %v %v/%v", backup.Name, single.Kind, single.Namespace, single.Name)
+}
 
-		// Now check if there is any failure or success
-		// TODO: On failure of one volume cancel other restores?
-		for _, vInfo := range volumeInfos {
-			if vInfo.Status == storkapi.ApplicationRestoreStatusInProgress || vInfo.Status == storkapi.ApplicationRestoreStatusInitial ||
-				vInfo.Status == storkapi.ApplicationRestoreStatusPending {
+// checkNamespaceCollisions checks that objects from different source
+// namespaces that are mapped to the same destination namespace don't have the
+// same name, unless they can be prefixed with their source namespace
+func (a *ApplicationRestoreController) checkNamespaceCollisions(restore *storkapi.ApplicationRestore) error {
+	if !hasMergedNamespaces(restore.Spec.NamespaceMapping) {
+		return nil
+	}
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	objects, err := a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	collisions, err := getNamespaceCollisions(restore, objects)
+	if err != nil {
+		return err
+	}
+	failed := make([]string, 0)
+	for key, sourceNamespaces := range collisions {
+		if restore.Spec.NamespaceCollisionPolicy == storkapi.ApplicationRestoreNamespaceCollisionPolicyPrefix &&
+			!strings.HasPrefix(key, "PersistentVolumeClaim ") {
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%v (from %v)", key, strings.Join(sourceNamespaces, ", ")))
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return fmt.Errorf("objects from multiple source namespaces would be restored with the same name: %v",
+		strings.Join(failed, "; "))
+}
+
+// hasMergedNamespaces checks if more than one source namespace is mapped to
+// the same destination namespace
+func hasMergedNamespaces(namespaceMapping map[string]string) bool {
+	destNamespaces := make(map[string]bool)
+	for _, destNamespace := range namespaceMapping {
+		if destNamespaces[destNamespace] {
+			return true
+		}
+		destNamespaces[destNamespace] = true
+	}
+	return false
+}
+
+// getNamespaceCollisionKey returns the key used to find objects that would be
+// restored with the same name, or an empty string if the object isn't
+// restored to a namespace
+func getNamespaceCollisionKey(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) (string, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return "", err
+	}
+	destNamespace, ok := restore.Spec.NamespaceMapping[metadata.GetNamespace()]
+	if metadata.GetNamespace() == "" || !ok {
+		return "", nil
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	return fmt.Sprintf("%v %v/%v", gvk.GroupKind(), destNamespace, metadata.GetName()), nil
+}
+
+// getNamespaceCollisions returns the objects selected for the restore from
+// different source namespaces that would be restored to the same destination
+// namespace with the same name. The source namespaces of the objects are
+// keyed by the kind, destination namespace and name.
+func getNamespaceCollisions(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) (map[string][]string, error) {
+	sources := make(map[string][]string)
+	for _, o := range objects {
+		selected, err := resourceSelected(restore.Spec.ResourceSelectors, o)
+		if err != nil {
+			return nil, err
+		}
+		if !selected {
+			continue
+		}
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		if len(restore.Spec.IncludeResources) != 0 {
+			gvk := o.GetObjectKind().GroupVersionKind()
+			info := storkapi.ObjectInfo{
+				Name:      metadata.GetName(),
+				Namespace: metadata.GetNamespace(),
+				GroupVersionKind: metav1.GroupVersionKind{
+					Group:   gvk.Group,
+					Version: gvk.Version,
+					Kind:    gvk.Kind,
+				},
+			}
+			included := false
+			for _, include := range restore.Spec.IncludeResources {
+				if objectInfoMatches(info, include) {
+					included = true
+					break
+				}
+			}
+			if !included {
+				continue
+			}
+		}
+		key, err := getNamespaceCollisionKey(restore, o)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			continue
+		}
+		if !slice.ContainsString(sources[key], metadata.GetNamespace(), nil) {
+			sources[key] = append(sources[key], metadata.GetNamespace())
+		}
+	}
+	collisions := make(map[string][]string)
+	for key, sourceNamespaces := range sources {
+		if len(sourceNamespaces) > 1 {
+			sort.Strings(sourceNamespaces)
+			collisions[key] = sourceNamespaces
+		}
+	}
+	return collisions, nil
+}
+
+// prefixCollidingObject prefixes the name of an object that collides with
+// objects from other source namespaces with its source namespace
+func prefixCollidingObject(object runtime.Unstructured, sourceNamespace string) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	metadata.SetName(fmt.Sprintf("%v-%v", sourceNamespace, metadata.GetName()))
+	return nil
+}
+
+// maxNameLengths are the maximum lengths of the names of the kinds whose
+// names are used in labels, or which must be DNS labels. Other names can be
+// DNS subdomains.
+var maxNameLengths = map[string]int{
+	"Service":     validation.DNS1123LabelMaxLength,
+	"Job":         validation.LabelValueMaxLength,
+	"StatefulSet": validation.LabelValueMaxLength - 11, // Room for the revision hash
+	"CronJob":     validation.LabelValueMaxLength - 11, // Room for the job suffix
+}
+
+// pathSegmentNameKinds are the kinds whose names are only validated as path
+// segments by the apiserver, like system:controller:* ClusterRoles
+var pathSegmentNameKinds = map[string]bool{
+	"Role":               true,
+	"ClusterRole":        true,
+	"RoleBinding":        true,
+	"ClusterRoleBinding": true,
+}
+
+// Length of the hash added to sanitized names
+const sanitizedNameHashLength = 8
+
+const (
+	// Length of the suffix added to the generateName of objects, and the
+	// length that the generateName is truncated to, matching the apiserver
+	generatedNameSuffixLength = 5
+	maxGeneratedNameLength    = validation.DNS1123LabelMaxLength - generatedNameSuffixLength
+)
+
+// getSanitizedName returns a valid name for an object of a kind, or the name
+// itself if it is valid. Names are validated the same way as the apiserver
+// validates them for the kind. Invalid characters are replaced with '-' and
+// names that are too long are truncated. A hash of the original name is added
+// to names that are changed so that different names don't end up the same.
+func getSanitizedName(kind string, name string) string {
+	maxLength, ok := maxNameLengths[kind]
+	if !ok {
+		maxLength = validation.DNS1123SubdomainMaxLength
+	}
+	var allowed func(r rune) bool
+	valid := false
+	switch {
+	case pathSegmentNameKinds[kind]:
+		valid = name != "." && name != ".." && !strings.ContainsAny(name, "/%")
+		allowed = func(r rune) bool {
+			return r != '/' && r != '%'
+		}
+	case maxLength > validation.DNS1123LabelMaxLength:
+		valid = len(validation.IsDNS1123Subdomain(name)) == 0
+		allowed = func(r rune) bool {
+			return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.'
+		}
+	default:
+		valid = len(validation.IsDNS1123Label(name)) == 0
+		allowed = func(r rune) bool {
+			return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+		}
+	}
+	if valid && len(name) <= maxLength {
+		return name
+	}
+
+	sanitized := name
+	if !pathSegmentNameKinds[kind] {
+		sanitized = strings.ToLower(sanitized)
+	}
+	sanitized = strings.Map(func(r rune) rune {
+		if allowed(r) {
+			return r
+		}
+		return '-'
+	}, sanitized)
+	if len(sanitized) > maxLength-sanitizedNameHashLength-1 {
+		sanitized = sanitized[:maxLength-sanitizedNameHashLength-1]
+	}
+	sanitized = strings.Trim(sanitized, "-.")
+	hash := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(hash[:])[:sanitizedNameHashLength]
+	if sanitized == "" {
+		return suffix
+	}
+	return sanitized + "-" + suffix
+}
+
+// prepareSanitizedName renames an object if its name isn't valid and records
+// the new name in the status of the restore. PVCs and PVs are skipped since
+// the volumes are restored using their names. References to the renamed
+// object from other objects are updated by prepareSanitizedReferences.
+func (a *ApplicationRestoreController) prepareSanitizedName(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if gvk.Kind == "PersistentVolumeClaim" || gvk.Kind == "PersistentVolume" {
+		return nil
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	name := metadata.GetName()
+	if name == "" {
+		return nil
+	}
+	sanitized := getSanitizedName(gvk.Kind, name)
+	if sanitized == name {
+		return nil
+	}
+	metadata.SetName(sanitized)
+
+	info := storkapi.ObjectInfo{
+		Name:      name,
+		Namespace: metadata.GetNamespace(),
+		GroupVersionKind: metav1.GroupVersionKind{
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			Kind:    gvk.Kind,
+		},
+	}
+	for _, sanitizedName := range restore.Status.SanitizedNames {
+		if objectInfoMatches(sanitizedName.ObjectInfo, info) {
+			return nil
+		}
+	}
+	log.ApplicationRestoreLog(restore).Infof("Restoring %v %v/%v as %v since its name isn't valid",
+		gvk.Kind, info.Namespace, name, sanitized)
+	restore.Status.SanitizedNames = append(restore.Status.SanitizedNames,
+		storkapi.ApplicationRestoreSanitizedName{
+			ObjectInfo:    info,
+			SanitizedName: sanitized,
+		})
+	return nil
+}
+
+// getSanitizedNameWarning returns a warning for a resource that was restored
+// with a sanitized name. Returns an empty string if it wasn't renamed.
+func getSanitizedNameWarning(
+	restore *storkapi.ApplicationRestore,
+	gvk schema.GroupVersionKind,
+	metadata metav1.Object,
+) string {
+	info := storkapi.ObjectInfo{
+		Namespace: metadata.GetNamespace(),
+		GroupVersionKind: metav1.GroupVersionKind{
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			Kind:    gvk.Kind,
+		},
+	}
+	for _, sanitizedName := range restore.Status.SanitizedNames {
+		if sanitizedName.SanitizedName != metadata.GetName() {
+			continue
+		}
+		info.Name = sanitizedName.Name
+		if objectInfoMatches(sanitizedName.ObjectInfo, info) {
+			return fmt.Sprintf("Restored with the name %v instead of %v since its name isn't valid",
+				sanitizedName.SanitizedName, sanitizedName.Name)
+		}
+	}
+	return ""
+}
+
+// sanitizedNames maps the kind, namespace and original name of the resources
+// that were renamed by the restore to the names that they are restored with
+type sanitizedNames map[string]string
+
+func getSanitizedNames(restore *storkapi.ApplicationRestore) sanitizedNames {
+	names := make(sanitizedNames)
+	for _, sanitizedName := range restore.Status.SanitizedNames {
+		names[sanitizedName.Kind+"/"+sanitizedName.Namespace+"/"+sanitizedName.Name] = sanitizedName.SanitizedName
+	}
+	return names
+}
+
+// rename updates the name in a field of an object if it refers to a resource
+// of the kind in the namespace that was renamed
+func (n sanitizedNames) rename(
+	content map[string]interface{},
+	kind string,
+	namespace string,
+	fields ...string,
+) error {
+	name, found, err := unstructured.NestedString(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	if sanitized, ok := n[kind+"/"+namespace+"/"+name]; ok {
+		return unstructured.SetNestedField(content, sanitized, fields...)
+	}
+	return nil
+}
+
+// forEachNestedItem calls fn with each item of a list in an object
+func forEachNestedItem(
+	content map[string]interface{},
+	fn func(item map[string]interface{}) error,
+	fields ...string,
+) error {
+	list, found, err := unstructured.NestedFieldNoCopy(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	items, ok := list.([]interface{})
+	if !ok {
+		return fmt.Errorf("%v is of type %T instead of a list", strings.Join(fields, "."), list)
+	}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			if err := fn(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// renamePodSpec updates the references to ServiceAccounts, ConfigMaps and
+// Secrets in a pod spec
+func (n sanitizedNames) renamePodSpec(content map[string]interface{}, namespace string, fields ...string) error {
+	spec, found, err := unstructured.NestedMap(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	if err := n.rename(spec, "ServiceAccount", namespace, "serviceAccountName"); err != nil {
+		return err
+	}
+	if err := n.rename(spec, "ServiceAccount", namespace, "serviceAccount"); err != nil {
+		return err
+	}
+	if err := forEachNestedItem(spec, func(secret map[string]interface{}) error {
+		return n.rename(secret, "Secret", namespace, "name")
+	}, "imagePullSecrets"); err != nil {
+		return err
+	}
+	if err := forEachNestedItem(spec, func(volume map[string]interface{}) error {
+		if err := n.rename(volume, "ConfigMap", namespace, "configMap", "name"); err != nil {
+			return err
+		}
+		if err := n.rename(volume, "Secret", namespace, "secret", "secretName"); err != nil {
+			return err
+		}
+		return forEachNestedItem(volume, func(source map[string]interface{}) error {
+			if err := n.rename(source, "ConfigMap", namespace, "configMap", "name"); err != nil {
+				return err
+			}
+			return n.rename(source, "Secret", namespace, "secret", "name")
+		}, "projected", "sources")
+	}, "volumes"); err != nil {
+		return err
+	}
+	renameContainer := func(container map[string]interface{}) error {
+		if err := forEachNestedItem(container, func(envFrom map[string]interface{}) error {
+			if err := n.rename(envFrom, "ConfigMap", namespace, "configMapRef", "name"); err != nil {
+				return err
+			}
+			return n.rename(envFrom, "Secret", namespace, "secretRef", "name")
+		}, "envFrom"); err != nil {
+			return err
+		}
+		return forEachNestedItem(container, func(env map[string]interface{}) error {
+			if err := n.rename(env, "ConfigMap", namespace, "valueFrom", "configMapKeyRef", "name"); err != nil {
+				return err
+			}
+			return n.rename(env, "Secret", namespace, "valueFrom", "secretKeyRef", "name")
+		}, "env")
+	}
+	for _, containers := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		if err := forEachNestedItem(spec, renameContainer, containers); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedMap(content, spec, fields...)
+}
+
+// renameBinding updates the role and the ServiceAccount subjects of a
+// RoleBinding or ClusterRoleBinding
+func (n sanitizedNames) renameBinding(content map[string]interface{}, namespace string) error {
+	roleKind, _, err := unstructured.NestedString(content, "roleRef", "kind")
+	if err != nil {
+		return err
+	}
+	roleNamespace := namespace
+	if roleKind == "ClusterRole" {
+		roleNamespace = ""
+	}
+	if err := n.rename(content, roleKind, roleNamespace, "roleRef", "name"); err != nil {
+		return err
+	}
+	return forEachNestedItem(content, func(subject map[string]interface{}) error {
+		subjectKind, _, err := unstructured.NestedString(subject, "kind")
+		if err != nil || subjectKind != "ServiceAccount" {
+			return err
+		}
+		subjectNamespace, found, err := unstructured.NestedString(subject, "namespace")
+		if err != nil {
+			return err
+		}
+		if !found {
+			subjectNamespace = namespace
+		}
+		return n.rename(subject, subjectKind, subjectNamespace, "name")
+	}, "subjects")
+}
+
+// renameIngress updates the backend Services and TLS Secrets of an Ingress.
+// Both the v1 and v1beta1 backends are handled.
+func (n sanitizedNames) renameIngress(content map[string]interface{}, namespace string) error {
+	renameBackend := func(backend map[string]interface{}) error {
+		if err := n.rename(backend, "Service", namespace, "service", "name"); err != nil {
+			return err
+		}
+		return n.rename(backend, "Service", namespace, "serviceName")
+	}
+	for _, field := range []string{"defaultBackend", "backend"} {
+		backend, found, err := unstructured.NestedFieldNoCopy(content, "spec", field)
+		if err != nil {
+			return err
+		}
+		if m, ok := backend.(map[string]interface{}); found && ok {
+			if err := renameBackend(m); err != nil {
+				return err
+			}
+		}
+	}
+	if err := forEachNestedItem(content, func(rule map[string]interface{}) error {
+		return forEachNestedItem(rule, func(path map[string]interface{}) error {
+			backend, found, err := unstructured.NestedFieldNoCopy(path, "backend")
+			if err != nil {
+				return err
+			}
+			if m, ok := backend.(map[string]interface{}); found && ok {
+				return renameBackend(m)
+			}
+			return nil
+		}, "http", "paths")
+	}, "spec", "rules"); err != nil {
+		return err
+	}
+	return forEachNestedItem(content, func(tls map[string]interface{}) error {
+		return n.rename(tls, "Secret", namespace, "secretName")
+	}, "spec", "tls")
+}
+
+// prepareSanitizedReferences updates the references to resources that were
+// renamed since their names weren't valid. References from pod specs,
+// StatefulSets, ServiceAccounts, role bindings, Ingresses and
+// HorizontalPodAutoscalers are updated.
+func prepareSanitizedReferences(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) error {
+	names := getSanitizedNames(restore)
+	if len(names) == 0 {
+		return nil
+	}
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
+		namespace := metadata.GetNamespace()
+		content := o.UnstructuredContent()
+		kind := o.GetObjectKind().GroupVersionKind().Kind
+		switch kind {
+		case "Pod":
+			err = names.renamePodSpec(content, namespace, "spec")
+		case "StatefulSet":
+			err = names.rename(content, "Service", namespace, "spec", "serviceName")
+		case "ServiceAccount":
+			err = forEachNestedItem(content, func(secret map[string]interface{}) error {
+				return names.rename(secret, "Secret", namespace, "name")
+			}, "secrets")
+			if err == nil {
+				err = forEachNestedItem(content, func(secret map[string]interface{}) error {
+					return names.rename(secret, "Secret", namespace, "name")
+				}, "imagePullSecrets")
+			}
+		case "RoleBinding", "ClusterRoleBinding":
+			err = names.renameBinding(content, namespace)
+		case "Ingress":
+			err = names.renameIngress(content, namespace)
+		case "HorizontalPodAutoscaler":
+			var targetKind string
+			if targetKind, _, err = unstructured.NestedString(content, "spec", "scaleTargetRef", "kind"); err == nil {
+				err = names.rename(content, targetKind, namespace, "spec", "scaleTargetRef", "name")
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("error updating references to renamed resources in %v %v/%v: %v",
+				kind, namespace, metadata.GetName(), err)
+		}
+		if templateFields := getPodTemplateFields(kind); templateFields != nil {
+			if err := names.renamePodSpec(content, namespace, append(templateFields, "spec")...); err != nil {
+				return fmt.Errorf("error updating references to renamed resources in %v %v/%v: %v",
+					kind, namespace, metadata.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// objectInfoMatches checks if the objects are the same, treating the core
+// group as empty
+func objectInfoMatches(first, second storkapi.ObjectInfo) bool {
+	if first.Group == "core" {
+		first.Group = ""
+	}
+	if second.Group == "core" {
+		second.Group = ""
+	}
+	return first == second
+}
+
+// checkBackupLocationEncryption checks that an encryption key is configured
+// for the backup location if it requires encryption
+func (a *ApplicationRestoreController) checkBackupLocationEncryption(restore *storkapi.ApplicationRestore) error {
+	backupLocation, err := k8sutils.GetBackupLocation(restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return fmt.Errorf("error getting backup location: %v", err)
+	}
+	return objectstore.CheckEncryption(backupLocation)
+}
+
+// verifyBackupComplete checks that the complete marker was uploaded for the
+// backup, which means that all the other objects were uploaded too. Backups
+// taken before the marker was added are restored with a warning.
+func (a *ApplicationRestoreController) verifyBackupComplete(restore *storkapi.ApplicationRestore) error {
+	if validationSkipped(restore, storkapi.ApplicationRestoreValidationCompleteMarker) {
+		return nil
+	}
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+	if err != nil {
+		return err
+	}
+	marker, err := a.downloadObject(backup, restore.Spec.BackupLocation, restore.Namespace, completeObjectName, true)
+	if err != nil {
+		return err
+	}
+	if marker != nil {
+		return nil
+	}
+	if backup.Annotations[completeMarkerAnnotation] == "true" {
+		return fmt.Errorf("backup %v is incomplete, not all of its objects were uploaded to the backup location", backup.Name)
+	}
+	message := fmt.Sprintf("Backup %v doesn't have a complete marker, it might be missing objects", backup.Name)
+	log.ApplicationRestoreLog(restore).Warnf(message)
+	a.recorder.Event(restore,
+		v1.EventTypeWarning,
+		string(storkapi.ApplicationRestoreStatusInProgress),
+		message)
+	return nil
+}
+
+// recordAudit writes the audit record for a restore that has reached a
+// terminal state. The record is written in the background and the restore is
+// annotated once it has been written, so that the restore isn't blocked.
+func (a *ApplicationRestoreController) recordAudit(restore *storkapi.ApplicationRestore) {
+	if a.auditSender == nil || audit.IsRecorded(restore) {
+		return
+	}
+	key := runtimeclient.ObjectKeyFromObject(restore)
+	a.auditSender.Send(audit.NewRestoreRecord(restore), func() {
+		restore := &storkapi.ApplicationRestore{}
+		if err := a.client.Get(context.TODO(), key, restore); err != nil {
+			logrus.Warnf("Error getting restore %v to update audit annotation: %v", key, err)
+			return
+		}
+		audit.SetRecorded(restore)
+		if err := a.client.Update(context.TODO(), restore); err != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Error updating audit annotation: %v", err)
+		}
+	})
+}
+
+// notifyWebhooks posts the current stage and status of the restore to its
+// notification webhooks. The webhooks are notified in the background, and
+// errors are only logged so that the restore isn't blocked. Notifications for
+// a restore are queued and posted one at a time, so that they are received in
+// the order that the changes happened.
+func (a *ApplicationRestoreController) notifyWebhooks(
+	restore *storkapi.ApplicationRestore,
+	previousStage storkapi.ApplicationRestoreStageType,
+) {
+	if len(restore.Spec.NotificationWebhooks) == 0 {
+		return
+	}
+	notification := &RestoreNotification{
+		Name:          restore.Name,
+		Namespace:     restore.Namespace,
+		UID:           string(restore.UID),
+		PreviousStage: previousStage,
+		Stage:         restore.Status.Stage,
+		Status:        restore.Status.Status,
+		Reason:        restore.Status.Reason,
+		Timestamp:     metav1.Now(),
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		log.ApplicationRestoreLog(restore).Warnf("Error creating notification: %v", err)
+		return
+	}
+	webhooks := make([]string, 0, len(restore.Spec.NotificationWebhooks))
+	for _, webhookURL := range restore.Spec.NotificationWebhooks {
+		if err := a.validateWebhookURL(restore, webhookURL); err != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Not notifying webhook %v: %v", webhookURL, err)
+			continue
+		}
+		webhooks = append(webhooks, webhookURL)
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	uid := string(restore.UID)
+	a.notificationLock.Lock()
+	defer a.notificationLock.Unlock()
+	if a.notificationQueues == nil {
+		a.notificationQueues = make(map[string][]*pendingNotification)
+	}
+	queue, delivering := a.notificationQueues[uid]
+	a.notificationQueues[uid] = append(queue, &pendingNotification{
+		logger:   log.ApplicationRestoreLog(restore),
+		webhooks: webhooks,
+		data:     data,
+	})
+	if !delivering {
+		go a.deliverNotifications(uid)
+	}
+}
+
+// deliverNotifications posts the queued notifications for a restore until
+// the queue is empty
+func (a *ApplicationRestoreController) deliverNotifications(uid string) {
+	for {
+		a.notificationLock.Lock()
+		queue := a.notificationQueues[uid]
+		if len(queue) == 0 {
+			delete(a.notificationQueues, uid)
+			a.notificationLock.Unlock()
+			return
+		}
+		notification := queue[0]
+		a.notificationQueues[uid] = queue[1:]
+		a.notificationLock.Unlock()
+
+		for _, webhookURL := range notification.webhooks {
+			var postErr error
+			err := wait.ExponentialBackoff(notificationWebhookBackoff, func() (bool, error) {
+				postErr = a.postNotification(webhookURL, notification.data)
+				return postErr == nil, nil
+			})
+			if err != nil {
+				notification.logger.Warnf("Error notifying webhook %v: %v", webhookURL, postErr)
+			}
+		}
+	}
+}
+
+func (a *ApplicationRestoreController) postNotification(webhookURL string, data []byte) error {
+	resp, err := a.webhookClient.Post(webhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response %v", resp.Status)
+	}
+	return nil
+}
+
+// finalizeStagedVolumes starts the final sync for volumes that have been
+// staged once the restore has been marked to be finalized
+func (a *ApplicationRestoreController) finalizeStagedVolumes(restore *storkapi.ApplicationRestore) error {
+	if !restore.Spec.FinalizeStagedRestore {
+		return nil
+	}
+
+	adoptedVolumes, driverRestore := splitAdoptedVolumes(restore)
+	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+	volumeInfos = append(volumeInfos, adoptedVolumes...)
+	for driverName := range a.getDriversForRestore(driverRestore) {
+		driver, err := volume.Get(driverName)
+		if err != nil {
+			return err
+		}
+
+		status, err := driver.FinalizeRestore(driverRestore)
+		if err != nil {
+			if _, ok := err.(*storkerrors.ErrNotSupported); !ok {
+				return fmt.Errorf("error finalizing restore for driver %v: %v", driverName, err)
+			}
+			// All the data would have been restored when staging for
+			// drivers that don't support it
+			status = make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+			for _, vInfo := range driverRestore.Status.Volumes {
+				if vInfo.DriverName == driverName {
+					status = append(status, vInfo)
+				}
+			}
+		}
+		volumeInfos = append(volumeInfos, status...)
+	}
+
+	restore.Status.Volumes = volumeInfos
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinalizeVolumes
+	restore.Status.Status = storkapi.ApplicationRestoreStatusInProgress
+	restore.Status.Reason = "Finalizing restore of staged volumes"
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), restore)
+}
+
+// adoptExistingVolume returns the restore info for a volume if its PVC already
+// exists and is bound in the destination namespace. Returns nil if the volume
+// needs to be restored.
+func (a *ApplicationRestoreController) adoptExistingVolume(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	volumeBackup *storkapi.ApplicationBackupVolumeInfo,
+) (*storkapi.ApplicationRestoreVolumeInfo, error) {
+	namespace := restore.Spec.NamespaceMapping[volumeBackup.Namespace]
+	pvc, err := target.coreOps.GetPersistentVolumeClaim(volumeBackup.PersistentVolumeClaim, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if pvc.Status.Phase != v1.ClaimBound || pvc.Spec.VolumeName == "" {
+		return nil, nil
+	}
+
+	message := fmt.Sprintf("Adopted existing volume %v for PVC %v/%v", pvc.Spec.VolumeName, pvc.Namespace, pvc.Name)
+	log.ApplicationRestoreLog(restore).Infof(message)
+	a.recorder.Event(restore,
+		v1.EventTypeNormal,
+		string(storkapi.ApplicationRestoreStatusRetained),
+		message)
+	return &storkapi.ApplicationRestoreVolumeInfo{
+		PersistentVolumeClaim: volumeBackup.PersistentVolumeClaim,
+		SourceNamespace:       volumeBackup.Namespace,
+		SourceVolume:          volumeBackup.Volume,
+		RestoreVolume:         pvc.Spec.VolumeName,
+		DriverName:            volumeBackup.DriverName,
+		Zones:                 volumeBackup.Zones,
+		Status:                storkapi.ApplicationRestoreStatusRetained,
+		Reason:                "Volume restore skipped as the existing volume was adopted",
+		TotalSize:             volumeBackup.TotalSize,
+	}, nil
+}
+
+// getStaticVolumes returns the names of the CSI PVs in the backup that were
+// statically provisioned. The resources are only downloaded if the backup has
+// any CSI volumes.
+func (a *ApplicationRestoreController) getStaticVolumes(
+	restore *storkapi.ApplicationRestore,
+	backup *storkapi.ApplicationBackup,
+) (map[string]bool, error) {
+	staticVolumes := make(map[string]bool)
+	hasCSIVolumes := false
+	for _, volumeBackup := range backup.Status.Volumes {
+		if volumeBackup.DriverName == "csi" {
+			hasCSIVolumes = true
+			break
+		}
+	}
+	if !hasCSIVolumes {
+		return staticVolumes, nil
+	}
+
+	objects, err := a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error downloading resources to find static volumes: %v", err)
+	}
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolume" {
+			continue
+		}
+		var pv v1.PersistentVolume
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pv); err != nil {
+			return nil, fmt.Errorf("error converting to persistent volume: %v", err)
+		}
+		if isStaticPersistentVolume(&pv) {
+			staticVolumes[pv.Name] = true
+		}
+	}
+	return staticVolumes, nil
+}
+
+// getStaticVolumeInfo returns the restore info for a statically provisioned
+// volume. The volume isn't restored by the driver, its PV is re-created when
+// the resources are applied, so it keeps the same name.
+func getStaticVolumeInfo(
+	volumeBackup *storkapi.ApplicationBackupVolumeInfo,
+) *storkapi.ApplicationRestoreVolumeInfo {
+	return &storkapi.ApplicationRestoreVolumeInfo{
+		PersistentVolumeClaim: volumeBackup.PersistentVolumeClaim,
+		SourceNamespace:       volumeBackup.Namespace,
+		SourceVolume:          volumeBackup.Volume,
+		RestoreVolume:         volumeBackup.Volume,
+		DriverName:            volumeBackup.DriverName,
+		Zones:                 volumeBackup.Zones,
+		Status:                storkapi.ApplicationRestoreStatusSuccessful,
+		Reason:                "Statically provisioned volume will be restored from its PV spec",
+		TotalSize:             volumeBackup.TotalSize,
+		Static:                true,
+	}
+}
+
+// isStaticPersistentVolume checks if the PV was created directly instead of
+// being dynamically provisioned for a PVC
+func isStaticPersistentVolume(pv *v1.PersistentVolume) bool {
+	_, ok := pv.Annotations[pvProvisionedByAnnotation]
+	return !ok
+}
+
+// splitAdoptedVolumes returns the volumes that were adopted or are statically
+// provisioned, along with a copy of the restore that only has the volumes
+// being restored by the drivers
+func splitAdoptedVolumes(
+	restore *storkapi.ApplicationRestore,
+) ([]*storkapi.ApplicationRestoreVolumeInfo, *storkapi.ApplicationRestore) {
+	hasStaticVolumes := false
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.Static {
+			hasStaticVolumes = true
+			break
+		}
+	}
+	if !restore.Spec.AdoptExistingVolumes && !hasStaticVolumes {
+		return nil, restore
+	}
+	driverRestore := restore.DeepCopy()
+	driverRestore.Status.Volumes = make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+	adoptedVolumes := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.Status == storkapi.ApplicationRestoreStatusRetained || vInfo.Static {
+			adoptedVolumes = append(adoptedVolumes, vInfo)
+		} else {
+			driverRestore.Status.Volumes = append(driverRestore.Status.Volumes, vInfo)
+		}
+	}
+	return adoptedVolumes, driverRestore
+}
+
+// isAdoptedVolumeObject checks if the object is the PVC or PV for a volume that
+// was adopted. Should be called after the object has been prepared for apply.
+func isAdoptedVolumeObject(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) (bool, error) {
+	if !restore.Spec.AdoptExistingVolumes {
+		return false, nil
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.Status != storkapi.ApplicationRestoreStatusRetained {
+			continue
+		}
+		switch kind {
+		case "PersistentVolume":
+			if metadata.GetName() == vInfo.RestoreVolume {
+				return true, nil
+			}
+		case "PersistentVolumeClaim":
+			if metadata.GetName() == vInfo.PersistentVolumeClaim &&
+				metadata.GetNamespace() == restore.Spec.NamespaceMapping[vInfo.SourceNamespace] {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (a *ApplicationRestoreController) namespaceRestoreAllowed(restore *storkapi.ApplicationRestore) bool {
+	// Restrict restores to only the namespace that the object belongs
+	// except for the namespace designated by the admin
+	if restore.Namespace != a.restoreAdminNamespace {
+		for _, ns := range restore.Spec.NamespaceMapping {
+			if ns != restore.Namespace {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (a *ApplicationRestoreController) getDriversForRestore(restore *storkapi.ApplicationRestore) map[string]bool {
+	drivers := make(map[string]bool)
+	for _, volumeInfo := range restore.Status.Volumes {
+		drivers[volumeInfo.DriverName] = true
+	}
+	return drivers
+}
+
+func (a *ApplicationRestoreController) getNamespacedObjectsToDelete(restore *storkapi.ApplicationRestore, objects []runtime.Unstructured) ([]runtime.Unstructured, error) {
+	tempObjects := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		objectType, err := meta.TypeAccessor(o)
+		if err != nil {
+			return nil, err
+		}
+
+		// Skip PVs, we will let the PVC handle PV deletion where needed
+		if objectType.GetKind() == "PersistentVolume" {
+			continue
+		}
+		// Skip PVCs for volumes that were adopted
+		if adopted, err := isAdoptedVolumeObject(restore, o); err != nil {
+			return nil, err
+		} else if adopted {
+			continue
+		}
+		tempObjects = append(tempObjects, o)
+	}
+
+	return tempObjects, nil
+}
+
+func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.ApplicationRestore) error {
+	if restore.Status.Stage == storkapi.ApplicationRestoreStageInitial {
+		restore.Status.Stage = storkapi.ApplicationRestoreStageVolumes
+	}
+	if restore.Status.Status == storkapi.ApplicationRestoreStatusStaged {
+		return a.finalizeStagedVolumes(restore)
+	}
+	if restore.Status.Status == storkapi.ApplicationRestoreStatusVolumesRestored &&
+		restore.Spec.WaitAfterVolumes &&
+		restore.Annotations[StorkRestoreResourcesAnnotation] != "true" {
+		return nil
+	}
+	if restore.Status.Volumes == nil || len(restore.Status.Volumes) == 0 {
+		backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+		if err != nil {
+			return fmt.Errorf("error getting backup spec for restore: %v", err)
+		}
+		target, err := a.getRestoreTarget(restore)
+		if err != nil {
+			return err
+		}
+		staticVolumes, err := a.getStaticVolumes(restore, backup)
+		if err != nil {
+			return err
+		}
+		backupVolumeInfoMappings := make(map[string][]*storkapi.ApplicationBackupVolumeInfo)
+		objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
+		info := storkapi.ObjectInfo{
+			GroupVersionKind: metav1.GroupVersionKind{
+				Group:   "core",
+				Version: "v1",
+				Kind:    "PersistentVolumeClaim",
+			},
+		}
+
+		for _, namespace := range backup.Spec.Namespaces {
+			if _, ok := restore.Spec.NamespaceMapping[namespace]; !ok {
+				continue
+			}
+			for _, volumeBackup := range backup.Status.Volumes {
+				if volumeBackup.Namespace != namespace {
+					continue
+				}
+				// If a list of resources was specified during restore check if
+				// this PVC was included
+				info.Name = volumeBackup.PersistentVolumeClaim
+				info.Namespace = volumeBackup.Namespace
+				if len(objectMap) != 0 {
+					if val, present := objectMap[info]; !present || !val {
+						continue
+					}
+				}
+
+				if volumeBackup.DriverName == "" {
+					volumeBackup.DriverName = volume.GetDefaultDriverName()
+				}
+				if volumeBackup.DriverName == "csi" && staticVolumes[volumeBackup.Volume] {
+					restore.Status.Volumes = append(restore.Status.Volumes, getStaticVolumeInfo(volumeBackup))
+					continue
+				}
+				if restore.Spec.AdoptExistingVolumes {
+					adoptedVolume, err := a.adoptExistingVolume(restore, target, volumeBackup)
+					if err != nil {
+						return err
+					}
+					if adoptedVolume != nil {
+						restore.Status.Volumes = append(restore.Status.Volumes, adoptedVolume)
+						continue
+					}
+				}
+				if backupVolumeInfoMappings[volumeBackup.DriverName] == nil {
+					backupVolumeInfoMappings[volumeBackup.DriverName] = make([]*storkapi.ApplicationBackupVolumeInfo, 0)
+				}
+				backupVolumeInfoMappings[volumeBackup.DriverName] = append(backupVolumeInfoMappings[volumeBackup.DriverName], volumeBackup)
+			}
+		}
+
+		// The restore can't make progress if the driver for any of the
+		// volumes isn't installed on the cluster
+		for driverName := range backupVolumeInfoMappings {
+			if _, err := volume.Get(driverName); err != nil {
+				if _, ok := err.(*storkerrors.ErrNotFound); ok {
+					return a.failRestoreForMissingDriver(restore, driverName)
+				}
+				return err
+			}
+		}
+
+		// Fail before starting the restore for any volumes if there isn't
+		// enough capacity for them
+		if !validationSkipped(restore, storkapi.ApplicationRestoreValidationCapacity) {
+			if err := a.checkRestoreCapacity(restore, backupVolumeInfoMappings); err != nil {
+				capacityErr, ok := err.(*storkerrors.ErrInsufficientCapacity)
+				if !ok {
+					return err
+				}
+				message := fmt.Sprintf("Insufficient capacity on target: %v", capacityErr)
+				log.ApplicationRestoreLog(restore).Errorf(message)
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+				restore.Status.FinishTimestamp = metav1.Now()
+				restore.Status.Reason = message
+				return a.client.Update(context.TODO(), restore)
+			}
+		}
+
+		// The volumes may not be provisioned or bound correctly if the
+		// storage class on the destination uses a different provisioner
+		if !validationSkipped(restore, storkapi.ApplicationRestoreValidationProvisioner) &&
+			len(backupVolumeInfoMappings) != 0 {
+			mismatches, err := a.checkProvisioners(restore, target, backup, backupVolumeInfoMappings)
+			if err != nil {
+				return err
+			}
+			if len(mismatches) != 0 {
+				message := fmt.Sprintf("Storage classes on target use different provisioners: %v", strings.Join(mismatches, "; "))
+				log.ApplicationRestoreLog(restore).Warnf(message)
+				if restore.Spec.FailOnProvisionerMismatch {
+					a.recorder.Event(restore,
+						v1.EventTypeWarning,
+						string(storkapi.ApplicationRestoreStatusFailed),
+						message)
+					restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+					restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+					restore.Status.FinishTimestamp = metav1.Now()
+					restore.Status.Reason = message
+					return a.client.Update(context.TODO(), restore)
+				}
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusInProgress),
+					message)
+			}
+		}
+
+		for driverName, vInfos := range backupVolumeInfoMappings {
+			driver, err := volume.Get(driverName)
+			if err != nil {
+				return err
+			}
+
+			// For each driver, check if it needs any additional resources to be
+			// restored before starting the volume restore
+			objects, err := a.downloadResources(restore, backup, restore.Spec.BackupLocation, restore.Namespace, target,
+				!validationSkipped(restore, storkapi.ApplicationRestoreValidationCRDReady))
+			if err != nil {
+				log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
+				return err
+			}
+
+			preRestoreObjects, err := driver.GetPreRestoreResources(backup, objects)
+			if err != nil {
+				log.ApplicationRestoreLog(restore).Errorf("Error getting PreRestore Resources: %v", err)
+				return err
+			}
+			if err := a.applyResources(restore, preRestoreObjects, nil); err != nil {
+				return err
+			}
+
+			// Pre-delete resources for CSI driver
+			if driverName == "csi" && restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
+				objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
+				objectBasedOnIncludeResources := make([]runtime.Unstructured, 0)
+				for _, o := range objects {
+					skip, err := a.resourceCollector.PrepareResourceForApply(
+						o,
+						objects,
+						objectMap,
+						restore.Spec.NamespaceMapping,
+						nil,
+						restore.Spec.IncludeOptionalResourceTypes,
+					)
+					if err != nil {
+						return err
+					}
+					if !skip {
+						objectBasedOnIncludeResources = append(
+							objectBasedOnIncludeResources,
+							o,
+						)
+					}
+				}
+				tempObjects, err := a.getNamespacedObjectsToDelete(
+					restore,
+					objectBasedOnIncludeResources,
+				)
+				if err != nil {
+					return err
+				}
+				err = a.resourceCollector.DeleteResources(
+					target.dynamicInterface,
+					tempObjects,
+					a.getDeleteOptions(restore))
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(restore.Spec.VolumePlacement) != 0 {
+				vInfos = getPlacedVolumeInfos(restore, vInfos)
+			}
+			var restoreVolumeInfos []*storkapi.ApplicationRestoreVolumeInfo
+			if restore.Spec.StagedRestore {
+				restoreVolumeInfos, err = driver.StageRestore(restore, vInfos)
+				// Drivers that can't stage volumes restore all the data in
+				// the first phase
+				if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+					restoreVolumeInfos, err = driver.StartRestore(restore, vInfos)
+				}
+			} else {
+				restoreVolumeInfos, err = driver.StartRestore(restore, vInfos)
+			}
+			if err != nil {
+				message := fmt.Sprintf("Error starting Application Restore for volumes: %v", err)
+				log.ApplicationRestoreLog(restore).Errorf(message)
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+				restore.Status.Reason = message
+				err = a.client.Update(context.TODO(), restore)
+				if err != nil {
+					return err
+				}
+
+				return nil
+			}
+			restore.Status.Volumes = append(restore.Status.Volumes, restoreVolumeInfos...)
+		}
+		restore.Status.Status = storkapi.ApplicationRestoreStatusInProgress
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		err = a.client.Update(context.TODO(), restore)
+		if err != nil {
+			return err
+		}
+	}
+
+	inProgress := false
+	// Skip checking status if no volumes are being restored
+	if len(restore.Status.Volumes) != 0 {
+		adoptedVolumes, driverRestore := splitAdoptedVolumes(restore)
+		drivers := a.getDriversForRestore(driverRestore)
+		volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+		volumeInfos = append(volumeInfos, adoptedVolumes...)
+
+		var err error
+		for driverName := range drivers {
+			driver, err := volume.Get(driverName)
+			if err != nil {
+				if _, ok := err.(*storkerrors.ErrNotFound); ok {
+					return a.failRestoreForMissingDriver(restore, driverName)
+				}
+				return err
+			}
+
+			status, err := driver.GetRestoreStatus(driverRestore)
+			if err != nil {
+				return fmt.Errorf("error getting restore status for driver %v: %v", driverName, err)
+			}
+			volumeInfos = append(volumeInfos, status...)
+		}
+
+		restore.Status.Volumes = volumeInfos
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		// Store the new status
+		err = a.client.Update(context.TODO(), restore)
+		if err != nil {
+			return err
+		}
+
+		// Now check if there is any failure or success
+		// TODO: On failure of one volume cancel other restores?
+		for _, vInfo := range volumeInfos {
+			if vInfo.Status == storkapi.ApplicationRestoreStatusInProgress || vInfo.Status == storkapi.ApplicationRestoreStatusInitial ||
+				vInfo.Status == storkapi.ApplicationRestoreStatusPending {
 				log.ApplicationRestoreLog(restore).Infof("Volume restore still in progress: %v->%v", vInfo.SourceVolume, vInfo.RestoreVolume)
 				inProgress = true
 			} else if vInfo.Status == storkapi.ApplicationRestoreStatusFailed {
@@ -507,510 +2493,4577 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
 				restore.Status.Reason = vInfo.Reason
 				break
-			} else if vInfo.Status == storkapi.ApplicationRestoreStatusSuccessful {
-				a.recorder.Event(restore,
-					v1.EventTypeNormal,
-					string(vInfo.Status),
-					fmt.Sprintf("Volume %v->%v restored successfully", vInfo.SourceVolume, vInfo.RestoreVolume))
+			} else if vInfo.Status == storkapi.ApplicationRestoreStatusSuccessful {
+				if restore.Spec.VerifyOnComplete && !vInfo.Verified {
+					verified, err := a.verifyRestoredVolume(restore, vInfo)
+					if err != nil {
+						return err
+					}
+					if !verified {
+						a.recorder.Event(restore,
+							v1.EventTypeWarning,
+							string(vInfo.Status),
+							fmt.Sprintf("Error verifying volume %v->%v: %v", vInfo.SourceVolume, vInfo.RestoreVolume, vInfo.Reason))
+						restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+						restore.Status.FinishTimestamp = metav1.Now()
+						restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+						restore.Status.Reason = vInfo.Reason
+						break
+					}
+				}
+				a.recorder.Event(restore,
+					v1.EventTypeNormal,
+					string(vInfo.Status),
+					fmt.Sprintf("Volume %v->%v restored successfully", vInfo.SourceVolume, vInfo.RestoreVolume))
+				if len(vInfo.Warnings) != 0 {
+					message := fmt.Sprintf("Volume %v->%v restored with warnings: %v",
+						vInfo.SourceVolume, vInfo.RestoreVolume, strings.Join(vInfo.Warnings, "; "))
+					log.ApplicationRestoreLog(restore).Warnf(message)
+					a.recorder.Event(restore,
+						v1.EventTypeWarning,
+						string(vInfo.Status),
+						message)
+				}
+			}
+		}
+	}
+
+	// Return if we have any volume restores still in progress
+	if inProgress {
+		return nil
+	}
+
+	// Wait for the restore to be finalized once the volumes have been staged
+	if restore.Spec.StagedRestore &&
+		restore.Status.Stage == storkapi.ApplicationRestoreStageVolumes &&
+		restore.Status.Status != storkapi.ApplicationRestoreStatusFailed {
+		restore.Status.Status = storkapi.ApplicationRestoreStatusStaged
+		restore.Status.Reason = "Volumes have been staged, set FinalizeStagedRestore to complete the restore"
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusStaged),
+			restore.Status.Reason)
+		return a.client.Update(context.TODO(), restore)
+	}
+
+	// Wait to be allowed to restore the resources so that the volumes can be
+	// validated first
+	if restore.Spec.WaitAfterVolumes &&
+		restore.Status.Status != storkapi.ApplicationRestoreStatusFailed &&
+		restore.Annotations[StorkRestoreResourcesAnnotation] != "true" {
+		restore.Status.Status = storkapi.ApplicationRestoreStatusVolumesRestored
+		restore.Status.Reason = fmt.Sprintf("Volumes have been restored, set the %v annotation to true to restore the resources",
+			StorkRestoreResourcesAnnotation)
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusVolumesRestored),
+			restore.Status.Reason)
+		return a.client.Update(context.TODO(), restore)
+	}
+
+	// If the restore hasn't failed move on to the next stage.
+	if restore.Status.Status != storkapi.ApplicationRestoreStatusFailed {
+		restore.Status.Stage = storkapi.ApplicationRestoreStageApplications
+		restore.Status.Status = storkapi.ApplicationRestoreStatusInProgress
+		restore.Status.Reason = "Application resources restore is in progress"
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		// Update the current state and then move on to restoring resources
+		err := a.client.Update(context.TODO(), restore)
+		if err != nil {
+			return err
+		}
+		err = a.restoreResources(restore)
+		if err != nil {
+			log.ApplicationRestoreLog(restore).Errorf("Error restoring resources: %v", err)
+			return err
+		}
+	}
+
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	// Only on success compute the total restore size
+	for _, vInfo := range restore.Status.Volumes {
+		restore.Status.TotalSize += vInfo.TotalSize
+	}
+
+	err := a.client.Update(context.TODO(), restore)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// verifyRestoredVolume asks the driver to verify the integrity of a restored
+// volume. Returns false, with the status of the volume set to
+// VerificationFailed, if the driver found that the data is corrupt. Volumes
+// from drivers that can't verify them are reported with a warning.
+func (a *ApplicationRestoreController) verifyRestoredVolume(
+	restore *storkapi.ApplicationRestore,
+	vInfo *storkapi.ApplicationRestoreVolumeInfo,
+) (bool, error) {
+	driver, err := volume.Get(vInfo.DriverName)
+	if err != nil {
+		return false, err
+	}
+	err = driver.VerifyRestore(restore, vInfo)
+	if err == nil {
+		log.ApplicationRestoreLog(restore).Infof("Volume %v->%v verified", vInfo.SourceVolume, vInfo.RestoreVolume)
+		vInfo.Verified = true
+		return true, nil
+	}
+	if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+		message := fmt.Sprintf("Volume %v->%v can't be verified by driver %v", vInfo.SourceVolume, vInfo.RestoreVolume, vInfo.DriverName)
+		log.ApplicationRestoreLog(restore).Warnf(message)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(vInfo.Status),
+			message)
+		vInfo.Verified = true
+		return true, nil
+	}
+	if _, ok := err.(*storkerrors.ErrVerificationFailed); ok {
+		vInfo.Status = storkapi.ApplicationRestoreStatusVerificationFailed
+		vInfo.Reason = err.Error()
+		return false, nil
+	}
+	return false, fmt.Errorf("error verifying volume %v: %v", vInfo.RestoreVolume, err)
+}
+
+func (a *ApplicationRestoreController) downloadObject(
+	backup *storkapi.ApplicationBackup,
+	backupLocation string,
+	namespace string,
+	objectName string,
+	skipIfNotPresent bool,
+) ([]byte, error) {
+	// The bucket is reused across reconciles since a restore downloads several
+	// objects from the same location
+	bucket, restoreLocation, err := objectstore.GetCachedBucket(backup.Spec.BackupLocation, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := objectstore.CheckEncryption(restoreLocation); err != nil {
+		return nil, err
+	}
+
+	objectPath := backup.Status.BackupPath
+	if skipIfNotPresent {
+		exists, err := a.backupObjectExists(bucket, restoreLocation, objectPath, objectName)
+		if err != nil || !exists {
+			return nil, nil
+		}
+	}
+
+	ctx, cancel := objectstore.GetContext(restoreLocation, objectstore.RequestRead)
+	defer cancel()
+	reader, err := bucket.NewReader(ctx, filepath.Join(objectPath, objectName), nil)
+	if err != nil {
+		objectstore.InvalidateCachedBucket(backup.Spec.BackupLocation, namespace)
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := ioutil.ReadAll(objectstore.NewCountingReader(reader, restoreLocation, objectstore.OperationRestore))
+	if err != nil {
+		objectstore.InvalidateCachedBucket(backup.Spec.BackupLocation, namespace)
+		return nil, err
+	}
+	if restoreLocation.Location.EncryptionKey != "" {
+		if data, err = crypto.Decrypt(data, restoreLocation.Location.EncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+
+	// The codec used for each object is detected, since the compression for
+	// the location could have changed after the backup was created
+	return compression.Decompress(data)
+}
+
+// backupObjectExists checks if an object exists in the backup path. The
+// objects in the path are listed once and cached, since the contents of a
+// backup don't change after it has completed. The requests use the exists
+// timeout for the location so that missing objects are detected quickly.
+func (a *ApplicationRestoreController) backupObjectExists(
+	bucket *blob.Bucket,
+	backupLocation *storkapi.BackupLocation,
+	objectPath string,
+	objectName string,
+) (bool, error) {
+	a.backupObjectsLock.Lock()
+	defer a.backupObjectsLock.Unlock()
+	if a.backupObjects == nil {
+		a.backupObjects = make(map[string]*backupObjectList)
+	}
+	list, ok := a.backupObjects[objectPath]
+	if !ok || time.Since(list.listTime) > backupObjectCacheTimeout {
+		// Remove stale entries from restores that have completed
+		for path, list := range a.backupObjects {
+			if time.Since(list.listTime) > backupObjectCacheTimeout {
+				delete(a.backupObjects, path)
+			}
+		}
+		list = &backupObjectList{
+			listTime: time.Now(),
+			objects:  make(map[string]bool),
+		}
+		ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestExists)
+		defer cancel()
+		iterator := bucket.List(&blob.ListOptions{
+			Prefix:    objectPath + "/",
+			Delimiter: "/",
+		})
+		for {
+			object, err := iterator.Next(ctx)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				// Fall back to checking the object directly
+				return bucket.Exists(ctx, filepath.Join(objectPath, objectName))
+			}
+			list.objects[filepath.Base(object.Key)] = true
+		}
+		a.backupObjects[objectPath] = list
+	}
+	return list.objects[objectName], nil
+}
+
+func (a *ApplicationRestoreController) downloadResources(
+	restore *storkapi.ApplicationRestore,
+	backup *storkapi.ApplicationBackup,
+	backupLocation string,
+	namespace string,
+	target *restoreTarget,
+	waitForCRDs bool,
+) ([]runtime.Unstructured, error) {
+	// create CRD resource first
+	if err := a.downloadCRD(restore, backup, backupLocation, namespace, target, waitForCRDs); err != nil {
+		return nil, fmt.Errorf("error downloading CRDs: %v", err)
+	}
+	return a.downloadResourceObjects(backup, backupLocation, namespace)
+}
+
+// downloadResourceObjects downloads the resources in the backup without
+// registering the CRDs for them
+func (a *ApplicationRestoreController) downloadResourceObjects(
+	backup *storkapi.ApplicationBackup,
+	backupLocation string,
+	namespace string,
+) ([]runtime.Unstructured, error) {
+	data, err := a.downloadObject(backup, backupLocation, namespace, resourceObjectName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]*unstructured.Unstructured, 0)
+	if err = json.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+	runtimeObjects := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		runtimeObjects = append(runtimeObjects, o)
+	}
+	return runtimeObjects, nil
+}
+
+// downloadCRD registers the CRDs from the backup. CRDs that use a conversion
+// webhook whose service doesn't exist yet are registered without conversion,
+// since the custom resources couldn't be applied otherwise. The conversion is
+// set back once the restore completes.
+func (a *ApplicationRestoreController) downloadCRD(
+	restore *storkapi.ApplicationRestore,
+	backup *storkapi.ApplicationBackup,
+	backupLocation string,
+	namespace string,
+	target *restoreTarget,
+	waitForCRDs bool,
+) error {
+	var crds []*apiextensionsv1beta1.CustomResourceDefinition
+	var crdsV1 []*apiextensionsv1.CustomResourceDefinition
+	crdData, err := a.downloadObject(backup, backupLocation, namespace, crdObjectName, true)
+	if err != nil {
+		return err
+	}
+	// No CRDs were uploaded
+	if crdData == nil {
+		return nil
+	}
+	if err = json.Unmarshal(crdData, &crds); err != nil {
+		return err
+	}
+	if err = json.Unmarshal(crdData, &crdsV1); err != nil {
+		return err
+	}
+	client, err := apiextensionsclient.NewForConfig(target.config)
+	if err != nil {
+		return err
+	}
+
+	// CRDs are backed up as v1beta1, so the conversion webhooks are only
+	// found in those
+	conversions := make(map[string]*apiextensionsv1.CustomResourceConversion)
+	relaxedConversions := make(map[string]bool)
+	for _, crd := range crds {
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Strategy != apiextensionsv1beta1.WebhookConverter {
+			continue
+		}
+		conversions[crd.GetName()] = getV1CRDConversion(conversion)
+		relax, err := conversionServiceMissing(target, conversion.WebhookClientConfig)
+		if err != nil {
+			return err
+		}
+		if relax {
+			relaxedConversions[crd.GetName()] = true
+			if err := setCRDConversionAnnotation(crd, conversions[crd.GetName()]); err != nil {
+				return err
+			}
+			crd.Spec.Conversion = &apiextensionsv1beta1.CustomResourceConversion{
+				Strategy: apiextensionsv1beta1.NoneConverter,
+			}
+		}
+	}
+
+	regCrd := make(map[string]bool)
+	for _, crd := range crds {
+		crd.ResourceVersion = ""
+		regCrd[crd.GetName()] = false
+		if _, err := client.ApiextensionsV1beta1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{}); err != nil {
+			if !errors.IsAlreadyExists(err) {
+				regCrd[crd.GetName()] = true
+				logrus.Warnf("error registering crds v1beta1 %v,%v", crd.GetName(), err)
+				continue
+			}
+		} else if relaxedConversions[crd.GetName()] {
+			a.recordRelaxedCRDConversion(restore, crd.GetName())
+		}
+		if !waitForCRDs {
+			continue
+		}
+		// wait for crd to be ready
+		if err := k8sutils.ValidateCRD(client, crd.GetName()); err != nil {
+			logrus.Warnf("Unable to validate crds v1beta1 %v,%v", crd.GetName(), err)
+		}
+	}
+
+	for _, crd := range crdsV1 {
+		if val, ok := regCrd[crd.GetName()]; ok && val {
+			crd.ResourceVersion = ""
+			if conversion, ok := conversions[crd.GetName()]; ok {
+				if relaxedConversions[crd.GetName()] {
+					if err := setCRDConversionAnnotation(crd, conversion); err != nil {
+						return err
+					}
+					crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+						Strategy: apiextensionsv1.NoneConverter,
+					}
+				} else {
+					crd.Spec.Conversion = conversion
+				}
+			}
+			var updatedVersions []apiextensionsv1.CustomResourceDefinitionVersion
+			// try to apply as v1 crd
+			var err error
+			if _, err = client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{}); err == nil || errors.IsAlreadyExists(err) {
+				if err == nil && relaxedConversions[crd.GetName()] {
+					a.recordRelaxedCRDConversion(restore, crd.GetName())
+				}
+				logrus.Infof("registered v1 crds %v,", crd.GetName())
+				continue
+			}
+			// updated fields
+			crd.Spec.PreserveUnknownFields = false
+			for _, version := range crd.Spec.Versions {
+				isTrue := true
+				if version.Schema == nil {
+					openAPISchema := &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: &isTrue},
+					}
+					version.Schema = openAPISchema
+				} else {
+					version.Schema.OpenAPIV3Schema.XPreserveUnknownFields = &isTrue
+				}
+				updatedVersions = append(updatedVersions, version)
+			}
+			crd.Spec.Versions = updatedVersions
+
+			if _, err := client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{}); err != nil {
+				if !errors.IsAlreadyExists(err) {
+					logrus.Warnf("error registering crdsv1 %v,%v", crd.GetName(), err)
+					continue
+				}
+			} else if relaxedConversions[crd.GetName()] {
+				a.recordRelaxedCRDConversion(restore, crd.GetName())
+			}
+			if !waitForCRDs {
+				continue
+			}
+			// wait for crd to be ready
+			if err := k8sutils.ValidateCRDV1(client, crd.GetName()); err != nil {
+				logrus.Warnf("Unable to validate crdsv1 %v,%v", crd.GetName(), err)
+			}
+
+		}
+	}
+
+	return nil
+}
+
+// getV1CRDConversion returns the v1 conversion for the v1beta1 conversion of a
+// CRD
+func getV1CRDConversion(
+	conversion *apiextensionsv1beta1.CustomResourceConversion,
+) *apiextensionsv1.CustomResourceConversion {
+	v1Conversion := &apiextensionsv1.CustomResourceConversion{
+		Strategy: apiextensionsv1.ConversionStrategyType(conversion.Strategy),
+	}
+	if conversion.Strategy != apiextensionsv1beta1.WebhookConverter {
+		return v1Conversion
+	}
+	reviewVersions := conversion.ConversionReviewVersions
+	if len(reviewVersions) == 0 {
+		// Default for apiextensions.k8s.io/v1beta1
+		reviewVersions = []string{"v1beta1"}
+	}
+	v1Conversion.Webhook = &apiextensionsv1.WebhookConversion{
+		ConversionReviewVersions: reviewVersions,
+	}
+	if clientConfig := conversion.WebhookClientConfig; clientConfig != nil {
+		v1Conversion.Webhook.ClientConfig = &apiextensionsv1.WebhookClientConfig{
+			URL:      clientConfig.URL,
+			CABundle: clientConfig.CABundle,
+		}
+		if service := clientConfig.Service; service != nil {
+			v1Conversion.Webhook.ClientConfig.Service = &apiextensionsv1.ServiceReference{
+				Namespace: service.Namespace,
+				Name:      service.Name,
+				Path:      service.Path,
+				Port:      service.Port,
+			}
+		}
+	}
+	return v1Conversion
+}
+
+// conversionServiceMissing checks if the service called by a conversion
+// webhook doesn't exist on the destination. Webhooks called using a URL are
+// assumed to be reachable.
+func conversionServiceMissing(
+	target *restoreTarget,
+	clientConfig *apiextensionsv1beta1.WebhookClientConfig,
+) (bool, error) {
+	if clientConfig == nil || clientConfig.Service == nil {
+		return false, nil
+	}
+	_, err := target.coreOps.GetService(clientConfig.Service.Name, clientConfig.Service.Namespace)
+	if err == nil {
+		return false, nil
+	}
+	if errors.IsNotFound(err) {
+		return true, nil
+	}
+	return false, err
+}
+
+// setCRDConversionAnnotation stores the conversion of a CRD in an annotation
+// so that it can be set back after the restore
+func setCRDConversionAnnotation(
+	crd metav1.Object,
+	conversion *apiextensionsv1.CustomResourceConversion,
+) error {
+	value, err := json.Marshal(conversion)
+	if err != nil {
+		return err
+	}
+	annotations := crd.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[crdConversionAnnotation] = string(value)
+	crd.SetAnnotations(annotations)
+	return nil
+}
+
+// recordRelaxedCRDConversion records a CRD whose conversion webhook was
+// disabled in the status of the restore
+func (a *ApplicationRestoreController) recordRelaxedCRDConversion(
+	restore *storkapi.ApplicationRestore,
+	name string,
+) {
+	if slice.ContainsString(restore.Status.RelaxedCRDConversions, name, nil) {
+		return
+	}
+	restore.Status.RelaxedCRDConversions = append(restore.Status.RelaxedCRDConversions, name)
+	a.recorder.Event(restore,
+		v1.EventTypeWarning,
+		string(storkapi.ApplicationRestoreStatusInProgress),
+		fmt.Sprintf("Conversion webhook disabled for CRD %v until the restore completes since its service doesn't exist", name))
+}
+
+// restoreCRDConversions sets the conversion of the CRDs whose conversion
+// webhook was disabled by the restore back to the original value. The
+// conversion is only set back once the service for the webhook exists, unless
+// force is set.
+func (a *ApplicationRestoreController) restoreCRDConversions(restore *storkapi.ApplicationRestore, force bool) error {
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	client, err := apiextensionsclient.NewForConfig(target.config)
+	if err != nil {
+		return err
+	}
+	for len(restore.Status.RelaxedCRDConversions) != 0 {
+		name := restore.Status.RelaxedCRDConversions[0]
+		crd, err := client.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		value, ok := "", false
+		if err == nil {
+			value, ok = crd.Annotations[crdConversionAnnotation]
+		}
+		if ok {
+			conversion := &apiextensionsv1.CustomResourceConversion{}
+			if err := json.Unmarshal([]byte(value), conversion); err != nil {
+				return fmt.Errorf("error parsing conversion for CRD %v: %v", name, err)
+			}
+			if !force && conversion.Webhook != nil && conversion.Webhook.ClientConfig != nil {
+				if service := conversion.Webhook.ClientConfig.Service; service != nil {
+					if _, err := target.coreOps.GetService(service.Name, service.Namespace); err != nil {
+						return fmt.Errorf("error getting service %v/%v for conversion webhook of CRD %v: %v",
+							service.Namespace, service.Name, name, err)
+					}
+				}
+			}
+			crd.Spec.Conversion = conversion
+			delete(crd.Annotations, crdConversionAnnotation)
+			if _, err := client.ApiextensionsV1().CustomResourceDefinitions().Update(context.TODO(), crd, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			log.ApplicationRestoreLog(restore).Infof("Set conversion back for CRD %v", name)
+		}
+		restore.Status.RelaxedCRDConversions = restore.Status.RelaxedCRDConversions[1:]
+	}
+	return nil
+}
+
+func (a *ApplicationRestoreController) updateResourceStatus(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	status storkapi.ApplicationRestoreStatusType,
+	reason string,
+) error {
+	return a.updateResourceStatusWithDiff(restore, object, status, reason, nil)
+}
+
+// updateGeneratedResourceStatus marks a resource that was created using its
+// generateName as successful, along with the name it was created with
+func (a *ApplicationRestoreController) updateGeneratedResourceStatus(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	generatedName string,
+) error {
+	if err := a.updateResourceStatus(
+		restore,
+		object,
+		storkapi.ApplicationRestoreStatusSuccessful,
+		fmt.Sprintf("Resource restored successfully with generated name %v", generatedName)); err != nil {
+		return err
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	a.resourceStatusLock.Lock()
+	defer a.resourceStatusLock.Unlock()
+	if resource := findResourceStatus(restore, object.GetObjectKind().GroupVersionKind(), metadata); resource != nil {
+		resource.GeneratedName = generatedName
+	}
+	return nil
+}
+
+// findResourceStatus returns the status of the resource for an object, or nil
+// if it hasn't been added to the status yet
+func findResourceStatus(
+	restore *storkapi.ApplicationRestore,
+	gkv schema.GroupVersionKind,
+	metadata metav1.Object,
+) *storkapi.ApplicationRestoreResourceInfo {
+	for _, resource := range restore.Status.Resources {
+		if resource.Name == metadata.GetName() &&
+			resource.Namespace == metadata.GetNamespace() &&
+			(resource.Group == gkv.Group || (resource.Group == "core" && gkv.Group == "")) &&
+			resource.Version == gkv.Version &&
+			resource.Kind == gkv.Kind {
+			return resource
+		}
+	}
+	return nil
+}
+
+// updateResourceStatusWithDiff updates the status of a resource along with
+// the diff from the object in the cluster for a dry run
+func (a *ApplicationRestoreController) updateResourceStatusWithDiff(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	status storkapi.ApplicationRestoreStatusType,
+	reason string,
+	diff *storkapi.ApplicationRestoreResourceDiff,
+) error {
+	// Resources from different namespaces are applied in parallel
+	a.resourceStatusLock.Lock()
+	defer a.resourceStatusLock.Unlock()
+	gkv := object.GetObjectKind().GroupVersionKind()
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		log.ApplicationRestoreLog(restore).Errorf("Error getting metadata for object %v %v", object, err)
+		return err
+	}
+	updatedResource := findResourceStatus(restore, gkv, metadata)
+	if updatedResource == nil {
+		updatedResource = &storkapi.ApplicationRestoreResourceInfo{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:      metadata.GetName(),
+				Namespace: metadata.GetNamespace(),
+				GroupVersionKind: metav1.GroupVersionKind{
+					Group:   gkv.Group,
+					Version: gkv.Version,
+					Kind:    gkv.Kind,
+				},
+			},
+		}
+		restore.Status.Resources = append(restore.Status.Resources, updatedResource)
+	}
+
+	updatedResource.Status = status
+	updatedResource.Reason = reason
+	updatedResource.Diff = diff
+	updatedResource.Warnings = nil
+	eventType := v1.EventTypeNormal
+	if warning := getSanitizedNameWarning(restore, gkv, metadata); warning != "" {
+		updatedResource.Warnings = []string{warning}
+		eventType = v1.EventTypeWarning
+		reason = fmt.Sprintf("%v (%v)", reason, warning)
+	}
+	if status == storkapi.ApplicationRestoreStatusFailed ||
+		status == storkapi.ApplicationRestoreStatusConflict {
+		eventType = v1.EventTypeWarning
+	}
+	eventMessage := fmt.Sprintf("%v %v/%v: %v",
+		gkv,
+		updatedResource.Namespace,
+		updatedResource.Name,
+		reason)
+	if restore.Status.CreatedBy != "" {
+		eventMessage = fmt.Sprintf("%v (initiated by %v)", eventMessage, restore.Status.CreatedBy)
+	}
+	a.recorder.Event(restore, eventType, string(status), eventMessage)
+	return nil
+}
+
+func (a *ApplicationRestoreController) getDeleteOptions(restore *storkapi.ApplicationRestore) *resourcecollector.DeleteOptions {
+	return &resourcecollector.DeleteOptions{
+		Concurrency:        restore.Spec.DeleteConcurrency,
+		BatchSize:          restore.Spec.DeleteBatchSize,
+		GracePeriodSeconds: restore.Spec.DeleteGracePeriodSeconds,
+	}
+}
+
+// getVolumePlacement returns the first placement from the restore spec that
+// matches a PVC in the source, or nil if none of them match
+func getVolumePlacement(
+	restore *storkapi.ApplicationRestore,
+	namespace string,
+	name string,
+) *storkapi.ApplicationRestoreVolumePlacement {
+	key := namespace + "/" + name
+	for i, placement := range restore.Spec.VolumePlacement {
+		if len(placement.PersistentVolumeClaims) == 0 ||
+			slice.ContainsString(placement.PersistentVolumeClaims, key, nil) {
+			return &restore.Spec.VolumePlacement[i]
+		}
+	}
+	return nil
+}
+
+// getPlacedVolumeInfos returns copies of the volumes to be restored with the
+// zones from their placement set in the options for the driver
+func getPlacedVolumeInfos(
+	restore *storkapi.ApplicationRestore,
+	vInfos []*storkapi.ApplicationBackupVolumeInfo,
+) []*storkapi.ApplicationBackupVolumeInfo {
+	placedInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0, len(vInfos))
+	for _, vInfo := range vInfos {
+		placement := getVolumePlacement(restore, vInfo.Namespace, vInfo.PersistentVolumeClaim)
+		if placement == nil || len(placement.Zones) == 0 {
+			placedInfos = append(placedInfos, vInfo)
+			continue
+		}
+		placedInfo := *vInfo
+		placedInfo.Options = make(map[string]string)
+		for k, v := range vInfo.Options {
+			placedInfo.Options[k] = v
+		}
+		placedInfo.Options[volume.RestoreZonesOption] = strings.Join(placement.Zones, ",")
+		placedInfos = append(placedInfos, &placedInfo)
+	}
+	return placedInfos
+}
+
+// preparePVPlacement replaces the node affinity of a restored PV with the
+// zones that the driver restored the volume to. The affinity is only replaced
+// for volumes whose driver placed them using the zones from the restore.
+func (a *ApplicationRestoreController) preparePVPlacement(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	sourceName string,
+) error {
+	var placedZones []string
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.SourceVolume == sourceName {
+			placedZones = vInfo.PlacedZones
+			break
+		}
+	}
+	if len(placedZones) == 0 {
+		return nil
+	}
+
+	nodeAffinity := &v1.VolumeNodeAffinity{
+		Required: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{
+				MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key:      v1.LabelTopologyZone,
+					Operator: v1.NodeSelectorOpIn,
+					Values:   placedZones,
+				}},
+			}},
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(nodeAffinity)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(object.UnstructuredContent(), content, "spec", "nodeAffinity")
+}
+
+func (a *ApplicationRestoreController) getPVNameMappings(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) (map[string]string, error) {
+	pvNameMappings := make(map[string]string)
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.SourceVolume == "" {
+			return nil, fmt.Errorf("SourceVolume missing for restore")
+		}
+		if vInfo.RestoreVolume == "" {
+			return nil, fmt.Errorf("RestoreVolume missing for restore")
+		}
+		pvNameMappings[vInfo.SourceVolume] = vInfo.RestoreVolume
+	}
+	return pvNameMappings, nil
+}
+
+func isGenericCSIPersistentVolume(pv *v1.PersistentVolume) (bool, error) {
+	driverName, err := volume.GetPVDriver(pv)
+	if err != nil {
+		return false, err
+	}
+	if driverName == "csi" {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (a *ApplicationRestoreController) removeCSIVolumesBeforeApply(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) ([]runtime.Unstructured, error) {
+	tempObjects := make([]runtime.Unstructured, 0)
+
+	// Get PVC to PV mapping first for checking if a PVC is bound to a generic CSI PV
+	pvcToPVMapping, err := resourcecollector.BuildPVCToPVMapping(objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PVC to PV mapping: %v", err)
+	}
+	pvToPVCMapping, err := getPVToPVCMapping(objects)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range objects {
+		objectType, err := meta.TypeAccessor(o)
+		if err != nil {
+			return nil, err
+		}
+
+		switch objectType.GetKind() {
+		case "PersistentVolume":
+			// check if this PV is a generic CSI one
+			var pv v1.PersistentVolume
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pv); err != nil {
+				return nil, fmt.Errorf("error converting to persistent volume: %v", err)
+			}
+
+			// Check if this PV is a generic CSI one
+			isGenericCSIPVC, err := isGenericCSIPersistentVolume(&pv)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check if PV was provisioned by a CSI driver: %v", err)
+			}
+
+			// Statically provisioned PVs aren't re-created by the CSI
+			// restore, so they are restored from the backup and bound to
+			// their PVC
+			if isGenericCSIPVC && isStaticPersistentVolume(&pv) {
+				if err := prepareStaticPersistentVolume(o, &pv, pvToPVCMapping[pv.Name]); err != nil {
+					return nil, err
+				}
+				log.ApplicationRestoreLog(restore).Debugf("restoring statically provisioned CSI PV: %s", pv.Name)
+				tempObjects = append(tempObjects, o)
+				continue
+			}
+
+			// Only add this object if it's not a generic CSI PV
+			if !isGenericCSIPVC {
+				tempObjects = append(tempObjects, o)
+			} else {
+				log.ApplicationRestoreLog(restore).Debugf("skipping CSI PV in restore: %s", pv.Name)
+			}
+
+		case "PersistentVolumeClaim":
+			// check if this PVC is a generic CSI one
+			var pvc v1.PersistentVolumeClaim
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pvc); err != nil {
+				return nil, fmt.Errorf("error converting PVC object: %v: %v", o, err)
+			}
+
+			// Find the matching PV for this PVC
+			pv, ok := pvcToPVMapping[resourcecollector.PVCMappingKey(pvc.Namespace, pvc.Name)]
+			if !ok {
+				log.ApplicationRestoreLog(restore).Debugf("failed to find PV for PVC %s during CSI volume skip. Will not skip volume", pvc.Name)
+				tempObjects = append(tempObjects, o)
+				continue
+			}
+
+			// We have found a PV for this PVC. Check if it is a generic CSI PV
+			// that we do not already have native volume driver support for.
+			isGenericCSIPVC, err := isGenericCSIPersistentVolume(pv)
+			if err != nil {
+				return nil, err
+			}
+
+			// Only add this object if it's not a generic CSI PVC. PVCs bound
+			// to static PVs are restored along with the PV.
+			if !isGenericCSIPVC || isStaticPersistentVolume(pv) {
+				tempObjects = append(tempObjects, o)
+			} else {
+				log.ApplicationRestoreLog(restore).Debugf("skipping CSI PVC in restore: %s", pvc.Name)
+			}
+
+		default:
+			// add all other objects
+			tempObjects = append(tempObjects, o)
+		}
+	}
+
+	return tempObjects, nil
+}
+
+// getPVToPVCMapping returns the PVCs in the objects keyed by the name of the PV
+// they are bound to
+func getPVToPVCMapping(objects []runtime.Unstructured) (map[string]*v1.PersistentVolumeClaim, error) {
+	pvToPVC := make(map[string]*v1.PersistentVolumeClaim)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolumeClaim" {
+			continue
+		}
+		pvc := &v1.PersistentVolumeClaim{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), pvc); err != nil {
+			return nil, fmt.Errorf("error converting PVC object: %v: %v", o, err)
+		}
+		if pvc.Spec.VolumeName != "" {
+			pvToPVC[pvc.Spec.VolumeName] = pvc
+		}
+	}
+	return pvToPVC, nil
+}
+
+// getVolumeMode returns the volume mode of a PV or PVC, which defaults to
+// Filesystem if it isn't set
+func getVolumeMode(volumeMode *v1.PersistentVolumeMode) v1.PersistentVolumeMode {
+	if volumeMode == nil {
+		return v1.PersistentVolumeFilesystem
+	}
+	return *volumeMode
+}
+
+// prepareStaticPersistentVolume binds a statically provisioned PV from the
+// backup to its PVC. The claimRef and storage class are removed from PVs when
+// they are collected, so they are set from the PVC. The reclaim policy and
+// volume source are restored as they were in the backup.
+func prepareStaticPersistentVolume(
+	object runtime.Unstructured,
+	pv *v1.PersistentVolume,
+	pvc *v1.PersistentVolumeClaim,
+) error {
+	if pvc == nil {
+		return nil
+	}
+	pv.Spec.ClaimRef = &v1.ObjectReference{
+		Kind:       "PersistentVolumeClaim",
+		APIVersion: "v1",
+		Namespace:  pvc.Namespace,
+		Name:       pvc.Name,
+	}
+	if pvc.Spec.StorageClassName != nil {
+		pv.Spec.StorageClassName = *pvc.Spec.StorageClassName
+	}
+	// The PVC won't bind unless the volume modes match, so use the mode
+	// requested by the PVC for raw block volumes
+	if getVolumeMode(pv.Spec.VolumeMode) != getVolumeMode(pvc.Spec.VolumeMode) {
+		volumeMode := getVolumeMode(pvc.Spec.VolumeMode)
+		pv.Spec.VolumeMode = &volumeMode
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pv)
+	if err != nil {
+		return fmt.Errorf("error converting static PV %v: %v", pv.Name, err)
+	}
+	object.SetUnstructuredContent(content)
+	return nil
+}
+
+// prepareWorkloadResource sets the replicas for workloads to 0 and suspends
+// CronJobs so that they don't start after being restored. The original number
+// of replicas is stored in an annotation.
+func (a *ApplicationRestoreController) prepareWorkloadResource(
+	object runtime.Unstructured,
+) error {
+	content := object.UnstructuredContent()
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "Deployment", "StatefulSet", "DeploymentConfig":
+	case "CronJob":
+		return suspendCronJob(object)
+	default:
+		return nil
+	}
+
+	replicas, found, err := unstructured.NestedInt64(content, "spec", "replicas")
+	if err != nil {
+		return err
+	}
+	if !found {
+		replicas = 1
+	}
+
+	err = unstructured.SetNestedField(content, int64(0), "spec", "replicas")
+	if err != nil {
+		return err
+	}
+
+	annotations, found, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	if !found {
+		annotations = make(map[string]string)
+	}
+	annotations[StorkRestoreReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
+
+// pendingOwnerReferences are the owner references of an object to owners that
+// are being restored, which are added once the owners have been applied
+type pendingOwnerReferences struct {
+	object          runtime.Unstructured
+	ownerReferences []metav1.OwnerReference
+}
+
+// prepareOwnerReferences removes the owner references from objects whose
+// owners aren't being restored and don't exist on the destination. The
+// objects would otherwise be garbage collected as soon as they are created.
+// The UIDs of owners that already exist on the destination are updated, since
+// they won't match the UIDs from the backup. References to owners that are
+// being restored are removed too and returned, so that they can be added with
+// the UIDs of the owners once they have been applied.
+func (a *ApplicationRestoreController) prepareOwnerReferences(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	objects []runtime.Unstructured,
+) ([]pendingOwnerReferences, error) {
+	pending := make([]pendingOwnerReferences, 0)
+	restored := make(map[string]bool)
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		restored[getObjectKey(gvk.Group, gvk.Kind, metadata.GetNamespace(), metadata.GetName())] = true
+	}
+
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		ownerReferences := metadata.GetOwnerReferences()
+		if len(ownerReferences) == 0 {
+			continue
+		}
+		updated := make([]metav1.OwnerReference, 0, len(ownerReferences))
+		restoredOwners := make([]metav1.OwnerReference, 0)
+		for _, ownerReference := range ownerReferences {
+			gv, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing apiVersion of owner %v: %v", ownerReference.Name, err)
+			}
+			// Owners can be namespaced or cluster scoped
+			if restored[getObjectKey(gv.Group, ownerReference.Kind, metadata.GetNamespace(), ownerReference.Name)] ||
+				restored[getObjectKey(gv.Group, ownerReference.Kind, "", ownerReference.Name)] {
+				restoredOwners = append(restoredOwners, ownerReference)
+				continue
+			}
+			owner, err := a.getOwner(target, gv.WithKind(ownerReference.Kind), metadata.GetNamespace(), ownerReference.Name)
+			if err != nil {
+				return nil, fmt.Errorf("error getting owner %v %v of %v: %v", ownerReference.Kind, ownerReference.Name, metadata.GetName(), err)
+			}
+			if owner == nil {
+				log.ApplicationRestoreLog(restore).Debugf("Removing owner reference to %v %v from %v %v/%v since the owner isn't being restored",
+					ownerReference.Kind, ownerReference.Name, o.GetObjectKind().GroupVersionKind().Kind, metadata.GetNamespace(), metadata.GetName())
+				continue
+			}
+			ownerReference.UID = owner.GetUID()
+			updated = append(updated, ownerReference)
+		}
+		if len(updated) == 0 {
+			updated = nil
+		}
+		metadata.SetOwnerReferences(updated)
+		if len(restoredOwners) != 0 {
+			pending = append(pending, pendingOwnerReferences{
+				object:          o,
+				ownerReferences: restoredOwners,
+			})
+		}
+	}
+	return pending, nil
+}
+
+// restoreOwnerReferences adds the owner references that were removed before
+// the objects were applied, with the UIDs of the restored owners. References
+// to owners that weren't restored are dropped.
+func (a *ApplicationRestoreController) restoreOwnerReferences(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	pending []pendingOwnerReferences,
+) error {
+	for _, p := range pending {
+		metadata, err := meta.Accessor(p.object)
+		if err != nil {
+			return err
+		}
+		ownerReferences := make([]metav1.OwnerReference, 0, len(p.ownerReferences))
+		for _, ownerReference := range p.ownerReferences {
+			gv, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+			if err != nil {
+				return fmt.Errorf("error parsing apiVersion of owner %v: %v", ownerReference.Name, err)
+			}
+			owner, err := a.getOwner(target, gv.WithKind(ownerReference.Kind), metadata.GetNamespace(), ownerReference.Name)
+			if err != nil {
+				return fmt.Errorf("error getting owner %v %v of %v: %v", ownerReference.Kind, ownerReference.Name, metadata.GetName(), err)
+			}
+			if owner == nil {
+				log.ApplicationRestoreLog(restore).Debugf("Removing owner reference to %v %v from %v %v/%v since the owner wasn't restored",
+					ownerReference.Kind, ownerReference.Name, p.object.GetObjectKind().GroupVersionKind().Kind, metadata.GetNamespace(), metadata.GetName())
+				continue
+			}
+			ownerReference.UID = owner.GetUID()
+			ownerReferences = append(ownerReferences, ownerReference)
+		}
+		if len(ownerReferences) == 0 {
+			continue
+		}
+
+		// Retried if the object was updated in the meantime, by the owner's
+		// controller for example
+		for retries := 0; ; retries++ {
+			object, err := a.resourceCollector.GetResource(target.dynamicInterface, p.object)
+			if err != nil {
+				if errors.IsNotFound(err) {
+					// The object wasn't restored
+					break
+				}
+				return err
+			}
+			object.SetOwnerReferences(mergeOwnerReferences(object.GetOwnerReferences(), ownerReferences))
+			_, err = a.resourceCollector.UpdateResource(target.dynamicInterface, object)
+			if err == nil {
+				break
+			}
+			if !errors.IsConflict(err) || retries >= ownerReferenceUpdateRetries {
+				return fmt.Errorf("error adding owner references to %v %v/%v: %v",
+					object.GetKind(), object.GetNamespace(), object.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeOwnerReferences adds the owner references that aren't already set on
+// the object. References are matched on the kind and name of the owner.
+func mergeOwnerReferences(existing []metav1.OwnerReference, added []metav1.OwnerReference) []metav1.OwnerReference {
+	merged := append([]metav1.OwnerReference{}, existing...)
+	for _, ownerReference := range added {
+		found := false
+		for _, e := range existing {
+			if e.Kind == ownerReference.Kind && e.Name == ownerReference.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, ownerReference)
+		}
+	}
+	return merged
+}
+
+// getOwner gets an owner from the destination, looking for it in the namespace
+// of the owned object first and then at the cluster scope. Returns nil if the
+// owner doesn't exist.
+func (a *ApplicationRestoreController) getOwner(
+	target *restoreTarget,
+	gvk schema.GroupVersionKind,
+	namespace string,
+	name string,
+) (*unstructured.Unstructured, error) {
+	for _, ns := range []string{namespace, ""} {
+		owner := &unstructured.Unstructured{}
+		owner.SetGroupVersionKind(gvk)
+		owner.SetNamespace(ns)
+		owner.SetName(name)
+		object, err := a.resourceCollector.GetResource(target.dynamicInterface, owner)
+		if err == nil {
+			return object, nil
+		}
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// suspendCronJob suspends a CronJob so that it doesn't start any jobs after
+// being restored. The original suspend setting is stored in an annotation.
+func suspendCronJob(object runtime.Unstructured) error {
+	if object.GetObjectKind().GroupVersionKind().Kind != "CronJob" {
+		return nil
+	}
+	content := object.UnstructuredContent()
+	suspend, _, err := unstructured.NestedBool(content, "spec", "suspend")
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(content, true, "spec", "suspend"); err != nil {
+		return err
+	}
+
+	annotations, found, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	if !found {
+		annotations = make(map[string]string)
+	}
+	annotations[StorkRestoreSuspendAnnotation] = strconv.FormatBool(suspend)
+	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
+
+// isFinishedJob checks if the object is a Job that had completed or failed
+// when it was backed up
+func isFinishedJob(object runtime.Unstructured) (bool, error) {
+	if object.GetObjectKind().GroupVersionKind().Kind != "Job" {
+		return false, nil
+	}
+	var job batchv1.Job
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &job); err != nil {
+		return false, err
+	}
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == v1.ConditionTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updateSkippedJobStatus reports a finished Job from the backup as skipped.
+// The object hasn't been prepared yet, so the status is recorded for the
+// namespace that it would have been restored to.
+func (a *ApplicationRestoreController) updateSkippedJobStatus(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	job := object.DeepCopyObject().(runtime.Unstructured)
+	metadata, err := meta.Accessor(job)
+	if err != nil {
+		return err
+	}
+	if namespace, ok := restore.Spec.NamespaceMapping[metadata.GetNamespace()]; ok {
+		metadata.SetNamespace(namespace)
+	}
+	return a.updateResourceStatus(
+		restore,
+		job,
+		storkapi.ApplicationRestoreStatusSkipped,
+		"Job had finished when it was backed up and wasn't restored so that it doesn't run again")
+}
+
+// restoredObjectNames tracks the names that objects are restored with, so that
+// references to them can be remapped. Objects are keyed by their kind and
+// source namespace and name.
+type restoredObjectNames struct {
+	names            map[string]string
+	sourceNamespaces map[string]string
+}
+
+func newRestoredObjectNames() *restoredObjectNames {
+	return &restoredObjectNames{
+		names:            make(map[string]string),
+		sourceNamespaces: make(map[string]string),
+	}
+}
+
+// add records the name that the object is restored with. Needs to be called
+// after the object has been prepared.
+func (r *restoredObjectNames) add(object runtime.Unstructured, sourceNamespace, sourceName string) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	r.names[kind+"/"+sourceNamespace+"/"+sourceName] = metadata.GetName()
+	r.sourceNamespaces[kind+"/"+metadata.GetNamespace()+"/"+metadata.GetName()] = sourceNamespace
+	return nil
+}
+
+// getSourceNamespace returns the namespace in the backup for a restored object
+func (r *restoredObjectNames) getSourceNamespace(object runtime.Unstructured) (string, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return "", err
+	}
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	if namespace, ok := r.sourceNamespaces[kind+"/"+metadata.GetNamespace()+"/"+metadata.GetName()]; ok {
+		return namespace, nil
+	}
+	return metadata.GetNamespace(), nil
+}
+
+// getName returns the name that an object from the backup is restored with.
+// Returns false if the object isn't being restored.
+func (r *restoredObjectNames) getName(kind, sourceNamespace, sourceName string) (string, bool) {
+	name, ok := r.names[kind+"/"+sourceNamespace+"/"+sourceName]
+	return name, ok
+}
+
+// preparePVCDataSources updates the data source for PVCs being restored based
+// on the policy in the restore. With the Remap policy, a data source that
+// refers to an object that is being restored too is updated to refer to the
+// restored object, using the name and namespace that it is restored with.
+// The data source is cleared otherwise.
+func (a *ApplicationRestoreController) preparePVCDataSources(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+	restoredNames *restoredObjectNames,
+) error {
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolumeClaim" {
+			continue
+		}
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
+		content := o.UnstructuredContent()
+		_, hasDataSource, err := unstructured.NestedFieldNoCopy(content, "spec", "dataSource")
+		if err != nil {
+			return err
+		}
+		_, hasDataSourceRef, err := unstructured.NestedFieldNoCopy(content, "spec", "dataSourceRef")
+		if err != nil {
+			return err
+		}
+		if !hasDataSource && !hasDataSourceRef {
+			continue
+		}
+		remapped := false
+		if restore.Spec.PVCDataSourcePolicy == storkapi.ApplicationRestorePVCDataSourcePolicyRemap {
+			sourceNamespace, err := restoredNames.getSourceNamespace(o)
+			if err != nil {
+				return err
+			}
+			if remapped, err = remapPVCDataSource(restore, content, sourceNamespace, restoredNames); err != nil {
+				return err
+			}
+		}
+		if remapped {
+			log.ApplicationRestoreLog(restore).Debugf("Remapped data source for PVC %v/%v",
+				metadata.GetNamespace(), metadata.GetName())
+			continue
+		}
+		unstructured.RemoveNestedField(content, "spec", "dataSource")
+		unstructured.RemoveNestedField(content, "spec", "dataSourceRef")
+	}
+	return nil
+}
+
+// remapPVCDataSource updates the dataSource and dataSourceRef of a PVC to
+// refer to the restored objects. Returns false if any of them refers to an
+// object that isn't being restored.
+func remapPVCDataSource(
+	restore *storkapi.ApplicationRestore,
+	content map[string]interface{},
+	sourceNamespace string,
+	restoredNames *restoredObjectNames,
+) (bool, error) {
+	for _, field := range []string{"dataSource", "dataSourceRef"} {
+		ref, found, err := unstructured.NestedStringMap(content, "spec", field)
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			continue
+		}
+		// Only a dataSourceRef can refer to another namespace
+		refNamespace := sourceNamespace
+		if ref["namespace"] != "" {
+			refNamespace = ref["namespace"]
+		}
+		name, ok := restoredNames.getName(ref["kind"], refNamespace, ref["name"])
+		if !ok {
+			return false, nil
+		}
+		if err := unstructured.SetNestedField(content, name, "spec", field, "name"); err != nil {
+			return false, err
+		}
+		if ref["namespace"] != "" {
+			if namespace, ok := restore.Spec.NamespaceMapping[ref["namespace"]]; ok {
+				if err := unstructured.SetNestedField(content, namespace, "spec", field, "namespace"); err != nil {
+					return false, err
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// meshSidecarInjection is the list of objects added to pods when a service
+// mesh injects its sidecar
+type meshSidecarInjection struct {
+	containers     []string
+	initContainers []string
+	volumes        []string
+	annotations    []string
+	labels         []string
+}
+
+var meshSidecarInjections = map[storkapi.ApplicationRestoreMeshType]meshSidecarInjection{
+	storkapi.ApplicationRestoreMeshIstio: {
+		containers:     []string{"istio-proxy"},
+		initContainers: []string{"istio-init", "istio-validation", "istio-proxy"},
+		volumes: []string{"istio-envoy", "istio-data", "istio-podinfo",
+			"istio-token", "istiod-ca-cert", "istio-certs"},
+		annotations: []string{"sidecar.istio.io/status"},
+		labels: []string{"security.istio.io/tlsMode",
+			"service.istio.io/canonical-name", "service.istio.io/canonical-revision"},
+	},
+	storkapi.ApplicationRestoreMeshLinkerd: {
+		containers:     []string{"linkerd-proxy"},
+		initContainers: []string{"linkerd-init"},
+		volumes:        []string{"linkerd-identity-end-entity", "linkerd-proxy-init-xtables-lock"},
+		annotations: []string{"linkerd.io/created-by", "linkerd.io/proxy-version",
+			"linkerd.io/identity-mode", "linkerd.io/trust-root-sha256"},
+		labels: []string{"linkerd.io/control-plane-ns", "linkerd.io/proxy-deployment",
+			"linkerd.io/workload-ns"},
+	},
+}
+
+// prepareMeshSidecars removes sidecars that were injected by a service mesh
+// from the pod template of workloads so that they are injected again by the
+// mesh on the destination cluster
+func (a *ApplicationRestoreController) prepareMeshSidecars(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	templateFields := getPodTemplateFields(object.GetObjectKind().GroupVersionKind().Kind)
+	if templateFields == nil {
+		return nil
+	}
+
+	content := object.UnstructuredContent()
+	template, found, err := unstructured.NestedMap(content, templateFields...)
+	if err != nil || !found {
+		return err
+	}
+	for _, meshType := range restore.Spec.StripMeshSidecars {
+		injection, ok := meshSidecarInjections[meshType]
+		if !ok {
+			return fmt.Errorf("invalid mesh type %v for stripping sidecars", meshType)
+		}
+		if err := removeNamedItems(template, injection.containers, "spec", "containers"); err != nil {
+			return err
+		}
+		if err := removeNamedItems(template, injection.initContainers, "spec", "initContainers"); err != nil {
+			return err
+		}
+		if err := removeNamedItems(template, injection.volumes, "spec", "volumes"); err != nil {
+			return err
+		}
+		for _, annotation := range injection.annotations {
+			unstructured.RemoveNestedField(template, "metadata", "annotations", annotation)
+		}
+		for _, label := range injection.labels {
+			unstructured.RemoveNestedField(template, "metadata", "labels", label)
+		}
+	}
+	return unstructured.SetNestedMap(content, template, templateFields...)
+}
+
+// prepareServiceAnnotations removes and replaces the annotations on
+// LoadBalancer Services based on the restore spec. These are usually specific
+// to the infrastructure of the cloud provider on the source cluster.
+func (a *ApplicationRestoreController) prepareServiceAnnotations(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	if len(restore.Spec.StripServiceAnnotations) == 0 && len(restore.Spec.ServiceAnnotationMapping) == 0 {
+		return nil
+	}
+	if object.GetObjectKind().GroupVersionKind().Kind != "Service" {
+		return nil
+	}
+	content := object.UnstructuredContent()
+	serviceType, _, err := unstructured.NestedString(content, "spec", "type")
+	if err != nil {
+		return err
+	}
+	if serviceType != string(v1.ServiceTypeLoadBalancer) {
+		return nil
+	}
+
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	annotations := metadata.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	for annotation := range annotations {
+		if matchesKeyPattern(annotation, restore.Spec.StripServiceAnnotations) {
+			delete(annotations, annotation)
+		}
+	}
+	for annotation, value := range restore.Spec.ServiceAnnotationMapping {
+		annotations[annotation] = value
+	}
+	metadata.SetAnnotations(annotations)
+	return nil
+}
+
+// prepareIngress maps the ingress class of Ingresses to the class on the
+// destination and removes the annotations for the ingress controller on the
+// source, so that the restored Ingresses are picked up by the controller on
+// the destination.
+func (a *ApplicationRestoreController) prepareIngress(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	if len(restore.Spec.IngressClassMapping) == 0 && len(restore.Spec.StripIngressAnnotations) == 0 {
+		return nil
+	}
+	if object.GetObjectKind().GroupVersionKind().Kind != "Ingress" {
+		return nil
+	}
+	content := object.UnstructuredContent()
+	className, found, err := unstructured.NestedString(content, "spec", "ingressClassName")
+	if err != nil {
+		return err
+	}
+	if found {
+		if mappedClassName, ok := restore.Spec.IngressClassMapping[className]; ok {
+			if err := unstructured.SetNestedField(content, mappedClassName, "spec", "ingressClassName"); err != nil {
+				return err
+			}
+		}
+	}
+
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	annotations := metadata.GetAnnotations()
+	if len(annotations) == 0 {
+		return nil
+	}
+	for annotation := range annotations {
+		if matchesKeyPattern(annotation, restore.Spec.StripIngressAnnotations) &&
+			!matchesKeyPattern(annotation, restore.Spec.KeepIngressAnnotations) {
+			delete(annotations, annotation)
+		}
+	}
+	if className, ok := annotations[ingressClassAnnotation]; ok {
+		if mappedClassName, ok := restore.Spec.IngressClassMapping[className]; ok {
+			annotations[ingressClassAnnotation] = mappedClassName
+		}
+	}
+	metadata.SetAnnotations(annotations)
+	return nil
+}
+
+// prepareImagePullSecrets renames the image pull secrets of ServiceAccounts and
+// of the pod templates of workloads based on the mapping from the restore spec
+func (a *ApplicationRestoreController) prepareImagePullSecrets(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	var fields []string
+	if kind == "ServiceAccount" {
+		fields = []string{"imagePullSecrets"}
+	} else if templateFields := getPodTemplateFields(kind); templateFields != nil {
+		fields = append(templateFields, "spec", "imagePullSecrets")
+	} else {
+		return nil
+	}
+
+	content := object.UnstructuredContent()
+	secrets, found, err := unstructured.NestedSlice(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	for i := range secrets {
+		secret, ok := secrets[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, err := unstructured.NestedString(secret, "name")
+		if err != nil {
+			return err
+		}
+		if mapped, ok := restore.Spec.ImagePullSecretMapping[name]; ok {
+			secret["name"] = mapped
+		}
+	}
+	return unstructured.SetNestedSlice(content, secrets, fields...)
+}
+
+// preparePriorityClass renames the PriorityClass of the pod templates of
+// workloads based on the mapping from the restore spec. The priority from the
+// source is removed since it has to match the value of the new class.
+func (a *ApplicationRestoreController) preparePriorityClass(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	templateFields := getPodTemplateFields(object.GetObjectKind().GroupVersionKind().Kind)
+	if templateFields == nil {
+		return nil
+	}
+	content := object.UnstructuredContent()
+	specFields := append(templateFields, "spec")
+	name, found, err := unstructured.NestedString(content, append(specFields, "priorityClassName")...)
+	if err != nil || !found {
+		return err
+	}
+	mapped, ok := restore.Spec.PriorityClassMapping[name]
+	if !ok {
+		return nil
+	}
+	unstructured.RemoveNestedField(content, append(specFields, "priority")...)
+	return unstructured.SetNestedField(content, mapped, append(specFields, "priorityClassName")...)
+}
+
+// prepareGroupMapping updates the API group of an object, and of its owner
+// references, based on the group mapping from the restore spec
+func (a *ApplicationRestoreController) prepareGroupMapping(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if group, ok := restore.Spec.GroupMapping[gvk.Group]; ok {
+		gvk.Group = group
+		object.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	ownerReferences := metadata.GetOwnerReferences()
+	if len(ownerReferences) == 0 {
+		return nil
+	}
+	for i, ownerReference := range ownerReferences {
+		gv, err := schema.ParseGroupVersion(ownerReference.APIVersion)
+		if err != nil {
+			return fmt.Errorf("error parsing apiVersion of owner %v: %v", ownerReference.Name, err)
+		}
+		if group, ok := restore.Spec.GroupMapping[gv.Group]; ok {
+			gv.Group = group
+			ownerReferences[i].APIVersion = gv.String()
+		}
+	}
+	metadata.SetOwnerReferences(ownerReferences)
+	return nil
+}
+
+// prepareSubstitutions applies the substitutions from the restore spec to the
+// data of ConfigMaps, and of Secrets if requested. The values in the data of
+// Secrets are base64 encoded, so they are decoded before being substituted.
+func (a *ApplicationRestoreController) prepareSubstitutions(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	content := object.UnstructuredContent()
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "ConfigMap":
+		return substituteFields(restore, content, false, "data")
+	case "Secret":
+		if !restore.Spec.SubstituteSecrets {
+			return nil
+		}
+		if err := substituteFields(restore, content, true, "data"); err != nil {
+			return err
+		}
+		return substituteFields(restore, content, false, "stringData")
+	}
+	return nil
+}
+
+// substituteFields applies the substitutions to each of the values in the map
+// at the given path
+func substituteFields(
+	restore *storkapi.ApplicationRestore,
+	content map[string]interface{},
+	encoded bool,
+	fields ...string,
+) error {
+	data, found, err := unstructured.NestedStringMap(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	updated := false
+	for key, value := range data {
+		if encoded {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return fmt.Errorf("error decoding %v.%v: %v", strings.Join(fields, "."), key, err)
+			}
+			value = string(decoded)
+		}
+		substituted, ok := substitute(restore, value)
+		if !ok {
+			continue
+		}
+		if encoded {
+			substituted = base64.StdEncoding.EncodeToString([]byte(substituted))
+		}
+		data[key] = substituted
+		updated = true
+	}
+	if !updated {
+		return nil
+	}
+	return unstructured.SetNestedStringMap(content, data, fields...)
+}
+
+// substitute executes the value as a template with the substitutions. Returns
+// false if the value doesn't need to be substituted, or if it isn't a valid
+// template or uses substitutions that aren't set, since the value could be a
+// template for another application.
+func substitute(restore *storkapi.ApplicationRestore, value string) (string, bool) {
+	if !strings.Contains(value, "{{") {
+		return "", false
+	}
+	tmpl, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		log.ApplicationRestoreLog(restore).Debugf("Skipping substitution for value that isn't a valid template: %v", err)
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, restore.Spec.Substitutions); err != nil {
+		log.ApplicationRestoreLog(restore).Debugf("Skipping substitution for value: %v", err)
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// prepareRestoredByLabel adds the label with the name of the restore and the
+// annotation with the time of the restore to an object. The label is only
+// added if the name of the restore is a valid label value.
+func (a *ApplicationRestoreController) prepareRestoredByLabel(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	restoreTime string,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	if len(validation.IsValidLabelValue(restore.Name)) == 0 {
+		labels := metadata.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[StorkRestoredByLabel] = restore.Name
+		metadata.SetLabels(labels)
+	}
+	annotations := metadata.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[StorkRestoreTimestampAnnotation] = restoreTime
+	metadata.SetAnnotations(annotations)
+	return nil
+}
+
+// prepareAnnotations removes the annotations from the restore spec from an
+// object, except for the ones that should be kept. This keeps controllers on
+// the destination from acting on the restored objects, for example by
+// creating DNS records or requesting certificates.
+func (a *ApplicationRestoreController) prepareAnnotations(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	annotations := metadata.GetAnnotations()
+	if len(annotations) == 0 {
+		return nil
+	}
+	for annotation := range annotations {
+		// Always kept so that restored objects can be correlated with the
+		// objects in the source
+		if annotation == resourcecollector.OriginalCreationTimestampAnnotation {
+			continue
+		}
+		if matchesKeyPattern(annotation, restore.Spec.StripAnnotations) &&
+			!matchesKeyPattern(annotation, restore.Spec.KeepAnnotations) {
+			delete(annotations, annotation)
+		}
+	}
+	metadata.SetAnnotations(annotations)
+	return nil
+}
+
+// prepareLabels removes the labels from the restore spec from an object, so
+// that controllers on the destination that watch those labels don't adopt
+// the restored objects
+func (a *ApplicationRestoreController) prepareLabels(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	objectLabels := metadata.GetLabels()
+	if len(objectLabels) == 0 {
+		return nil
+	}
+	for label := range objectLabels {
+		if matchesKeyPattern(label, restore.Spec.StripLabels) {
+			delete(objectLabels, label)
+		}
+	}
+	metadata.SetLabels(objectLabels)
+	return nil
+}
+
+// prepareFinalizers removes the finalizers from the restore spec from an
+// object. Finalizers whose controllers aren't running on the destination
+// would never be removed, leaving the object stuck when it is deleted.
+func (a *ApplicationRestoreController) prepareFinalizers(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	finalizers := metadata.GetFinalizers()
+	if len(finalizers) == 0 {
+		return nil
+	}
+	updated := make([]string, 0, len(finalizers))
+	for _, finalizer := range finalizers {
+		if !matchesKeyPattern(finalizer, restore.Spec.StripFinalizers) {
+			updated = append(updated, finalizer)
+		}
+	}
+	metadata.SetFinalizers(updated)
+	return nil
+}
+
+// prepareCNIAnnotations removes the annotations and node affinity set for the
+// CNI on the source cluster from Pods and the pod templates of workloads so
+// that they can be scheduled with the CNI on the destination cluster
+func (a *ApplicationRestoreController) prepareCNIAnnotations(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	templateFields := getPodTemplateFields(kind)
+	if kind == "Pod" {
+		templateFields = []string{}
+	} else if templateFields == nil {
+		return nil
+	}
+
+	content := object.UnstructuredContent()
+	template := content
+	if len(templateFields) != 0 {
+		var found bool
+		var err error
+		template, found, err = unstructured.NestedMap(content, templateFields...)
+		if err != nil || !found {
+			return err
+		}
+	}
+
+	annotations, _, err := unstructured.NestedStringMap(template, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	for annotation := range annotations {
+		if matchesKeyPattern(annotation, restore.Spec.StripCNIAnnotations) {
+			unstructured.RemoveNestedField(template, "metadata", "annotations", annotation)
+		}
+	}
+
+	if len(restore.Spec.StripCNINodeAffinityKeys) != 0 {
+		nodeSelector, _, err := unstructured.NestedStringMap(template, "spec", "nodeSelector")
+		if err != nil {
+			return err
+		}
+		for key := range nodeSelector {
+			if matchesKeyPattern(key, restore.Spec.StripCNINodeAffinityKeys) {
+				unstructured.RemoveNestedField(template, "spec", "nodeSelector", key)
+			}
+		}
+		if err := stripNodeAffinityKeys(template, restore.Spec.StripCNINodeAffinityKeys); err != nil {
+			return err
+		}
+	}
+
+	if len(templateFields) == 0 {
+		object.SetUnstructuredContent(template)
+		return nil
+	}
+	return unstructured.SetNestedMap(content, template, templateFields...)
+}
+
+// stripNodeAffinityKeys removes the match expressions for the given node
+// label keys from the node affinity of a pod template. Required terms are
+// ORed, so if any of them doesn't have requirements left the pod can be
+// scheduled on any node and the required affinity is removed. Preferred terms
+// without requirements left are removed.
+func stripNodeAffinityKeys(template map[string]interface{}, keys []string) error {
+	affinityFields := []string{"spec", "affinity", "nodeAffinity"}
+	content, found, err := unstructured.NestedMap(template, affinityFields...)
+	if err != nil || !found {
+		return err
+	}
+	var nodeAffinity v1.NodeAffinity
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &nodeAffinity); err != nil {
+		return err
+	}
+
+	stripRequirements := func(requirements []v1.NodeSelectorRequirement) []v1.NodeSelectorRequirement {
+		updated := make([]v1.NodeSelectorRequirement, 0, len(requirements))
+		for _, requirement := range requirements {
+			if !matchesKeyPattern(requirement.Key, keys) {
+				updated = append(updated, requirement)
+			}
+		}
+		return updated
+	}
+	if required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+		for i := range required.NodeSelectorTerms {
+			term := &required.NodeSelectorTerms[i]
+			term.MatchExpressions = stripRequirements(term.MatchExpressions)
+			if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+				nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+				break
+			}
+		}
+	}
+	preferred := make([]v1.PreferredSchedulingTerm, 0, len(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution))
+	for _, term := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		term.Preference.MatchExpressions = stripRequirements(term.Preference.MatchExpressions)
+		if len(term.Preference.MatchExpressions) != 0 || len(term.Preference.MatchFields) != 0 {
+			preferred = append(preferred, term)
+		}
+	}
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = preferred
+
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil && len(preferred) == 0 {
+		unstructured.RemoveNestedField(template, affinityFields...)
+		return nil
+	}
+	content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(&nodeAffinity)
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedMap(template, content, affinityFields...)
+}
+
+// matchesKeyPattern checks if the key matches any of the patterns. Patterns
+// ending with * match all keys with that prefix.
+func matchesKeyPattern(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if key == pattern ||
+			(strings.HasSuffix(pattern, "*") && strings.HasPrefix(key, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// getPodSpec returns the spec for a Pod or the pod template of a workload.
+// Returns nil for other kinds.
+func getPodSpec(object runtime.Unstructured) (*v1.PodSpec, error) {
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	fields := getPodTemplateFields(kind)
+	if kind == "Pod" {
+		fields = []string{}
+	} else if fields == nil {
+		return nil, nil
+	}
+	content, found, err := unstructured.NestedMap(object.UnstructuredContent(), append(fields, "spec")...)
+	if err != nil || !found {
+		return nil, err
+	}
+	var spec v1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// prepareStorageClasses checks that the storage classes used by PVCs and the
+// volume claim templates of StatefulSets exist on the destination. If
+// requested, the ones that don't exist are replaced with the default storage
+// class of the destination. A warning is reported for each missing class.
+// PVCs that are bound to a PV by name are skipped, since they only bind if
+// their class matches the class of the PV, which doesn't need to exist.
+func (a *ApplicationRestoreController) prepareStorageClasses(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	objects []runtime.Unstructured,
+) error {
+	var storageClasses *storagev1.StorageClassList
+	defaultClass := ""
+	missingClasses := make(map[string]bool)
+	// Storage classes from the backup are applied before the PVCs
+	restoredClasses := make(map[string]bool)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind == "StorageClass" {
+			if metadata, err := meta.Accessor(o); err == nil {
+				restoredClasses[metadata.GetName()] = true
+			}
+		}
+	}
+	for _, o := range objects {
+		var claims []map[string]interface{}
+		content := o.UnstructuredContent()
+		switch o.GetObjectKind().GroupVersionKind().Kind {
+		case "PersistentVolumeClaim":
+			volumeName, _, err := unstructured.NestedString(content, "spec", "volumeName")
+			if err != nil {
+				return err
+			}
+			if volumeName != "" {
+				continue
+			}
+			claims = append(claims, content)
+		case "StatefulSet":
+			// The templates aren't copied so that they can be updated in
+			// place
+			templates, _, err := unstructured.NestedFieldNoCopy(content, "spec", "volumeClaimTemplates")
+			if err != nil {
+				return err
+			}
+			templateList, _ := templates.([]interface{})
+			for _, template := range templateList {
+				if claim, ok := template.(map[string]interface{}); ok {
+					claims = append(claims, claim)
+				}
+			}
+		default:
+			continue
+		}
+
+		for _, claim := range claims {
+			className, found, err := unstructured.NestedString(claim, "metadata", "annotations", v1.BetaStorageClassAnnotation)
+			if err != nil {
+				return err
+			}
+			classFields := []string{"metadata", "annotations", v1.BetaStorageClassAnnotation}
+			if !found {
+				className, _, err = unstructured.NestedString(claim, "spec", "storageClassName")
+				if err != nil {
+					return err
+				}
+				classFields = []string{"spec", "storageClassName"}
+			}
+			// PVCs without a class use the default one anyway
+			if className == "" || restoredClasses[className] {
+				continue
+			}
+
+			if storageClasses == nil {
+				storageClasses = &storagev1.StorageClassList{}
+				if err := target.client.List(context.TODO(), storageClasses); err != nil {
+					return fmt.Errorf("error listing storage classes: %v", err)
+				}
+				for _, storageClass := range storageClasses.Items {
+					if storageClass.Annotations[defaultStorageClassAnnotation] == "true" ||
+						storageClass.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+						defaultClass = storageClass.Name
+					}
+				}
+			}
+			exists := false
+			for _, storageClass := range storageClasses.Items {
+				if storageClass.Name == className {
+					exists = true
+					break
+				}
+			}
+			if exists {
+				continue
+			}
+			missingClasses[className] = true
+			if !restore.Spec.UseDefaultStorageClassOnMissing {
+				continue
+			}
+			if defaultClass == "" {
+				return fmt.Errorf("storage class %v doesn't exist and there is no default storage class", className)
+			}
+			if err := unstructured.SetNestedField(claim, defaultClass, classFields...); err != nil {
+				return err
+			}
+		}
+	}
+
+	for className := range missingClasses {
+		message := fmt.Sprintf("Storage class %v doesn't exist on the destination", className)
+		if restore.Spec.UseDefaultStorageClassOnMissing {
+			message = fmt.Sprintf("%v, using default storage class %v instead", message, defaultClass)
+		}
+		log.ApplicationRestoreLog(restore).Warnf(message)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			message)
+	}
+	return nil
+}
+
+// checkPodSecurity reports the pods being restored that would be rejected by
+// the Pod Security level enforced on their namespace. If RelaxPodSecurity is
+// set the level is changed to privileged for those namespaces.
+func (a *ApplicationRestoreController) checkPodSecurity(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	objects []runtime.Unstructured,
+) error {
+	levels := make(map[string]string)
+	violatingNamespaces := make([]string, 0)
+	for _, o := range objects {
+		spec, err := getPodSpec(o)
+		if err != nil || spec == nil {
+			continue
+		}
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
+		namespace := metadata.GetNamespace()
+		level, ok := levels[namespace]
+		if !ok {
+			ns, err := target.coreOps.GetNamespace(namespace)
+			if err != nil {
+				return err
+			}
+			// Check against the original level if it was already relaxed
+			level = getOriginalPodSecurityLevel(ns)
+			levels[namespace] = level
+		}
+
+		violations := k8sutils.GetPodSecurityViolations(level, spec)
+		if len(violations) == 0 {
+			continue
+		}
+		message := fmt.Sprintf("Pods for %v %v/%v violate the %v Pod Security level of the namespace: %v",
+			o.GetObjectKind().GroupVersionKind().Kind, namespace, metadata.GetName(), level, strings.Join(violations, "; "))
+		log.ApplicationRestoreLog(restore).Warnf(message)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			message)
+		if !slice.ContainsString(violatingNamespaces, namespace, nil) {
+			violatingNamespaces = append(violatingNamespaces, namespace)
+		}
+	}
+	if !restore.Spec.RelaxPodSecurity {
+		return nil
+	}
+
+	for _, namespace := range violatingNamespaces {
+		if slice.ContainsString(restore.Status.RelaxedNamespaces, namespace, nil) {
+			continue
+		}
+		ns, err := target.coreOps.GetNamespace(namespace)
+		if err != nil {
+			return err
+		}
+		relaxNamespacePodSecurity(restore, ns)
+		if _, err := target.coreOps.UpdateNamespace(ns); err != nil {
+			return fmt.Errorf("error relaxing Pod Security level for namespace %v: %v", namespace, err)
+		}
+		restore.Status.RelaxedNamespaces = append(restore.Status.RelaxedNamespaces, namespace)
+		message := fmt.Sprintf("Relaxed Pod Security level for namespace %v to %v", namespace, k8sutils.PodSecurityLevelPrivileged)
+		log.ApplicationRestoreLog(restore).Infof(message)
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			message)
+	}
+	return nil
+}
+
+// restorePodSecurity sets the Pod Security level back for the namespaces that
+// were relaxed by the restore
+func (a *ApplicationRestoreController) restorePodSecurity(restore *storkapi.ApplicationRestore) error {
+	if len(restore.Status.RelaxedNamespaces) == 0 {
+		return nil
+	}
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	for len(restore.Status.RelaxedNamespaces) != 0 {
+		namespace := restore.Status.RelaxedNamespaces[0]
+		ns, err := target.coreOps.GetNamespace(namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil && unrelaxNamespacePodSecurity(restore, ns) {
+			if _, err := target.coreOps.UpdateNamespace(ns); err != nil {
+				return err
+			}
+			log.ApplicationRestoreLog(restore).Infof("Set Pod Security level for namespace %v back to %v",
+				namespace, ns.Labels[k8sutils.PodSecurityEnforceLabel])
+		}
+		restore.Status.RelaxedNamespaces = restore.Status.RelaxedNamespaces[1:]
+	}
+	return nil
+}
+
+// getPodSecurityAnnotation returns the annotation that the original Pod
+// Security level of a namespace is recorded in by a restore. Each restore uses
+// its own annotation so that restores into the same namespace don't set the
+// level back while the others are still running.
+func getPodSecurityAnnotation(restore *storkapi.ApplicationRestore) string {
+	return podSecurityEnforceAnnotation + "-" + string(restore.UID)
+}
+
+// getOriginalPodSecurityLevel returns the Pod Security level enforced on the
+// namespace before it was relaxed by any restore
+func getOriginalPodSecurityLevel(ns *v1.Namespace) string {
+	for key, value := range ns.Annotations {
+		if strings.HasPrefix(key, podSecurityEnforceAnnotation) {
+			return value
+		}
+	}
+	return ns.Labels[k8sutils.PodSecurityEnforceLabel]
+}
+
+// relaxNamespacePodSecurity records the original Pod Security level of the
+// namespace for the restore and sets it to privileged
+func relaxNamespacePodSecurity(restore *storkapi.ApplicationRestore, ns *v1.Namespace) {
+	original := getOriginalPodSecurityLevel(ns)
+	if ns.Annotations == nil {
+		ns.Annotations = make(map[string]string)
+	}
+	if ns.Labels == nil {
+		ns.Labels = make(map[string]string)
+	}
+	ns.Annotations[getPodSecurityAnnotation(restore)] = original
+	ns.Labels[k8sutils.PodSecurityEnforceLabel] = k8sutils.PodSecurityLevelPrivileged
+}
+
+// unrelaxNamespacePodSecurity removes the original Pod Security level recorded
+// for the restore and sets the level back if no other restore has relaxed it.
+// Returns true if the namespace was changed.
+func unrelaxNamespacePodSecurity(restore *storkapi.ApplicationRestore, ns *v1.Namespace) bool {
+	annotation := getPodSecurityAnnotation(restore)
+	original, ok := ns.Annotations[annotation]
+	if !ok {
+		return false
+	}
+	delete(ns.Annotations, annotation)
+	for key := range ns.Annotations {
+		if strings.HasPrefix(key, podSecurityEnforceAnnotation) {
+			return true
+		}
+	}
+	if original == "" {
+		delete(ns.Labels, k8sutils.PodSecurityEnforceLabel)
+	} else {
+		if ns.Labels == nil {
+			ns.Labels = make(map[string]string)
+		}
+		ns.Labels[k8sutils.PodSecurityEnforceLabel] = original
+	}
+	return true
+}
+
+// getPodTemplateFields returns the path to the pod template for workloads.
+// Returns nil for other kinds.
+func getPodTemplateFields(kind string) []string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "DeploymentConfig", "Job":
+		return []string{"spec", "template"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	}
+	return nil
+}
+
+// getPodTemplateReferences returns the ConfigMaps and Secrets referenced by
+// the pod template of a workload, keyed by kind/namespace/name
+func getPodTemplateReferences(object runtime.Unstructured) (map[string]bool, error) {
+	references := make(map[string]bool)
+	templateFields := getPodTemplateFields(object.GetObjectKind().GroupVersionKind().Kind)
+	if templateFields == nil {
+		return references, nil
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	templateContent, found, err := unstructured.NestedMap(object.UnstructuredContent(), templateFields...)
+	if err != nil || !found {
+		return references, err
+	}
+	var template v1.PodTemplateSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(templateContent, &template); err != nil {
+		return nil, err
+	}
+
+	addReference := func(kind string, name string) {
+		if name != "" {
+			references[fmt.Sprintf("%v/%v/%v", kind, metadata.GetNamespace(), name)] = true
+		}
+	}
+	for _, secret := range template.Spec.ImagePullSecrets {
+		addReference("Secret", secret.Name)
+	}
+	for _, podVolume := range template.Spec.Volumes {
+		if podVolume.ConfigMap != nil {
+			addReference("ConfigMap", podVolume.ConfigMap.Name)
+		}
+		if podVolume.Secret != nil {
+			addReference("Secret", podVolume.Secret.SecretName)
+		}
+		if podVolume.Projected != nil {
+			for _, source := range podVolume.Projected.Sources {
+				if source.ConfigMap != nil {
+					addReference("ConfigMap", source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					addReference("Secret", source.Secret.Name)
+				}
+			}
+		}
+	}
+	containers := append(template.Spec.InitContainers, template.Spec.Containers...)
+	for _, container := range containers {
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				addReference("ConfigMap", envFrom.ConfigMapRef.Name)
+			}
+			if envFrom.SecretRef != nil {
+				addReference("Secret", envFrom.SecretRef.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				addReference("ConfigMap", env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				addReference("Secret", env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	return references, nil
+}
+
+// removeNamedItems removes items with the given names from a list of objects
+// in the content
+func removeNamedItems(content map[string]interface{}, names []string, fields ...string) error {
+	items, found, err := unstructured.NestedSlice(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	filtered := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if itemMap, ok := item.(map[string]interface{}); ok {
+			if name, ok := itemMap["name"].(string); ok && slice.ContainsString(names, name, nil) {
+				continue
+			}
+		}
+		filtered = append(filtered, item)
+	}
+	return unstructured.SetNestedSlice(content, filtered, fields...)
+}
+
+// orderObjectsForApply moves ConfigMaps and Secrets referenced by the pod
+// templates of workloads before all other objects so that the pods don't fail
+// to start while waiting for them. HorizontalPodAutoscalers are moved after
+// all other objects so that the workloads they scale exist when they are
+// applied. The scale target is referenced by name in the same namespace, so it
+// doesn't need to be updated when the namespace is mapped.
+// PodDisruptionBudgets are applied after that so that the workloads they
+// protect exist when checking if their pods are ready. ResourceQuotas and LimitRanges are
+// applied last so that they don't block the creation of the other objects
+// being restored to the namespace. Objects are then sorted by the restore
+// order annotation, if it is set. The annotation only orders the budgets and
+// quotas among themselves so that they are still applied after the workloads.
+func orderObjectsForApply(objects []runtime.Unstructured) []runtime.Unstructured {
+	// Workloads whose pod template can't be parsed are left to fail when
+	// they are applied
+	references := make(map[string]bool)
+	for _, o := range objects {
+		workloadReferences, err := getPodTemplateReferences(o)
+		if err != nil {
+			continue
+		}
+		for reference := range workloadReferences {
+			references[reference] = true
+		}
+	}
+
+	dependencies := make([]runtime.Unstructured, 0)
+	ordered := make([]runtime.Unstructured, 0, len(objects))
+	autoscalers := make([]runtime.Unstructured, 0)
+	disruptionBudgets := make([]runtime.Unstructured, 0)
+	quotas := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		kind := o.GetObjectKind().GroupVersionKind().Kind
+		switch kind {
+		case "ConfigMap", "Secret":
+			metadata, err := meta.Accessor(o)
+			if err == nil && references[fmt.Sprintf("%v/%v/%v", kind, metadata.GetNamespace(), metadata.GetName())] {
+				dependencies = append(dependencies, o)
+			} else {
+				ordered = append(ordered, o)
+			}
+		case "HorizontalPodAutoscaler":
+			autoscalers = append(autoscalers, o)
+		case "PodDisruptionBudget":
+			disruptionBudgets = append(disruptionBudgets, o)
+		case "ResourceQuota", "LimitRange":
+			quotas = append(quotas, o)
+		default:
+			ordered = append(ordered, o)
+		}
+	}
+	ordered = append(dependencies, ordered...)
+	ordered = append(ordered, autoscalers...)
+
+	// The order set by users takes precedence, the order above is kept for
+	// objects with the same value
+	sortByRestoreOrder(ordered)
+	sortByRestoreOrder(disruptionBudgets)
+	sortByRestoreOrder(quotas)
+	ordered = append(ordered, disruptionBudgets...)
+	return append(ordered, quotas...)
+}
+
+func sortByRestoreOrder(objects []runtime.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return getRestoreOrder(objects[i]) < getRestoreOrder(objects[j])
+	})
+}
+
+// splitApplyStages splits the ordered objects into stages of consecutive
+// objects with the same restore order. Objects in a stage are applied in
+// parallel across namespaces, so each stage has to be applied before the
+// next one to keep the order across namespaces.
+func splitApplyStages(objects []runtime.Unstructured) [][]runtime.Unstructured {
+	stages := make([][]runtime.Unstructured, 0)
+	for i, o := range objects {
+		if i == 0 || getRestoreOrder(o) != getRestoreOrder(objects[i-1]) {
+			stages = append(stages, make([]runtime.Unstructured, 0))
+		}
+		stages[len(stages)-1] = append(stages[len(stages)-1], o)
+	}
+	return stages
+}
+
+// getRestoreOrder returns the value of the restore order annotation for an
+// object. Returns 0 if it isn't set or isn't a valid integer.
+func getRestoreOrder(object runtime.Unstructured) int {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return 0
+	}
+	value, ok := metadata.GetAnnotations()[StorkRestoreOrderAnnotation]
+	if !ok {
+		return 0
+	}
+	order, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return order
+}
+
+// disruptionBudgetTargetsReady checks if the pods selected by a
+// PodDisruptionBudget are ready enough to satisfy it. Applying the budget
+// before that would block node drains until the workloads come up.
+func disruptionBudgetTargetsReady(
+	target *restoreTarget,
+	object runtime.Unstructured,
+) (bool, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	content := object.UnstructuredContent()
+	selectorContent, found, err := unstructured.NestedMap(content, "spec", "selector")
+	if err != nil || !found {
+		return true, err
+	}
+	var labelSelector metav1.LabelSelector
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorContent, &labelSelector); err != nil {
+		return false, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return false, err
+	}
+	minAvailable, minAvailableFound, err := getNestedIntOrString(content, "spec", "minAvailable")
+	if err != nil {
+		return false, err
+	}
+	maxUnavailable, maxUnavailableFound, err := getNestedIntOrString(content, "spec", "maxUnavailable")
+	if err != nil {
+		return false, err
+	}
+
+	pods, err := target.coreOps.GetPods(metadata.GetNamespace(), nil)
+	if err != nil {
+		return false, err
+	}
+	total := 0
+	ready := 0
+	for _, pod := range pods.Items {
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		total++
+		if target.coreOps.IsPodReady(pod) {
+			ready++
+		}
+	}
+	if total == 0 {
+		return false, nil
+	}
+	required := total
+	if minAvailableFound {
+		if required, err = intstr.GetScaledValueFromIntOrPercent(&minAvailable, total, true); err != nil {
+			return false, err
+		}
+	} else if maxUnavailableFound {
+		unavailable, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, total, true)
+		if err != nil {
+			return false, err
+		}
+		required = total - unavailable
+	}
+	return ready >= required, nil
+}
+
+// deferDisruptionBudget records a PodDisruptionBudget in the status of the
+// restore so that it is applied by a later pass once its pods are ready
+func (a *ApplicationRestoreController) deferDisruptionBudget(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+	existed bool,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(object.UnstructuredContent())
+	if err != nil {
+		return err
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	log.ApplicationRestoreLog(restore).Infof("Pods for PodDisruptionBudget %v/%v aren't ready, applying it once they are",
+		metadata.GetNamespace(), metadata.GetName())
+	a.resourceStatusLock.Lock()
+	defer a.resourceStatusLock.Unlock()
+	restore.Status.PendingDisruptionBudgets = append(restore.Status.PendingDisruptionBudgets,
+		storkapi.ApplicationRestorePendingDisruptionBudget{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:      metadata.GetName(),
+				Namespace: metadata.GetNamespace(),
+				GroupVersionKind: metav1.GroupVersionKind{
+					Group:   gvk.Group,
+					Version: gvk.Version,
+					Kind:    gvk.Kind,
+				},
+			},
+			Object:            runtime.RawExtension{Raw: data},
+			DeferredTimestamp: metav1.Now(),
+			Existed:           existed,
+		})
+	return nil
+}
+
+// applyPendingDisruptionBudgets applies the PodDisruptionBudgets that were
+// deferred once the pods that they select are ready, or once the pods haven't
+// been ready for a while. Returns true if any of them were applied.
+func (a *ApplicationRestoreController) applyPendingDisruptionBudgets(
+	restore *storkapi.ApplicationRestore,
+) (bool, error) {
+	if len(restore.Status.PendingDisruptionBudgets) == 0 {
+		return false, nil
+	}
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return false, err
+	}
+	pending := make([]storkapi.ApplicationRestorePendingDisruptionBudget, 0)
+	for _, budget := range restore.Status.PendingDisruptionBudgets {
+		object := &unstructured.Unstructured{}
+		if err := object.UnmarshalJSON(budget.Object.Raw); err != nil {
+			return false, err
+		}
+		ready, err := disruptionBudgetTargetsReady(target, object)
+		if err != nil {
+			return false, err
+		}
+		if !ready && time.Since(budget.DeferredTimestamp.Time) < pdbTargetsTimeout {
+			pending = append(pending, budget)
+			continue
+		}
+		if !ready {
+			log.ApplicationRestoreLog(restore).Warnf("Pods for PodDisruptionBudget %v/%v aren't ready, applying it anyway",
+				budget.Namespace, budget.Name)
+		}
+		if err := a.applyObject(restore, target, object, budget.Existed); err != nil {
+			return false, err
+		}
+	}
+	applied := len(pending) != len(restore.Status.PendingDisruptionBudgets)
+	restore.Status.PendingDisruptionBudgets = pending
+	return applied, nil
+}
+
+func getNestedIntOrString(content map[string]interface{}, fields ...string) (intstr.IntOrString, bool, error) {
+	value, found, err := unstructured.NestedFieldNoCopy(content, fields...)
+	if err != nil || !found {
+		return intstr.IntOrString{}, false, err
+	}
+	switch v := value.(type) {
+	case int64:
+		return intstr.FromInt(int(v)), true, nil
+	case float64:
+		return intstr.FromInt(int(v)), true, nil
+	case string:
+		return intstr.Parse(v), true, nil
+	}
+	return intstr.IntOrString{}, false, fmt.Errorf("invalid value for %v: %v", strings.Join(fields, "."), value)
+}
+
+// getSelectorLabelValue returns the value of the selector label for a
+// restore. Restore names can be longer than label values, so longer names are
+// truncated and a hash of the name is added, the same way as sanitized names.
+func getSelectorLabelValue(restore *storkapi.ApplicationRestore) string {
+	if len(restore.Name) <= validation.LabelValueMaxLength {
+		return restore.Name
+	}
+	hash := sha256.Sum256([]byte(restore.Name))
+	truncated := strings.TrimRight(restore.Name[:validation.LabelValueMaxLength-sanitizedNameHashLength-1], "-.")
+	return truncated + "-" + hex.EncodeToString(hash[:])[:sanitizedNameHashLength]
+}
+
+// prepareSelectorLabel adds the selector label from the restore to the
+// selectors of workloads and services, and to the pod templates of workloads,
+// so that they only select pods that were restored
+func (a *ApplicationRestoreController) prepareSelectorLabel(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	content := object.UnstructuredContent()
+	value := getSelectorLabelValue(restore)
+	var selectorFields []string
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
+		selectorFields = []string{"spec", "selector", "matchLabels"}
+	case "DeploymentConfig":
+		selectorFields = []string{"spec", "selector"}
+	case "Service":
+		// Services without selectors have their endpoints managed
+		// separately
+		selector, found, err := unstructured.NestedStringMap(content, "spec", "selector")
+		if err != nil || !found || len(selector) == 0 {
+			return err
+		}
+		selector[restore.Spec.SelectorLabelKey] = value
+		return unstructured.SetNestedStringMap(content, selector, "spec", "selector")
+	default:
+		return nil
+	}
+
+	for _, fields := range [][]string{selectorFields, {"spec", "template", "metadata", "labels"}} {
+		labels, _, err := unstructured.NestedStringMap(content, fields...)
+		if err != nil {
+			return err
+		}
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[restore.Spec.SelectorLabelKey] = value
+		if err := unstructured.SetNestedStringMap(content, labels, fields...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resourceSelected checks if the object matches any of the selectors. All
+// objects are selected if there aren't any selectors.
+func resourceSelected(
+	selectors []storkapi.ApplicationRestoreResourceSelector,
+	object runtime.Unstructured,
+) (bool, error) {
+	if len(selectors) == 0 {
+		return true, nil
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	for _, selector := range selectors {
+		if selector.Kind != "" && selector.Kind != kind {
+			continue
+		}
+		if selector.LabelSelector != nil {
+			labelSelector, err := metav1.LabelSelectorAsSelector(selector.LabelSelector)
+			if err != nil {
+				return false, fmt.Errorf("invalid label selector for resources: %v", err)
+			}
+			if !labelSelector.Matches(labels.Set(metadata.GetLabels())) {
+				continue
+			}
+		}
+		if fieldsMatch(selector.FieldMatchers, object) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fieldsMatch checks if the fields of the object match all the matchers
+func fieldsMatch(
+	matchers []storkapi.ApplicationRestoreFieldMatcher,
+	object runtime.Unstructured,
+) bool {
+	for _, matcher := range matchers {
+		value, found, err := unstructured.NestedFieldNoCopy(object.UnstructuredContent(), strings.Split(matcher.Path, ".")...)
+		if err != nil || !found {
+			return false
+		}
+		if len(matcher.Values) == 0 {
+			continue
+		}
+		// Lists and maps can't be matched with a value
+		switch value.(type) {
+		case []interface{}, map[string]interface{}:
+			return false
+		}
+		if !slice.ContainsString(matcher.Values, fmt.Sprintf("%v", value), nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// prepareResources prepares the objects from the backup to be applied to the
+// destination. Returns the objects that should be applied. If checksums isn't
+// nil, the checksums of the namespaced objects from the backup are added to it
+// keyed by the group, namespace and name that they are restored with.
+func (a *ApplicationRestoreController) prepareResources(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+	checksums map[string]string,
+) ([]runtime.Unstructured, error) {
+	pvNameMappings, err := a.getPVNameMappings(restore, objects)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects that collide are only renamed with the Prefix policy, the
+	// restore fails in the Initial stage otherwise
+	collisions := make(map[string][]string)
+	if restore.Spec.NamespaceCollisionPolicy == storkapi.ApplicationRestoreNamespaceCollisionPolicyPrefix &&
+		hasMergedNamespaces(restore.Spec.NamespaceMapping) {
+		if collisions, err = getNamespaceCollisions(restore, objects); err != nil {
+			return nil, err
+		}
+	}
+
+	objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
+	restoreTime := time.Now().UTC().Format(time.RFC3339)
+	restoredNames := newRestoredObjectNames()
+	tempObjects := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		// Selectors are matched against the object from the backup, before
+		// its namespace or labels are updated
+		selected, err := resourceSelected(restore.Spec.ResourceSelectors, o)
+		if err != nil {
+			return nil, err
+		}
+		if !selected {
+			continue
+		}
+		// Jobs that had finished would run again if they were restored
+		if !restore.Spec.RestoreCompletedJobs {
+			finished, err := isFinishedJob(o)
+			if err != nil {
+				return nil, err
+			}
+			if finished {
+				if err := a.updateSkippedJobStatus(restore, o); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		// Skip objects that haven't been modified if requested. Needs to be
+		// checked before the object is prepared since that removes the
+		// modification time
+		if !restore.Spec.ChangedSince.IsZero() {
+			modified, err := resourcecollector.ModifiedSince(o, restore.Spec.ChangedSince)
+			if err != nil {
+				return nil, err
+			}
+			if !modified {
+				continue
+			}
+		}
+		// The collision key depends on the source namespace, so it needs
+		// to be found before the namespace is mapped
+		collisionKey, err := getNamespaceCollisionKey(restore, o)
+		if err != nil {
+			return nil, err
+		}
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		sourceNamespace := metadata.GetNamespace()
+		sourceName := metadata.GetName()
+		// The checksum is from the object in the backup, before it is
+		// prepared for the destination
+		checksum := ""
+		if _, ok := restore.Spec.NamespaceMapping[sourceNamespace]; ok && checksums != nil {
+			if checksum, err = getObjectChecksum(o); err != nil {
+				return nil, err
+			}
+		}
+		skip, err := a.resourceCollector.PrepareResourceForApply(
+			o,
+			objects,
+			objectMap,
+			restore.Spec.NamespaceMapping,
+			pvNameMappings,
+			restore.Spec.IncludeOptionalResourceTypes)
+		if err != nil {
+			return nil, err
+		}
+		if !skip {
+			if len(restore.Spec.GroupMapping) != 0 {
+				if err := a.prepareGroupMapping(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if _, ok := collisions[collisionKey]; ok {
+				if err := prefixCollidingObject(o, sourceNamespace); err != nil {
+					return nil, err
+				}
+			}
+			if restore.Spec.SanitizeNames {
+				if err := a.prepareSanitizedName(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if restore.Spec.StartWorkloadsPaused {
+				if err := a.prepareWorkloadResource(o); err != nil {
+					return nil, err
+				}
+			} else if restore.Spec.SuspendCronJobs {
+				if err := suspendCronJob(o); err != nil {
+					return nil, err
+				}
+			}
+			if restore.Spec.SelectorLabelKey != "" {
+				if err := a.prepareSelectorLabel(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.StripMeshSidecars) != 0 {
+				if err := a.prepareMeshSidecars(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.ImagePullSecretMapping) != 0 {
+				if err := a.prepareImagePullSecrets(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.PriorityClassMapping) != 0 {
+				if err := a.preparePriorityClass(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if err := a.prepareServiceAnnotations(restore, o); err != nil {
+				return nil, err
+			}
+			if err := a.prepareIngress(restore, o); err != nil {
+				return nil, err
+			}
+			if len(restore.Spec.Substitutions) != 0 {
+				if err := a.prepareSubstitutions(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if restore.Spec.RelaxWebhookFailurePolicy {
+				if err := a.prepareWebhookFailurePolicy(o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.StripAnnotations) != 0 {
+				if err := a.prepareAnnotations(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.StripLabels) != 0 {
+				if err := a.prepareLabels(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.StripFinalizers) != 0 {
+				if err := a.prepareFinalizers(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.VolumePlacement) != 0 && o.GetObjectKind().GroupVersionKind().Kind == "PersistentVolume" {
+				if err := a.preparePVPlacement(restore, o, sourceName); err != nil {
+					return nil, err
+				}
+			}
+			if len(restore.Spec.StripCNIAnnotations) != 0 || len(restore.Spec.StripCNINodeAffinityKeys) != 0 {
+				if err := a.prepareCNIAnnotations(restore, o); err != nil {
+					return nil, err
+				}
+			}
+			// Added after the annotations are stripped so that they are
+			// always kept
+			if restore.Spec.MarkRestoredResources {
+				if err := a.prepareRestoredByLabel(restore, o, restoreTime); err != nil {
+					return nil, err
+				}
+			}
+			// The existing PVCs and PVs for adopted volumes are kept as is
+			if adopted, err := isAdoptedVolumeObject(restore, o); err != nil {
+				return nil, err
+			} else if adopted {
+				if err := a.updateResourceStatus(
+					restore,
+					o,
+					storkapi.ApplicationRestoreStatusRetained,
+					"Resource restore skipped as the existing volume was adopted"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := restoredNames.add(o, sourceNamespace, sourceName); err != nil {
+				return nil, err
+			}
+			if checksum != "" {
+				gvk := o.GetObjectKind().GroupVersionKind()
+				checksums[getObjectKey(gvk.Group, gvk.Kind, metadata.GetNamespace(), metadata.GetName())] = checksum
+			}
+			tempObjects = append(tempObjects, o)
+		}
+	}
+	objects = tempObjects
+
+	// Convert objects from versions that aren't served by this cluster anymore
+	objects, unsupported, err := a.resourceCollector.ConvertResourcesToSupportedVersion(objects)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range unsupported {
+		if err := a.updateResourceStatus(
+			restore,
+			u.Object,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error converting resource: %v", u.Reason)); err != nil {
+			return nil, err
+		}
+	}
+
+	if restore.Spec.SanitizeNames {
+		if err := prepareSanitizedReferences(restore, objects); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.preparePVCDataSources(restore, objects, restoredNames); err != nil {
+		return nil, err
+	}
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.prepareStorageClasses(restore, target, objects); err != nil {
+		return nil, err
+	}
+	if len(restore.Spec.ApplyHooks) != 0 {
+		if err := a.runApplyHooks(restore, objects); err != nil {
+			return nil, err
+		}
+	}
+	return objects, nil
+}
+
+// runApplyHooks posts the objects to the apply hooks that match their type and
+// replaces them with the objects returned by the hooks. The hooks for an
+// object are run in order, and objects are posted in parallel.
+func (a *ApplicationRestoreController) runApplyHooks(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) error {
+	for _, hook := range restore.Spec.ApplyHooks {
+		if err := a.validateWebhookURL(restore, hook.URL); err != nil {
+			return fmt.Errorf("invalid apply hook %v: %v", hook.URL, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var lastError error
+	workers := make(chan struct{}, applyHookConcurrency)
+	for _, o := range objects {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(o runtime.Unstructured) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			if err := a.runApplyHooksForObject(restore, o); err != nil {
+				lock.Lock()
+				lastError = err
+				lock.Unlock()
+			}
+		}(o)
+	}
+	wg.Wait()
+	return lastError
+}
+
+// runApplyHooksForObject runs the apply hooks that match the object. The
+// response from a hook is reused if it was already called for the object by
+// the restore.
+func (a *ApplicationRestoreController) runApplyHooksForObject(
+	restore *storkapi.ApplicationRestore,
+	o runtime.Unstructured,
+) error {
+	metadata, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+	gvk := o.GetObjectKind().GroupVersionKind()
+	for i, hook := range restore.Spec.ApplyHooks {
+		if !applyHookMatches(hook, gvk) {
+			continue
+		}
+		key := fmt.Sprintf("%v/%v/%v/%v/%v", i, gvk.Group, gvk.Kind, metadata.GetNamespace(), metadata.GetName())
+		if result, ok := a.getApplyHookResult(restore, key); ok {
+			if result != nil {
+				o.SetUnstructuredContent(runtime.DeepCopyJSON(result))
+			}
+			continue
+		}
+		if err := a.runApplyHook(hook, o); err != nil {
+			err = fmt.Errorf("error running apply hook %v for %v %v/%v: %v",
+				hook.URL, gvk.Kind, metadata.GetNamespace(), metadata.GetName(), err)
+			if !hook.IgnoreFailure {
+				return err
+			}
+			log.ApplicationRestoreLog(restore).Warnf("%v, applying the resource without it", err)
+			a.setApplyHookResult(restore, key, nil)
+			continue
+		}
+		a.setApplyHookResult(restore, key, o.UnstructuredContent())
+	}
+	return nil
+}
+
+// getApplyHookResult returns the object returned by a hook for the restore.
+// The object is nil if the hook failed and its failure was ignored.
+func (a *ApplicationRestoreController) getApplyHookResult(
+	restore *storkapi.ApplicationRestore,
+	key string,
+) (map[string]interface{}, bool) {
+	a.applyHookLock.Lock()
+	defer a.applyHookLock.Unlock()
+	list, ok := a.applyHookResults[string(restore.UID)]
+	if !ok {
+		return nil, false
+	}
+	result, ok := list.results[key]
+	return result, ok
+}
+
+func (a *ApplicationRestoreController) setApplyHookResult(
+	restore *storkapi.ApplicationRestore,
+	key string,
+	result map[string]interface{},
+) {
+	a.applyHookLock.Lock()
+	defer a.applyHookLock.Unlock()
+	if a.applyHookResults == nil {
+		a.applyHookResults = make(map[string]*applyHookResultList)
+	}
+	list, ok := a.applyHookResults[string(restore.UID)]
+	if !ok {
+		// Remove stale entries from restores that have completed
+		for uid, list := range a.applyHookResults {
+			if time.Since(list.updateTime) > applyHookCacheTimeout {
+				delete(a.applyHookResults, uid)
+			}
+		}
+		list = &applyHookResultList{
+			results: make(map[string]map[string]interface{}),
+		}
+		a.applyHookResults[string(restore.UID)] = list
+	}
+	list.updateTime = time.Now()
+	if result != nil {
+		result = runtime.DeepCopyJSON(result)
+	}
+	list.results[key] = result
+}
+
+// applyHookMatches checks if the hook is for objects of the given type.
+// Secrets are only sent to hooks that explicitly allow them.
+func applyHookMatches(hook storkapi.ApplicationRestoreApplyHook, gvk schema.GroupVersionKind) bool {
+	if gvk.Group == "" && gvk.Kind == "Secret" && !hook.AllowSecrets {
+		return false
+	}
+	group := hook.Group
+	if group == "core" {
+		group = ""
+	}
+	return hook.Kind == gvk.Kind &&
+		(group == "" || group == gvk.Group) &&
+		(hook.Version == "" || hook.Version == gvk.Version)
+}
+
+// validateWebhookURL checks that a webhook called by the restore is for a
+// service in the namespace of the restore, so that restores can't be used to
+// send requests to arbitrary endpoints from the controller. Restores in the
+// admin namespace can call services in any namespace.
+func (a *ApplicationRestoreController) validateWebhookURL(
+	restore *storkapi.ApplicationRestore,
+	webhookURL string,
+) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := strings.TrimSuffix(u.Hostname(), ".cluster.local")
+	parts := strings.Split(host, ".")
+	if len(parts) != 3 || parts[2] != "svc" || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("host %q isn't a service in the cluster, expected <service>.<namespace>.svc", u.Hostname())
+	}
+	if parts[1] != restore.Namespace && restore.Namespace != a.restoreAdminNamespace {
+		return fmt.Errorf("service %v isn't in the namespace of the restore", host)
+	}
+	return nil
+}
+
+// runApplyHook posts the object to the hook and updates it with the object
+// from the response. The response can't change which object is restored.
+func (a *ApplicationRestoreController) runApplyHook(
+	hook storkapi.ApplicationRestoreApplyHook,
+	object runtime.Unstructured,
+) error {
+	data, err := json.Marshal(object.UnstructuredContent())
+	if err != nil {
+		return err
+	}
+	timeout := applyHookDefaultTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		// Redirects aren't followed since they could be to any endpoint
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Post(hook.URL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response %v", resp.Status)
+	}
+
+	updated := &unstructured.Unstructured{}
+	if err := json.NewDecoder(resp.Body).Decode(&updated.Object); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if updated.GroupVersionKind() != gvk ||
+		updated.GetName() != metadata.GetName() ||
+		updated.GetNamespace() != metadata.GetNamespace() {
+		return fmt.Errorf("response is for %v %v %v/%v instead",
+			updated.GetAPIVersion(), updated.GetKind(), updated.GetNamespace(), updated.GetName())
+	}
+	object.SetUnstructuredContent(updated.Object)
+	return nil
+}
+
+func (a *ApplicationRestoreController) applyResources(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+	checksums map[string]string,
+) error {
+	objects, err := a.prepareResources(restore, objects, checksums)
+	if err != nil {
+		return err
+	}
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	if err := a.checkPodSecurity(restore, target, objects); err != nil {
+		return err
+	}
+	pendingOwners, err := a.prepareOwnerReferences(restore, target, objects)
+	if err != nil {
+		return err
+	}
+	// Objects that are replaced are deleted before they are applied, so
+	// the objects that existed have to be found first
+	existing, err := a.getExistingResources(restore, target, objects)
+	if err != nil {
+		return err
+	}
+	// First delete the existing objects if they exist and replace policy is set
+	// to Delete. Referenced cluster scoped resources could be used by other
+	// namespaces and existing APIServices serve the whole cluster, so they
+	// are never deleted.
+	if restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
+		deleteObjects := make([]runtime.Unstructured, 0)
+		for _, o := range objects {
+			if !resourcecollector.IsReferencedResource(o) && !resourcecollector.IsAPIService(o) {
+				deleteObjects = append(deleteObjects, o)
+			}
+		}
+		err = a.resourceCollector.DeleteResources(
+			target.dynamicInterface,
+			deleteObjects,
+			a.getDeleteOptions(restore))
+		if err != nil {
+			return err
+		}
+	}
+
+	// skip CSI PV/PVCs before applying
+	objects, err = a.removeCSIVolumesBeforeApply(restore, objects)
+	if err != nil {
+		return err
+	}
+
+	// Webhook configurations are applied after everything else, since
+	// requests to the API server could be rejected until the webhook
+	// backends are running. The other objects are applied in stages so that
+	// the restore order is kept across namespaces.
+	webhookConfigurations := make([]runtime.Unstructured, 0)
+	applyObjects := make([]runtime.Unstructured, 0, len(objects))
+	for _, o := range orderObjectsForApply(objects) {
+		if resourcecollector.IsWebhookConfiguration(o.GetObjectKind().GroupVersionKind().Kind) {
+			webhookConfigurations = append(webhookConfigurations, o)
+			continue
+		}
+		applyObjects = append(applyObjects, o)
+	}
+	for _, stage := range splitApplyStages(applyObjects) {
+		if err := a.applyStage(restore, target, stage, existing); err != nil {
+			return err
+		}
+	}
+
+	for _, o := range webhookConfigurations {
+		if err := a.applyWebhookConfiguration(restore, target, o, existing); err != nil {
+			return err
+		}
+	}
+	return a.restoreOwnerReferences(restore, target, pendingOwners)
+}
+
+// getExistingResources returns the keys of the objects that already exist on
+// the destination. Only objects that didn't exist are deleted if the restore
+// is rolled back, so they are only looked up if RollbackOnCancel is set.
+func (a *ApplicationRestoreController) getExistingResources(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	objects []runtime.Unstructured,
+) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if !restore.Spec.RollbackOnCancel {
+		return existing, nil
+	}
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		exists, err := a.resourceCollector.ResourceExists(target.dynamicInterface, o)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			existing[getExistingResourceKey(o, metadata)] = true
+		}
+	}
+	return existing, nil
+}
+
+// getExistingResourceKey returns the key for an object in the map returned by
+// getExistingResources
+func getExistingResourceKey(object runtime.Unstructured, metadata metav1.Object) string {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	return getObjectKey(gvk.Group, gvk.Kind, metadata.GetNamespace(), metadata.GetName())
+}
+
+// applyStage applies the objects in a stage. Cluster scoped objects can be
+// used by objects in any namespace, so they are applied first. Objects in
+// different namespaces rarely depend on each other, so each namespace is then
+// applied in parallel.
+func (a *ApplicationRestoreController) applyStage(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	objects []runtime.Unstructured,
+	existing map[string]bool,
+) error {
+	clusterObjects := make([]runtime.Unstructured, 0)
+	namespaces := make([]string, 0)
+	namespacedObjects := make(map[string][]runtime.Unstructured)
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return err
+		}
+		namespace := metadata.GetNamespace()
+		if namespace == "" {
+			clusterObjects = append(clusterObjects, o)
+			continue
+		}
+		if _, ok := namespacedObjects[namespace]; !ok {
+			namespaces = append(namespaces, namespace)
+		}
+		namespacedObjects[namespace] = append(namespacedObjects[namespace], o)
+	}
+
+	for _, o := range clusterObjects {
+		if err := a.applyResource(restore, target, o, existing); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var lastError error
+	workers := make(chan struct{}, namespaceApplyConcurrency)
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(objects []runtime.Unstructured) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			for _, o := range objects {
+				if err := a.applyResource(restore, target, o, existing); err != nil {
+					lock.Lock()
+					lastError = err
+					lock.Unlock()
+					return
+				}
 			}
+		}(namespacedObjects[namespace])
+	}
+	wg.Wait()
+	return lastError
+}
+
+// applyWebhookConfiguration applies a webhook configuration if all the
+// services called by its webhooks exist. Otherwise it is marked as failed and
+// skipped, since requests to the API server that the webhooks apply to would
+// be rejected.
+func (a *ApplicationRestoreController) applyWebhookConfiguration(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	o runtime.Unstructured,
+	existing map[string]bool,
+) error {
+	services, err := resourcecollector.GetWebhookServices(o)
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		parts := strings.SplitN(service, "/", 2)
+		_, err := target.coreOps.GetService(parts[1], parts[0])
+		if err == nil {
+			continue
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Skipped since the service %v called by its webhooks doesn't exist", service))
+	}
+
+	if err := a.applyResource(restore, target, o, existing); err != nil {
+		return err
+	}
+	if restore.Spec.RelaxWebhookFailurePolicy {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return err
 		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		restore.Status.RelaxedWebhookConfigurations = append(restore.Status.RelaxedWebhookConfigurations,
+			storkapi.ObjectInfo{
+				GroupVersionKind: metav1.GroupVersionKind{
+					Group:   gvk.Group,
+					Version: gvk.Version,
+					Kind:    gvk.Kind,
+				},
+				Name: metadata.GetName(),
+			})
 	}
+	return nil
+}
 
-	// Return if we have any volume restores still in progress
-	if inProgress {
+// prepareWebhookFailurePolicy sets the failure policy of the webhooks in a
+// webhook configuration to Ignore. The original policies are stored in an
+// annotation so that they can be set back once the restore completes.
+func (a *ApplicationRestoreController) prepareWebhookFailurePolicy(
+	object runtime.Unstructured,
+) error {
+	if !resourcecollector.IsWebhookConfiguration(object.GetObjectKind().GroupVersionKind().Kind) {
 		return nil
 	}
-
-	// If the restore hasn't failed move on to the next stage.
-	if restore.Status.Status != storkapi.ApplicationRestoreStatusFailed {
-		restore.Status.Stage = storkapi.ApplicationRestoreStageApplications
-		restore.Status.Status = storkapi.ApplicationRestoreStatusInProgress
-		restore.Status.Reason = "Application resources restore is in progress"
-		restore.Status.LastUpdateTimestamp = metav1.Now()
-		// Update the current state and then move on to restoring resources
-		err := a.client.Update(context.TODO(), restore)
+	content := object.UnstructuredContent()
+	webhooks, _, err := unstructured.NestedSlice(content, "webhooks")
+	if err != nil {
+		return err
+	}
+	policies := make(map[string]string)
+	for _, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, err := unstructured.NestedString(webhookMap, "name")
 		if err != nil {
 			return err
 		}
-		err = a.restoreResources(restore)
+		policy, found, err := unstructured.NestedString(webhookMap, "failurePolicy")
 		if err != nil {
-			log.ApplicationRestoreLog(restore).Errorf("Error restoring resources: %v", err)
 			return err
 		}
+		if !found {
+			// Default for admissionregistration.k8s.io/v1
+			policy = "Fail"
+		}
+		policies[name] = policy
+		webhookMap["failurePolicy"] = "Ignore"
+	}
+	if err := unstructured.SetNestedSlice(content, webhooks, "webhooks"); err != nil {
+		return err
 	}
 
-	restore.Status.LastUpdateTimestamp = metav1.Now()
-	// Only on success compute the total restore size
-	for _, vInfo := range restore.Status.Volumes {
-		restore.Status.TotalSize += vInfo.TotalSize
+	value, err := json.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
 	}
+	annotations := metadata.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[webhookFailurePolicyAnnotation] = string(value)
+	metadata.SetAnnotations(annotations)
+	return nil
+}
 
-	err := a.client.Update(context.TODO(), restore)
+// restoreWebhookFailurePolicies sets the failure policies of the webhooks in
+// the webhook configurations relaxed by the restore back to their original
+// values
+func (a *ApplicationRestoreController) restoreWebhookFailurePolicies(restore *storkapi.ApplicationRestore) error {
+	target, err := a.getRestoreTarget(restore)
 	if err != nil {
 		return err
 	}
+	for len(restore.Status.RelaxedWebhookConfigurations) != 0 {
+		info := restore.Status.RelaxedWebhookConfigurations[0]
+		gvr := schema.GroupVersionResource{
+			Group:    info.Group,
+			Version:  info.Version,
+			Resource: strings.ToLower(info.Kind) + "s",
+		}
+		client := target.dynamicInterface.Resource(gvr)
+		object, err := client.Get(context.TODO(), info.Name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			if err := setWebhookFailurePolicies(object); err != nil {
+				return err
+			}
+			if _, err := client.Update(context.TODO(), object, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			log.ApplicationRestoreLog(restore).Infof("Set failure policies back for webhooks in %v %v", info.Kind, info.Name)
+		}
+		restore.Status.RelaxedWebhookConfigurations = restore.Status.RelaxedWebhookConfigurations[1:]
+	}
 	return nil
 }
 
-func (a *ApplicationRestoreController) downloadObject(
-	backup *storkapi.ApplicationBackup,
-	backupLocation string,
-	namespace string,
-	objectName string,
-	skipIfNotPresent bool,
-) ([]byte, error) {
-	restoreLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, namespace)
+// setWebhookFailurePolicies sets the failure policies of the webhooks in a
+// webhook configuration from the annotation added when they were relaxed
+func setWebhookFailurePolicies(object *unstructured.Unstructured) error {
+	annotations := object.GetAnnotations()
+	value, ok := annotations[webhookFailurePolicyAnnotation]
+	if !ok {
+		return nil
+	}
+	policies := make(map[string]string)
+	if err := json.Unmarshal([]byte(value), &policies); err != nil {
+		return fmt.Errorf("error parsing failure policies: %v", err)
+	}
+	content := object.UnstructuredContent()
+	webhooks, _, err := unstructured.NestedSlice(content, "webhooks")
 	if err != nil {
-		return nil, err
+		return err
+	}
+	for _, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, err := unstructured.NestedString(webhookMap, "name")
+		if err != nil {
+			return err
+		}
+		if policy, ok := policies[name]; ok {
+			webhookMap["failurePolicy"] = policy
+		}
+	}
+	if err := unstructured.SetNestedSlice(content, webhooks, "webhooks"); err != nil {
+		return err
+	}
+	delete(annotations, webhookFailurePolicyAnnotation)
+	object.SetAnnotations(annotations)
+	return nil
+}
+
+// applyResource applies a single object and updates its status in the
+// restore. Errors applying the object are only recorded in the status.
+func (a *ApplicationRestoreController) applyResource(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	o runtime.Unstructured,
+	existing map[string]bool,
+) error {
+	metadata, err := meta.Accessor(o)
+	if err != nil {
+		return err
 	}
-	bucket, err := objectstore.GetBucket(restoreLocation)
+	objectType, err := meta.TypeAccessor(o)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	existed := existing[getExistingResourceKey(o, metadata)]
 
-	objectPath := backup.Status.BackupPath
-	if skipIfNotPresent {
-		exists, err := bucket.Exists(context.TODO(), filepath.Join(objectPath, objectName))
-		if err != nil || !exists {
-			return nil, nil
+	// Budgets are applied by a later pass if their pods aren't ready yet
+	if objectType.GetKind() == "PodDisruptionBudget" && !restore.Spec.StartWorkloadsPaused {
+		ready, err := disruptionBudgetTargetsReady(target, o)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			return a.deferDisruptionBudget(restore, o, existed)
 		}
 	}
+	return a.applyObject(restore, target, o, existed)
+}
 
-	data, err := bucket.ReadAll(context.TODO(), filepath.Join(objectPath, objectName))
+// applyObject applies a single object and updates its status in the restore.
+// existed is set if the object existed on the destination before the restore
+// started replacing objects.
+func (a *ApplicationRestoreController) applyObject(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	o runtime.Unstructured,
+	existed bool,
+) error {
+	metadata, err := meta.Accessor(o)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if restoreLocation.Location.EncryptionKey != "" {
-		if data, err = crypto.Decrypt(data, restoreLocation.Location.EncryptionKey); err != nil {
-			return nil, err
+	objectType, err := meta.TypeAccessor(o)
+	if err != nil {
+		return err
+	}
+
+	// Objects with generateName are created with a generated name if
+	// requested, or if an object with their name couldn't be replaced
+	if restore.Spec.UseGenerateName && generateNameAllowed(o, metadata) {
+		return a.applyResourceWithGenerateName(restore, target, o)
+	}
+
+	log.ApplicationRestoreLog(restore).Infof("Applying %v %v/%v", objectType.GetKind(), metadata.GetNamespace(), metadata.GetName())
+	retained := false
+	merging := false
+	merged := false
+	err = a.resourceCollector.ApplyResource(
+		target.dynamicInterface,
+		o)
+	if err != nil && errors.IsAlreadyExists(err) && generateNameAllowed(o, metadata) &&
+		restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
+		return a.applyResourceWithGenerateName(restore, target, o)
+	}
+	if err != nil && errors.IsAlreadyExists(err) && resourcecollector.IsReferencedResource(o) {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusRetained,
+			"Referenced cluster scoped resource already exists and was retained")
+	}
+	if err != nil && errors.IsAlreadyExists(err) && resourcecollector.IsAPIService(o) {
+		msg := fmt.Sprintf("APIService %v already exists on the destination and was retained", metadata.GetName())
+		log.ApplicationRestoreLog(restore).Warningf(msg)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusRetained),
+			msg)
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusRetained,
+			msg)
+	}
+	if err != nil && errors.IsAlreadyExists(err) {
+		switch restore.Spec.ReplacePolicy {
+		case storkapi.ApplicationRestoreReplacePolicyDelete:
+			log.ApplicationRestoreLog(restore).Errorf("Error deleting %v %v during restore: %v", objectType.GetKind(), metadata.GetName(), err)
+		case storkapi.ApplicationRestoreReplacePolicyRetain:
+			log.ApplicationRestoreLog(restore).Warningf("Error deleting %v %v during restore, ReplacePolicy set to Retain: %v", objectType.GetKind(), metadata.GetName(), err)
+			retained = true
+			err = nil
+		case storkapi.ApplicationRestoreReplacePolicyMerge:
+			// The spec of bound PVCs can't be updated, so they are retained
+			// and checked for conflicts instead
+			if objectType.GetKind() == "PersistentVolumeClaim" {
+				retained = true
+				err = nil
+				break
+			}
+			log.ApplicationRestoreLog(restore).Infof("Merging %v %v/%v with existing resource", objectType.GetKind(), metadata.GetNamespace(), metadata.GetName())
+			merging = true
+			err = a.resourceCollector.MergeResource(target.dynamicInterface, o)
+			merged = err == nil
 		}
 	}
 
-	return data, nil
+	if err != nil && merging && errors.IsConflict(err) {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusConflict,
+			fmt.Sprintf("Resource restore skipped as fields are managed by others on the existing resource: %v", err))
+	} else if err != nil {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error applying resource: %v", err))
+	} else if retained && objectType.GetKind() == "PersistentVolumeClaim" {
+		return a.updateRetainedPVCStatus(restore, target, o)
+	} else if retained {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusRetained,
+			"Resource restore skipped as it was already present and ReplacePolicy is set to Retain")
+	} else if merged {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusSuccessful,
+			"Resource merged with the existing resource")
+	}
+	if err := a.updateResourceStatus(
+		restore,
+		o,
+		storkapi.ApplicationRestoreStatusSuccessful,
+		"Resource restored successfully"); err != nil {
+		return err
+	}
+	if restore.Spec.RollbackOnCancel && !existed {
+		return a.markResourceCreated(restore, o)
+	}
+	return nil
 }
 
-func (a *ApplicationRestoreController) downloadResources(
-	backup *storkapi.ApplicationBackup,
-	backupLocation string,
-	namespace string,
-) ([]runtime.Unstructured, error) {
-	// create CRD resource first
-	if err := a.downloadCRD(backup, backupLocation, namespace); err != nil {
-		return nil, fmt.Errorf("error downloading CRDs: %v", err)
-	}
-	data, err := a.downloadObject(backup, backupLocation, namespace, resourceObjectName, false)
+// markResourceCreated records that a resource was created by the restore, so
+// that it is deleted if the restore is rolled back
+func (a *ApplicationRestoreController) markResourceCreated(
+	restore *storkapi.ApplicationRestore,
+	object runtime.Unstructured,
+) error {
+	metadata, err := meta.Accessor(object)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	a.resourceStatusLock.Lock()
+	defer a.resourceStatusLock.Unlock()
+	if resource := findResourceStatus(restore, object.GetObjectKind().GroupVersionKind(), metadata); resource != nil {
+		resource.Created = true
+	}
+	return nil
+}
 
-	objects := make([]*unstructured.Unstructured, 0)
-	if err = json.Unmarshal(data, &objects); err != nil {
-		return nil, err
+// generateNameAllowed checks if an object can be restored with a generated
+// name. PVCs and PVs are restored with their names since PVs are bound to
+// their claims by name.
+func generateNameAllowed(object runtime.Unstructured, metadata metav1.Object) bool {
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "PersistentVolumeClaim", "PersistentVolume":
+		return false
 	}
-	runtimeObjects := make([]runtime.Unstructured, 0)
-	for _, o := range objects {
-		runtimeObjects = append(runtimeObjects, o)
+	return metadata.GetGenerateName() != ""
+}
+
+// getGeneratedName returns the name for an object restored using its
+// generateName. The name from the status is used if the object was already
+// restored by an earlier pass. Otherwise the name is generated from the UID of
+// the restore and the name of the object, so that it is the same if the
+// status is lost and the object is applied again.
+func getGeneratedName(restore *storkapi.ApplicationRestore, object runtime.Unstructured, metadata metav1.Object) string {
+	if resource := findResourceStatus(restore, object.GetObjectKind().GroupVersionKind(), metadata); resource != nil &&
+		resource.GeneratedName != "" {
+		return resource.GeneratedName
 	}
-	return runtimeObjects, nil
+	generateName := metadata.GetGenerateName()
+	if len(generateName) > maxGeneratedNameLength {
+		generateName = generateName[:maxGeneratedNameLength]
+	}
+	hash := sha256.Sum256([]byte(string(restore.UID) + "/" + metadata.GetNamespace() + "/" + metadata.GetName()))
+	return generateName + hex.EncodeToString(hash[:])[:generatedNameSuffixLength]
 }
 
-func (a *ApplicationRestoreController) downloadCRD(
-	backup *storkapi.ApplicationBackup,
-	backupLocation string,
-	namespace string,
+// applyResourceWithGenerateName creates an object using its generateName and
+// records the name it was created with in its status. An object that already
+// exists with the name was created by an earlier pass of the restore.
+func (a *ApplicationRestoreController) applyResourceWithGenerateName(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	o runtime.Unstructured,
 ) error {
-	var crds []*apiextensionsv1beta1.CustomResourceDefinition
-	var crdsV1 []*apiextensionsv1.CustomResourceDefinition
-	crdData, err := a.downloadObject(backup, backupLocation, namespace, crdObjectName, true)
+	metadata, err := meta.Accessor(o)
 	if err != nil {
 		return err
 	}
-	// No CRDs were uploaded
-	if crdData == nil {
-		return nil
+	a.resourceStatusLock.Lock()
+	generatedName := getGeneratedName(restore, o, metadata)
+	a.resourceStatusLock.Unlock()
+	log.ApplicationRestoreLog(restore).Infof("Applying %v %v/%v with generated name %v",
+		o.GetObjectKind().GroupVersionKind().Kind, metadata.GetNamespace(), metadata.GetName(), generatedName)
+	err = a.resourceCollector.CreateResourceWithName(target.dynamicInterface, o, generatedName)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error applying resource with generateName: %v", err))
 	}
-	if err = json.Unmarshal(crdData, &crds); err != nil {
+	if err := a.updateGeneratedResourceStatus(restore, o, generatedName); err != nil {
 		return err
 	}
-	if err = json.Unmarshal(crdData, &crdsV1); err != nil {
-		return err
+	if restore.Spec.RollbackOnCancel {
+		return a.markResourceCreated(restore, o)
 	}
-	config, err := rest.InClusterConfig()
+	return nil
+}
+
+// updateRetainedPVCStatus updates the status for a PVC that was retained. If
+// the existing PVC is bound to a different PV than the one being restored it
+// is marked as a conflict so that it can be fixed up manually.
+func (a *ApplicationRestoreController) updateRetainedPVCStatus(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	object runtime.Unstructured,
+) error {
+	var pvc v1.PersistentVolumeClaim
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &pvc); err != nil {
+		return fmt.Errorf("error converting PVC object: %v: %v", object, err)
+	}
+	existingPVC, err := target.coreOps.GetPersistentVolumeClaim(pvc.Name, pvc.Namespace)
 	if err != nil {
-		return fmt.Errorf("error getting cluster config: %v", err)
+		return fmt.Errorf("error getting existing PVC %v/%v: %v", pvc.Namespace, pvc.Name, err)
+	}
+	if pvc.Spec.VolumeName != "" && existingPVC.Spec.VolumeName != "" &&
+		pvc.Spec.VolumeName != existingPVC.Spec.VolumeName {
+		log.ApplicationRestoreLog(restore).Warnf("PVC %v/%v is bound to PV %v, expected PV %v",
+			pvc.Namespace, pvc.Name, existingPVC.Spec.VolumeName, pvc.Spec.VolumeName)
+		return a.updateResourceStatus(
+			restore,
+			object,
+			storkapi.ApplicationRestoreStatusConflict,
+			fmt.Sprintf("Resource restore skipped as existing PVC is bound to PV %v instead of restored PV %v",
+				existingPVC.Spec.VolumeName, pvc.Spec.VolumeName))
 	}
+	return a.updateResourceStatus(
+		restore,
+		object,
+		storkapi.ApplicationRestoreStatusRetained,
+		fmt.Sprintf("Resource restore skipped as it was already present and ReplacePolicy is set to %v", restore.Spec.ReplacePolicy))
+}
 
-	client, err := apiextensionsclient.NewForConfig(config)
+func (a *ApplicationRestoreController) restoreResources(
+	restore *storkapi.ApplicationRestore,
+) error {
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
 	if err != nil {
+		log.ApplicationRestoreLog(restore).Errorf("Error getting backup: %v", err)
 		return err
 	}
 
-	regCrd := make(map[string]bool)
-	for _, crd := range crds {
-		crd.ResourceVersion = ""
-		regCrd[crd.GetName()] = false
-		if _, err := client.ApiextensionsV1beta1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
-			regCrd[crd.GetName()] = true
-			logrus.Warnf("error registering crds v1beta1 %v,%v", crd.GetName(), err)
-			continue
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	var objects []runtime.Unstructured
+	if restore.Spec.VolumesOnly {
+		// CRDs don't need to be registered since only PVCs and PVs are
+		// restored
+		objects, err = a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
+		if err == nil {
+			objects = filterVolumeObjects(objects)
 		}
-		// wait for crd to be ready
-		if err := k8sutils.ValidateCRD(client, crd.GetName()); err != nil {
-			logrus.Warnf("Unable to validate crds v1beta1 %v,%v", crd.GetName(), err)
+	} else {
+		objects, err = a.downloadResources(restore, backup, restore.Spec.BackupLocation, restore.Namespace, target,
+			!validationSkipped(restore, storkapi.ApplicationRestoreValidationCRDReady))
+	}
+	if err != nil {
+		log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
+		return err
+	}
+
+	// CSI PVs are removed before they are applied, so find the UIDs of all
+	// the PVs first
+	var pvSourceUIDs map[string]string
+	if restore.Spec.PreservePVUID {
+		if pvSourceUIDs, err = getPVSourceUIDs(objects); err != nil {
+			return err
 		}
 	}
 
-	for _, crd := range crdsV1 {
-		if val, ok := regCrd[crd.GetName()]; ok && val {
-			crd.ResourceVersion = ""
-			var updatedVersions []apiextensionsv1.CustomResourceDefinitionVersion
-			// try to apply as v1 crd
-			var err error
-			if _, err = client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{}); err == nil || errors.IsAlreadyExists(err) {
-				logrus.Infof("registered v1 crds %v,", crd.GetName())
-				continue
-			}
-			// updated fields
-			crd.Spec.PreserveUnknownFields = false
-			for _, version := range crd.Spec.Versions {
-				isTrue := true
-				if version.Schema == nil {
-					openAPISchema := &apiextensionsv1.CustomResourceValidation{
-						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: &isTrue},
-					}
-					version.Schema = openAPISchema
-				} else {
-					version.Schema.OpenAPIV3Schema.XPreserveUnknownFields = &isTrue
-				}
-				updatedVersions = append(updatedVersions, version)
-			}
-			crd.Spec.Versions = updatedVersions
+	// The checksums are from the objects in the backup, before they are
+	// prepared for the destination. The objects that are applied are added
+	// again with the names they are restored with when they are prepared, the
+	// ones keyed by the name in the backup are used for the CSI PVCs which
+	// aren't applied.
+	checksums, err := getObjectChecksums(restore, objects)
+	if err != nil {
+		return err
+	}
 
-			if _, err := client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
-				logrus.Warnf("error registering crdsv1 %v,%v", crd.GetName(), err)
-				continue
-			}
-			// wait for crd to be ready
-			if err := k8sutils.ValidateCRDV1(client, crd.GetName()); err != nil {
-				logrus.Warnf("Unable to validate crdsv1 %v,%v", crd.GetName(), err)
-			}
+	// The CSI PVCs are removed before the objects are applied, so find the
+	// objects to keep first. The names of the restored objects are added
+	// from the status once they have been applied.
+	var backupObjectKeys map[string]bool
+	if restore.Spec.PruneExtraneous && !restore.Spec.VolumesOnly {
+		if backupObjectKeys, err = getPruneKeys(restore, objects); err != nil {
+			return err
+		}
+	}
+
+	// skip CSI PV/PVCs before applying
+	objects, err = a.removeCSIVolumesBeforeApply(restore, objects)
+	if err != nil {
+		return err
+	}
 
-		}
+	if err := a.applyResources(restore, objects, checksums); err != nil {
+		return err
 	}
 
-	return nil
-}
+	if restore.Spec.SettleDelay.Duration > 0 {
+		restore.Status.Stage = storkapi.ApplicationRestoreStageSettle
+		restore.Status.Status = storkapi.ApplicationRestoreStatusInProgress
+		restore.Status.Reason = fmt.Sprintf("Waiting %v for restored resources to settle", restore.Spec.SettleDelay.Duration)
+		restore.Status.ResourcesAppliedTimestamp = metav1.Now()
+	} else {
+		setRestoreFinalStatus(restore)
+	}
 
-func (a *ApplicationRestoreController) updateResourceStatus(
-	restore *storkapi.ApplicationRestore,
-	object runtime.Unstructured,
-	status storkapi.ApplicationRestoreStatusType,
-	reason string,
-) error {
-	var updatedResource *storkapi.ApplicationRestoreResourceInfo
-	gkv := object.GetObjectKind().GroupVersionKind()
-	metadata, err := meta.Accessor(object)
-	if err != nil {
-		log.ApplicationRestoreLog(restore).Errorf("Error getting metadata for object %v %v", object, err)
+	// Add all CSI PVCs and PVs back into resources.
+	// CSI PVs are dynamically generated by the CSI controller for restore,
+	// so we need to get the new PV name after restore volumes finishes
+	if err := a.addCSIVolumeResources(restore, target); err != nil {
 		return err
 	}
-	for _, resource := range restore.Status.Resources {
-		if resource.Name == metadata.GetName() &&
-			resource.Namespace == metadata.GetNamespace() &&
-			(resource.Group == gkv.Group || (resource.Group == "core" && gkv.Group == "")) &&
-			resource.Version == gkv.Version &&
-			resource.Kind == gkv.Kind {
-			updatedResource = resource
-			break
+
+	// Pruned once the PVCs restored by the CSI driver are in the status
+	if backupObjectKeys != nil {
+		if err := a.pruneExtraneousResources(restore, target, backupObjectKeys); err != nil {
+			return fmt.Errorf("error pruning resources: %v", err)
 		}
 	}
-	if updatedResource == nil {
-		updatedResource = &storkapi.ApplicationRestoreResourceInfo{
-			ObjectInfo: storkapi.ObjectInfo{
-				Name:      metadata.GetName(),
-				Namespace: metadata.GetNamespace(),
-				GroupVersionKind: metav1.GroupVersionKind{
-					Group:   gkv.Group,
-					Version: gkv.Version,
-					Kind:    gkv.Kind,
-				},
-			},
+
+	if pvSourceUIDs != nil {
+		if err := a.recordPVUIDs(restore, target, pvSourceUIDs); err != nil {
+			return fmt.Errorf("error recording PV UIDs: %v", err)
 		}
-		restore.Status.Resources = append(restore.Status.Resources, updatedResource)
 	}
 
-	updatedResource.Status = status
-	updatedResource.Reason = reason
-	eventType := v1.EventTypeNormal
-	if status == storkapi.ApplicationRestoreStatusFailed {
-		eventType = v1.EventTypeWarning
+	recordChecksums(restore, checksums)
+	if restore.Spec.ReportChanges {
+		if err := a.reportChanges(restore); err != nil {
+			return fmt.Errorf("error reporting changes: %v", err)
+		}
 	}
-	eventMessage := fmt.Sprintf("%v %v/%v: %v",
-		gkv,
-		updatedResource.Namespace,
-		updatedResource.Name,
-		reason)
-	a.recorder.Event(restore, eventType, string(status), eventMessage)
+
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	if err := a.client.Update(context.TODO(), restore); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (a *ApplicationRestoreController) getPVNameMappings(
+// getPVSourceUIDs returns the UIDs of the PVs in the backup keyed by the name
+// of the PV. PVs from backups that didn't record the UID are skipped.
+func getPVSourceUIDs(objects []runtime.Unstructured) (map[string]string, error) {
+	uids := make(map[string]string)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolume" {
+			continue
+		}
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		if uid, ok := metadata.GetAnnotations()[resourcecollector.PVSourceUIDAnnotation]; ok {
+			uids[metadata.GetName()] = uid
+		}
+	}
+	return uids, nil
+}
+
+// recordPVUIDs records the UIDs of the backed up and restored PVs for each
+// restored volume. PVs that are provisioned by CSI drivers aren't restored
+// from the backup, so the annotation with the original UID is added to them.
+func (a *ApplicationRestoreController) recordPVUIDs(
 	restore *storkapi.ApplicationRestore,
-	objects []runtime.Unstructured,
-) (map[string]string, error) {
-	pvNameMappings := make(map[string]string)
-	for _, vInfo := range restore.Status.Volumes {
-		if vInfo.SourceVolume == "" {
-			return nil, fmt.Errorf("SourceVolume missing for restore")
+	target *restoreTarget,
+	pvSourceUIDs map[string]string,
+) error {
+	for _, volumeInfo := range restore.Status.Volumes {
+		sourceUID, ok := pvSourceUIDs[volumeInfo.SourceVolume]
+		if !ok || volumeInfo.Status != storkapi.ApplicationRestoreStatusSuccessful {
+			continue
 		}
-		if vInfo.RestoreVolume == "" {
-			return nil, fmt.Errorf("RestoreVolume missing for restore")
+		pv, err := target.coreOps.GetPersistentVolume(volumeInfo.RestoreVolume)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
 		}
-		pvNameMappings[vInfo.SourceVolume] = vInfo.RestoreVolume
+		if pv.Annotations[resourcecollector.PVSourceUIDAnnotation] != sourceUID {
+			if pv.Annotations == nil {
+				pv.Annotations = make(map[string]string)
+			}
+			pv.Annotations[resourcecollector.PVSourceUIDAnnotation] = sourceUID
+			if err := target.client.Update(context.TODO(), pv); err != nil {
+				return err
+			}
+		}
+		volumeInfo.SourceVolumeUID = sourceUID
+		volumeInfo.RestoreVolumeUID = string(pv.UID)
 	}
-	return pvNameMappings, nil
+	return nil
 }
 
-func getNamespacedPVCLocation(pvc *v1.PersistentVolumeClaim) string {
-	return fmt.Sprintf("%s/%s", pvc.Namespace, pvc.Name)
+// getObjectKey returns the key used to match objects on the destination with
+// the objects being restored
+func getObjectKey(group string, kind string, namespace string, name string) string {
+	if group == "core" {
+		group = ""
+	}
+	return fmt.Sprintf("%v/%v/%v/%v", group, kind, namespace, name)
 }
 
-// getPVCToPVMapping constructs a mapping of PVC name/namespace to PV objects
-func getPVCToPVMapping(allObjects []runtime.Unstructured) (map[string]*v1.PersistentVolume, error) {
+// getObjectChecksum returns the checksum of the content of an object. Fields
+// that are set by the apiserver are ignored.
+func getObjectChecksum(object runtime.Unstructured) (string, error) {
+	content := runtime.DeepCopyJSON(object.UnstructuredContent())
+	unstructured.RemoveNestedField(content, "status")
+	for _, field := range []string{"uid", "resourceVersion", "generation", "creationTimestamp", "managedFields", "selfLink"} {
+		unstructured.RemoveNestedField(content, "metadata", field)
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	checksum := sha256.Sum256(data)
+	return hex.EncodeToString(checksum[:]), nil
+}
 
-	// Get mapping of PVC name to PV name
-	pvNameToPVCName := make(map[string]string)
-	for _, o := range allObjects {
-		objectType, err := meta.TypeAccessor(o)
+// getObjectChecksums returns the checksums of the namespaced objects from the
+// backup keyed by the object in its destination namespace
+func getObjectChecksums(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) (map[string]string, error) {
+	checksums := make(map[string]string)
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
 		if err != nil {
 			return nil, err
 		}
-
-		// If a PV, assign it to the mapping based on the claimRef UID
-		if objectType.GetKind() == "PersistentVolumeClaim" {
-			pvc := &v1.PersistentVolumeClaim{}
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), pvc); err != nil {
-				return nil, fmt.Errorf("error converting to persistent volume: %v", err)
-			}
-
-			pvNameToPVCName[pvc.Spec.VolumeName] = getNamespacedPVCLocation(pvc)
+		namespace, ok := restore.Spec.NamespaceMapping[metadata.GetNamespace()]
+		if !ok {
+			continue
 		}
-	}
-
-	// Get actual mapping of PVC name to PV object
-	pvcNameToPV := make(map[string]*v1.PersistentVolume)
-	for _, o := range allObjects {
-		objectType, err := meta.TypeAccessor(o)
+		checksum, err := getObjectChecksum(o)
 		if err != nil {
 			return nil, err
 		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		checksums[getObjectKey(gvk.Group, gvk.Kind, namespace, metadata.GetName())] = checksum
+	}
+	return checksums, nil
+}
 
-		// If a PV, assign it to the mapping based on the claimRef UID
-		if objectType.GetKind() == "PersistentVolume" {
-			pv := &v1.PersistentVolume{}
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), pv); err != nil {
-				return nil, fmt.Errorf("error converting to persistent volume: %v", err)
-			}
+// recordChecksums sets the checksum of the backed up object for each restored
+// resource, so that the next restore can report what changed
+func recordChecksums(
+	restore *storkapi.ApplicationRestore,
+	checksums map[string]string,
+) {
+	for _, resource := range restore.Status.Resources {
+		if resource.Status != storkapi.ApplicationRestoreStatusSuccessful &&
+			resource.Status != storkapi.ApplicationRestoreStatusRetained {
+			continue
+		}
+		resource.Checksum = checksums[getObjectKey(resource.Group, resource.Kind, resource.Namespace, resource.Name)]
+	}
+}
 
-			pvcName := pvNameToPVCName[pv.Name]
+// getPreviousRestore returns the last restore in the namespace of the restore
+// that completed restoring resources to the same destination namespaces, or
+// nil if there isn't one
+func getPreviousRestore(restore *storkapi.ApplicationRestore) (*storkapi.ApplicationRestore, error) {
+	restores, err := storkops.Instance().ListApplicationRestores(restore.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make(map[string]bool)
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		namespaces[namespace] = true
+	}
 
-			// add this PVC name/PV obj mapping
-			pvcNameToPV[pvcName] = pv
+	var previous *storkapi.ApplicationRestore
+	for i, r := range restores.Items {
+		if r.Name == restore.Name ||
+			r.Spec.DryRun ||
+			r.Spec.ExportType != "" ||
+			r.Status.Stage != storkapi.ApplicationRestoreStageFinal ||
+			(r.Status.Status != storkapi.ApplicationRestoreStatusSuccessful &&
+				r.Status.Status != storkapi.ApplicationRestoreStatusPartialSuccess) {
+			continue
+		}
+		previousNamespaces := make(map[string]bool)
+		for _, namespace := range r.Spec.NamespaceMapping {
+			previousNamespaces[namespace] = true
+		}
+		if !reflect.DeepEqual(namespaces, previousNamespaces) {
+			continue
+		}
+		if previous == nil || r.Status.FinishTimestamp.After(previous.Status.FinishTimestamp.Time) {
+			previous = &restores.Items[i]
 		}
 	}
-
-	return pvcNameToPV, nil
+	return previous, nil
 }
 
-func isGenericCSIPersistentVolume(pv *v1.PersistentVolume) (bool, error) {
-	driverName, err := volume.GetPVDriver(pv)
+// reportChanges compares the checksums of the restored resources to the ones
+// recorded by the previous restore to the same namespaces and records the
+// objects that were added, removed or modified in the status
+func (a *ApplicationRestoreController) reportChanges(restore *storkapi.ApplicationRestore) error {
+	previous, err := getPreviousRestore(restore)
 	if err != nil {
-		return false, err
+		return err
 	}
-	if driverName == "csi" {
-		return true, nil
+	if previous == nil {
+		log.ApplicationRestoreLog(restore).Infof("No previous restore found to report changes against")
+		return nil
 	}
 
-	return false, nil
+	getChecksums := func(r *storkapi.ApplicationRestore) (map[string]string, map[string]storkapi.ObjectInfo) {
+		checksums := make(map[string]string)
+		objects := make(map[string]storkapi.ObjectInfo)
+		for _, resource := range r.Status.Resources {
+			if resource.Checksum == "" {
+				continue
+			}
+			key := getObjectKey(resource.Group, resource.Kind, resource.Namespace, resource.Name)
+			checksums[key] = resource.Checksum
+			objects[key] = resource.ObjectInfo
+		}
+		return checksums, objects
+	}
+	currentChecksums, currentObjects := getChecksums(restore)
+	previousChecksums, previousObjects := getChecksums(previous)
+	if len(previousChecksums) == 0 {
+		log.ApplicationRestoreLog(restore).Infof("Previous restore %v didn't record checksums, not reporting changes", previous.Name)
+		return nil
+	}
+
+	changes := &storkapi.ApplicationRestoreChanges{
+		PreviousRestore: previous.Name,
+		PreviousBackup:  previous.Spec.BackupName,
+		Added:           make([]storkapi.ObjectInfo, 0),
+		Removed:         make([]storkapi.ObjectInfo, 0),
+		Modified:        make([]storkapi.ObjectInfo, 0),
+	}
+	keys := make([]string, 0, len(currentChecksums))
+	for key := range currentChecksums {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		previousChecksum, ok := previousChecksums[key]
+		if !ok {
+			changes.Added = append(changes.Added, currentObjects[key])
+		} else if previousChecksum != currentChecksums[key] {
+			changes.Modified = append(changes.Modified, currentObjects[key])
+		}
+	}
+	keys = keys[:0]
+	for key := range previousChecksums {
+		if _, ok := currentChecksums[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		changes.Removed = append(changes.Removed, previousObjects[key])
+	}
+	restore.Status.Changes = changes
+
+	a.recorder.Event(restore,
+		v1.EventTypeNormal,
+		string(restore.Status.Status),
+		fmt.Sprintf("Since restore %v of backup %v: %v objects added, %v removed and %v modified",
+			previous.Name, previous.Spec.BackupName, len(changes.Added), len(changes.Removed), len(changes.Modified)))
+	return nil
 }
 
-func (a *ApplicationRestoreController) removeCSIVolumesBeforeApply(
-	restore *storkapi.ApplicationRestore,
-	objects []runtime.Unstructured,
-) ([]runtime.Unstructured, error) {
-	tempObjects := make([]runtime.Unstructured, 0)
+// legacyGroups are the groups that kinds were moved out of, keyed by the
+// kind. The apiserver serves the same objects from both groups.
+var legacyGroups = map[string]map[string]string{
+	"extensions": {
+		"Deployment":    "apps",
+		"DaemonSet":     "apps",
+		"ReplicaSet":    "apps",
+		"Ingress":       "networking.k8s.io",
+		"NetworkPolicy": "networking.k8s.io",
+	},
+}
 
-	// Get PVC to PV mapping first for checking if a PVC is bound to a generic CSI PV
-	pvcToPVMapping, err := getPVCToPVMapping(objects)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get PVC to PV mapping: %v", err)
+// getPruneKey returns the key for an object when pruning. Objects in legacy
+// groups use the key of the group they were moved to, so an object listed
+// from either group matches the object that was restored.
+func getPruneKey(group string, kind string, namespace string, name string) string {
+	if groups, ok := legacyGroups[group]; ok {
+		if newGroup, ok := groups[kind]; ok {
+			group = newGroup
+		}
 	}
+	return getObjectKey(group, kind, namespace, name)
+}
+
+// getPruneKeys returns the keys for the objects from the backup in their
+// destination namespaces, with the group from the group mapping. Objects from
+// namespaces that aren't being restored are ignored. Objects that are renamed
+// when they are restored are added from the status by getRestoredPruneKeys.
+func getPruneKeys(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) (map[string]bool, error) {
+	keys := make(map[string]bool)
 	for _, o := range objects {
-		objectType, err := meta.TypeAccessor(o)
+		metadata, err := meta.Accessor(o)
 		if err != nil {
 			return nil, err
 		}
+		namespace, ok := restore.Spec.NamespaceMapping[metadata.GetNamespace()]
+		if !ok {
+			continue
+		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		keys[getPruneKey(gvk.Group, gvk.Kind, namespace, metadata.GetName())] = true
+		if group, ok := restore.Spec.GroupMapping[gvk.Group]; ok {
+			keys[getPruneKey(group, gvk.Kind, namespace, metadata.GetName())] = true
+		}
+	}
+	return keys, nil
+}
 
-		switch objectType.GetKind() {
-		case "PersistentVolume":
-			// check if this PV is a generic CSI one
-			var pv v1.PersistentVolume
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pv); err != nil {
-				return nil, fmt.Errorf("error converting to persistent volume: %v", err)
-			}
-
-			// Check if this PV is a generic CSI one
-			isGenericCSIPVC, err := isGenericCSIPersistentVolume(&pv)
-			if err != nil {
-				return nil, fmt.Errorf("failed to check if PV was provisioned by a CSI driver: %v", err)
-			}
+// addRestoredPruneKeys adds the keys for the objects in the status of the
+// restore, which have the group and name that they were restored with, and
+// the PVCs of the restored volumes
+func addRestoredPruneKeys(restore *storkapi.ApplicationRestore, keys map[string]bool) {
+	for _, resource := range restore.Status.Resources {
+		keys[getPruneKey(resource.Group, resource.Kind, resource.Namespace, resource.Name)] = true
+		if resource.GeneratedName != "" {
+			keys[getPruneKey(resource.Group, resource.Kind, resource.Namespace, resource.GeneratedName)] = true
+		}
+	}
+	for _, volumeInfo := range restore.Status.Volumes {
+		namespace, ok := restore.Spec.NamespaceMapping[volumeInfo.SourceNamespace]
+		if !ok {
+			namespace = volumeInfo.SourceNamespace
+		}
+		keys[getPruneKey("", "PersistentVolumeClaim", namespace, volumeInfo.PersistentVolumeClaim)] = true
+	}
+}
 
-			// Only add this object if it's not a generic CSI PV
-			if !isGenericCSIPVC {
-				tempObjects = append(tempObjects, o)
-			} else {
-				log.ApplicationRestoreLog(restore).Debugf("skipping CSI PV in restore: %s", pv.Name)
-			}
+// getExtraneousObjects returns the objects of a type from a destination
+// namespace that can be pruned since they aren't in the backup
+func getExtraneousObjects(
+	restore *storkapi.ApplicationRestore,
+	backupObjectKeys map[string]bool,
+	resourceType metav1.APIResource,
+	objects []unstructured.Unstructured,
+) []runtime.Unstructured {
+	extraneous := make([]runtime.Unstructured, 0)
+	if !resourceType.Namespaced || matchesKeyPattern(resourceType.Kind, restore.Spec.PruneProtectedKinds) {
+		return extraneous
+	}
+	gvk := schema.GroupVersionKind{
+		Group:   resourceType.Group,
+		Version: resourceType.Version,
+		Kind:    resourceType.Kind,
+	}
+	for i := range objects {
+		object := &objects[i]
+		object.SetGroupVersionKind(gvk)
+		if backupObjectKeys[getPruneKey(gvk.Group, gvk.Kind, object.GetNamespace(), object.GetName())] ||
+			!pruneAllowed(object) {
+			continue
+		}
+		extraneous = append(extraneous, object)
+	}
+	return extraneous
+}
 
-		case "PersistentVolumeClaim":
-			// check if this PVC is a generic CSI one
-			var pvc v1.PersistentVolumeClaim
-			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pvc); err != nil {
-				return nil, fmt.Errorf("error converting PVC object: %v: %v", o, err)
-			}
+// pruneExtraneousResources deletes the objects in the destination namespaces
+// that aren't in the backup. Only the types of resources that are collected
+// for backups are checked. Objects owned by other objects, the ones that are
+// created in every namespace and kinds that are protected in the spec are
+// never deleted.
+func (a *ApplicationRestoreController) pruneExtraneousResources(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	backupObjectKeys map[string]bool,
+) error {
+	addRestoredPruneKeys(restore, backupObjectKeys)
 
-			// Find the matching PV for this PVC
-			pv, ok := pvcToPVMapping[getNamespacedPVCLocation(&pvc)]
-			if !ok {
-				log.ApplicationRestoreLog(restore).Debugf("failed to find PV for PVC %s during CSI volume skip. Will not skip volume", pvc.Name)
-				tempObjects = append(tempObjects, o)
-				continue
-			}
+	resourceTypes, err := a.resourceCollector.GetResourceTypes(restore.Spec.IncludeOptionalResourceTypes, true)
+	if err != nil {
+		return err
+	}
+	namespaces := make(map[string]bool)
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		namespaces[namespace] = true
+	}
 
-			// We have found a PV for this PVC. Check if it is a generic CSI PV
-			// that we do not already have native volume driver support for.
-			isGenericCSIPVC, err := isGenericCSIPersistentVolume(pv)
+	extraneous := make([]runtime.Unstructured, 0)
+	for _, resourceType := range resourceTypes {
+		if !resourceType.Namespaced || matchesKeyPattern(resourceType.Kind, restore.Spec.PruneProtectedKinds) {
+			continue
+		}
+		gvr := schema.GroupVersionResource{
+			Group:    resourceType.Group,
+			Version:  resourceType.Version,
+			Resource: resourceType.Name,
+		}
+		for namespace := range namespaces {
+			list, err := target.dynamicInterface.Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
 			if err != nil {
-				return nil, err
+				if errors.IsNotFound(err) || errors.IsForbidden(err) {
+					continue
+				}
+				return err
 			}
+			extraneous = append(extraneous, getExtraneousObjects(restore, backupObjectKeys, resourceType, list.Items)...)
+		}
+	}
+	if len(extraneous) == 0 {
+		return nil
+	}
+
+	log.ApplicationRestoreLog(restore).Infof("Pruning %v resources that aren't in the backup", len(extraneous))
+	if err := a.resourceCollector.DeleteResources(
+		target.dynamicInterface,
+		extraneous,
+		a.getDeleteOptions(restore)); err != nil {
+		return err
+	}
+	for _, object := range extraneous {
+		if err := a.updateResourceStatus(
+			restore,
+			object,
+			storkapi.ApplicationRestoreStatusSuccessful,
+			"Resource deleted since it isn't in the backup"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			// Only add this object if it's not a generic CSI PVC
-			if !isGenericCSIPVC {
-				tempObjects = append(tempObjects, o)
-			} else {
-				log.ApplicationRestoreLog(restore).Debugf("skipping CSI PVC in restore: %s", pvc.Name)
-			}
+// pruneAllowed checks if an object on the destination can be pruned. Objects
+// owned by other objects are managed by controllers, and some objects are
+// created in every namespace by the cluster.
+func pruneAllowed(object *unstructured.Unstructured) bool {
+	if len(object.GetOwnerReferences()) != 0 || resourcecollector.SkipResource(object.GetAnnotations()) {
+		return false
+	}
+	switch object.GetKind() {
+	case "ServiceAccount":
+		return object.GetName() != "default"
+	case "ConfigMap":
+		return object.GetName() != "kube-root-ca.crt"
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(object.Object, "type")
+		return secretType != string(v1.SecretTypeServiceAccountToken)
+	}
+	return true
+}
 
-		default:
-			// add all other objects
-			tempObjects = append(tempObjects, o)
+// setRestoreFinalStatus marks the restore as complete, with its status based
+// on the status of the restored resources
+func setRestoreFinalStatus(restore *storkapi.ApplicationRestore) {
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+	restore.Status.FinishTimestamp = metav1.Now()
+	restore.Status.Status = storkapi.ApplicationRestoreStatusSuccessful
+	restore.Status.Reason = "Volumes and resources were restored up successfully"
+	if restore.Spec.VolumesOnly {
+		restore.Status.Reason = "Volumes were restored successfully, other resources were skipped"
+	}
+	for _, resource := range restore.Status.Resources {
+		if resource.Status != storkapi.ApplicationRestoreStatusSuccessful &&
+			resource.Status != storkapi.ApplicationRestoreStatusSkipped {
+			restore.Status.Status = storkapi.ApplicationRestoreStatusPartialSuccess
+			restore.Status.Reason = "Volumes were restored successfully. Some existing resources were not replaced"
+			break
 		}
 	}
-
-	return tempObjects, nil
 }
 
-func (a *ApplicationRestoreController) applyResources(
+// settleResources checks the restored resources for failures until the
+// settle delay has passed since they were applied. Deferred
+// PodDisruptionBudgets are applied once their pods are ready. The restore is marked as
+// PartialSuccess as soon as any failures are found, otherwise its final
+// status is set once the delay has passed.
+func (a *ApplicationRestoreController) settleResources(
 	restore *storkapi.ApplicationRestore,
-	objects []runtime.Unstructured,
 ) error {
-	pvNameMappings, err := a.getPVNameMappings(restore, objects)
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	applied, err := a.applyPendingDisruptionBudgets(restore)
+	if err != nil {
+		log.ApplicationRestoreLog(restore).Warnf("Error applying PodDisruptionBudgets: %v", err)
+	}
+	problems, err := a.getSettleProblems(restore, target)
 	if err != nil {
 		return err
 	}
+	if len(problems) != 0 {
+		message := fmt.Sprintf("Volumes and resources were restored, but some resources failed to settle: %v",
+			strings.Join(problems, "; "))
+		log.ApplicationRestoreLog(restore).Warnf(message)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusPartialSuccess),
+			message)
+		restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+		restore.Status.FinishTimestamp = metav1.Now()
+		restore.Status.Status = storkapi.ApplicationRestoreStatusPartialSuccess
+		restore.Status.Reason = message
+	} else if time.Since(restore.Status.ResourcesAppliedTimestamp.Time) >= restore.Spec.SettleDelay.Duration {
+		setRestoreFinalStatus(restore)
+	} else if !applied {
+		return nil
+	}
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), restore)
+}
 
-	objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
-	tempObjects := make([]runtime.Unstructured, 0)
-	for _, o := range objects {
-		skip, err := a.resourceCollector.PrepareResourceForApply(
-			o,
-			objects,
-			objectMap,
-			restore.Spec.NamespaceMapping,
-			pvNameMappings,
-			restore.Spec.IncludeOptionalResourceTypes)
+// getSettleProblems returns the failures seen for the restored resources. These
+// are restored PVCs that have lost their volumes, and pods in the restored
+// namespaces with containers that are crash looping or can't pull their
+// images.
+func (a *ApplicationRestoreController) getSettleProblems(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+) ([]string, error) {
+	problems := make([]string, 0)
+	namespaces := make(map[string]bool)
+	for _, resource := range restore.Status.Resources {
+		if resource.Namespace == "" {
+			continue
+		}
+		namespaces[resource.Namespace] = true
+		if resource.Kind != "PersistentVolumeClaim" {
+			continue
+		}
+		pvc, err := target.coreOps.GetPersistentVolumeClaim(resource.Name, resource.Namespace)
 		if err != nil {
-			return err
+			if errors.IsNotFound(err) {
+				problems = append(problems, fmt.Sprintf("PVC %v/%v was deleted", resource.Namespace, resource.Name))
+				continue
+			}
+			return nil, err
 		}
-		if !skip {
-			tempObjects = append(tempObjects, o)
+		if pvc.Status.Phase == v1.ClaimLost {
+			problems = append(problems, fmt.Sprintf("PVC %v/%v lost its volume", resource.Namespace, resource.Name))
 		}
 	}
-	objects = tempObjects
-	// First delete the existing objects if they exist and replace policy is set
-	// to Delete
-	if restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
-		err = a.resourceCollector.DeleteResources(
-			a.dynamicInterface,
-			objects)
+
+	for namespace := range namespaces {
+		pods, err := target.coreOps.GetPods(namespace, nil)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			statuses := make([]v1.ContainerStatus, 0)
+			statuses = append(statuses, pod.Status.InitContainerStatuses...)
+			statuses = append(statuses, pod.Status.ContainerStatuses...)
+			for _, status := range statuses {
+				if status.State.Waiting == nil {
+					continue
+				}
+				switch status.State.Waiting.Reason {
+				case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull":
+					problems = append(problems, fmt.Sprintf("container %v in pod %v/%v is in %v",
+						status.Name, pod.Namespace, pod.Name, status.State.Waiting.Reason))
+				}
+			}
 		}
 	}
+	sort.Strings(problems)
+	return problems, nil
+}
 
-	// skip CSI PV/PVCs before applying
-	objects, err = a.removeCSIVolumesBeforeApply(restore, objects)
+// previewResources compares the resources in the backup with the objects in
+// the cluster for a dry run. The differences are recorded in the status of
+// each resource and nothing is restored.
+func (a *ApplicationRestoreController) previewResources(
+	restore *storkapi.ApplicationRestore,
+) error {
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
 	if err != nil {
+		log.ApplicationRestoreLog(restore).Errorf("Error getting backup: %v", err)
+		return err
+	}
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	objects, err := a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
 		return err
 	}
 
-	for _, o := range objects {
-		metadata, err := meta.Accessor(o)
-		if err != nil {
-			return err
-		}
-		objectType, err := meta.TypeAccessor(o)
-		if err != nil {
-			return err
-		}
-
-		log.ApplicationRestoreLog(restore).Infof("Applying %v %v/%v", objectType.GetKind(), metadata.GetNamespace(), metadata.GetName())
-		retained := false
+	// The objects are compared as they would be applied by the restore
+	objects, err = a.prepareResources(restore, objects, nil)
+	if err != nil {
+		return err
+	}
 
-		err = a.resourceCollector.ApplyResource(
-			a.dynamicInterface,
-			o)
-		if err != nil && errors.IsAlreadyExists(err) {
-			switch restore.Spec.ReplacePolicy {
-			case storkapi.ApplicationRestoreReplacePolicyDelete:
-				log.ApplicationRestoreLog(restore).Errorf("Error deleting %v %v during restore: %v", objectType.GetKind(), metadata.GetName(), err)
-			case storkapi.ApplicationRestoreReplacePolicyRetain:
-				log.ApplicationRestoreLog(restore).Warningf("Error deleting %v %v during restore, ReplacePolicy set to Retain: %v", objectType.GetKind(), metadata.GetName(), err)
-				retained = true
-				err = nil
+	missingNamespaces := make(map[string]bool)
+	rejected := 0
+	for _, o := range objects {
+		if restore.Spec.ServerDryRun {
+			validated, err := a.serverDryRunResource(restore, target, o, missingNamespaces)
+			if err != nil {
+				return err
+			}
+			if !validated {
+				rejected++
+				continue
 			}
 		}
-
+		diff, err := a.resourceCollector.DiffResource(target.dynamicInterface, o)
 		if err != nil {
 			if err := a.updateResourceStatus(
 				restore,
 				o,
 				storkapi.ApplicationRestoreStatusFailed,
-				fmt.Sprintf("Error applying resource: %v", err)); err != nil {
-				return err
-			}
-		} else if retained {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusRetained,
-				"Resource restore skipped as it was already present and ReplacePolicy is set to Retain"); err != nil {
+				fmt.Sprintf("Error comparing resource: %v", err)); err != nil {
 				return err
 			}
-		} else {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusSuccessful,
-				"Resource restored successfully"); err != nil {
-				return err
+			continue
+		}
+		reason := "Resource doesn't exist and would be created"
+		if diff.Exists {
+			reason = fmt.Sprintf("Resource exists with %v added, %v removed and %v changed fields",
+				len(diff.Added), len(diff.Removed), len(diff.Changed))
+		}
+		if err := a.updateResourceStatusWithDiff(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusSuccessful,
+			reason,
+			diff); err != nil {
+			return err
+		}
+	}
+
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+	restore.Status.FinishTimestamp = metav1.Now()
+	if rejected > 0 {
+		restore.Status.Status = storkapi.ApplicationRestoreStatusPartialSuccess
+		restore.Status.Reason = fmt.Sprintf("Dry run completed, %v resources were rejected by the apiserver. No volumes or resources were restored", rejected)
+	} else {
+		restore.Status.Status = storkapi.ApplicationRestoreStatusSuccessful
+		restore.Status.Reason = "Dry run completed, no volumes or resources were restored"
+	}
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), restore)
+}
+
+// serverDryRunResource validates a resource against the apiserver of the
+// destination with a server-side apply dry run. Returns false if the resource
+// was rejected, in which case its status has been updated with the error.
+// Resources in namespaces that don't exist yet can't be validated, since the
+// namespaces aren't created for a dry run, so they are reported as valid.
+func (a *ApplicationRestoreController) serverDryRunResource(
+	restore *storkapi.ApplicationRestore,
+	target *restoreTarget,
+	object runtime.Unstructured,
+	missingNamespaces map[string]bool,
+) (bool, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	namespace := metadata.GetNamespace()
+	if namespace != "" {
+		missing, ok := missingNamespaces[namespace]
+		if !ok {
+			if _, err := target.coreOps.GetNamespace(namespace); err != nil {
+				if !errors.IsNotFound(err) {
+					return false, err
+				}
+				missing = true
 			}
+			missingNamespaces[namespace] = missing
+		}
+		if missing {
+			return true, nil
 		}
 	}
-	return nil
+	if err := a.resourceCollector.DryRunApplyResource(target.dynamicInterface, object); err != nil {
+		if err := a.updateResourceStatus(
+			restore,
+			object,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Resource rejected by the apiserver: %v", err)); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
 }
 
-func (a *ApplicationRestoreController) restoreResources(
+// exportResources writes the resources that would be applied by the restore
+// as a multi-document YAML bundle to a ConfigMap or the backup location
+// instead of applying them
+func (a *ApplicationRestoreController) exportResources(
 	restore *storkapi.ApplicationRestore,
 ) error {
 	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
@@ -1018,61 +7071,162 @@ func (a *ApplicationRestoreController) restoreResources(
 		log.ApplicationRestoreLog(restore).Errorf("Error getting backup: %v", err)
 		return err
 	}
-
-	objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.Namespace)
+	objects, err := a.downloadResourceObjects(backup, restore.Spec.BackupLocation, restore.Namespace)
 	if err != nil {
 		log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
 		return err
 	}
-
-	// skip CSI PV/PVCs before applying
-	objects, err = a.removeCSIVolumesBeforeApply(restore, objects)
+	objects, err = a.prepareResources(restore, objects, nil)
 	if err != nil {
 		return err
 	}
 
-	if err := a.applyResources(restore, objects); err != nil {
+	var bundle bytes.Buffer
+	printer := &printers.YAMLPrinter{}
+	for _, o := range objects {
+		if err := printer.PrintObj(o, &bundle); err != nil {
+			return err
+		}
+	}
+
+	var exportPath string
+	switch restore.Spec.ExportType {
+	case storkapi.ApplicationRestoreExportConfigMap:
+		exportPath, err = a.exportToConfigMap(restore, bundle.Bytes())
+	case storkapi.ApplicationRestoreExportBackupLocation:
+		exportPath, err = a.exportToBackupLocation(restore, bundle.Bytes())
+	default:
+		err = fmt.Errorf("invalid export type %v", restore.Spec.ExportType)
+	}
+	if err != nil {
 		return err
 	}
 
+	for _, o := range objects {
+		if err := a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusSuccessful,
+			"Resource exported successfully"); err != nil {
+			return err
+		}
+	}
+	restore.Status.ExportPath = exportPath
 	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
 	restore.Status.FinishTimestamp = metav1.Now()
 	restore.Status.Status = storkapi.ApplicationRestoreStatusSuccessful
-	restore.Status.Reason = "Volumes and resources were restored up successfully"
-	for _, resource := range restore.Status.Resources {
-		if resource.Status != storkapi.ApplicationRestoreStatusSuccessful {
-			restore.Status.Status = storkapi.ApplicationRestoreStatusPartialSuccess
-			restore.Status.Reason = "Volumes were restored successfully. Some existing resources were not replaced"
-			break
+	restore.Status.Reason = fmt.Sprintf("Resources were exported to %v, no volumes or resources were restored", exportPath)
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), restore)
+}
+
+// exportToConfigMap writes the bundle to a ConfigMap owned by the restore so
+// that it is deleted along with it
+func (a *ApplicationRestoreController) exportToConfigMap(
+	restore *storkapi.ApplicationRestore,
+	bundle []byte,
+) (string, error) {
+	if len(bundle) > maxConfigMapDataSize {
+		return "", fmt.Errorf("exported resources are %v bytes which is too large for a ConfigMap, export to the backup location instead", len(bundle))
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restore.Name + "-export",
+			Namespace: restore.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(restore, storkapi.SchemeGroupVersion.WithKind(reflect.TypeOf(storkapi.ApplicationRestore{}).Name())),
+			},
+		},
+		Data: map[string]string{
+			exportObjectName: string(bundle),
+		},
+	}
+	if _, err := core.Instance().CreateConfigMap(configMap); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return "", err
+		}
+		if _, err := core.Instance().UpdateConfigMap(configMap); err != nil {
+			return "", err
 		}
 	}
+	return configMap.Name, nil
+}
 
-	// Add all CSI PVCs and PVs back into resources.
-	// CSI PVs are dynamically generated by the CSI controller for restore,
-	// so we need to get the new PV name after restore volumes finishes
-	if err := a.addCSIVolumeResources(restore); err != nil {
-		return err
+// exportToBackupLocation writes the bundle to the backup location of the
+// restore, encrypting it if an encryption key is set for the location
+func (a *ApplicationRestoreController) exportToBackupLocation(
+	restore *storkapi.ApplicationRestore,
+	bundle []byte,
+) (string, error) {
+	backupLocation, err := k8sutils.GetBackupLocation(restore.Spec.BackupLocation, restore.Namespace)
+	if err != nil {
+		return "", err
+	}
+	if err := objectstore.CheckEncryption(backupLocation); err != nil {
+		return "", err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return "", err
+	}
+	if backupLocation.Location.EncryptionKey != "" {
+		if bundle, err = crypto.Encrypt(bundle, backupLocation.Location.EncryptionKey); err != nil {
+			return "", err
+		}
 	}
 
-	restore.Status.LastUpdateTimestamp = metav1.Now()
-	if err := a.client.Update(context.TODO(), restore); err != nil {
-		return err
+	exportPath := filepath.Join(restore.Namespace, restore.Name, string(restore.UID), exportObjectName)
+	ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestWrite)
+	defer cancel()
+	if err := bucket.WriteAll(ctx, exportPath, bundle, nil); err != nil {
+		return "", err
 	}
+	return exportPath, nil
+}
 
-	return nil
+// filterVolumeObjects returns only the PVCs and PVs from the objects
+func filterVolumeObjects(objects []runtime.Unstructured) []runtime.Unstructured {
+	volumeObjects := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		switch o.GetObjectKind().GroupVersionKind().Kind {
+		case "PersistentVolumeClaim", "PersistentVolume":
+			volumeObjects = append(volumeObjects, o)
+		}
+	}
+	return volumeObjects
 }
 
-func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.ApplicationRestore) error {
+func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.ApplicationRestore, target *restoreTarget) error {
 	for _, vrInfo := range restore.Status.Volumes {
-		if vrInfo.DriverName != "csi" {
+		// Static volumes are applied from the backup along with the other
+		// resources, so their status has already been updated
+		if vrInfo.DriverName != "csi" || vrInfo.Status == storkapi.ApplicationRestoreStatusRetained || vrInfo.Static {
 			continue
 		}
 
 		// Update PV resource for this volume
-		pv, err := core.Instance().GetPersistentVolume(vrInfo.RestoreVolume)
+		pv, err := target.coreOps.GetPersistentVolume(vrInfo.RestoreVolume)
 		if err != nil {
 			return fmt.Errorf("failed to get PV %s: %v", vrInfo.RestoreVolume, err)
 		}
+		ns, ok := restore.Spec.NamespaceMapping[vrInfo.SourceNamespace]
+		if !ok {
+			ns = vrInfo.SourceNamespace
+		}
+		pvc, err := target.coreOps.GetPersistentVolumeClaim(vrInfo.PersistentVolumeClaim, ns)
+		if err != nil {
+			return fmt.Errorf("failed to get PVC %s/%s: %v", ns, vrInfo.PersistentVolumeClaim, err)
+		}
+		// Raw block volumes can't be used by the workloads if the volume
+		// was provisioned with a filesystem instead
+		status := storkapi.ApplicationRestoreStatusSuccessful
+		reason := "Resource restored successfully"
+		if getVolumeMode(pv.Spec.VolumeMode) != getVolumeMode(pvc.Spec.VolumeMode) {
+			status = storkapi.ApplicationRestoreStatusFailed
+			reason = fmt.Sprintf("Volume was restored with volume mode %v instead of %v",
+				getVolumeMode(pv.Spec.VolumeMode), getVolumeMode(pvc.Spec.VolumeMode))
+			log.ApplicationRestoreLog(restore).Warnf("PV %v for PVC %v/%v: %v", pv.Name, ns, pvc.Name, reason)
+		}
 		pvContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pv)
 		if err != nil {
 			return fmt.Errorf("failed to convert PV %s to unstructured: %v", vrInfo.RestoreVolume, err)
@@ -1087,20 +7241,12 @@ func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.A
 		if err := a.updateResourceStatus(
 			restore,
 			pvObj,
-			storkapi.ApplicationRestoreStatusSuccessful,
-			"Resource restored successfully"); err != nil {
+			status,
+			reason); err != nil {
 			return err
 		}
 
 		// Update PVC resource for this volume
-		ns, ok := restore.Spec.NamespaceMapping[vrInfo.SourceNamespace]
-		if !ok {
-			ns = vrInfo.SourceNamespace
-		}
-		pvc, err := core.Instance().GetPersistentVolumeClaim(vrInfo.PersistentVolumeClaim, ns)
-		if err != nil {
-			return fmt.Errorf("failed to get PVC %s/%s: %v", ns, vrInfo.PersistentVolumeClaim, err)
-		}
 		pvcContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pvc)
 		if err != nil {
 			return fmt.Errorf("failed to convert PVC %s to unstructured: %v", vrInfo.RestoreVolume, err)
@@ -1115,8 +7261,8 @@ func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.A
 		if err := a.updateResourceStatus(
 			restore,
 			pvcObj,
-			storkapi.ApplicationRestoreStatusSuccessful,
-			"Resource restored successfully"); err != nil {
+			status,
+			reason); err != nil {
 			return err
 		}
 	}
@@ -1125,20 +7271,104 @@ func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.A
 }
 
 func (a *ApplicationRestoreController) cleanupRestore(restore *storkapi.ApplicationRestore) error {
-	drivers := a.getDriversForRestore(restore)
+	_, driverRestore := splitAdoptedVolumes(restore)
+	drivers := a.getDriversForRestore(driverRestore)
 	for driverName := range drivers {
 		driver, err := volume.Get(driverName)
 		if err != nil {
+			// Don't block the removal of the finalizer if the driver was
+			// uninstalled, there is nothing to cancel without it
+			if _, ok := err.(*storkerrors.ErrNotFound); ok {
+				message := fmt.Sprintf("Skipping cancelling the restore for volume driver %v since it is not installed", driverName)
+				log.ApplicationRestoreLog(restore).Warnf(message)
+				a.recorder.Event(restore,
+					v1.EventTypeWarning,
+					string(storkapi.ApplicationRestoreStatusFailed),
+					message)
+				continue
+			}
 			return fmt.Errorf("get %s driver: %s", driverName, err)
 		}
-		if err = driver.CancelRestore(restore); err != nil {
+		if err = driver.CancelRestore(driverRestore); err != nil {
 			return fmt.Errorf("cancel restore: %s", err)
 		}
 	}
+	if err := a.restorePodSecurity(restore); err != nil {
+		return fmt.Errorf("restore pod security: %s", err)
+	}
+	// The conversions and failure policies are set back even if the webhook
+	// services weren't restored, so that nothing is left relaxed once the
+	// restore is removed
+	if len(restore.Status.RelaxedWebhookConfigurations) != 0 {
+		if err := a.restoreWebhookFailurePolicies(restore); err != nil {
+			return fmt.Errorf("restore webhook failure policies: %s", err)
+		}
+	}
+	if len(restore.Status.RelaxedCRDConversions) != 0 {
+		if err := a.restoreCRDConversions(restore, true); err != nil {
+			return fmt.Errorf("restore CRD conversions: %s", err)
+		}
+	}
+	// Restores that completed are rolled back in the Final stage if they
+	// failed, the resources are kept otherwise
+	if restore.Spec.RollbackOnCancel && !restore.Status.RolledBack &&
+		restore.Status.Stage != storkapi.ApplicationRestoreStageFinal {
+		if err := a.rollbackResources(restore); err != nil {
+			return fmt.Errorf("rollback resources: %s", err)
+		}
+	}
+	return nil
+}
+
+// rollbackResources deletes the resources that were created by the restore.
+// Resources that existed before the restore aren't marked as created, so they
+// are left as is. ServiceAccounts and ClusterRoleBindings are merged instead
+// of being replaced, so they are never deleted.
+func (a *ApplicationRestoreController) rollbackResources(restore *storkapi.ApplicationRestore) error {
+	target, err := a.getRestoreTarget(restore)
+	if err != nil {
+		return err
+	}
+	objects := make([]runtime.Unstructured, 0)
+	for _, resource := range restore.Status.Resources {
+		if !resource.Created {
+			continue
+		}
+		group := resource.Group
+		if group == "core" {
+			group = ""
+		}
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   group,
+			Version: resource.Version,
+			Kind:    resource.Kind,
+		})
+		object.SetNamespace(resource.Namespace)
+		object.SetName(resource.Name)
+		if resource.GeneratedName != "" {
+			object.SetName(resource.GeneratedName)
+		}
+		objects = append(objects, object)
+	}
+	if len(objects) != 0 {
+		log.ApplicationRestoreLog(restore).Infof("Rolling back %v resources created by the restore", len(objects))
+		if err := a.resourceCollector.DeleteResources(
+			target.dynamicInterface,
+			objects,
+			a.getDeleteOptions(restore)); err != nil {
+			return err
+		}
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(restore.Status.Status),
+			fmt.Sprintf("Deleted %v resources created by the restore", len(objects)))
+	}
+	restore.Status.RolledBack = true
 	return nil
 }
 
-func (a *ApplicationRestoreController) createCRD() error {
+func (a *ApplicationRestoreController) createCRD(config *rest.Config) error {
 	resource := apiextensions.CustomResource{
 		Name:    storkapi.ApplicationRestoreResourceName,
 		Plural:  storkapi.ApplicationRestoreResourcePlural,
@@ -1147,10 +7377,5 @@ func (a *ApplicationRestoreController) createCRD() error {
 		Scope:   apiextensionsv1beta1.NamespaceScoped,
 		Kind:    reflect.TypeOf(storkapi.ApplicationRestore{}).Name(),
 	}
-	err := apiextensions.Instance().CreateCRD(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-
-	return apiextensions.Instance().ValidateCRD(resource, validateCRDTimeout, validateCRDInterval)
+	return k8sutils.CreateCRD(config, resource)
 }
@@ -0,0 +1,177 @@
+// +build unittest
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newApplyHookObject(kind, name string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion("v1")
+	object.SetKind(kind)
+	object.SetNamespace("dest")
+	object.SetName(name)
+	return object
+}
+
+// newApplyHookServer returns a server that adds a label to the objects posted
+// to it, or responds with the object returned by modify if it is set
+func newApplyHookServer(t *testing.T, calls *int32, modify func(*unstructured.Unstructured)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		object := &unstructured.Unstructured{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&object.Object))
+		object.SetLabels(map[string]string{"hooked": "true"})
+		if modify != nil {
+			modify(object)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(object.Object))
+	}))
+}
+
+func TestApplyHookMatches(t *testing.T) {
+	configMapHook := storkapi.ApplicationRestoreApplyHook{
+		GroupVersionKind: metav1.GroupVersionKind{Group: "core", Kind: "ConfigMap"},
+	}
+	secretHook := storkapi.ApplicationRestoreApplyHook{
+		GroupVersionKind: metav1.GroupVersionKind{Kind: "Secret"},
+	}
+	allowedSecretHook := secretHook
+	allowedSecretHook.AllowSecrets = true
+
+	configMap := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	secret := schema.GroupVersionKind{Version: "v1", Kind: "Secret"}
+	require.True(t, applyHookMatches(configMapHook, configMap))
+	require.False(t, applyHookMatches(configMapHook, secret))
+	require.False(t, applyHookMatches(secretHook, secret), "Secrets shouldn't be sent unless allowed")
+	require.True(t, applyHookMatches(allowedSecretHook, secret))
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	a := &ApplicationRestoreController{restoreAdminNamespace: "admin"}
+	restore := &storkapi.ApplicationRestore{ObjectMeta: metav1.ObjectMeta{Namespace: "app"}}
+	adminRestore := &storkapi.ApplicationRestore{ObjectMeta: metav1.ObjectMeta{Namespace: "admin"}}
+
+	tests := []struct {
+		restore *storkapi.ApplicationRestore
+		url     string
+		valid   bool
+	}{
+		{restore, "http://hook.app.svc/mutate", true},
+		{restore, "https://hook.app.svc.cluster.local:8443/mutate", true},
+		{restore, "http://hook.other.svc/mutate", false},
+		{adminRestore, "http://hook.other.svc/mutate", true},
+		{restore, "http://169.254.169.254/latest/meta-data", false},
+		{restore, "http://example.com/mutate", false},
+		{restore, "http://hook.app.svc.example.com/mutate", false},
+		{restore, "file://hook.app.svc/mutate", false},
+	}
+	for _, test := range tests {
+		err := a.validateWebhookURL(test.restore, test.url)
+		if test.valid {
+			require.NoError(t, err, test.url)
+		} else {
+			require.Error(t, err, test.url)
+		}
+	}
+}
+
+func TestRunApplyHook(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	var calls int32
+
+	server := newApplyHookServer(t, &calls, nil)
+	defer server.Close()
+	hook := storkapi.ApplicationRestoreApplyHook{URL: server.URL}
+	object := newApplyHookObject("ConfigMap", "config")
+	require.NoError(t, a.runApplyHook(hook, object))
+	require.Equal(t, "true", object.GetLabels()["hooked"])
+
+	// Responses can't change the type or name of the object
+	for _, modify := range []func(*unstructured.Unstructured){
+		func(o *unstructured.Unstructured) { o.SetAPIVersion("v2") },
+		func(o *unstructured.Unstructured) { o.SetKind("Secret") },
+		func(o *unstructured.Unstructured) { o.SetName("other") },
+		func(o *unstructured.Unstructured) { o.SetNamespace("other") },
+	} {
+		server := newApplyHookServer(t, &calls, modify)
+		object := newApplyHookObject("ConfigMap", "config")
+		require.Error(t, a.runApplyHook(storkapi.ApplicationRestoreApplyHook{URL: server.URL}, object))
+		require.Empty(t, object.GetLabels(), "Object shouldn't be updated from an invalid response")
+		server.Close()
+	}
+}
+
+func TestRunApplyHooksForObjectCachesResults(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	var calls int32
+	server := newApplyHookServer(t, &calls, nil)
+	defer server.Close()
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", UID: "uid"},
+		Spec: storkapi.ApplicationRestoreSpec{
+			ApplyHooks: []storkapi.ApplicationRestoreApplyHook{{
+				GroupVersionKind: metav1.GroupVersionKind{Kind: "ConfigMap"},
+				URL:              server.URL,
+			}},
+		},
+	}
+
+	// The hook is only called once for an object even if it is applied
+	// again
+	for i := 0; i < 3; i++ {
+		object := newApplyHookObject("ConfigMap", "config")
+		require.NoError(t, a.runApplyHooksForObject(restore, object))
+		require.Equal(t, "true", object.GetLabels()["hooked"])
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// Other objects and types that don't match aren't affected
+	object := newApplyHookObject("ConfigMap", "other")
+	require.NoError(t, a.runApplyHooksForObject(restore, object))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	object = newApplyHookObject("Secret", "config")
+	require.NoError(t, a.runApplyHooksForObject(restore, object))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	require.Empty(t, object.GetLabels())
+
+	// Ignored failures aren't retried either
+	server.Close()
+	restore.Spec.ApplyHooks[0].IgnoreFailure = true
+	object = newApplyHookObject("ConfigMap", "failed")
+	require.NoError(t, a.runApplyHooksForObject(restore, object))
+	require.Empty(t, object.GetLabels())
+	_, ok := a.getApplyHookResult(restore, "0//ConfigMap/dest/failed")
+	require.True(t, ok)
+
+	restore.Spec.ApplyHooks[0].IgnoreFailure = false
+	object = newApplyHookObject("ConfigMap", "unreachable")
+	require.Error(t, a.runApplyHooksForObject(restore, object))
+}
+
+func TestRunApplyHooksRejectsExternalURL(t *testing.T) {
+	a := &ApplicationRestoreController{restoreAdminNamespace: "admin"}
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "app", UID: "uid"},
+		Spec: storkapi.ApplicationRestoreSpec{
+			ApplyHooks: []storkapi.ApplicationRestoreApplyHook{{
+				GroupVersionKind: metav1.GroupVersionKind{Kind: "ConfigMap"},
+				URL:              "http://169.254.169.254/",
+				IgnoreFailure:    true,
+			}},
+		},
+	}
+	err := a.runApplyHooks(restore, nil)
+	require.Error(t, err)
+}
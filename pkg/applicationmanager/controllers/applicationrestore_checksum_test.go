@@ -0,0 +1,53 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRecordChecksumsRenamed(t *testing.T) {
+	a := &ApplicationRestoreController{
+		targets: map[string]*cachedRestoreTarget{"": {target: &restoreTarget{}}},
+	}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{"source": "dest"},
+			SanitizeNames:    true,
+		},
+	}
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{
+		"data": map[string]interface{}{"key": "value"},
+	})
+	configMap.SetNamespace("source")
+	configMap.SetName("Config_Map")
+	checksum, err := getObjectChecksum(configMap)
+	require.NoError(t, err)
+
+	checksums, err := getObjectChecksums(restore, []runtime.Unstructured{configMap})
+	require.NoError(t, err)
+	objects, err := a.prepareResources(restore, []runtime.Unstructured{configMap.DeepCopy()}, checksums)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	sanitizedName := objects[0].(*unstructured.Unstructured).GetName()
+	require.NotEqual(t, "Config_Map", sanitizedName)
+
+	// The checksum is recorded for the resource with the name it was
+	// restored with
+	restore.Status.Resources = []*storkapi.ApplicationRestoreResourceInfo{{
+		ObjectInfo: storkapi.ObjectInfo{
+			Name:             sanitizedName,
+			Namespace:        "dest",
+			GroupVersionKind: metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		},
+		Status: storkapi.ApplicationRestoreStatusSuccessful,
+	}}
+	recordChecksums(restore, checksums)
+	require.Equal(t, checksum, restore.Status.Resources[0].Checksum)
+}
@@ -0,0 +1,72 @@
+// +build unittest
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// withGenerateName sets the generateName on the object
+func withGenerateName(object *unstructured.Unstructured, generateName string) *unstructured.Unstructured {
+	object.SetGenerateName(generateName)
+	return object
+}
+
+func TestGenerateNameAllowed(t *testing.T) {
+	tests := []struct {
+		object  *unstructured.Unstructured
+		allowed bool
+	}{
+		{withGenerateName(newNamedObject("v1", "ConfigMap", "dest", "config-abcde"), "config-"), true},
+		{newNamedObject("v1", "ConfigMap", "dest", "config"), false},
+		{withGenerateName(newNamedObject("v1", "PersistentVolumeClaim", "dest", "pvc-abcde"), "pvc-"), false},
+		{withGenerateName(newNamedObject("v1", "PersistentVolume", "dest", "pv-abcde"), "pv-"), false},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.allowed, generateNameAllowed(test.object, test.object),
+			"Unexpected result for %v %v", test.object.GetKind(), test.object.GetName())
+	}
+}
+
+func TestGetGeneratedName(t *testing.T) {
+	restore := &storkapi.ApplicationRestore{}
+	restore.UID = "restore-uid"
+	object := withGenerateName(newNamedObject("v1", "ConfigMap", "dest", "config-abcde"), "config-")
+
+	// The name is the same each time the object is applied
+	name := getGeneratedName(restore, object, object)
+	require.True(t, strings.HasPrefix(name, "config-"), "Unexpected generated name %v", name)
+	require.Len(t, name, len("config-")+generatedNameSuffixLength)
+	require.Equal(t, name, getGeneratedName(restore, object, object))
+
+	// Different objects and restores get different names
+	other := withGenerateName(newNamedObject("v1", "ConfigMap", "dest", "config-fghij"), "config-")
+	require.NotEqual(t, name, getGeneratedName(restore, other, other))
+	otherRestore := restore.DeepCopy()
+	otherRestore.UID = "other-uid"
+	require.NotEqual(t, name, getGeneratedName(otherRestore, object, object))
+
+	// Long generateNames are truncated
+	long := withGenerateName(newNamedObject("v1", "ConfigMap", "dest", "config"), strings.Repeat("a", 70))
+	require.Len(t, getGeneratedName(restore, long, long), validation.DNS1123LabelMaxLength)
+
+	// The name from the status is reused
+	restore.Status.Resources = []*storkapi.ApplicationRestoreResourceInfo{
+		{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:             "config-abcde",
+				Namespace:        "dest",
+				GroupVersionKind: metav1.GroupVersionKind{Group: "core", Version: "v1", Kind: "ConfigMap"},
+			},
+			GeneratedName: "config-12345",
+		},
+	}
+	require.Equal(t, "config-12345", getGeneratedName(restore, object, object))
+}
@@ -0,0 +1,75 @@
+// +build unittest
+
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeliverNotificationsInOrder(t *testing.T) {
+	var lock sync.Mutex
+	stages := make([]storkapi.ApplicationRestoreStageType, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := &RestoreNotification{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(notification))
+		// Slow down the first notification so that a later one would
+		// overtake it if they weren't serialized
+		if notification.Stage == storkapi.ApplicationRestoreStageInitial {
+			time.Sleep(100 * time.Millisecond)
+		}
+		lock.Lock()
+		stages = append(stages, notification.Stage)
+		lock.Unlock()
+	}))
+	defer server.Close()
+
+	a := &ApplicationRestoreController{webhookClient: http.DefaultClient}
+	expected := []storkapi.ApplicationRestoreStageType{
+		storkapi.ApplicationRestoreStageInitial,
+		storkapi.ApplicationRestoreStageVolumes,
+		storkapi.ApplicationRestoreStageApplications,
+		storkapi.ApplicationRestoreStageFinal,
+	}
+	a.notificationQueues = map[string][]*pendingNotification{}
+	for _, stage := range expected {
+		data, err := json.Marshal(&RestoreNotification{Stage: stage})
+		require.NoError(t, err)
+		a.notificationQueues["uid"] = append(a.notificationQueues["uid"], &pendingNotification{
+			logger:   logrus.NewEntry(logrus.New()),
+			webhooks: []string{server.URL},
+			data:     data,
+		})
+	}
+	a.deliverNotifications("uid")
+
+	require.Equal(t, expected, stages)
+	_, ok := a.notificationQueues["uid"]
+	require.False(t, ok, "Queue should be removed once it is empty")
+}
+
+func TestNotifyWebhooksSkipsExternalURLs(t *testing.T) {
+	a := &ApplicationRestoreController{webhookClient: http.DefaultClient}
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "app", UID: "uid"},
+		Spec: storkapi.ApplicationRestoreSpec{
+			NotificationWebhooks: []string{
+				"http://169.254.169.254/latest/meta-data",
+				"http://notify.other.svc/",
+			},
+		},
+	}
+	a.notifyWebhooks(restore, storkapi.ApplicationRestoreStageInitial)
+	a.notificationLock.Lock()
+	defer a.notificationLock.Unlock()
+	require.Empty(t, a.notificationQueues)
+}
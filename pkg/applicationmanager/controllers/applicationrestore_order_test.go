@@ -0,0 +1,52 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// withRestoreOrder sets the restore order annotation on the object
+func withRestoreOrder(object *unstructured.Unstructured, order string) *unstructured.Unstructured {
+	object.SetAnnotations(map[string]string{StorkRestoreOrderAnnotation: order})
+	return object
+}
+
+func TestOrderObjectsForApply(t *testing.T) {
+	objects := []runtime.Unstructured{
+		withRestoreOrder(newNamedObject("v1", "PodDisruptionBudget", "ns", "pdb"), "-1"),
+		newNamedObject("v1", "ResourceQuota", "ns", "quota"),
+		newNamedObject("v1", "HorizontalPodAutoscaler", "ns", "hpa"),
+		withRestoreOrder(newNamedObject("v1", "Deployment", "ns", "late"), "10"),
+		newNamedObject("v1", "Deployment", "ns", "app"),
+		withRestoreOrder(newNamedObject("v1", "Service", "ns", "early"), "-5"),
+		withRestoreOrder(newNamedObject("v1", "PodDisruptionBudget", "ns", "pdb-first"), "-2"),
+	}
+	ordered := orderObjectsForApply(objects)
+	// Budgets and quotas stay after the workloads even if they have a lower
+	// order
+	require.Equal(t, []string{"early", "app", "hpa", "late", "pdb-first", "pdb", "quota"}, getObjectNames(ordered))
+}
+
+func TestSplitApplyStages(t *testing.T) {
+	objects := []runtime.Unstructured{
+		withRestoreOrder(newNamedObject("v1", "Service", "ns1", "first"), "-1"),
+		withRestoreOrder(newNamedObject("v1", "Service", "ns2", "second"), "-1"),
+		newNamedObject("v1", "Deployment", "ns1", "app1"),
+		withRestoreOrder(newNamedObject("v1", "Deployment", "ns2", "app2"), "invalid"),
+		withRestoreOrder(newNamedObject("v1", "Deployment", "ns2", "last"), "1"),
+		newNamedObject("v1", "PodDisruptionBudget", "ns1", "pdb"),
+	}
+	stages := splitApplyStages(objects)
+	require.Len(t, stages, 4)
+	require.Equal(t, []string{"first", "second"}, getObjectNames(stages[0]))
+	require.Equal(t, []string{"app1", "app2"}, getObjectNames(stages[1]))
+	require.Equal(t, []string{"last"}, getObjectNames(stages[2]))
+	require.Equal(t, []string{"pdb"}, getObjectNames(stages[3]))
+
+	require.Empty(t, splitApplyStages(nil))
+}
@@ -0,0 +1,71 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGetPlacedVolumeInfos(t *testing.T) {
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			VolumePlacement: []storkapi.ApplicationRestoreVolumePlacement{
+				{PersistentVolumeClaims: []string{"src/placed"}, Zones: []string{"zone-a", "zone-b"}},
+			},
+		},
+	}
+	vInfos := []*storkapi.ApplicationBackupVolumeInfo{
+		{Namespace: "src", PersistentVolumeClaim: "placed", Options: map[string]string{"key": "value"}},
+		{Namespace: "src", PersistentVolumeClaim: "other"},
+	}
+	placed := getPlacedVolumeInfos(restore, vInfos)
+	require.Len(t, placed, 2)
+	require.Equal(t, "zone-a,zone-b", placed[0].Options[volume.RestoreZonesOption])
+	require.Equal(t, "value", placed[0].Options["key"])
+	require.NotContains(t, vInfos[0].Options, volume.RestoreZonesOption, "Options of the backup were changed")
+	require.True(t, vInfos[1] == placed[1], "Volume without placement was copied")
+}
+
+func TestPreparePVPlacement(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{}
+	restore.Status.Volumes = []*storkapi.ApplicationRestoreVolumeInfo{
+		{SourceVolume: "placed-pv", PlacedZones: []string{"zone-a"}},
+		{SourceVolume: "unplaced-pv"},
+	}
+	sourceAffinity := map[string]interface{}{"required": "source"}
+
+	tests := []struct {
+		name     string
+		affinity bool
+	}{
+		{name: "placed-pv", affinity: true},
+		{name: "unplaced-pv", affinity: false},
+		{name: "unknown-pv", affinity: false},
+	}
+	for _, test := range tests {
+		object := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		require.NoError(t, unstructured.SetNestedMap(object.Object, sourceAffinity, "spec", "nodeAffinity"))
+		require.NoError(t, a.preparePVPlacement(restore, object, test.name), "Error preparing %v", test.name)
+		values, found, err := unstructured.NestedSlice(object.Object, "spec", "nodeAffinity", "required",
+			"nodeSelectorTerms")
+		if !test.affinity {
+			// The affinity is only replaced if the driver placed the volume
+			affinity, _, _ := unstructured.NestedMap(object.Object, "spec", "nodeAffinity")
+			require.Equal(t, sourceAffinity, affinity, "Affinity of %v was changed", test.name)
+			continue
+		}
+		require.NoError(t, err)
+		require.True(t, found, "Affinity of %v wasn't set", test.name)
+		require.Len(t, values, 1)
+		expressions, _, _ := unstructured.NestedSlice(values[0].(map[string]interface{}), "matchExpressions")
+		require.Len(t, expressions, 1)
+		zones, _, _ := unstructured.NestedStringSlice(expressions[0].(map[string]interface{}), "values")
+		require.Equal(t, []string{"zone-a"}, zones)
+	}
+}
@@ -0,0 +1,55 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newPodSecurityRestore(uid string) *storkapi.ApplicationRestore {
+	restore := &storkapi.ApplicationRestore{}
+	restore.UID = types.UID(uid)
+	return restore
+}
+
+func TestRelaxNamespacePodSecurity(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+	}{
+		{name: "no labels"},
+		{name: "no level", labels: map[string]string{"app": "test"}},
+		{name: "restricted", labels: map[string]string{k8sutils.PodSecurityEnforceLabel: k8sutils.PodSecurityLevelRestricted}},
+	}
+	for _, test := range tests {
+		ns := &v1.Namespace{}
+		ns.Labels = test.labels
+		original := ns.Labels[k8sutils.PodSecurityEnforceLabel]
+		first := newPodSecurityRestore("first")
+		second := newPodSecurityRestore("second")
+
+		relaxNamespacePodSecurity(first, ns)
+		require.Equal(t, k8sutils.PodSecurityLevelPrivileged, ns.Labels[k8sutils.PodSecurityEnforceLabel], test.name)
+		require.Equal(t, original, getOriginalPodSecurityLevel(ns), test.name)
+
+		// A concurrent restore records the same original level
+		relaxNamespacePodSecurity(second, ns)
+		require.Equal(t, original, ns.Annotations[getPodSecurityAnnotation(second)], test.name)
+
+		// The level is only set back once both restores are done
+		require.True(t, unrelaxNamespacePodSecurity(first, ns), test.name)
+		require.Equal(t, k8sutils.PodSecurityLevelPrivileged, ns.Labels[k8sutils.PodSecurityEnforceLabel], test.name)
+		require.False(t, unrelaxNamespacePodSecurity(first, ns), test.name)
+		require.True(t, unrelaxNamespacePodSecurity(second, ns), test.name)
+		level, ok := ns.Labels[k8sutils.PodSecurityEnforceLabel]
+		require.Equal(t, original != "", ok, test.name)
+		require.Equal(t, original, level, test.name)
+		require.Empty(t, ns.Annotations, test.name)
+	}
+}
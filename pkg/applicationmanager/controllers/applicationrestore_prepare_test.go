@@ -0,0 +1,688 @@
+// +build unittest
+
+package controllers
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/resourcecollector"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+)
+
+func newPrepareObject(apiVersion, kind string, content map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: content}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetNamespace("ns")
+	object.SetName("test")
+	return object
+}
+
+// newNamedObject returns an object without any content in the namespace with
+// the name
+func newNamedObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	object := newPrepareObject(apiVersion, kind, map[string]interface{}{})
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	return object
+}
+
+// getObjectNames returns the names of the objects in order
+func getObjectNames(objects []runtime.Unstructured) []string {
+	names := make([]string, 0, len(objects))
+	for _, o := range objects {
+		names = append(names, o.(*unstructured.Unstructured).GetName())
+	}
+	return names
+}
+
+func newPrepareDeployment(podSpec map[string]interface{}) *unstructured.Unstructured {
+	return newPrepareObject("apps/v1", "Deployment", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{},
+				"spec":     podSpec,
+			},
+		},
+	})
+}
+
+func TestPrepareMeshSidecars(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			StripMeshSidecars: []storkapi.ApplicationRestoreMeshType{storkapi.ApplicationRestoreMeshIstio},
+		},
+	}
+	object := newPrepareDeployment(map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app"},
+			map[string]interface{}{"name": "istio-proxy"},
+		},
+		"initContainers": []interface{}{
+			map[string]interface{}{"name": "istio-init"},
+		},
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "data"},
+			map[string]interface{}{"name": "istio-envoy"},
+		},
+	})
+	require.NoError(t, unstructured.SetNestedStringMap(object.Object,
+		map[string]string{"sidecar.istio.io/status": "{}", "app": "keep"}, "spec", "template", "metadata", "annotations"))
+	require.NoError(t, a.prepareMeshSidecars(restore, object))
+
+	containers, _, _ := unstructured.NestedSlice(object.Object, "spec", "template", "spec", "containers")
+	require.Equal(t, []interface{}{map[string]interface{}{"name": "app"}}, containers)
+	initContainers, _, _ := unstructured.NestedSlice(object.Object, "spec", "template", "spec", "initContainers")
+	require.Empty(t, initContainers)
+	volumes, _, _ := unstructured.NestedSlice(object.Object, "spec", "template", "spec", "volumes")
+	require.Equal(t, []interface{}{map[string]interface{}{"name": "data"}}, volumes)
+	annotations, _, _ := unstructured.NestedStringMap(object.Object, "spec", "template", "metadata", "annotations")
+	require.Equal(t, map[string]string{"app": "keep"}, annotations)
+
+	// Objects without a pod template aren't changed
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	require.NoError(t, a.prepareMeshSidecars(restore, configMap))
+
+	restore.Spec.StripMeshSidecars = []storkapi.ApplicationRestoreMeshType{"invalid"}
+	require.Error(t, a.prepareMeshSidecars(restore, object), "Expected error for invalid mesh type")
+}
+
+func newNodeSelectorTerm(keys ...string) interface{} {
+	expressions := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		expressions = append(expressions, map[string]interface{}{
+			"key":      key,
+			"operator": "Exists",
+		})
+	}
+	return map[string]interface{}{"matchExpressions": expressions}
+}
+
+func TestPrepareCNIAnnotations(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			StripCNIAnnotations:      []string{"k8s.v1.cni.cncf.io/*"},
+			StripCNINodeAffinityKeys: []string{"cni/*"},
+		},
+	}
+	object := newPrepareDeployment(map[string]interface{}{
+		"nodeSelector": map[string]interface{}{
+			"cni/network": "a",
+			"zone":        "z1",
+		},
+		"affinity": map[string]interface{}{
+			"nodeAffinity": map[string]interface{}{
+				"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+					"nodeSelectorTerms": []interface{}{
+						newNodeSelectorTerm("cni/network", "zone"),
+						newNodeSelectorTerm("disk"),
+					},
+				},
+				"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+					map[string]interface{}{"weight": int64(1), "preference": newNodeSelectorTerm("cni/network")},
+					map[string]interface{}{"weight": int64(2), "preference": newNodeSelectorTerm("zone")},
+				},
+			},
+		},
+	})
+	require.NoError(t, unstructured.SetNestedStringMap(object.Object,
+		map[string]string{"k8s.v1.cni.cncf.io/networks": "net", "app": "keep"}, "spec", "template", "metadata", "annotations"))
+	require.NoError(t, a.prepareCNIAnnotations(restore, object))
+
+	annotations, _, _ := unstructured.NestedStringMap(object.Object, "spec", "template", "metadata", "annotations")
+	require.Equal(t, map[string]string{"app": "keep"}, annotations)
+	nodeSelector, _, _ := unstructured.NestedStringMap(object.Object, "spec", "template", "spec", "nodeSelector")
+	require.Equal(t, map[string]string{"zone": "z1"}, nodeSelector)
+	terms, _, _ := unstructured.NestedSlice(object.Object, "spec", "template", "spec", "affinity",
+		"nodeAffinity", "requiredDuringSchedulingIgnoredDuringExecution", "nodeSelectorTerms")
+	require.Len(t, terms, 2)
+	expressions, _, _ := unstructured.NestedSlice(terms[0].(map[string]interface{}), "matchExpressions")
+	require.Len(t, expressions, 1)
+	require.Equal(t, "zone", expressions[0].(map[string]interface{})["key"])
+	preferred, _, _ := unstructured.NestedSlice(object.Object, "spec", "template", "spec", "affinity",
+		"nodeAffinity", "preferredDuringSchedulingIgnoredDuringExecution")
+	require.Len(t, preferred, 1)
+	require.Equal(t, int64(2), preferred[0].(map[string]interface{})["weight"])
+
+	// A required term without any requirements left matches all nodes, so
+	// the required affinity is removed instead of restricting the pod to
+	// the remaining terms
+	pod := newPrepareObject("v1", "Pod", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"affinity": map[string]interface{}{
+				"nodeAffinity": map[string]interface{}{
+					"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+						"nodeSelectorTerms": []interface{}{
+							newNodeSelectorTerm("cni/network"),
+							newNodeSelectorTerm("disk"),
+						},
+					},
+				},
+			},
+		},
+	})
+	require.NoError(t, a.prepareCNIAnnotations(restore, pod))
+	_, found, err := unstructured.NestedMap(pod.Object, "spec", "affinity", "nodeAffinity")
+	require.NoError(t, err)
+	require.False(t, found, "Expected node affinity to be removed")
+
+	// Objects without a pod template aren't changed
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	require.NoError(t, a.prepareCNIAnnotations(restore, configMap))
+	require.Equal(t, newPrepareObject("v1", "ConfigMap", map[string]interface{}{}), configMap)
+}
+
+func TestPrepareServiceAnnotations(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			StripServiceAnnotations:  []string{"service.beta.kubernetes.io/*"},
+			ServiceAnnotationMapping: map[string]string{"example.com/lb": "internal"},
+		},
+	}
+	tests := []struct {
+		name        string
+		serviceType string
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			name:        "load balancer",
+			serviceType: "LoadBalancer",
+			annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb", "app": "keep"},
+			expected:    map[string]string{"app": "keep", "example.com/lb": "internal"},
+		},
+		{
+			name:        "load balancer without annotations",
+			serviceType: "LoadBalancer",
+			expected:    map[string]string{"example.com/lb": "internal"},
+		},
+		{
+			name:        "cluster IP",
+			serviceType: "ClusterIP",
+			annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+			expected:    map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+		},
+	}
+	for _, test := range tests {
+		object := newPrepareObject("v1", "Service", map[string]interface{}{
+			"spec": map[string]interface{}{"type": test.serviceType},
+		})
+		object.SetAnnotations(test.annotations)
+		require.NoError(t, a.prepareServiceAnnotations(restore, object), test.name)
+		require.Equal(t, test.expected, object.GetAnnotations(), test.name)
+	}
+}
+
+func TestPrepareIngress(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			IngressClassMapping:     map[string]string{"nginx": "traefik"},
+			StripIngressAnnotations: []string{"nginx.ingress.kubernetes.io/*"},
+			KeepIngressAnnotations:  []string{"nginx.ingress.kubernetes.io/rewrite-target"},
+		},
+	}
+	tests := []struct {
+		name        string
+		className   string
+		annotations map[string]string
+		expected    map[string]string
+		class       string
+	}{
+		{
+			name:      "mapped class",
+			className: "nginx",
+			annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/ssl-redirect":   "true",
+				"nginx.ingress.kubernetes.io/rewrite-target": "/",
+				"app": "keep",
+			},
+			expected: map[string]string{"nginx.ingress.kubernetes.io/rewrite-target": "/", "app": "keep"},
+			class:    "traefik",
+		},
+		{
+			name:      "unmapped class",
+			className: "other",
+			class:     "other",
+		},
+		{
+			name:        "class annotation",
+			annotations: map[string]string{ingressClassAnnotation: "nginx"},
+			expected:    map[string]string{ingressClassAnnotation: "traefik"},
+		},
+	}
+	for _, test := range tests {
+		spec := map[string]interface{}{}
+		if test.className != "" {
+			spec["ingressClassName"] = test.className
+		}
+		object := newPrepareObject("networking.k8s.io/v1", "Ingress", map[string]interface{}{"spec": spec})
+		object.SetAnnotations(test.annotations)
+		require.NoError(t, a.prepareIngress(restore, object), test.name)
+		require.Equal(t, test.expected, object.GetAnnotations(), test.name)
+		class, _, _ := unstructured.NestedString(object.Object, "spec", "ingressClassName")
+		require.Equal(t, test.class, class, test.name)
+	}
+}
+
+func TestPrepareImagePullSecrets(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			ImagePullSecretMapping: map[string]string{"source-registry": "dest-registry"},
+		},
+	}
+	secrets := func() []interface{} {
+		return []interface{}{
+			map[string]interface{}{"name": "source-registry"},
+			map[string]interface{}{"name": "other"},
+		}
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "dest-registry"},
+		map[string]interface{}{"name": "other"},
+	}
+
+	serviceAccount := newPrepareObject("v1", "ServiceAccount", map[string]interface{}{"imagePullSecrets": secrets()})
+	require.NoError(t, a.prepareImagePullSecrets(restore, serviceAccount))
+	mapped, _, _ := unstructured.NestedSlice(serviceAccount.Object, "imagePullSecrets")
+	require.Equal(t, expected, mapped)
+
+	deployment := newPrepareDeployment(map[string]interface{}{"imagePullSecrets": secrets()})
+	require.NoError(t, a.prepareImagePullSecrets(restore, deployment))
+	mapped, _, _ = unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "imagePullSecrets")
+	require.Equal(t, expected, mapped)
+
+	secret := newPrepareObject("v1", "Secret", map[string]interface{}{"imagePullSecrets": secrets()})
+	require.NoError(t, a.prepareImagePullSecrets(restore, secret))
+	mapped, _, _ = unstructured.NestedSlice(secret.Object, "imagePullSecrets")
+	require.Equal(t, secrets(), mapped, "Objects that aren't workloads or ServiceAccounts shouldn't be changed")
+}
+
+func TestPreparePriorityClass(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			PriorityClassMapping: map[string]string{"high": "critical"},
+		},
+	}
+	tests := []struct {
+		name      string
+		className string
+		expected  string
+		priority  bool
+	}{
+		{name: "mapped class", className: "high", expected: "critical"},
+		{name: "unmapped class", className: "low", expected: "low", priority: true},
+	}
+	for _, test := range tests {
+		object := newPrepareDeployment(map[string]interface{}{
+			"priorityClassName": test.className,
+			"priority":          int64(1000),
+		})
+		require.NoError(t, a.preparePriorityClass(restore, object), test.name)
+		className, _, _ := unstructured.NestedString(object.Object, "spec", "template", "spec", "priorityClassName")
+		require.Equal(t, test.expected, className, test.name)
+		_, found, _ := unstructured.NestedInt64(object.Object, "spec", "template", "spec", "priority")
+		require.Equal(t, test.priority, found, test.name)
+	}
+}
+
+func TestPrepareGroupMapping(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			GroupMapping: map[string]string{"example.com": "example.org"},
+		},
+	}
+	object := newPrepareObject("example.com/v1", "Widget", map[string]interface{}{})
+	object.SetOwnerReferences([]metav1.OwnerReference{
+		{APIVersion: "example.com/v1", Kind: "WidgetSet", Name: "set"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"},
+	})
+	require.NoError(t, a.prepareGroupMapping(restore, object))
+	require.Equal(t, "example.org/v1", object.GetAPIVersion())
+	require.Equal(t, "example.org/v1", object.GetOwnerReferences()[0].APIVersion)
+	require.Equal(t, "apps/v1", object.GetOwnerReferences()[1].APIVersion)
+
+	unmapped := newPrepareObject("apps/v1", "Deployment", map[string]interface{}{})
+	require.NoError(t, a.prepareGroupMapping(restore, unmapped))
+	require.Equal(t, "apps/v1", unmapped.GetAPIVersion())
+
+	invalid := newPrepareObject("example.com/v1", "Widget", map[string]interface{}{})
+	invalid.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "a/b/c", Name: "invalid"}})
+	require.Error(t, a.prepareGroupMapping(restore, invalid), "Expected error for invalid owner apiVersion")
+}
+
+func TestPrepareSubstitutions(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			Substitutions: map[string]string{"Cluster": "dest"},
+		},
+	}
+	data := func() map[string]interface{} {
+		return map[string]interface{}{
+			"substituted": "cluster={{ .Cluster }}",
+			"plain":       "value",
+			"missing":     "{{ .Missing }}",
+			"invalid":     "{{ .Cluster",
+		}
+	}
+	expected := map[string]string{
+		"substituted": "cluster=dest",
+		"plain":       "value",
+		"missing":     "{{ .Missing }}",
+		"invalid":     "{{ .Cluster",
+	}
+
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{"data": data()})
+	require.NoError(t, a.prepareSubstitutions(restore, configMap))
+	substituted, _, _ := unstructured.NestedStringMap(configMap.Object, "data")
+	require.Equal(t, expected, substituted)
+
+	encoded := make(map[string]interface{})
+	for key, value := range data() {
+		encoded[key] = base64.StdEncoding.EncodeToString([]byte(value.(string)))
+	}
+	secret := newPrepareObject("v1", "Secret", map[string]interface{}{"data": encoded, "stringData": data()})
+	require.NoError(t, a.prepareSubstitutions(restore, secret))
+	substituted, _, _ = unstructured.NestedStringMap(secret.Object, "stringData")
+	require.Equal(t, data()["substituted"], substituted["substituted"], "Secrets shouldn't be substituted by default")
+
+	restore.Spec.SubstituteSecrets = true
+	require.NoError(t, a.prepareSubstitutions(restore, secret))
+	substituted, _, _ = unstructured.NestedStringMap(secret.Object, "stringData")
+	require.Equal(t, expected, substituted)
+	substituted, _, _ = unstructured.NestedStringMap(secret.Object, "data")
+	for key, value := range expected {
+		decoded, err := base64.StdEncoding.DecodeString(substituted[key])
+		require.NoError(t, err)
+		require.Equal(t, value, string(decoded), key)
+	}
+
+	invalid := newPrepareObject("v1", "Secret", map[string]interface{}{"data": map[string]interface{}{"key": "not base64!"}})
+	require.Error(t, a.prepareSubstitutions(restore, invalid), "Expected error for invalid secret data")
+}
+
+func TestPrepareRestoredByLabel(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	tests := []struct {
+		name        string
+		restoreName string
+		labeled     bool
+	}{
+		{name: "valid name", restoreName: "restore", labeled: true},
+		{name: "name longer than a label value", restoreName: string(make([]byte, 64)), labeled: false},
+	}
+	for _, test := range tests {
+		restore := &storkapi.ApplicationRestore{}
+		restore.Name = test.restoreName
+		object := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+		object.SetLabels(map[string]string{"app": "keep"})
+		require.NoError(t, a.prepareRestoredByLabel(restore, object, "2021-01-01T00:00:00Z"), test.name)
+		label, ok := object.GetLabels()[StorkRestoredByLabel]
+		require.Equal(t, test.labeled, ok, test.name)
+		if ok {
+			require.Equal(t, test.restoreName, label, test.name)
+		}
+		require.Equal(t, "keep", object.GetLabels()["app"], test.name)
+		require.Equal(t, "2021-01-01T00:00:00Z", object.GetAnnotations()[StorkRestoreTimestampAnnotation], test.name)
+	}
+}
+
+func TestPrepareSelectorLabel(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{SelectorLabelKey: "restore"},
+	}
+	restore.Name = "restore"
+	deployment := newPrepareObject("apps/v1", "Deployment", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{"app": "test"},
+			},
+		},
+	})
+	require.NoError(t, a.prepareSelectorLabel(restore, deployment))
+	matchLabels, _, _ := unstructured.NestedStringMap(deployment.Object, "spec", "selector", "matchLabels")
+	require.Equal(t, map[string]string{"app": "test", "restore": "restore"}, matchLabels)
+	labels, _, _ := unstructured.NestedStringMap(deployment.Object, "spec", "template", "metadata", "labels")
+	require.Equal(t, map[string]string{"restore": "restore"}, labels)
+
+	// Services without selectors aren't changed
+	service := newPrepareObject("v1", "Service", map[string]interface{}{"spec": map[string]interface{}{}})
+	require.NoError(t, a.prepareSelectorLabel(restore, service))
+	_, found, _ := unstructured.NestedStringMap(service.Object, "spec", "selector")
+	require.False(t, found)
+
+	// Names longer than a label value are truncated with a hash of the name
+	restore.Name = strings.Repeat("a", 61) + "-" + strings.Repeat("b", 10)
+	service = newPrepareObject("v1", "Service", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"app": "test"},
+		},
+	})
+	require.NoError(t, a.prepareSelectorLabel(restore, service))
+	selector, _, _ := unstructured.NestedStringMap(service.Object, "spec", "selector")
+	require.Empty(t, validation.IsValidLabelValue(selector["restore"]))
+	require.True(t, strings.HasPrefix(selector["restore"], strings.Repeat("a", 54)+"-"), selector["restore"])
+	require.NotEqual(t, selector["restore"], getSelectorLabelValue(&storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: strings.Repeat("a", 61) + "-" + strings.Repeat("c", 10)},
+	}))
+}
+
+func newPrepareDataSourcePVC(name string, dataSource map[string]interface{}) *unstructured.Unstructured {
+	pvc := newPrepareObject("v1", "PersistentVolumeClaim", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"dataSource":    runtime.DeepCopyJSON(dataSource),
+			"dataSourceRef": runtime.DeepCopyJSON(dataSource),
+		},
+	})
+	pvc.SetNamespace("dest")
+	pvc.SetName(name)
+	return pvc
+}
+
+func TestPreparePVCDataSources(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping:    map[string]string{"source": "dest", "other": "other-dest"},
+			PVCDataSourcePolicy: storkapi.ApplicationRestorePVCDataSourcePolicyRemap,
+		},
+	}
+
+	// The snapshot was renamed when it was restored
+	snapshot := newPrepareObject("snapshot.storage.k8s.io/v1", "VolumeSnapshot", map[string]interface{}{})
+	snapshot.SetNamespace("dest")
+	snapshot.SetName("snap-renamed")
+	source := newPrepareObject("v1", "PersistentVolumeClaim", map[string]interface{}{"spec": map[string]interface{}{}})
+	source.SetNamespace("dest")
+	source.SetName("source-renamed")
+	crossNamespace := newPrepareDataSourcePVC("cross", map[string]interface{}{
+		"kind": "PersistentVolumeClaim", "name": "source", "namespace": "source",
+	})
+	crossNamespace.SetNamespace("other-dest")
+	remapped := newPrepareDataSourcePVC("remapped", map[string]interface{}{
+		"apiGroup": "snapshot.storage.k8s.io", "kind": "VolumeSnapshot", "name": "snap",
+	})
+	missing := newPrepareDataSourcePVC("missing", map[string]interface{}{
+		"apiGroup": "snapshot.storage.k8s.io", "kind": "VolumeSnapshot", "name": "deleted",
+	})
+	plain := newPrepareObject("v1", "PersistentVolumeClaim", map[string]interface{}{"spec": map[string]interface{}{}})
+
+	restoredNames := newRestoredObjectNames()
+	require.NoError(t, restoredNames.add(snapshot, "source", "snap"))
+	require.NoError(t, restoredNames.add(source, "source", "source"))
+	require.NoError(t, restoredNames.add(crossNamespace, "other", "cross"))
+	require.NoError(t, restoredNames.add(remapped, "source", "remapped"))
+	require.NoError(t, restoredNames.add(missing, "source", "missing"))
+	objects := []runtime.Unstructured{snapshot, source, crossNamespace, remapped, missing, plain}
+	require.NoError(t, a.preparePVCDataSources(restore, objects, restoredNames))
+
+	for _, field := range []string{"dataSource", "dataSourceRef"} {
+		dataSource, _, _ := unstructured.NestedStringMap(remapped.Object, "spec", field)
+		require.Equal(t, map[string]string{
+			"apiGroup": "snapshot.storage.k8s.io", "kind": "VolumeSnapshot", "name": "snap-renamed",
+		}, dataSource, field)
+		dataSource, _, _ = unstructured.NestedStringMap(crossNamespace.Object, "spec", field)
+		require.Equal(t, map[string]string{
+			"kind": "PersistentVolumeClaim", "name": "source-renamed", "namespace": "dest",
+		}, dataSource, field)
+		_, found, _ := unstructured.NestedFieldNoCopy(missing.Object, "spec", field)
+		require.False(t, found, "Expected %v to be cleared for a data source that isn't restored", field)
+	}
+	require.Equal(t, map[string]interface{}{}, plain.Object["spec"])
+
+	// Data sources are cleared with the Clear policy
+	restore.Spec.PVCDataSourcePolicy = storkapi.ApplicationRestorePVCDataSourcePolicyClear
+	remapped = newPrepareDataSourcePVC("remapped", map[string]interface{}{
+		"apiGroup": "snapshot.storage.k8s.io", "kind": "VolumeSnapshot", "name": "snap",
+	})
+	require.NoError(t, a.preparePVCDataSources(restore, []runtime.Unstructured{snapshot, remapped}, restoredNames))
+	require.Equal(t, map[string]interface{}{}, remapped.Object["spec"])
+}
+
+func TestPrepareAnnotations(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			StripAnnotations: []string{"external-dns.alpha.kubernetes.io/*", "cert-manager.io/*"},
+			KeepAnnotations:  []string{"cert-manager.io/issuer"},
+		},
+	}
+	object := newPrepareObject("v1", "Service", map[string]interface{}{})
+	object.SetAnnotations(map[string]string{
+		"external-dns.alpha.kubernetes.io/hostname": "example.com",
+		"cert-manager.io/cluster-issuer":            "issuer",
+		"cert-manager.io/issuer":                    "issuer",
+		"app":                                       "keep",
+		resourcecollector.OriginalCreationTimestampAnnotation: "2021-01-01T00:00:00Z",
+	})
+	require.NoError(t, a.prepareAnnotations(restore, object))
+	require.Equal(t, map[string]string{
+		"cert-manager.io/issuer": "issuer",
+		"app":                    "keep",
+		resourcecollector.OriginalCreationTimestampAnnotation: "2021-01-01T00:00:00Z",
+	}, object.GetAnnotations())
+}
+
+func TestPrepareLabels(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			StripLabels: []string{"argocd.argoproj.io/*", "helm.sh/chart"},
+		},
+	}
+	object := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	object.SetLabels(map[string]string{
+		"argocd.argoproj.io/instance": "app",
+		"helm.sh/chart":               "chart",
+		"app":                         "keep",
+	})
+	require.NoError(t, a.prepareLabels(restore, object))
+	require.Equal(t, map[string]string{"app": "keep"}, object.GetLabels())
+
+	unlabeled := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	require.NoError(t, a.prepareLabels(restore, unlabeled))
+	require.Empty(t, unlabeled.GetLabels())
+}
+
+func TestPrepareFinalizers(t *testing.T) {
+	a := &ApplicationRestoreController{}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			StripFinalizers: []string{"example.com/*"},
+		},
+	}
+	object := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	object.SetFinalizers([]string{"example.com/cleanup", "kubernetes.io/pvc-protection"})
+	require.NoError(t, a.prepareFinalizers(restore, object))
+	require.Equal(t, []string{"kubernetes.io/pvc-protection"}, object.GetFinalizers())
+}
+
+func TestPrepareWorkloadResource(t *testing.T) {
+	a := &ApplicationRestoreController{}
+
+	deployment := newPrepareObject("apps/v1", "Deployment", map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(3)},
+	})
+	require.NoError(t, a.prepareWorkloadResource(deployment))
+	replicas, _, err := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), replicas)
+	require.Equal(t, "3", deployment.GetAnnotations()[StorkRestoreReplicasAnnotation])
+
+	// Replicas default to 1 if they aren't set
+	statefulSet := newPrepareObject("apps/v1", "StatefulSet", map[string]interface{}{})
+	require.NoError(t, a.prepareWorkloadResource(statefulSet))
+	require.Equal(t, "1", statefulSet.GetAnnotations()[StorkRestoreReplicasAnnotation])
+
+	cronJob := newPrepareObject("batch/v1beta1", "CronJob", map[string]interface{}{})
+	require.NoError(t, a.prepareWorkloadResource(cronJob))
+	suspend, _, err := unstructured.NestedBool(cronJob.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.True(t, suspend)
+	require.Equal(t, "false", cronJob.GetAnnotations()[StorkRestoreSuspendAnnotation])
+
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	require.NoError(t, a.prepareWorkloadResource(configMap))
+	require.Empty(t, configMap.GetAnnotations())
+}
+
+func TestSuspendCronJob(t *testing.T) {
+	cronJob := newPrepareObject("batch/v1beta1", "CronJob", map[string]interface{}{
+		"spec": map[string]interface{}{"suspend": true},
+	})
+	cronJob.SetAnnotations(map[string]string{"app": "test"})
+	require.NoError(t, suspendCronJob(cronJob))
+	suspend, _, err := unstructured.NestedBool(cronJob.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.True(t, suspend)
+	require.Equal(t, map[string]string{"app": "test", StorkRestoreSuspendAnnotation: "true"}, cronJob.GetAnnotations())
+
+	job := newPrepareObject("batch/v1", "Job", map[string]interface{}{})
+	require.NoError(t, suspendCronJob(job))
+	_, found, err := unstructured.NestedFieldNoCopy(job.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestUpdateSkippedJobStatus(t *testing.T) {
+	a := &ApplicationRestoreController{recorder: record.NewFakeRecorder(10)}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{NamespaceMapping: map[string]string{"ns": "dest"}},
+	}
+	job := newPrepareObject("batch/v1", "Job", map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Complete", "status": "True"},
+			},
+		},
+	})
+	finished, err := isFinishedJob(job)
+	require.NoError(t, err)
+	require.True(t, finished)
+
+	require.NoError(t, a.updateSkippedJobStatus(restore, job))
+	require.Len(t, restore.Status.Resources, 1)
+	require.Equal(t, "dest", restore.Status.Resources[0].Namespace)
+	require.Equal(t, storkapi.ApplicationRestoreStatusSkipped, restore.Status.Resources[0].Status)
+	require.Equal(t, "ns", job.GetNamespace(), "Object from the backup shouldn't be modified")
+
+	// Skipped Jobs don't make the restore partially successful
+	setRestoreFinalStatus(restore)
+	require.Equal(t, storkapi.ApplicationRestoreStatusSuccessful, restore.Status.Status)
+}
@@ -0,0 +1,146 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestPruneExtraneousObjects(t *testing.T) {
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping:    map[string]string{"src": "dest"},
+			GroupMapping:        map[string]string{"example.com": "example.org"},
+			PruneProtectedKinds: []string{"Lease*"},
+		},
+	}
+	restore.Status.Resources = []*storkapi.ApplicationRestoreResourceInfo{
+		{ObjectInfo: storkapi.ObjectInfo{
+			Name:             "restored-app",
+			Namespace:        "dest",
+			GroupVersionKind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		}},
+		{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:             "job-",
+				Namespace:        "dest",
+				GroupVersionKind: metav1.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"},
+			},
+			GeneratedName: "job-abcde",
+		},
+	}
+	restore.Status.Volumes = []*storkapi.ApplicationRestoreVolumeInfo{
+		{PersistentVolumeClaim: "csi-pvc", SourceNamespace: "src"},
+	}
+
+	backupObjects := []runtime.Unstructured{
+		newNamedObject("v1", "ConfigMap", "src", "config"),
+		newNamedObject("extensions/v1beta1", "Ingress", "src", "ingress"),
+		newNamedObject("example.com/v1", "Widget", "src", "widget"),
+		newNamedObject("v1", "ConfigMap", "other", "not-restored"),
+	}
+	keys, err := getPruneKeys(restore, backupObjects)
+	require.NoError(t, err, "Error getting prune keys")
+	addRestoredPruneKeys(restore, keys)
+
+	owned := newNamedObject("v1", "ConfigMap", "dest", "owned")
+	owned.SetOwnerReferences([]metav1.OwnerReference{{Name: "owner"}})
+	tokenSecret := newNamedObject("v1", "Secret", "dest", "token")
+	tokenSecret.Object["type"] = "kubernetes.io/service-account-token"
+
+	tests := []struct {
+		name         string
+		resourceType metav1.APIResource
+		objects      []*unstructured.Unstructured
+		pruned       []string
+	}{
+		{
+			name:         "config maps from the backup are kept",
+			resourceType: metav1.APIResource{Version: "v1", Kind: "ConfigMap", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("v1", "ConfigMap", "dest", "config"),
+				newNamedObject("v1", "ConfigMap", "dest", "extra"),
+				newNamedObject("v1", "ConfigMap", "dest", "kube-root-ca.crt"),
+				newNamedObject("v1", "ConfigMap", "dest", "not-restored"),
+				owned,
+			},
+			pruned: []string{"extra", "not-restored"},
+		},
+		{
+			name:         "PVCs restored by the driver are kept",
+			resourceType: metav1.APIResource{Version: "v1", Kind: "PersistentVolumeClaim", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("v1", "PersistentVolumeClaim", "dest", "csi-pvc"),
+				newNamedObject("v1", "PersistentVolumeClaim", "dest", "extra-pvc"),
+			},
+			pruned: []string{"extra-pvc"},
+		},
+		{
+			name:         "objects restored with a different name are kept",
+			resourceType: metav1.APIResource{Group: "apps", Version: "v1", Kind: "Deployment", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("apps/v1", "Deployment", "dest", "restored-app"),
+				newNamedObject("apps/v1", "Deployment", "dest", "app"),
+			},
+			pruned: []string{"app"},
+		},
+		{
+			name:         "objects restored with a generated name are kept",
+			resourceType: metav1.APIResource{Group: "batch", Version: "v1", Kind: "Job", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("batch/v1", "Job", "dest", "job-abcde"),
+				newNamedObject("batch/v1", "Job", "dest", "job-fghij"),
+			},
+			pruned: []string{"job-fghij"},
+		},
+		{
+			name:         "objects from a legacy group are kept",
+			resourceType: metav1.APIResource{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("networking.k8s.io/v1", "Ingress", "dest", "ingress"),
+			},
+			pruned: []string{},
+		},
+		{
+			name:         "objects from a mapped group are kept",
+			resourceType: metav1.APIResource{Group: "example.org", Version: "v1", Kind: "Widget", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("example.org/v1", "Widget", "dest", "widget"),
+				newNamedObject("example.org/v1", "Widget", "dest", "other-widget"),
+			},
+			pruned: []string{"other-widget"},
+		},
+		{
+			name:         "service account tokens are kept",
+			resourceType: metav1.APIResource{Version: "v1", Kind: "Secret", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				tokenSecret,
+				newNamedObject("v1", "Secret", "dest", "extra-secret"),
+			},
+			pruned: []string{"extra-secret"},
+		},
+		{
+			name:         "protected kinds are kept",
+			resourceType: metav1.APIResource{Group: "coordination.k8s.io", Version: "v1", Kind: "Lease", Namespaced: true},
+			objects: []*unstructured.Unstructured{
+				newNamedObject("coordination.k8s.io/v1", "Lease", "dest", "lease"),
+			},
+			pruned: []string{},
+		},
+	}
+
+	for _, test := range tests {
+		objects := make([]unstructured.Unstructured, 0)
+		for _, o := range test.objects {
+			objects = append(objects, *o)
+		}
+		extraneous := getExtraneousObjects(restore, keys, test.resourceType, objects)
+		require.ElementsMatch(t, test.pruned, getObjectNames(extraneous), test.name)
+	}
+}
@@ -0,0 +1,73 @@
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updateRecordingClient records the objects that are updated. Other calls
+// aren't expected.
+type updateRecordingClient struct {
+	runtimeclient.Client
+	updated []runtimeclient.Object
+}
+
+func (c *updateRecordingClient) Update(ctx context.Context, obj runtimeclient.Object, opts ...runtimeclient.UpdateOption) error {
+	c.updated = append(c.updated, obj)
+	return nil
+}
+
+func TestRecordFailedReconcile(t *testing.T) {
+	client := &updateRecordingClient{}
+	a := &ApplicationRestoreController{
+		client:   client,
+		recorder: record.NewFakeRecorder(10),
+	}
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+		Spec:       storkapi.ApplicationRestoreSpec{MaxReconcileRetries: 2},
+		Status: storkapi.ApplicationRestoreStatus{
+			Stage:  storkapi.ApplicationRestoreStageApplications,
+			Status: storkapi.ApplicationRestoreStatusInProgress,
+		},
+	}
+
+	// Conflicts aren't counted
+	conflict := errors.NewConflict(schema.GroupResource{Resource: "applicationrestores"}, "restore", fmt.Errorf("modified"))
+	require.NoError(t, a.recordFailedReconcile(restore, conflict, conflict.Error()))
+	require.Equal(t, 0, restore.Status.FailedReconciles)
+	require.Empty(t, client.updated)
+
+	// The object from the caller is updated
+	failure := fmt.Errorf("failure")
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, a.recordFailedReconcile(restore, failure, failure.Error()))
+		require.Equal(t, i, restore.Status.FailedReconciles)
+		require.Equal(t, storkapi.ApplicationRestoreStageApplications, restore.Status.Stage)
+		require.Len(t, client.updated, i)
+		require.True(t, client.updated[i-1] == restore, "Restore from the caller should be updated")
+	}
+
+	// The restore is failed once the retries are exceeded, so that the
+	// caller notices the change in stage
+	require.NoError(t, a.recordFailedReconcile(restore, failure, failure.Error()))
+	require.Equal(t, 3, restore.Status.FailedReconciles)
+	require.Equal(t, storkapi.ApplicationRestoreStageFinal, restore.Status.Stage)
+	require.Equal(t, storkapi.ApplicationRestoreStatusFailed, restore.Status.Status)
+
+	// Nothing is recorded once the restore is done
+	require.NoError(t, a.recordFailedReconcile(restore, failure, failure.Error()))
+	require.Equal(t, 3, restore.Status.FailedReconciles)
+	require.Len(t, client.updated, 3)
+}
@@ -0,0 +1,65 @@
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newRollbackConfigMap(name string) *unstructured.Unstructured {
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{
+		"data": map[string]interface{}{"key": name},
+	})
+	configMap.SetNamespace("ns")
+	configMap.SetName(name)
+	return configMap
+}
+
+func TestRollbackReplacedResources(t *testing.T) {
+	dynamicInterface := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newRollbackConfigMap("existing"))
+	a := &ApplicationRestoreController{
+		recorder: record.NewFakeRecorder(10),
+		targets: map[string]*cachedRestoreTarget{"": {target: &restoreTarget{
+			dynamicInterface: dynamicInterface,
+		}}},
+	}
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{"ns": "ns"},
+			ReplacePolicy:    storkapi.ApplicationRestoreReplacePolicyDelete,
+			RollbackOnCancel: true,
+		},
+	}
+	objects := []runtime.Unstructured{
+		newRollbackConfigMap("existing"),
+		newRollbackConfigMap("new"),
+	}
+	require.NoError(t, a.applyResources(restore, objects, nil))
+
+	// The existing object was replaced, so it isn't marked as created even
+	// though it was deleted before it was applied
+	require.Len(t, restore.Status.Resources, 2)
+	for _, resource := range restore.Status.Resources {
+		require.Equal(t, storkapi.ApplicationRestoreStatusSuccessful, resource.Status, resource.Name)
+		require.Equal(t, resource.Name == "new", resource.Created, resource.Name)
+	}
+
+	require.NoError(t, a.rollbackResources(restore))
+	configMaps := dynamicInterface.Resource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Namespace("ns")
+	_, err := configMaps.Get(context.TODO(), "existing", metav1.GetOptions{})
+	require.NoError(t, err)
+	_, err = configMaps.Get(context.TODO(), "new", metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err), "expected new ConfigMap to be deleted, got %v", err)
+}
@@ -0,0 +1,125 @@
+// +build unittest
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetSanitizedName(t *testing.T) {
+	tests := []struct {
+		kind      string
+		name      string
+		unchanged bool
+		maxLength int
+	}{
+		{kind: "ConfigMap", name: "config", unchanged: true},
+		{kind: "ConfigMap", name: "config.example.com", unchanged: true},
+		{kind: "ConfigMap", name: "Config_Map", maxLength: validation.DNS1123SubdomainMaxLength},
+		{kind: "ConfigMap", name: strings.Repeat("a", 300), maxLength: validation.DNS1123SubdomainMaxLength},
+		{kind: "Service", name: "svc.example", maxLength: validation.DNS1123LabelMaxLength},
+		{kind: "Service", name: strings.Repeat("a", 64), maxLength: validation.DNS1123LabelMaxLength},
+		{kind: "StatefulSet", name: strings.Repeat("a", 60), maxLength: validation.LabelValueMaxLength - 11},
+		{kind: "ClusterRole", name: "system:controller:job-controller", unchanged: true},
+		{kind: "ClusterRoleBinding", name: "system:kube-dns", unchanged: true},
+		{kind: "Role", name: "Upper:Case", unchanged: true},
+		{kind: "RoleBinding", name: "a/b", maxLength: validation.DNS1123SubdomainMaxLength},
+		{kind: "Role", name: "..", maxLength: validation.DNS1123SubdomainMaxLength},
+	}
+	for _, test := range tests {
+		sanitized := getSanitizedName(test.kind, test.name)
+		if test.unchanged {
+			require.Equal(t, test.name, sanitized, "Valid name for %v was changed", test.kind)
+			continue
+		}
+		require.NotEqual(t, test.name, sanitized, "Invalid name for %v wasn't changed", test.kind)
+		require.True(t, len(sanitized) <= test.maxLength, "Sanitized name %v for %v is too long", sanitized, test.kind)
+		require.Equal(t, sanitized, getSanitizedName(test.kind, sanitized), "Sanitized name %v for %v isn't valid", sanitized, test.kind)
+		require.Equal(t, sanitized, getSanitizedName(test.kind, test.name), "Sanitized name for %v isn't stable", test.kind)
+	}
+
+	// Names that only differ in invalid characters get different names
+	require.NotEqual(t, getSanitizedName("ConfigMap", "a_b"), getSanitizedName("ConfigMap", "a:b"))
+}
+
+func TestPrepareSanitizedReferences(t *testing.T) {
+	a := &ApplicationRestoreController{
+		recorder: record.NewFakeRecorder(10),
+		targets:  map[string]*cachedRestoreTarget{"": {target: &restoreTarget{}}},
+	}
+	restore := &storkapi.ApplicationRestore{
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{"ns": "ns"},
+			SanitizeNames:    true,
+		},
+	}
+	configMap := newPrepareObject("v1", "ConfigMap", map[string]interface{}{})
+	configMap.SetName("Config_Map")
+	serviceAccount := newPrepareObject("v1", "ServiceAccount", map[string]interface{}{})
+	serviceAccount.SetName("Service_Account")
+	deployment := newPrepareDeployment(map[string]interface{}{
+		"serviceAccountName": "Service_Account",
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "Config_Map"}},
+		},
+		"containers": []interface{}{
+			map[string]interface{}{
+				"name": "app",
+				"envFrom": []interface{}{
+					map[string]interface{}{"configMapRef": map[string]interface{}{"name": "Config_Map"}},
+				},
+			},
+		},
+	})
+	roleBinding := newPrepareObject("rbac.authorization.k8s.io/v1", "RoleBinding", map[string]interface{}{
+		"roleRef": map[string]interface{}{"kind": "Role", "name": "role"},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "Service_Account", "namespace": "ns"},
+		},
+	})
+
+	objects, err := a.prepareResources(restore, []runtime.Unstructured{configMap, serviceAccount, deployment, roleBinding}, nil)
+	require.NoError(t, err)
+	require.Len(t, objects, 4)
+	configMapName := objects[0].(*unstructured.Unstructured).GetName()
+	serviceAccountName := objects[1].(*unstructured.Unstructured).GetName()
+	require.NotEqual(t, "Config_Map", configMapName)
+	require.NotEqual(t, "Service_Account", serviceAccountName)
+
+	content := objects[2].UnstructuredContent()
+	name, _, err := unstructured.NestedString(content, "spec", "template", "spec", "serviceAccountName")
+	require.NoError(t, err)
+	require.Equal(t, serviceAccountName, name)
+	podSpec, _, err := unstructured.NestedMap(content, "spec", "template", "spec")
+	require.NoError(t, err)
+	volume := podSpec["volumes"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, configMapName, volume["configMap"].(map[string]interface{})["name"])
+	container := podSpec["containers"].([]interface{})[0].(map[string]interface{})
+	envFrom := container["envFrom"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, configMapName, envFrom["configMapRef"].(map[string]interface{})["name"])
+
+	subjects, _, err := unstructured.NestedSlice(objects[3].UnstructuredContent(), "subjects")
+	require.NoError(t, err)
+	require.Equal(t, serviceAccountName, subjects[0].(map[string]interface{})["name"])
+	roleName, _, err := unstructured.NestedString(objects[3].UnstructuredContent(), "roleRef", "name")
+	require.NoError(t, err)
+	require.Equal(t, "role", roleName)
+
+	// Only the renamed resources get a warning
+	for _, o := range objects {
+		require.NoError(t, a.updateResourceStatus(restore, o, storkapi.ApplicationRestoreStatusSuccessful, "Resource restored successfully"))
+	}
+	require.Len(t, restore.Status.Resources, 4)
+	require.Len(t, restore.Status.Resources[0].Warnings, 1)
+	require.Len(t, restore.Status.Resources[1].Warnings, 1)
+	require.Empty(t, restore.Status.Resources[2].Warnings)
+	require.Empty(t, restore.Status.Resources[3].Warnings)
+}
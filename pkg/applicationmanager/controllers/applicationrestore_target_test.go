@@ -0,0 +1,48 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestGetRestoreTargetCached(t *testing.T) {
+	clusterPair := &storkapi.ClusterPair{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "ns", ResourceVersion: "1"},
+		Spec: storkapi.ClusterPairSpec{
+			Config: api.Config{CurrentContext: "missing"},
+		},
+		Status: storkapi.ClusterPairStatus{SchedulerStatus: storkapi.ClusterPairStatusReady},
+	}
+	storkops.SetInstance(storkops.New(fake.NewSimpleClientset(), fakeclient.NewSimpleClientset(clusterPair), nil))
+
+	cached := &restoreTarget{}
+	a := &ApplicationRestoreController{
+		targets: map[string]*cachedRestoreTarget{
+			"ns/remote": {resourceVersion: "1", target: cached},
+		},
+	}
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"},
+		Spec:       storkapi.ApplicationRestoreSpec{ClusterPair: "remote"},
+	}
+	for i := 0; i < 2; i++ {
+		target, err := a.getRestoreTarget(restore)
+		require.NoError(t, err)
+		require.True(t, target == cached, "Cached target should be returned")
+	}
+
+	// The clients are created again once the ClusterPair is updated. The
+	// config in the ClusterPair isn't valid, so that fails.
+	a.targets["ns/remote"].resourceVersion = "0"
+	_, err := a.getRestoreTarget(restore)
+	require.Error(t, err)
+}
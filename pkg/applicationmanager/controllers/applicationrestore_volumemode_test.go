@@ -0,0 +1,109 @@
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func volumeModePtr(volumeMode v1.PersistentVolumeMode) *v1.PersistentVolumeMode {
+	return &volumeMode
+}
+
+func TestAddCSIVolumeResourcesVolumeMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		pvVolumeMode *v1.PersistentVolumeMode
+		pvcMode      *v1.PersistentVolumeMode
+		status       storkapi.ApplicationRestoreStatusType
+	}{
+		{name: "default", status: storkapi.ApplicationRestoreStatusSuccessful},
+		{
+			name:         "block",
+			pvVolumeMode: volumeModePtr(v1.PersistentVolumeBlock),
+			pvcMode:      volumeModePtr(v1.PersistentVolumeBlock),
+			status:       storkapi.ApplicationRestoreStatusSuccessful,
+		},
+		{
+			name:         "unset filesystem",
+			pvVolumeMode: volumeModePtr(v1.PersistentVolumeFilesystem),
+			status:       storkapi.ApplicationRestoreStatusSuccessful,
+		},
+		{
+			name:    "block restored as filesystem",
+			pvcMode: volumeModePtr(v1.PersistentVolumeBlock),
+			status:  storkapi.ApplicationRestoreStatusFailed,
+		},
+		{
+			name:         "filesystem restored as block",
+			pvVolumeMode: volumeModePtr(v1.PersistentVolumeBlock),
+			status:       storkapi.ApplicationRestoreStatusFailed,
+		},
+	}
+	for _, test := range tests {
+		pv := &v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv"}}
+		pv.Spec.VolumeMode = test.pvVolumeMode
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "dest"}}
+		pvc.Spec.VolumeMode = test.pvcMode
+		pvc.Spec.VolumeName = "pv"
+		target := &restoreTarget{coreOps: core.New(fake.NewSimpleClientset(pv, pvc))}
+
+		a := &ApplicationRestoreController{recorder: record.NewFakeRecorder(10)}
+		restore := &storkapi.ApplicationRestore{
+			Spec: storkapi.ApplicationRestoreSpec{
+				NamespaceMapping: map[string]string{"src": "dest"},
+			},
+		}
+		restore.Status.Volumes = []*storkapi.ApplicationRestoreVolumeInfo{
+			{
+				DriverName:            "csi",
+				PersistentVolumeClaim: "pvc",
+				SourceNamespace:       "src",
+				RestoreVolume:         "pv",
+			},
+		}
+		require.NoError(t, a.addCSIVolumeResources(restore, target), test.name)
+		require.Len(t, restore.Status.Resources, 2, test.name)
+		for _, resource := range restore.Status.Resources {
+			require.Equal(t, test.status, resource.Status, "%v: unexpected status for %v", test.name, resource.Kind)
+		}
+	}
+}
+
+func TestPrepareStaticPersistentVolumeMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		pvMode     *v1.PersistentVolumeMode
+		pvcMode    *v1.PersistentVolumeMode
+		volumeMode v1.PersistentVolumeMode
+	}{
+		{name: "default", volumeMode: v1.PersistentVolumeFilesystem},
+		{name: "block", pvMode: volumeModePtr(v1.PersistentVolumeBlock), pvcMode: volumeModePtr(v1.PersistentVolumeBlock), volumeMode: v1.PersistentVolumeBlock},
+		{name: "PVC requests block", pvcMode: volumeModePtr(v1.PersistentVolumeBlock), volumeMode: v1.PersistentVolumeBlock},
+		{name: "PVC requests filesystem", pvMode: volumeModePtr(v1.PersistentVolumeBlock), volumeMode: v1.PersistentVolumeFilesystem},
+	}
+	for _, test := range tests {
+		pv := &v1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv"}}
+		pv.Spec.VolumeMode = test.pvMode
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "dest"}}
+		pvc.Spec.VolumeMode = test.pvcMode
+
+		object := &unstructured.Unstructured{}
+		require.NoError(t, prepareStaticPersistentVolume(object, pv, pvc), test.name)
+		var prepared v1.PersistentVolume
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &prepared))
+		require.Equal(t, test.volumeMode, getVolumeMode(prepared.Spec.VolumeMode), test.name)
+		require.Equal(t, "pvc", prepared.Spec.ClaimRef.Name, test.name)
+		require.Equal(t, "dest", prepared.Spec.ClaimRef.Namespace, test.name)
+	}
+}
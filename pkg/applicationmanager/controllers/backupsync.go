@@ -9,12 +9,15 @@ import (
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/audit"
+	"github.com/libopenstorage/stork/pkg/compression"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	"gocloud.dev/blob"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 )
@@ -60,6 +63,13 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 	if !location.Location.Sync {
 		return nil
 	}
+	if err := objectstore.Validate(location); err != nil {
+		b.Recorder.Event(location,
+			v1.EventTypeWarning,
+			"ValidationFailed",
+			err.Error())
+		return err
+	}
 	bucket, err := objectstore.GetBucket(location)
 	if err != nil {
 		return err
@@ -95,7 +105,9 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 				return err
 			}
 			if object.IsDir {
-				data, err := bucket.ReadAll(context.TODO(), filepath.Join(object.Key, metadataObjectName))
+				ctx, cancel := objectstore.GetContext(location, objectstore.RequestRead)
+				data, err := bucket.ReadAll(ctx, filepath.Join(object.Key, metadataObjectName))
+				cancel()
 				if err != nil {
 					log.BackupLocationLog(location).Errorf("Error syncing backup %v: %v", backupName, err)
 					continue
@@ -106,6 +118,10 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 						continue
 					}
 				}
+				if data, err = compression.Decompress(data); err != nil {
+					log.BackupLocationLog(location).Errorf("Error decompressing backup %v during sync: %v", backupName, err)
+					continue
+				}
 				backupInfo := storkv1.ApplicationBackup{}
 				if err = json.Unmarshal(data, &backupInfo); err != nil {
 					log.BackupLocationLog(location).Errorf("Error parsing backup %v during sync: %v", backupName, err)
@@ -139,6 +155,9 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 				backupInfo.SelfLink = ""
 				backupInfo.OwnerReferences = nil
 				backupInfo.Spec.ReclaimPolicy = storkv1.ApplicationBackupReclaimPolicyRetain
+				// The audit record would have been written by the cluster
+				// that took the backup
+				audit.SetRecorded(&backupInfo)
 				_, err = storkops.Instance().CreateApplicationBackup(&backupInfo)
 				if err != nil {
 					return err
@@ -0,0 +1,197 @@
+package applicationmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// OperationsPath is the path of the endpoint that lists the operations
+const OperationsPath = "/operations"
+
+// Resources that a user has to be allowed to list in all namespaces to get the
+// operations
+var operationsResources = []string{
+	stork_api.ApplicationBackupResourcePlural,
+	stork_api.ApplicationRestoreResourcePlural,
+	stork_api.GroupVolumeSnapshotResourcePlural,
+}
+
+// Operation is the current state of an ApplicationBackup, ApplicationRestore
+// or GroupVolumeSnapshot
+type Operation struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Stage     string `json:"stage"`
+	Status    string `json:"status"`
+	// LastUpdateTimestamp isn't set for GroupVolumeSnapshots since they
+	// don't record it
+	LastUpdateTimestamp *metav1.Time `json:"lastUpdateTimestamp,omitempty"`
+}
+
+// ListOperations returns the ApplicationBackups, ApplicationRestores and
+// GroupVolumeSnapshots in all namespaces with their current stage and status.
+// If activeOnly is set, the operations that have reached their final stage are
+// skipped.
+func ListOperations(activeOnly bool) ([]Operation, error) {
+	operations := make([]Operation, 0)
+
+	backups, err := storkops.Instance().ListApplicationBackups("")
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups.Items {
+		if activeOnly && backup.Status.Stage == stork_api.ApplicationBackupStageFinal {
+			continue
+		}
+		lastUpdate := backup.Status.LastUpdateTimestamp
+		operations = append(operations, Operation{
+			Kind:                "ApplicationBackup",
+			Name:                backup.Name,
+			Namespace:           backup.Namespace,
+			Stage:               string(backup.Status.Stage),
+			Status:              string(backup.Status.Status),
+			LastUpdateTimestamp: &lastUpdate,
+		})
+	}
+
+	restores, err := storkops.Instance().ListApplicationRestores("")
+	if err != nil {
+		return nil, err
+	}
+	for _, restore := range restores.Items {
+		if activeOnly && restore.Status.Stage == stork_api.ApplicationRestoreStageFinal {
+			continue
+		}
+		lastUpdate := restore.Status.LastUpdateTimestamp
+		operations = append(operations, Operation{
+			Kind:                "ApplicationRestore",
+			Name:                restore.Name,
+			Namespace:           restore.Namespace,
+			Stage:               string(restore.Status.Stage),
+			Status:              string(restore.Status.Status),
+			LastUpdateTimestamp: &lastUpdate,
+		})
+	}
+
+	groupSnapshots, err := storkops.Instance().ListGroupSnapshots("")
+	if err != nil {
+		return nil, err
+	}
+	for _, groupSnapshot := range groupSnapshots.Items {
+		if activeOnly && groupSnapshot.Status.Stage == stork_api.GroupSnapshotStageFinal {
+			continue
+		}
+		operations = append(operations, Operation{
+			Kind:      "GroupVolumeSnapshot",
+			Name:      groupSnapshot.Name,
+			Namespace: groupSnapshot.Namespace,
+			Stage:     string(groupSnapshot.Status.Stage),
+			Status:    string(groupSnapshot.Status.Status),
+		})
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Namespace != operations[j].Namespace {
+			return operations[i].Namespace < operations[j].Namespace
+		}
+		return operations[i].Name < operations[j].Name
+	})
+	return operations, nil
+}
+
+// OperationsHandler serves the operations. The endpoint is served on the same
+// port as the metrics, so requests are authenticated with the bearer token of
+// the user and are only served if the user can list the operations in all
+// namespaces.
+type OperationsHandler struct {
+	Client kubernetes.Interface
+}
+
+// ServeHTTP responds with the operations as JSON. Only the operations that are
+// in progress are listed unless the all query parameter is set to true.
+func (h *OperationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status, err := h.authorize(r)
+	if err != nil {
+		logrus.Warnf("Rejected request for operations: %v", err)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+	operations, err := ListOperations(!all)
+	if err != nil {
+		logrus.Errorf("Error listing operations: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(operations); err != nil {
+		logrus.Errorf("Error writing operations: %v", err)
+	}
+}
+
+// authorize checks that the request has the token of a user that can list all
+// the operation resources. Returns the HTTP status to respond with if not.
+func (h *OperationsHandler) authorize(r *http.Request) (int, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		return http.StatusUnauthorized, fmt.Errorf("no bearer token")
+	}
+	review, err := h.Client.AuthenticationV1().TokenReviews().Create(
+		context.TODO(),
+		&authenticationv1.TokenReview{
+			Spec: authenticationv1.TokenReviewSpec{Token: token},
+		},
+		metav1.CreateOptions{})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if !review.Status.Authenticated {
+		return http.StatusUnauthorized, fmt.Errorf("invalid token: %v", review.Status.Error)
+	}
+
+	user := review.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	for _, resource := range operationsResources {
+		access, err := h.Client.AuthorizationV1().SubjectAccessReviews().Create(
+			context.TODO(),
+			&authorizationv1.SubjectAccessReview{
+				Spec: authorizationv1.SubjectAccessReviewSpec{
+					User:   user.Username,
+					UID:    user.UID,
+					Groups: user.Groups,
+					Extra:  extra,
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Verb:     "list",
+						Group:    stork_crd.GroupName,
+						Resource: resource,
+					},
+				},
+			},
+			metav1.CreateOptions{})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if !access.Status.Allowed {
+			return http.StatusForbidden, fmt.Errorf("user %v can't list %v", user.Username, resource)
+		}
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,63 @@
+// +build unittest
+
+package applicationmanager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newOperationsHandler(allowedUser string) *OperationsHandler {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token != "invalid" {
+			review.Status.Authenticated = true
+			review.Status.User.Username = review.Spec.Token
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == allowedUser &&
+			review.Spec.ResourceAttributes.Verb == "list" &&
+			review.Spec.ResourceAttributes.Namespace == ""
+		return true, review, nil
+	})
+	return &OperationsHandler{Client: client}
+}
+
+func TestOperationsHandlerAuthorization(t *testing.T) {
+	storkops.SetInstance(storkops.New(fake.NewSimpleClientset(), fakeclient.NewSimpleClientset(), nil))
+	handler := newOperationsHandler("admin")
+
+	tests := []struct {
+		authorization string
+		status        int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Basic admin", http.StatusUnauthorized},
+		{"Bearer invalid", http.StatusUnauthorized},
+		{"Bearer user", http.StatusForbidden},
+		{"Bearer admin", http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, OperationsPath, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, test.status, rec.Code, "Unexpected status for %q", test.authorization)
+	}
+}
@@ -0,0 +1,257 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/crypto"
+	"github.com/libopenstorage/stork/pkg/objectstore"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RecordedAnnotation is set on objects once their audit record has been
+	// written to the sink so that it is only recorded once
+	RecordedAnnotation = "stork.libopenstorage.org/audit-recorded"
+
+	// Prefix under which records are written in an objectstore sink. Uses a
+	// name that can't be a valid namespace so that it doesn't clash with
+	// backups stored in the same location
+	objectStorePrefix = "_audit"
+	webhookTimeout    = 10 * time.Second
+)
+
+// Record is the audit record written for a backup or restore once it reaches
+// a terminal state
+type Record struct {
+	Kind            string      `json:"kind"`
+	Name            string      `json:"name"`
+	Namespace       string      `json:"namespace"`
+	UID             string      `json:"uid"`
+	BackupName      string      `json:"backupName"`
+	BackupLocation  string      `json:"backupLocation"`
+	Namespaces      []string    `json:"namespaces"`
+	Status          string      `json:"status"`
+	Reason          string      `json:"reason"`
+	TotalSize       uint64      `json:"totalSize"`
+	NumVolumes      int         `json:"numVolumes"`
+	NumResources    int         `json:"numResources"`
+	CreateTimestamp metav1.Time `json:"createTimestamp"`
+	FinishTimestamp metav1.Time `json:"finishTimestamp"`
+	InitiatedBy     string      `json:"initiatedBy"`
+	// SkippedValidations are the checks that were skipped for a restore
+	SkippedValidations []string `json:"skippedValidations,omitempty"`
+}
+
+// Sink is a destination for audit records
+type Sink interface {
+	// Write writes the record to the sink
+	Write(*Record) error
+}
+
+// NewSink returns a sink for the given config. URLs with an http or https
+// scheme are treated as webhooks, anything else should be a reference to a
+// BackupLocation in the form namespace/name. Returns nil if no sink is
+// configured.
+func NewSink(config string) (Sink, error) {
+	if config == "" {
+		return nil, nil
+	}
+	if strings.HasPrefix(config, "http://") || strings.HasPrefix(config, "https://") {
+		return &webhookSink{
+			url:    config,
+			client: &http.Client{Timeout: webhookTimeout},
+		}, nil
+	}
+	parts := strings.Split(config, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid audit sink %v, should be a webhook URL or namespace/name of a BackupLocation", config)
+	}
+	return &objectStoreSink{
+		namespace: parts[0],
+		name:      parts[1],
+	}, nil
+}
+
+// NewBackupRecord creates an audit record for an ApplicationBackup
+func NewBackupRecord(backup *stork_api.ApplicationBackup) *Record {
+	return &Record{
+		Kind:            "ApplicationBackup",
+		Name:            backup.Name,
+		Namespace:       backup.Namespace,
+		UID:             string(backup.UID),
+		BackupName:      backup.Name,
+		BackupLocation:  backup.Spec.BackupLocation,
+		Namespaces:      backup.Spec.Namespaces,
+		Status:          string(backup.Status.Status),
+		Reason:          backup.Status.Reason,
+		TotalSize:       backup.Status.TotalSize,
+		NumVolumes:      len(backup.Status.Volumes),
+		NumResources:    len(backup.Status.Resources),
+		CreateTimestamp: backup.CreationTimestamp,
+		FinishTimestamp: backup.Status.FinishTimestamp,
+		InitiatedBy:     backup.Annotations[stork_api.CreatedByAnnotation],
+	}
+}
+
+// NewRestoreRecord creates an audit record for an ApplicationRestore
+func NewRestoreRecord(restore *stork_api.ApplicationRestore) *Record {
+	namespaces := make([]string, 0)
+	for _, ns := range restore.Spec.NamespaceMapping {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	record := &Record{
+		Kind:            "ApplicationRestore",
+		Name:            restore.Name,
+		Namespace:       restore.Namespace,
+		UID:             string(restore.UID),
+		BackupName:      restore.Spec.BackupName,
+		BackupLocation:  restore.Spec.BackupLocation,
+		Namespaces:      namespaces,
+		Status:          string(restore.Status.Status),
+		Reason:          restore.Status.Reason,
+		TotalSize:       restore.Status.TotalSize,
+		NumVolumes:      len(restore.Status.Volumes),
+		NumResources:    len(restore.Status.Resources),
+		CreateTimestamp: restore.CreationTimestamp,
+		FinishTimestamp: restore.Status.FinishTimestamp,
+		InitiatedBy:     restore.Status.CreatedBy,
+	}
+	for _, validation := range restore.Status.SkippedValidations {
+		record.SkippedValidations = append(record.SkippedValidations, string(validation))
+	}
+	return record
+}
+
+// IsRecorded returns whether the audit record has already been written for
+// the object
+func IsRecorded(object metav1.Object) bool {
+	_, ok := object.GetAnnotations()[RecordedAnnotation]
+	return ok
+}
+
+// SetRecorded marks the object as having its audit record written
+func SetRecorded(object metav1.Object) {
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[RecordedAnnotation] = "true"
+	object.SetAnnotations(annotations)
+}
+
+// Sender writes audit records to a sink in the background so that
+// reconciling objects isn't blocked by a slow sink
+type Sender struct {
+	sink    Sink
+	lock    sync.Mutex
+	pending map[string]bool
+}
+
+// NewSender returns a sender for the sink. Returns nil if no sink is
+// configured.
+func NewSender(sink Sink) *Sender {
+	if sink == nil {
+		return nil
+	}
+	return &Sender{
+		sink:    sink,
+		pending: make(map[string]bool),
+	}
+}
+
+// Send writes the record to the sink in the background and calls done once it
+// has been written. Errors are only logged. Records for an object that is
+// still being written are ignored.
+func (s *Sender) Send(record *Record, done func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.pending[record.UID] {
+		return
+	}
+	s.pending[record.UID] = true
+
+	go func() {
+		defer func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			delete(s.pending, record.UID)
+		}()
+		if err := s.sink.Write(record); err != nil {
+			logrus.Warnf("Error writing audit record for %v %v/%v: %v", record.Kind, record.Namespace, record.Name, err)
+			return
+		}
+		done()
+	}()
+}
+
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (w *webhookSink) Write(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error posting audit record to %v: %v", w.url, err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error posting audit record to %v: %v", w.url, resp.Status)
+	}
+	return nil
+}
+
+type objectStoreSink struct {
+	namespace string
+	name      string
+}
+
+func (o *objectStoreSink) Write(record *Record) error {
+	backupLocation, err := storkops.Instance().GetBackupLocation(o.name, o.namespace)
+	if err != nil {
+		return fmt.Errorf("error getting backup location for audit sink: %v", err)
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(record, "", " ")
+	if err != nil {
+		return err
+	}
+	if backupLocation.Location.EncryptionKey != "" {
+		if data, err = crypto.Encrypt(data, backupLocation.Location.EncryptionKey); err != nil {
+			return err
+		}
+	}
+
+	objectPath := filepath.Join(objectStorePrefix, record.Kind, record.Namespace, record.Name, record.UID+".json")
+	ctx, cancel := objectstore.GetContext(backupLocation, objectstore.RequestWrite)
+	defer cancel()
+	writer, err := bucket.NewWriter(ctx, objectPath, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(data); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,120 @@
+// +build unittest
+
+package audit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewBackupRecord(t *testing.T) {
+	backup := &stork_api.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "backup",
+			Namespace:   "ns",
+			UID:         "uid",
+			Annotations: map[string]string{stork_api.CreatedByAnnotation: "user"},
+		},
+		Spec: stork_api.ApplicationBackupSpec{
+			BackupLocation: "location",
+			Namespaces:     []string{"app"},
+		},
+		Status: stork_api.ApplicationBackupStatus{
+			Status:    stork_api.ApplicationBackupStatusSuccessful,
+			TotalSize: 100,
+			Volumes:   []*stork_api.ApplicationBackupVolumeInfo{{}},
+		},
+	}
+	record := NewBackupRecord(backup)
+	require.Equal(t, "ApplicationBackup", record.Kind)
+	require.Equal(t, "backup", record.BackupName)
+	require.Equal(t, "location", record.BackupLocation)
+	require.Equal(t, []string{"app"}, record.Namespaces)
+	require.Equal(t, string(stork_api.ApplicationBackupStatusSuccessful), record.Status)
+	require.Equal(t, uint64(100), record.TotalSize)
+	require.Equal(t, 1, record.NumVolumes)
+	require.Equal(t, "user", record.InitiatedBy)
+}
+
+func TestNewRestoreRecord(t *testing.T) {
+	restore := &stork_api.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns", UID: "uid"},
+		Spec: stork_api.ApplicationRestoreSpec{
+			BackupName:       "backup",
+			NamespaceMapping: map[string]string{"app": "app-restored", "db": "db-restored", "cache": "cache-restored"},
+		},
+		Status: stork_api.ApplicationRestoreStatus{
+			Status:             stork_api.ApplicationRestoreStatusPartialSuccess,
+			CreatedBy:          "user",
+			SkippedValidations: []stork_api.ApplicationRestoreValidationType{stork_api.ApplicationRestoreValidationCRDReady},
+		},
+	}
+	record := NewRestoreRecord(restore)
+	require.Equal(t, "ApplicationRestore", record.Kind)
+	require.Equal(t, "backup", record.BackupName)
+	require.Equal(t, []string{"app-restored", "cache-restored", "db-restored"}, record.Namespaces)
+	require.Equal(t, "user", record.InitiatedBy)
+	require.Equal(t, []string{string(stork_api.ApplicationRestoreValidationCRDReady)}, record.SkippedValidations)
+}
+
+type fakeSink struct {
+	records chan *Record
+	release chan error
+}
+
+func (f *fakeSink) Write(record *Record) error {
+	f.records <- record
+	return <-f.release
+}
+
+func TestSender(t *testing.T) {
+	require.Nil(t, NewSender(nil))
+
+	sink := &fakeSink{
+		records: make(chan *Record, 2),
+		release: make(chan error),
+	}
+	sender := NewSender(sink)
+	done := make(chan struct{}, 2)
+	record := &Record{Kind: "ApplicationRestore", Name: "restore", Namespace: "ns", UID: "uid"}
+
+	// A record for an object that is still being written is ignored
+	sender.Send(record, func() { done <- struct{}{} })
+	require.Equal(t, record, <-sink.records)
+	sender.Send(record, func() { done <- struct{}{} })
+	sink.release <- nil
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timeout waiting for record to be written")
+	}
+	require.Empty(t, sink.records)
+
+	// done isn't called if the record couldn't be written
+	require.Eventually(t, func() bool {
+		sender.lock.Lock()
+		defer sender.lock.Unlock()
+		return len(sender.pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	sender.Send(record, func() { done <- struct{}{} })
+	require.Equal(t, record, <-sink.records)
+	sink.release <- fmt.Errorf("sink error")
+	require.Eventually(t, func() bool {
+		sender.lock.Lock()
+		defer sender.lock.Unlock()
+		return len(sender.pending) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, done)
+}
+
+func TestSetRecorded(t *testing.T) {
+	object := &metav1.ObjectMeta{}
+	require.False(t, IsRecorded(object))
+	SetRecorded(object)
+	require.True(t, IsRecorded(object))
+}
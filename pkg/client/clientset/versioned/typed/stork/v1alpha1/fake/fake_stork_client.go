@@ -72,6 +72,10 @@ func (c *FakeStorkV1alpha1) GroupVolumeSnapshots(namespace string) v1alpha1.Grou
 	return &FakeGroupVolumeSnapshots{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) GroupVolumeSnapshotSchedules(namespace string) v1alpha1.GroupVolumeSnapshotScheduleInterface {
+	return &FakeGroupVolumeSnapshotSchedules{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) Migrations(namespace string) v1alpha1.MigrationInterface {
 	return &FakeMigrations{c, namespace}
 }
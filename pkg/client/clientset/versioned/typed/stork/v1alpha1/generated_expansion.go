@@ -40,6 +40,8 @@ type DataExportExpansion interface{}
 
 type GroupVolumeSnapshotExpansion interface{}
 
+type GroupVolumeSnapshotScheduleExpansion interface{}
+
 type MigrationExpansion interface{}
 
 type MigrationScheduleExpansion interface{}
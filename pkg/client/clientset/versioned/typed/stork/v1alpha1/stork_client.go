@@ -37,6 +37,7 @@ type StorkV1alpha1Interface interface {
 	ClusterPairsGetter
 	DataExportsGetter
 	GroupVolumeSnapshotsGetter
+	GroupVolumeSnapshotSchedulesGetter
 	MigrationsGetter
 	MigrationSchedulesGetter
 	NamespacedSchedulePoliciesGetter
@@ -95,6 +96,10 @@ func (c *StorkV1alpha1Client) GroupVolumeSnapshots(namespace string) GroupVolume
 	return newGroupVolumeSnapshots(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) GroupVolumeSnapshotSchedules(namespace string) GroupVolumeSnapshotScheduleInterface {
+	return newGroupVolumeSnapshotSchedules(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) Migrations(namespace string) MigrationInterface {
 	return newMigrations(c, namespace)
 }
@@ -75,6 +75,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().DataExports().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("groupvolumesnapshots"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().GroupVolumeSnapshots().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("groupvolumesnapshotschedules"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().GroupVolumeSnapshotSchedules().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("migrations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().Migrations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("migrationschedules"):
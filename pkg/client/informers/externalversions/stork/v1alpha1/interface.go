@@ -46,6 +46,8 @@ type Interface interface {
 	DataExports() DataExportInformer
 	// GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 	GroupVolumeSnapshots() GroupVolumeSnapshotInformer
+	// GroupVolumeSnapshotSchedules returns a GroupVolumeSnapshotScheduleInformer.
+	GroupVolumeSnapshotSchedules() GroupVolumeSnapshotScheduleInformer
 	// Migrations returns a MigrationInformer.
 	Migrations() MigrationInformer
 	// MigrationSchedules returns a MigrationScheduleInformer.
@@ -128,6 +130,11 @@ func (v *version) GroupVolumeSnapshots() GroupVolumeSnapshotInformer {
 	return &groupVolumeSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// GroupVolumeSnapshotSchedules returns a GroupVolumeSnapshotScheduleInformer.
+func (v *version) GroupVolumeSnapshotSchedules() GroupVolumeSnapshotScheduleInformer {
+	return &groupVolumeSnapshotScheduleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Migrations returns a MigrationInformer.
 func (v *version) Migrations() MigrationInformer {
 	return &migrationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -94,6 +94,14 @@ type GroupVolumeSnapshotListerExpansion interface{}
 // GroupVolumeSnapshotNamespaceLister.
 type GroupVolumeSnapshotNamespaceListerExpansion interface{}
 
+// GroupVolumeSnapshotScheduleListerExpansion allows custom methods to be added to
+// GroupVolumeSnapshotScheduleLister.
+type GroupVolumeSnapshotScheduleListerExpansion interface{}
+
+// GroupVolumeSnapshotScheduleNamespaceListerExpansion allows custom methods to be added to
+// GroupVolumeSnapshotScheduleNamespaceLister.
+type GroupVolumeSnapshotScheduleNamespaceListerExpansion interface{}
+
 // MigrationListerExpansion allows custom methods to be added to
 // MigrationLister.
 type MigrationListerExpansion interface{}
@@ -0,0 +1,192 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+)
+
+const (
+	// Gzip compresses data with gzip
+	Gzip = "gzip"
+	// LZ4 compresses data with the LZ4 frame format
+	LZ4 = "lz4"
+	// Zstd compresses data with zstd
+	Zstd = "zstd"
+)
+
+// Codec compresses and decompresses data. The compressed data must start with
+// a header that identifies the codec, so that the codec doesn't need to be
+// known when the data is decompressed.
+type Codec interface {
+	// Name returns the name used to select the codec
+	Name() string
+	// Header returns the bytes that data compressed by the codec starts with
+	Header() []byte
+	// NewWriter returns a writer that compresses the data written to it
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	// NewReader returns a reader that decompresses the data read from r
+	NewReader(r io.Reader) (io.Reader, error)
+}
+
+var (
+	codecs     = make(map[string]Codec)
+	codecsLock sync.RWMutex
+)
+
+func init() {
+	Register(&gzipCodec{})
+	Register(&lz4Codec{})
+	Register(&zstdCodec{})
+}
+
+// Register makes a codec available to compress and decompress data. A codec
+// registered with the same name as an existing one replaces it.
+func Register(codec Codec) {
+	codecsLock.Lock()
+	defer codecsLock.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// Get returns the codec with the given name
+func Get(name string) (Codec, error) {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported compression codec %v", name)
+	}
+	return codec, nil
+}
+
+// Compress the given data with the named codec. The data is returned as is if
+// the name is empty.
+func Compress(data []byte, name string) ([]byte, error) {
+	if name == "" {
+		return data, nil
+	}
+	codec, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writer, err := codec.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, fmt.Errorf("error compressing data with %v: %v", name, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("error compressing data with %v: %v", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress the given data with the codec that matches its header. The data
+// is returned as is if it doesn't start with the header of any codec, so data
+// that was written before it was compressed can still be read.
+func Decompress(data []byte) ([]byte, error) {
+	codec := detect(data)
+	if codec == nil {
+		return data, nil
+	}
+	reader, err := codec.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing data with %v: %v", codec.Name(), err)
+	}
+	decompressed, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing data with %v: %v", codec.Name(), err)
+	}
+	return decompressed, nil
+}
+
+func detect(data []byte) Codec {
+	codecsLock.RLock()
+	defer codecsLock.RUnlock()
+	for _, codec := range codecs {
+		if bytes.HasPrefix(data, codec.Header()) {
+			return codec
+		}
+	}
+	return nil
+}
+
+type gzipCodec struct{}
+
+func (g *gzipCodec) Name() string {
+	return Gzip
+}
+
+func (g *gzipCodec) Header() []byte {
+	return []byte{0x1f, 0x8b}
+}
+
+func (g *gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (g *gzipCodec) NewReader(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+type lz4Codec struct{}
+
+func (l *lz4Codec) Name() string {
+	return LZ4
+}
+
+func (l *lz4Codec) Header() []byte {
+	return []byte{0x04, 0x22, 0x4d, 0x18}
+}
+
+func (l *lz4Codec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return lz4.NewWriter(w), nil
+}
+
+func (l *lz4Codec) NewReader(r io.Reader) (io.Reader, error) {
+	return lz4.NewReader(r), nil
+}
+
+type zstdCodec struct{}
+
+func (z *zstdCodec) Name() string {
+	return Zstd
+}
+
+func (z *zstdCodec) Header() []byte {
+	return []byte{0x28, 0xb5, 0x2f, 0xfd}
+}
+
+func (z *zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+func (z *zstdCodec) NewReader(r io.Reader) (io.Reader, error) {
+	decoder, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{decoder: decoder}, nil
+}
+
+// zstdReader closes the decoder once all the data has been read, so that its
+// goroutines are stopped
+type zstdReader struct {
+	decoder *zstd.Decoder
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.decoder.Read(p)
+	if err != nil {
+		z.decoder.Close()
+	}
+	return n, err
+}
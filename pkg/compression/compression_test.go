@@ -0,0 +1,41 @@
+// +build unittest
+
+package compression
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressDecompress(t *testing.T) {
+	originalData := bytes.Repeat([]byte(`{"kind":"ConfigMap","apiVersion":"v1"}`), 128)
+
+	for _, name := range []string{Gzip, LZ4, Zstd} {
+		compressedData, err := Compress(originalData, name)
+		require.NoError(t, err, "Error compressing data with %v", name)
+		require.True(t, len(compressedData) < len(originalData), "Data wasn't compressed with %v", name)
+
+		decompressedData, err := Decompress(compressedData)
+		require.NoError(t, err, "Error decompressing data with %v", name)
+		require.Equal(t, originalData, decompressedData, "Original and decompressed data mismatch for %v", name)
+	}
+}
+
+func TestDecompressUncompressed(t *testing.T) {
+	originalData := []byte(`[{"kind":"ConfigMap","apiVersion":"v1"}]`)
+
+	compressedData, err := Compress(originalData, "")
+	require.NoError(t, err, "Error compressing data without a codec")
+	require.Equal(t, originalData, compressedData, "Data shouldn't be compressed without a codec")
+
+	decompressedData, err := Decompress(originalData)
+	require.NoError(t, err, "Error decompressing uncompressed data")
+	require.Equal(t, originalData, decompressedData, "Uncompressed data should be returned as is")
+}
+
+func TestCompressInvalidCodec(t *testing.T) {
+	_, err := Compress([]byte("data"), "invalid")
+	require.Error(t, err, "Expected error compressing data with invalid codec")
+}
@@ -14,12 +14,21 @@ import (
 const (
 	// FinalizerCleanup is a kubernetes finalizer for stork controllers.
 	FinalizerCleanup = "stork.libopenstorage.org/finalizer-cleanup"
+)
 
+// The reconcile periods can be changed when stork is started, before any of
+// the controllers are registered.
+var (
 	// DefaultRequeue is a reconcile period for a resource on success.
 	DefaultRequeue = 10 * time.Second
 
 	// DefaultRequeueError is a reconcile period for a resource on error.
 	DefaultRequeueError = 2 * time.Second
+
+	// VolumeRequeue is a reconcile period for a resource while its volumes
+	// are being transferred, which usually takes much longer than updating
+	// the other resources.
+	VolumeRequeue = 30 * time.Second
 )
 
 // RegisterTo creates a new controller for a provided config and registers it to the controller manager.
@@ -33,3 +33,29 @@ type ErrNotSupported struct {
 func (e *ErrNotSupported) Error() string {
 	return fmt.Sprintf("%v not supported. Reason: %v", e.Feature, e.Reason)
 }
+
+// ErrInsufficientCapacity error type for when the storage doesn't have enough
+// free capacity for an operation
+type ErrInsufficientCapacity struct {
+	// Required capacity in bytes
+	Required uint64
+	// Available capacity in bytes
+	Available uint64
+}
+
+func (e *ErrInsufficientCapacity) Error() string {
+	return fmt.Sprintf("insufficient capacity, %v bytes required but only %v bytes available", e.Required, e.Available)
+}
+
+// ErrVerificationFailed error type for when the storage detects that the data
+// in a volume is corrupt
+type ErrVerificationFailed struct {
+	// Volume is the ID of the volume that failed verification
+	Volume string
+	// Reason for the failure
+	Reason string
+}
+
+func (e *ErrVerificationFailed) Error() string {
+	return fmt.Sprintf("verification failed for volume %v: %v", e.Volume, e.Reason)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +27,7 @@ import (
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -45,6 +48,18 @@ const (
 	volumeSnapshotInitialDelay = 2 * time.Second
 	volumeSnapshotFactor       = 1
 	volumeSnapshotSteps        = 60
+
+	// forceDeleteAnnotation can be set to true on a group snapshot to remove
+	// it even if the snapshots couldn't be deleted from the driver
+	forceDeleteAnnotation = "stork.libopenstorage.org/force-delete"
+
+	// DefaultStatusPollJitter is the default jitter factor for the interval
+	// at which the status of group snapshots in progress is checked
+	DefaultStatusPollJitter = 0.5
+
+	// groupSnapshotNameAnnotation is added to the volumesnapshots created for a
+	// group snapshot with the name of the group snapshot
+	groupSnapshotNameAnnotation = "stork.libopenstorage.org/groupSnapshotName"
 )
 
 var snapDeleteBackoff = wait.Backoff{
@@ -54,11 +69,12 @@ var snapDeleteBackoff = wait.Backoff{
 }
 
 // NewGroupSnapshot creates a new instance of GroupSnapshotController.
-func NewGroupSnapshot(mgr manager.Manager, d volume.Driver, r record.EventRecorder) *GroupSnapshotController {
+func NewGroupSnapshot(mgr manager.Manager, d volume.Driver, r record.EventRecorder, statusPollJitter float64) *GroupSnapshotController {
 	return &GroupSnapshotController{
-		client:    mgr.GetClient(),
-		volDriver: d,
-		recorder:  r,
+		client:           mgr.GetClient(),
+		volDriver:        d,
+		recorder:         r,
+		statusPollJitter: statusPollJitter,
 	}
 }
 
@@ -68,18 +84,27 @@ type GroupSnapshotController struct {
 
 	volDriver           volume.Driver
 	recorder            record.EventRecorder
-	bgChannelsForRules  map[string]chan bool
+	bgChannelsForRules  map[string][]chan bool
 	minResourceVersions map[string]string
+	// statusPollJitter is the jitter factor added to the requeue interval
+	// while checking the status of snapshots from the driver, so that the
+	// driver calls for group snapshots created together are spread out
+	statusPollJitter float64
+	// adminNamespace is the only namespace in which group snapshots can
+	// select PVCs from other namespaces
+	adminNamespace string
 }
 
 // Init Initialize the groupSnapshot controller
-func (m *GroupSnapshotController) Init(mgr manager.Manager) error {
+func (m *GroupSnapshotController) Init(mgr manager.Manager, adminNamespace string) error {
 	err := m.createCRD()
 	if err != nil {
 		return err
 	}
 
-	m.bgChannelsForRules = make(map[string]chan bool)
+	m.adminNamespace = adminNamespace
+
+	m.bgChannelsForRules = make(map[string][]chan bool)
 	m.minResourceVersions = make(map[string]string)
 
 	return controllers.RegisterTo(mgr, "group-snapshot-controller", m, &stork_api.GroupVolumeSnapshot{})
@@ -117,15 +142,22 @@ func (m *GroupSnapshotController) Reconcile(ctx context.Context, request reconci
 		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
 	}
 
+	if groupSnapshot.Status.Stage == stork_api.GroupSnapshotStageSnapshot && m.statusPollJitter > 0 {
+		return reconcile.Result{RequeueAfter: wait.Jitter(controllers.DefaultRequeue, m.statusPollJitter)}, nil
+	}
 	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
 }
 
 func (m *GroupSnapshotController) handle(ctx context.Context, groupSnapshot *stork_api.GroupVolumeSnapshot) error {
 	if groupSnapshot.DeletionTimestamp != nil {
 		if controllers.ContainsFinalizer(groupSnapshot, controllers.FinalizerCleanup) {
-			if err := m.handleDelete(groupSnapshot); err != nil {
+			deleted, err := m.handleDelete(ctx, groupSnapshot)
+			if err != nil {
 				return fmt.Errorf("cleanup: %s", err)
 			}
+			if !deleted {
+				return nil
+			}
 		}
 
 		if groupSnapshot.GetFinalizers() != nil {
@@ -185,9 +217,11 @@ func (m *GroupSnapshotController) handle(ctx context.Context, groupSnapshot *sto
 		// triggered
 		snapUID := string(groupSnapshot.ObjectMeta.UID)
 		if areAllSnapshotsStarted(groupSnapshot.Status.VolumeSnapshots) {
-			backgroundChannel, present := m.bgChannelsForRules[snapUID]
+			backgroundChannels, present := m.bgChannelsForRules[snapUID]
 			if present {
-				backgroundChannel <- true
+				for _, backgroundChannel := range backgroundChannels {
+					backgroundChannel <- true
+				}
 				delete(m.bgChannelsForRules, snapUID)
 			}
 		}
@@ -196,6 +230,18 @@ func (m *GroupSnapshotController) handle(ctx context.Context, groupSnapshot *sto
 		updatedGroupSnapshot, updateCRDForThisEvent, err = m.handlePostSnap(groupSnapshot)
 		if err == nil {
 			groupSnapshot = updatedGroupSnapshot
+			if groupSnapshot.Status.Status == stork_api.GroupSnapshotSuccessful {
+				// The group snapshot has been taken, so don't fail it if the
+				// older snapshots couldn't be deleted
+				if pruneErr := m.pruneVolumeSnapshots(groupSnapshot); pruneErr != nil {
+					message := fmt.Sprintf("Error deleting older volumesnapshots: %v", pruneErr)
+					log.GroupSnapshotLog(groupSnapshot).Warnf(message)
+					m.recorder.Event(groupSnapshot,
+						v1.EventTypeWarning,
+						string(stork_api.GroupSnapshotFailed),
+						message)
+				}
+			}
 		}
 	case stork_api.GroupSnapshotStageFinal:
 		return m.handleFinal(groupSnapshot)
@@ -258,13 +304,24 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 		err = fmt.Errorf("matchLabels are required for group snapshots. Refer to spec examples")
 	}
 
+	for _, association := range groupSnap.Spec.RuleAssociations {
+		if len(association.PVCSelector.MatchExpressions) > 0 {
+			err = fmt.Errorf("matchExpressions are currently not supported for rule associations. Use matchLabels")
+		}
+	}
+
+	if !m.namespacesAllowed(groupSnap) {
+		err = fmt.Errorf("group snapshots selecting PVCs from other namespaces can only be created in the admin namespace (%v)",
+			m.adminNamespace)
+	}
+
 	if err != nil {
 		groupSnap.Status.Status = stork_api.GroupSnapshotFailed
 		groupSnap.Status.Stage = stork_api.GroupSnapshotStageFinal
 		return updateCRD, err
 	}
 
-	_, err = k8sutils.GetPVCsForGroupSnapshot(groupSnap.Namespace, groupSnap.Spec.PVCSelector.MatchLabels)
+	pvcs, err := k8sutils.GetPVCsForGroupSnapshot(k8sutils.GetGroupSnapshotNamespaces(groupSnap), groupSnap.Spec.PVCSelector.MatchLabels)
 	if err != nil {
 		if groupSnap.Status.Status == stork_api.GroupSnapshotPending {
 			return !updateCRD, err
@@ -274,23 +331,24 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 		groupSnap.Status.Stage = stork_api.GroupSnapshotStagePreChecks
 	} else {
 		// Validate pre and post snap rules
-		preSnapRuleName := groupSnap.Spec.PreExecRule
-		if len(preSnapRuleName) > 0 {
-			if _, err := storkops.Instance().GetRule(preSnapRuleName, groupSnap.Namespace); err != nil {
-				return !updateCRD, err
-			}
-		}
-
-		postSnapRuleName := groupSnap.Spec.PostExecRule
-		if len(postSnapRuleName) > 0 {
-			if _, err := storkops.Instance().GetRule(postSnapRuleName, groupSnap.Namespace); err != nil {
-				return !updateCRD, err
+		ruleTargets := getRuleTargets(groupSnap, pvcs)
+		hasPreSnapRules := false
+		for _, target := range ruleTargets {
+			for _, name := range []string{target.PreExecRule, target.PostExecRule} {
+				if name == "" {
+					continue
+				}
+				if _, err := storkops.Instance().GetRule(name, groupSnap.Namespace); err != nil {
+					return !updateCRD, err
+				}
 			}
+			hasPreSnapRules = hasPreSnapRules || target.PreExecRule != ""
 		}
 
 		groupSnap.Status.Status = stork_api.GroupSnapshotInProgress
+		groupSnap.Status.RuleTargets = ruleTargets
 
-		if len(preSnapRuleName) > 0 {
+		if hasPreSnapRules {
 			// done with pre-checks, move to pre-snapshot stage
 			groupSnap.Status.Stage = stork_api.GroupSnapshotStagePreSnapshot
 		} else {
@@ -302,29 +360,132 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 	return updateCRD, err
 }
 
-func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolumeSnapshot) (
-	*stork_api.GroupVolumeSnapshot, bool, error) {
-	ruleName := groupSnap.Spec.PreExecRule
-	if len(ruleName) == 0 {
-		groupSnap.Status.Status = stork_api.GroupSnapshotInProgress
-		// No rule, move to snapshot stage
-		groupSnap.Status.Stage = stork_api.GroupSnapshotStageSnapshot
-		return groupSnap, updateCRD, nil
+// getRuleTargets returns the rules for a group snapshot along with the pods
+// they are run on. The rules from the spec are run on all the pods in the
+// namespace of the group snapshot, and the rules from the associations are run
+// on the pods using the PVCs that match their selector, in each namespace
+// with such PVCs.
+func getRuleTargets(
+	groupSnap *stork_api.GroupVolumeSnapshot,
+	pvcs []v1.PersistentVolumeClaim,
+) []stork_api.GroupVolumeSnapshotRuleTarget {
+	targets := make([]stork_api.GroupVolumeSnapshotRuleTarget, 0)
+	if groupSnap.Spec.PreExecRule != "" || groupSnap.Spec.PostExecRule != "" {
+		targets = append(targets, stork_api.GroupVolumeSnapshotRuleTarget{
+			PreExecRule:  groupSnap.Spec.PreExecRule,
+			PostExecRule: groupSnap.Spec.PostExecRule,
+			Namespace:    groupSnap.Namespace,
+		})
 	}
+	for _, association := range groupSnap.Spec.RuleAssociations {
+		if association.PreExecRule == "" && association.PostExecRule == "" {
+			continue
+		}
+		selector := labels.SelectorFromSet(association.PVCSelector.MatchLabels)
+		namespacePVCs := make(map[string][]string)
+		for _, pvc := range pvcs {
+			if selector.Matches(labels.Set(pvc.Labels)) {
+				namespacePVCs[pvc.Namespace] = append(namespacePVCs[pvc.Namespace], pvc.Name)
+			}
+		}
+		namespaces := make([]string, 0, len(namespacePVCs))
+		for namespace := range namespacePVCs {
+			namespaces = append(namespaces, namespace)
+		}
+		sort.Strings(namespaces)
+		for _, namespace := range namespaces {
+			pvcNames := namespacePVCs[namespace]
+			sort.Strings(pvcNames)
+			targets = append(targets, stork_api.GroupVolumeSnapshotRuleTarget{
+				PreExecRule:            association.PreExecRule,
+				PostExecRule:           association.PostExecRule,
+				Namespace:              namespace,
+				PersistentVolumeClaims: pvcNames,
+			})
+		}
+	}
+	return targets
+}
 
-	log.GroupSnapshotLog(groupSnap).Infof("Running pre-snapshot rule: %s", ruleName)
-	r, err := storkops.Instance().GetRule(ruleName, groupSnap.Namespace)
+// getRecordedRuleTargets returns the rule targets recorded in the status of a
+// group snapshot. They are only computed from the PVCs currently selected by
+// the group snapshot if they weren't recorded.
+func getRecordedRuleTargets(groupSnap *stork_api.GroupVolumeSnapshot) ([]stork_api.GroupVolumeSnapshotRuleTarget, error) {
+	if groupSnap.Status.RuleTargets != nil {
+		return groupSnap.Status.RuleTargets, nil
+	}
+	var pvcs []v1.PersistentVolumeClaim
+	if len(groupSnap.Spec.RuleAssociations) > 0 {
+		var err error
+		pvcs, err = k8sutils.GetPVCsForGroupSnapshot(k8sutils.GetGroupSnapshotNamespaces(groupSnap), groupSnap.Spec.PVCSelector.MatchLabels)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return getRuleTargets(groupSnap, pvcs), nil
+}
+
+// executeRuleForTarget runs the pre or post rule of a target on its pods
+func executeRuleForTarget(
+	groupSnap *stork_api.GroupVolumeSnapshot,
+	target stork_api.GroupVolumeSnapshotRuleTarget,
+	ruleType rule.Type,
+) (chan bool, error) {
+	name := target.PreExecRule
+	if ruleType == rule.PostExecRule {
+		name = target.PostExecRule
+	}
+	log.GroupSnapshotLog(groupSnap).Infof("Running %v: %s in namespace %s", ruleType, name, target.Namespace)
+	r, err := storkops.Instance().GetRule(name, groupSnap.Namespace)
 	if err != nil {
-		return nil, !updateCRD, err
+		return nil, err
 	}
+	if len(target.PersistentVolumeClaims) == 0 {
+		return rule.ExecuteRule(r, ruleType, groupSnap, target.Namespace)
+	}
+	return rule.ExecuteRuleForPVCs(r, ruleType, groupSnap, target.Namespace, target.PersistentVolumeClaims)
+}
 
-	backgroundCommandTermChan, err := rule.ExecuteRule(r, rule.PreExecRule, groupSnap, groupSnap.Namespace)
+func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolumeSnapshot) (
+	*stork_api.GroupVolumeSnapshot, bool, error) {
+	ruleTargets, err := getRecordedRuleTargets(groupSnap)
 	if err != nil {
+		return nil, !updateCRD, err
+	}
+
+	backgroundCommandTermChans := make([]chan bool, 0)
+	// terminate background commands if running
+	terminateBackgroundCommands := func() {
+		for _, backgroundCommandTermChan := range backgroundCommandTermChans {
+			backgroundCommandTermChan <- true
+		}
+	}
+	// run the post rules for the pre rules that have already run, so that
+	// the applications aren't left quiesced
+	runPostRules := func(targets []stork_api.GroupVolumeSnapshotRuleTarget) {
+		for _, target := range targets {
+			if target.PreExecRule == "" || target.PostExecRule == "" {
+				continue
+			}
+			if _, err := executeRuleForTarget(groupSnap, target, rule.PostExecRule); err != nil {
+				log.GroupSnapshotLog(groupSnap).Warnf("Error running post-snapshot rule %v in namespace %v after pre-snapshot rules failed: %v",
+					target.PostExecRule, target.Namespace, err)
+			}
+		}
+	}
+	for i, target := range ruleTargets {
+		if target.PreExecRule == "" {
+			continue
+		}
+		backgroundCommandTermChan, err := executeRuleForTarget(groupSnap, target, rule.PreExecRule)
 		if backgroundCommandTermChan != nil {
-			backgroundCommandTermChan <- true // terminate background commands if running
+			backgroundCommandTermChans = append(backgroundCommandTermChans, backgroundCommandTermChan)
+		}
+		if err != nil {
+			terminateBackgroundCommands()
+			runPostRules(ruleTargets[:i])
+			return nil, !updateCRD, err
 		}
-
-		return nil, !updateCRD, err
 	}
 
 	// refresh the latest groupSnap as ExecuteRule might have  updated it
@@ -333,9 +494,9 @@ func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolume
 		return nil, !updateCRD, err
 	}
 
-	if backgroundCommandTermChan != nil {
+	if len(backgroundCommandTermChans) > 0 {
 		snapUID := string(groupSnap.ObjectMeta.UID)
-		m.bgChannelsForRules[snapUID] = backgroundCommandTermChan
+		m.bgChannelsForRules[snapUID] = backgroundCommandTermChans
 	}
 
 	// done with pre-snapshot, move to snapshot stage
@@ -472,22 +633,39 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 	}
 	parentUUID := groupSnap.GetUID()
 	snapLabels := groupSnap.GetLabels()
-	snapAnnotations := groupSnap.GetAnnotations()
+	snapAnnotations := make(map[string]string)
+	for k, v := range groupSnap.GetAnnotations() {
+		snapAnnotations[k] = v
+	}
+	snapAnnotations[groupSnapshotNameAnnotation] = parentName
 	createSnapObjects := make([]*crdv1.VolumeSnapshot, 0)
 
 	if len(groupSnap.Spec.RestoreNamespaces) > 0 {
-		if len(snapAnnotations) == 0 {
-			snapAnnotations = make(map[string]string)
-		}
-
 		snapAnnotations[snapshotcontrollers.StorkSnapshotRestoreNamespacesAnnotation] = strings.Join(groupSnap.Spec.RestoreNamespaces, ",")
 	}
 
 	for _, snapshot := range snapshots {
-		parentPVCOrVolID, err := m.getPVCNameFromVolumeID(snapshot.ParentVolumeID)
+		parentPVCOrVolID, snapNamespace, err := m.getPVCNameFromVolumeID(snapshot.ParentVolumeID)
 		if err != nil {
 			return nil, err
 		}
+		if snapNamespace == "" {
+			snapNamespace = parentNamespace
+		}
+		// Owner references can't point to objects in other namespaces, so
+		// snapshots in other namespaces are only removed when the group
+		// snapshot is deleted
+		var ownerReferences []metav1.OwnerReference
+		if snapNamespace == parentNamespace {
+			ownerReferences = []metav1.OwnerReference{
+				{
+					Name:       parentName,
+					UID:        parentUUID,
+					Kind:       groupSnap.GetObjectKind().GroupVersionKind().Kind,
+					APIVersion: groupSnap.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+				},
+			}
+		}
 
 		volumeSnapshotName := fmt.Sprintf("%s-%s-%s", parentName, parentPVCOrVolID, parentUUID)
 
@@ -512,7 +690,7 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 				VolumeSnapshotRef: &v1.ObjectReference{
 					Kind:      "VolumeSnapshot",
 					Name:      volumeSnapshotName,
-					Namespace: parentNamespace,
+					Namespace: snapNamespace,
 				},
 				PersistentVolumeRef:      &v1.ObjectReference{},
 				VolumeSnapshotDataSource: *snapshot.DataSource,
@@ -540,18 +718,11 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 
 		snap := &crdv1.VolumeSnapshot{
 			Metadata: metav1.ObjectMeta{
-				Name:        volumeSnapshotName,
-				Namespace:   parentNamespace,
-				Labels:      snapLabels,
-				Annotations: snapAnnotations,
-				OwnerReferences: []metav1.OwnerReference{
-					{
-						Name:       parentName,
-						UID:        parentUUID,
-						Kind:       groupSnap.GetObjectKind().GroupVersionKind().Kind,
-						APIVersion: groupSnap.GetObjectKind().GroupVersionKind().GroupVersion().String(),
-					},
-				},
+				Name:            volumeSnapshotName,
+				Namespace:       snapNamespace,
+				Labels:          snapLabels,
+				Annotations:     snapAnnotations,
+				OwnerReferences: ownerReferences,
 			},
 			Spec: crdv1.VolumeSnapshotSpec{
 				SnapshotDataName:          snapData.Metadata.Name,
@@ -584,6 +755,7 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 		createSnapObjects = append(createSnapObjects, snap)
 
 		snapshot.VolumeSnapshotName = volumeSnapshotName
+		snapshot.VolumeSnapshotNamespace = snapNamespace
 		updatedStatues = append(updatedStatues, snapshot)
 	}
 
@@ -625,33 +797,68 @@ func revertSnapObjs(snapObjs []*crdv1.VolumeSnapshot) {
 	logrus.Infof("Successfully reverted volumesnapshots")
 }
 
-// this is best effort as can be vol ID if PVC is deleted
-func (m *GroupSnapshotController) getPVCNameFromVolumeID(volID string) (string, error) {
+// this is best effort as can be vol ID if PVC is deleted. The namespace of the
+// PVC is returned along with the name, it is empty if the PVC wasn't found
+func (m *GroupSnapshotController) getPVCNameFromVolumeID(volID string) (string, string, error) {
 	volInfo, err := m.volDriver.InspectVolume(volID)
 	if err != nil {
 		logrus.Warnf("Volume: %s not found due to: %v", volID, err)
-		return volID, nil
+		return volID, "", nil
 	}
 
 	parentPV, err := core.Instance().GetPersistentVolume(volInfo.VolumeName)
 	if err != nil {
 		logrus.Warnf("Parent PV: %s not found due to: %v", volInfo.VolumeName, err)
-		return volID, nil
+		return volID, "", nil
 	}
 
 	pvc, err := core.Instance().GetPersistentVolumeClaim(parentPV.Spec.ClaimRef.Name, parentPV.Spec.ClaimRef.Namespace)
 	if err != nil {
-		return volID, nil
+		return volID, "", nil
 	}
 
-	return pvc.GetName(), nil
+	return pvc.GetName(), pvc.GetNamespace(), nil
 
 }
 
+// namespacesAllowed checks if the group snapshot is allowed to select PVCs
+// from the namespaces in its spec. Only group snapshots in the admin namespace
+// can select PVCs from other namespaces
+func (m *GroupSnapshotController) namespacesAllowed(groupSnap *stork_api.GroupVolumeSnapshot) bool {
+	if groupSnap.Namespace == m.adminNamespace {
+		return true
+	}
+	for _, ns := range groupSnap.Spec.Namespaces {
+		if ns != groupSnap.Namespace {
+			return false
+		}
+	}
+	return true
+}
+
+// getVolumeSnapshotNamespace returns the namespace of a volumesnapshot created
+// for the group snapshot
+func getVolumeSnapshotNamespace(
+	groupSnap *stork_api.GroupVolumeSnapshot,
+	snapshot *stork_api.VolumeSnapshotStatus,
+) string {
+	if snapshot.VolumeSnapshotNamespace != "" {
+		return snapshot.VolumeSnapshotNamespace
+	}
+	return groupSnap.GetNamespace()
+}
+
 func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolumeSnapshot) (
 	*stork_api.GroupVolumeSnapshot, bool, error) {
-	ruleName := groupSnap.Spec.PostExecRule
-	if len(ruleName) == 0 { // No rule, move to final stage
+	ruleTargets, err := getRecordedRuleTargets(groupSnap)
+	if err != nil {
+		return nil, !updateCRD, err
+	}
+	hasPostSnapRules := false
+	for _, target := range ruleTargets {
+		hasPostSnapRules = hasPostSnapRules || target.PostExecRule != ""
+	}
+	if !hasPostSnapRules { // No rule, move to final stage
 		if groupSnap.Status.Status != stork_api.GroupSnapshotFailed {
 			groupSnap.Status.Status = stork_api.GroupSnapshotSuccessful
 		}
@@ -659,15 +866,13 @@ func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolum
 		return groupSnap, updateCRD, nil
 	}
 
-	logrus.Infof("Running post-snapshot rule: %s", ruleName)
-	r, err := storkops.Instance().GetRule(ruleName, groupSnap.Namespace)
-	if err != nil {
-		return nil, !updateCRD, err
-	}
-
-	_, err = rule.ExecuteRule(r, rule.PostExecRule, groupSnap, groupSnap.Namespace)
-	if err != nil {
-		return nil, !updateCRD, err
+	for _, target := range ruleTargets {
+		if target.PostExecRule == "" {
+			continue
+		}
+		if _, err := executeRuleForTarget(groupSnap, target, rule.PostExecRule); err != nil {
+			return nil, !updateCRD, err
+		}
 	}
 
 	// refresh the latest groupSnap as ExecuteRule might have  updated it
@@ -687,14 +892,23 @@ func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolum
 func (m *GroupSnapshotController) handleFinal(groupSnap *stork_api.GroupVolumeSnapshot) error {
 	// Check if user has updated restore namespace
 	childSnapshots := groupSnap.Status.VolumeSnapshots
-	if len(childSnapshots) > 0 {
-		currentRestoreNamespaces := ""
-		latestRestoreNamespacesInCSV := strings.Join(groupSnap.Spec.RestoreNamespaces, ",")
-
-		vsObject, err := k8sextops.Instance().GetSnapshot(childSnapshots[0].VolumeSnapshotName, groupSnap.GetNamespace())
+	// Snapshots could have been deleted by the retention of newer group
+	// snapshots, so check the first one that still exists
+	var vsObject *crdv1.VolumeSnapshot
+	for _, childSnap := range childSnapshots {
+		vs, err := k8sextops.Instance().GetSnapshot(childSnap.VolumeSnapshotName, getVolumeSnapshotNamespace(groupSnap, childSnap))
 		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
 			return err
 		}
+		vsObject = vs
+		break
+	}
+	if vsObject != nil {
+		currentRestoreNamespaces := ""
+		latestRestoreNamespacesInCSV := strings.Join(groupSnap.Spec.RestoreNamespaces, ",")
 
 		childSnapAnnotations := vsObject.Metadata.Annotations
 		if childSnapAnnotations != nil {
@@ -705,7 +919,7 @@ func (m *GroupSnapshotController) handleFinal(groupSnap *stork_api.GroupVolumeSn
 			log.GroupSnapshotLog(groupSnap).Infof("Updating restore namespaces for groupsnapshot to: %s",
 				latestRestoreNamespacesInCSV)
 			for _, childSnap := range childSnapshots {
-				vs, err := k8sextops.Instance().GetSnapshot(childSnap.VolumeSnapshotName, groupSnap.GetNamespace())
+				vs, err := k8sextops.Instance().GetSnapshot(childSnap.VolumeSnapshotName, getVolumeSnapshotNamespace(groupSnap, childSnap))
 				if err != nil {
 					if errors.IsNotFound(err) {
 						continue
@@ -730,14 +944,142 @@ func (m *GroupSnapshotController) handleFinal(groupSnap *stork_api.GroupVolumeSn
 	return nil
 }
 
-func (m *GroupSnapshotController) handleDelete(groupSnap *stork_api.GroupVolumeSnapshot) error {
+// pruneVolumeSnapshots deletes the older volumesnapshots for the PVCs in the
+// group snapshot that aren't retained by MaxSnapshots and MaxSnapshotAge.
+// Only volumesnapshots created by group snapshots from the same schedule are
+// considered. Nothing is pruned for group snapshots that aren't from a
+// schedule, since the snapshots of unrelated group snapshots can't be told
+// apart. The snapshots from this group snapshot are always kept.
+func (m *GroupSnapshotController) pruneVolumeSnapshots(groupSnap *stork_api.GroupVolumeSnapshot) error {
+	maxSnapshots := groupSnap.Spec.MaxSnapshots
+	maxAge := groupSnap.Spec.MaxSnapshotAge.Duration
+	scheduleName := groupSnap.Annotations[GroupSnapshotScheduleNameAnnotation]
+	if (maxSnapshots <= 0 && maxAge <= 0) || scheduleName == "" {
+		return nil
+	}
+
+	namespaces := make(map[string]bool)
+	for _, childSnap := range groupSnap.Status.VolumeSnapshots {
+		namespaces[getVolumeSnapshotNamespace(groupSnap, childSnap)] = true
+	}
+	for namespace := range namespaces {
+		snapshots, err := k8sextops.Instance().ListSnapshots(namespace)
+		if err != nil {
+			return err
+		}
+		// Find the PVCs with snapshots from this group snapshot and the
+		// snapshots from the other group snapshots for each PVC
+		pvcs := make(map[string]bool)
+		older := make(map[string][]crdv1.VolumeSnapshot)
+		for _, snap := range snapshots.Items {
+			parentName, ok := snap.Metadata.Annotations[groupSnapshotNameAnnotation]
+			if !ok || snap.Metadata.Annotations[GroupSnapshotScheduleNameAnnotation] != scheduleName {
+				continue
+			}
+			pvcName := snap.Spec.PersistentVolumeClaimName
+			if parentName == groupSnap.Name {
+				pvcs[pvcName] = true
+				continue
+			}
+			older[pvcName] = append(older[pvcName], snap)
+		}
+
+		for pvcName := range pvcs {
+			snaps := older[pvcName]
+			sort.Slice(snaps, func(i, j int) bool {
+				return snaps[j].Metadata.CreationTimestamp.Before(&snaps[i].Metadata.CreationTimestamp)
+			})
+			// The snapshot from this group snapshot is kept
+			kept := 1
+			for _, snap := range snaps {
+				if (maxSnapshots <= 0 || kept < maxSnapshots) &&
+					(maxAge <= 0 || time.Since(snap.Metadata.CreationTimestamp.Time) <= maxAge) {
+					kept++
+					continue
+				}
+				log.GroupSnapshotLog(groupSnap).Infof("Deleting volumesnapshot %v/%v for PVC %v since it isn't retained",
+					namespace, snap.Metadata.Name, pvcName)
+				if err := k8sextops.Instance().DeleteSnapshot(snap.Metadata.Name, namespace); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// handleDelete deletes the group snapshot from the driver. Returns true once
+// the finalizer can be removed. Failed deletes are retried at the interval
+// of snapDeleteBackoff. Once its steps have been used up the group snapshot
+// is removed anyway if the force delete annotation is set.
+func (m *GroupSnapshotController) handleDelete(
+	ctx context.Context,
+	groupSnap *stork_api.GroupVolumeSnapshot,
+) (bool, error) {
 	// no need to track minResourceVersion for this group snap any longer
 	delete(m.minResourceVersions, string(groupSnap.UID))
 
-	if err := m.volDriver.DeleteGroupSnapshot(groupSnap); err != nil {
-		return err
+	if groupSnap.Status.DeleteAttempts > 0 &&
+		time.Since(groupSnap.Status.LastDeleteAttemptTimestamp.Time) < snapDeleteBackoff.Duration {
+		return false, nil
+	}
+	deleteErr := m.volDriver.DeleteGroupSnapshot(groupSnap)
+	if deleteErr == nil {
+		return true, nil
+	}
+	groupSnap.Status.DeleteAttempts++
+	groupSnap.Status.LastDeleteAttemptTimestamp = metav1.Now()
+	log.GroupSnapshotLog(groupSnap).Infof("Failed to delete group snapshot (attempt %v of %v) due to: %v",
+		groupSnap.Status.DeleteAttempts, snapDeleteBackoff.Steps, deleteErr)
+
+	force, _ := strconv.ParseBool(groupSnap.Annotations[forceDeleteAnnotation])
+	if groupSnap.Status.DeleteAttempts < snapDeleteBackoff.Steps || !force {
+		if groupSnap.Status.DeleteAttempts == snapDeleteBackoff.Steps {
+			m.recorder.Event(groupSnap,
+				v1.EventTypeWarning,
+				string(stork_api.GroupSnapshotFailed),
+				fmt.Sprintf("Failed to delete group snapshot after %v attempts, set %v to remove it without deleting the snapshots from the driver: %v",
+					groupSnap.Status.DeleteAttempts, forceDeleteAnnotation, deleteErr))
+		}
+		if err := m.client.Update(ctx, groupSnap); err != nil {
+			return false, err
+		}
+		return false, deleteErr
+	}
+
+	// Snapshots in other namespaces don't have owner references to the group
+	// snapshot, so they have to be deleted before it is removed
+	if err := deleteOtherNamespaceSnapshots(groupSnap); err != nil {
+		if updateErr := m.client.Update(ctx, groupSnap); updateErr != nil {
+			return false, updateErr
+		}
+		return false, err
 	}
+	message := fmt.Sprintf("Removing group snapshot without deleting the snapshots from the driver after %v attempts since %v is set: %v",
+		groupSnap.Status.DeleteAttempts, forceDeleteAnnotation, deleteErr)
+	log.GroupSnapshotLog(groupSnap).Warnf(message)
+	m.recorder.Event(groupSnap,
+		v1.EventTypeWarning,
+		string(stork_api.GroupSnapshotFailed),
+		message)
+	return true, nil
+}
 
+// deleteOtherNamespaceSnapshots deletes the volumesnapshots for a group
+// snapshot that are in namespaces other than the group snapshot's
+func deleteOtherNamespaceSnapshots(groupSnap *stork_api.GroupVolumeSnapshot) error {
+	for _, snapshot := range groupSnap.Status.VolumeSnapshots {
+		if snapshot.VolumeSnapshotName == "" ||
+			snapshot.VolumeSnapshotNamespace == "" ||
+			snapshot.VolumeSnapshotNamespace == groupSnap.Namespace {
+			continue
+		}
+		err := k8sextops.Instance().DeleteSnapshot(snapshot.VolumeSnapshotName, snapshot.VolumeSnapshotNamespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting volumesnapshot %v/%v: %v",
+				snapshot.VolumeSnapshotNamespace, snapshot.VolumeSnapshotName, err)
+		}
+	}
 	return nil
 }
 
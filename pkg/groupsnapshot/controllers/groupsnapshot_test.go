@@ -0,0 +1,215 @@
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newGroupSnapshotPVC(namespace, name string, labels map[string]string) v1.PersistentVolumeClaim {
+	return v1.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+			Labels:    labels,
+		},
+	}
+}
+
+func newRuleAssociation(app, preExecRule, postExecRule string) stork_api.GroupVolumeSnapshotRuleAssociation {
+	association := stork_api.GroupVolumeSnapshotRuleAssociation{
+		PreExecRule:  preExecRule,
+		PostExecRule: postExecRule,
+	}
+	association.PVCSelector.MatchLabels = map[string]string{"app": app}
+	return association
+}
+
+func TestGetRuleTargets(t *testing.T) {
+	pvcs := []v1.PersistentVolumeClaim{
+		newGroupSnapshotPVC("ns2", "db-1", map[string]string{"app": "db"}),
+		newGroupSnapshotPVC("ns1", "db-2", map[string]string{"app": "db"}),
+		newGroupSnapshotPVC("ns1", "db-1", map[string]string{"app": "db"}),
+		newGroupSnapshotPVC("ns1", "web", map[string]string{"app": "web"}),
+	}
+
+	tests := []struct {
+		name     string
+		spec     stork_api.GroupVolumeSnapshotSpec
+		expected []stork_api.GroupVolumeSnapshotRuleTarget
+	}{
+		{
+			name:     "no rules",
+			expected: []stork_api.GroupVolumeSnapshotRuleTarget{},
+		},
+		{
+			name: "rules from the spec run on all pods",
+			spec: stork_api.GroupVolumeSnapshotSpec{
+				PreExecRule:  "pre",
+				PostExecRule: "post",
+			},
+			expected: []stork_api.GroupVolumeSnapshotRuleTarget{
+				{PreExecRule: "pre", PostExecRule: "post", Namespace: "admin"},
+			},
+		},
+		{
+			name: "associations run on the pods of their PVCs",
+			spec: stork_api.GroupVolumeSnapshotSpec{
+				PreExecRule: "pre",
+				RuleAssociations: []stork_api.GroupVolumeSnapshotRuleAssociation{
+					newRuleAssociation("db", "db-pre", "db-post"),
+					newRuleAssociation("web", "", "web-post"),
+					newRuleAssociation("cache", "cache-pre", "cache-post"),
+					newRuleAssociation("db", "", ""),
+				},
+			},
+			expected: []stork_api.GroupVolumeSnapshotRuleTarget{
+				{PreExecRule: "pre", Namespace: "admin"},
+				{PreExecRule: "db-pre", PostExecRule: "db-post", Namespace: "ns1", PersistentVolumeClaims: []string{"db-1", "db-2"}},
+				{PreExecRule: "db-pre", PostExecRule: "db-post", Namespace: "ns2", PersistentVolumeClaims: []string{"db-1"}},
+				{PostExecRule: "web-post", Namespace: "ns1", PersistentVolumeClaims: []string{"web"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		groupSnap := &stork_api.GroupVolumeSnapshot{Spec: test.spec}
+		groupSnap.Namespace = "admin"
+		require.Equal(t, test.expected, getRuleTargets(groupSnap, pvcs), test.name)
+	}
+}
+
+func TestGetRecordedRuleTargets(t *testing.T) {
+	groupSnap := &stork_api.GroupVolumeSnapshot{
+		Spec: stork_api.GroupVolumeSnapshotSpec{
+			PreExecRule: "pre",
+			RuleAssociations: []stork_api.GroupVolumeSnapshotRuleAssociation{
+				newRuleAssociation("db", "db-pre", "db-post"),
+			},
+		},
+	}
+	recorded := []stork_api.GroupVolumeSnapshotRuleTarget{
+		{PreExecRule: "db-pre", PostExecRule: "db-post", Namespace: "ns1", PersistentVolumeClaims: []string{"db-1"}},
+	}
+	groupSnap.Status.RuleTargets = recorded
+
+	// The recorded targets are used without listing the PVCs again
+	targets, err := getRecordedRuleTargets(groupSnap)
+	require.NoError(t, err, "Error getting recorded rule targets")
+	require.Equal(t, recorded, targets)
+}
+
+// failingDeleteDriver fails to delete group snapshots
+type failingDeleteDriver struct {
+	volume.Driver
+	deletes int
+}
+
+func (d *failingDeleteDriver) DeleteGroupSnapshot(*stork_api.GroupVolumeSnapshot) error {
+	d.deletes++
+	return fmt.Errorf("driver unavailable")
+}
+
+// updateCountingClient counts the objects that are updated. Other calls
+// aren't expected.
+type updateCountingClient struct {
+	runtimeclient.Client
+	updates int
+}
+
+func (c *updateCountingClient) Update(ctx context.Context, obj runtimeclient.Object, opts ...runtimeclient.UpdateOption) error {
+	c.updates++
+	return nil
+}
+
+// snapshotDeleteRecorder records the volumesnapshots that are deleted
+type snapshotDeleteRecorder struct {
+	k8sextops.Ops
+	deleted []string
+}
+
+func (r *snapshotDeleteRecorder) DeleteSnapshot(name string, namespace string) error {
+	r.deleted = append(r.deleted, namespace+"/"+name)
+	return nil
+}
+
+func TestHandleDeleteForce(t *testing.T) {
+	snapshotOps := &snapshotDeleteRecorder{}
+	k8sextops.SetInstance(snapshotOps)
+	driver := &failingDeleteDriver{}
+	client := &updateCountingClient{}
+	m := &GroupSnapshotController{
+		client:              client,
+		volDriver:           driver,
+		recorder:            record.NewFakeRecorder(10),
+		minResourceVersions: make(map[string]string),
+	}
+	groupSnap := &stork_api.GroupVolumeSnapshot{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        "group",
+			Namespace:   "ns",
+			Annotations: map[string]string{forceDeleteAnnotation: "true"},
+		},
+		Status: stork_api.GroupVolumeSnapshotStatus{
+			VolumeSnapshots: []*stork_api.VolumeSnapshotStatus{
+				{VolumeSnapshotName: "local", VolumeSnapshotNamespace: "ns"},
+				{VolumeSnapshotName: "other", VolumeSnapshotNamespace: "other"},
+			},
+		},
+	}
+
+	// The finalizer isn't removed on the first failure even though force is
+	// set
+	deleted, err := m.handleDelete(context.TODO(), groupSnap)
+	require.Error(t, err)
+	require.False(t, deleted)
+	require.Equal(t, 1, groupSnap.Status.DeleteAttempts)
+	require.Equal(t, 1, client.updates)
+
+	// Not retried until the backoff interval has passed
+	deleted, err = m.handleDelete(context.TODO(), groupSnap)
+	require.NoError(t, err)
+	require.False(t, deleted)
+	require.Equal(t, 1, driver.deletes)
+
+	// Removed once all the attempts have failed, after deleting the
+	// snapshots in other namespaces
+	groupSnap.Status.DeleteAttempts = snapDeleteBackoff.Steps - 1
+	groupSnap.Status.LastDeleteAttemptTimestamp = meta.NewTime(time.Now().Add(-snapDeleteBackoff.Duration))
+	deleted, err = m.handleDelete(context.TODO(), groupSnap)
+	require.NoError(t, err)
+	require.True(t, deleted)
+	require.Equal(t, []string{"other/other"}, snapshotOps.deleted)
+}
+
+func TestHandleDeleteWithoutForce(t *testing.T) {
+	k8sextops.SetInstance(&snapshotDeleteRecorder{})
+	m := &GroupSnapshotController{
+		client:              &updateCountingClient{},
+		volDriver:           &failingDeleteDriver{},
+		recorder:            record.NewFakeRecorder(10),
+		minResourceVersions: make(map[string]string),
+	}
+	groupSnap := &stork_api.GroupVolumeSnapshot{
+		ObjectMeta: meta.ObjectMeta{Name: "group", Namespace: "ns"},
+		Status: stork_api.GroupVolumeSnapshotStatus{
+			DeleteAttempts:             snapDeleteBackoff.Steps,
+			LastDeleteAttemptTimestamp: meta.NewTime(time.Now().Add(-snapDeleteBackoff.Duration)),
+		},
+	}
+	deleted, err := m.handleDelete(context.TODO(), groupSnap)
+	require.Error(t, err)
+	require.False(t, deleted)
+}
@@ -0,0 +1,387 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/schedule"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	nameTimeSuffixFormat string = "2006-01-02-150405"
+
+	// GroupSnapshotScheduleNameAnnotation Annotation used to specify the name
+	// of schedule that created the group snapshot
+	GroupSnapshotScheduleNameAnnotation = "stork.libopenstorage.org/groupSnapshotScheduleName"
+	// GroupSnapshotSchedulePolicyTypeAnnotation Annotation used to specify the
+	// type of the policy that triggered the group snapshot
+	GroupSnapshotSchedulePolicyTypeAnnotation = "stork.libopenstorage.org/groupSnapshotSchedulePolicyType"
+)
+
+// NewGroupSnapshotScheduleController creates a new instance of GroupSnapshotScheduleController.
+func NewGroupSnapshotScheduleController(mgr manager.Manager, r record.EventRecorder) *GroupSnapshotScheduleController {
+	return &GroupSnapshotScheduleController{
+		client:   mgr.GetClient(),
+		recorder: r,
+	}
+}
+
+// GroupSnapshotScheduleController reconciles GroupVolumeSnapshotSchedule
+// objects. The group snapshots that it creates are handled by the
+// GroupSnapshotController.
+type GroupSnapshotScheduleController struct {
+	client runtimeclient.Client
+
+	recorder record.EventRecorder
+}
+
+// Init Initialize the group snapshot schedule controller
+func (s *GroupSnapshotScheduleController) Init(mgr manager.Manager) error {
+	err := s.createCRD(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("register crd: %s", err)
+	}
+
+	return controllers.RegisterTo(mgr, "group-snapshot-schedule-controller", s, &stork_api.GroupVolumeSnapshotSchedule{})
+}
+
+// Reconcile manages GroupVolumeSnapshotSchedule resources.
+func (s *GroupSnapshotScheduleController) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	logrus.Tracef("Reconciling GroupVolumeSnapshotSchedule %s/%s", request.Namespace, request.Name)
+
+	groupSnapshotSchedule := &stork_api.GroupVolumeSnapshotSchedule{}
+	err := s.client.Get(context.TODO(), request.NamespacedName, groupSnapshotSchedule)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+	}
+
+	if err = s.handle(context.TODO(), groupSnapshotSchedule); err != nil {
+		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(s), groupSnapshotSchedule.Namespace, groupSnapshotSchedule.Name, err)
+		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+	}
+
+	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+}
+
+// Handle updates for GroupVolumeSnapshotSchedule objects
+func (s *GroupSnapshotScheduleController) handle(ctx context.Context, groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule) error {
+	// Nothing to do for delete. The group snapshots are deleted through
+	// their owner reference if the reclaim policy is Delete.
+	if groupSnapshotSchedule.DeletionTimestamp != nil {
+		return nil
+	}
+
+	s.setDefaults(groupSnapshotSchedule)
+	// First update the status of any pending group snapshots
+	err := s.updateGroupSnapshotStatus(groupSnapshotSchedule)
+	if err != nil {
+		msg := fmt.Sprintf("Error updating group snapshot status: %v", err)
+		s.recorder.Event(groupSnapshotSchedule,
+			v1.EventTypeWarning,
+			string(stork_api.GroupSnapshotFailed),
+			msg)
+		log.GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Error(msg)
+		return err
+	}
+
+	if groupSnapshotSchedule.Spec.Suspend == nil || !*groupSnapshotSchedule.Spec.Suspend {
+		// Then check if any of the policies require a trigger
+		policyType, start, err := s.shouldStartGroupSnapshot(groupSnapshotSchedule)
+		if err != nil {
+			msg := fmt.Sprintf("Error checking if group snapshot should be triggered: %v", err)
+			s.recorder.Event(groupSnapshotSchedule,
+				v1.EventTypeWarning,
+				string(stork_api.GroupSnapshotFailed),
+				msg)
+			log.GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Error(msg)
+			return nil
+		}
+
+		// Start a group snapshot for a policy if required
+		if start {
+			err := s.startGroupSnapshot(groupSnapshotSchedule, policyType)
+			if err != nil {
+				msg := fmt.Sprintf("Error triggering group snapshot for schedule(%v): %v", policyType, err)
+				s.recorder.Event(groupSnapshotSchedule,
+					v1.EventTypeWarning,
+					string(stork_api.GroupSnapshotFailed),
+					msg)
+				log.GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Error(msg)
+				return err
+			}
+		}
+	}
+
+	// Finally, prune any old group snapshots that were triggered for this
+	// schedule
+	err = s.pruneGroupSnapshots(groupSnapshotSchedule)
+	if err != nil {
+		msg := fmt.Sprintf("Error pruning old group snapshots: %v", err)
+		s.recorder.Event(groupSnapshotSchedule,
+			v1.EventTypeWarning,
+			string(stork_api.GroupSnapshotFailed),
+			msg)
+		log.GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Error(msg)
+		return err
+	}
+
+	return nil
+}
+
+func (s *GroupSnapshotScheduleController) setDefaults(groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule) {
+	if groupSnapshotSchedule.Spec.ReclaimPolicy == "" {
+		groupSnapshotSchedule.Spec.ReclaimPolicy = stork_api.ReclaimPolicyDelete
+	}
+}
+
+func getGroupSnapshotStatus(name string, namespace string) (stork_api.GroupVolumeSnapshotStatusType, error) {
+	groupSnapshot, err := storkops.Instance().GetGroupSnapshot(name, namespace)
+	if err != nil {
+		// Don't wait forever for group snapshots that were deleted before
+		// they completed
+		if errors.IsNotFound(err) {
+			return stork_api.GroupSnapshotFailed, nil
+		}
+		return stork_api.GroupSnapshotFailed, err
+	}
+	if groupSnapshot.Status.Stage != stork_api.GroupSnapshotStageFinal {
+		return stork_api.GroupSnapshotInProgress, nil
+	}
+	return groupSnapshot.Status.Status, nil
+}
+
+func (s *GroupSnapshotScheduleController) updateGroupSnapshotStatus(groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule) error {
+	updated := false
+	for _, policyGroupSnapshot := range groupSnapshotSchedule.Status.Items {
+		for _, groupSnapshot := range policyGroupSnapshot {
+			// Get the updated status if we see it as not completed
+			if !s.isGroupSnapshotComplete(groupSnapshot.Status) {
+				pendingGroupSnapshotStatus, err := getGroupSnapshotStatus(groupSnapshot.Name, groupSnapshotSchedule.Namespace)
+				if err != nil {
+					return err
+				}
+
+				// Check again and update the status if it is completed
+				groupSnapshot.Status = pendingGroupSnapshotStatus
+				if s.isGroupSnapshotComplete(groupSnapshot.Status) {
+					groupSnapshot.FinishTimestamp = metav1.NewTime(schedule.GetCurrentTime())
+					if pendingGroupSnapshotStatus == stork_api.GroupSnapshotSuccessful {
+						s.recorder.Event(groupSnapshotSchedule,
+							v1.EventTypeNormal,
+							string(stork_api.GroupSnapshotSuccessful),
+							fmt.Sprintf("Scheduled group snapshot (%v) completed successfully", groupSnapshot.Name))
+					} else {
+						s.recorder.Event(groupSnapshotSchedule,
+							v1.EventTypeWarning,
+							string(stork_api.GroupSnapshotFailed),
+							fmt.Sprintf("Scheduled group snapshot (%v) failed", groupSnapshot.Name))
+					}
+				}
+				updated = true
+			}
+		}
+	}
+	if updated {
+		err := s.client.Update(context.TODO(), groupSnapshotSchedule)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *GroupSnapshotScheduleController) isGroupSnapshotComplete(status stork_api.GroupVolumeSnapshotStatusType) bool {
+	return status == stork_api.GroupSnapshotSuccessful || status == stork_api.GroupSnapshotFailed
+}
+
+func (s *GroupSnapshotScheduleController) shouldStartGroupSnapshot(groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule) (stork_api.SchedulePolicyType, bool, error) {
+	// Don't trigger a new group snapshot if one is already in progress
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		policyGroupSnapshot, present := groupSnapshotSchedule.Status.Items[policyType]
+		if present {
+			for _, groupSnapshot := range policyGroupSnapshot {
+				if !s.isGroupSnapshotComplete(groupSnapshot.Status) {
+					return stork_api.SchedulePolicyTypeInvalid, false, nil
+				}
+			}
+		}
+	}
+
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		var latestGroupSnapshotTimestamp metav1.Time
+		policyGroupSnapshot, present := groupSnapshotSchedule.Status.Items[policyType]
+		if present {
+			for _, groupSnapshot := range policyGroupSnapshot {
+				if latestGroupSnapshotTimestamp.Before(&groupSnapshot.CreationTimestamp) {
+					latestGroupSnapshotTimestamp = groupSnapshot.CreationTimestamp
+				}
+			}
+		}
+		trigger, err := schedule.TriggerRequired(
+			groupSnapshotSchedule.Spec.SchedulePolicyName,
+			groupSnapshotSchedule.Namespace,
+			policyType,
+			latestGroupSnapshotTimestamp,
+		)
+		if err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+		if trigger {
+			return policyType, true, nil
+		}
+	}
+	return stork_api.SchedulePolicyTypeInvalid, false, nil
+}
+
+func (s *GroupSnapshotScheduleController) formatGroupSnapshotName(groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) string {
+	return strings.Join([]string{groupSnapshotSchedule.Name, strings.ToLower(string(policyType)), time.Now().Format(nameTimeSuffixFormat)}, "-")
+}
+
+func (s *GroupSnapshotScheduleController) startGroupSnapshot(groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) error {
+	groupSnapshotName := s.formatGroupSnapshotName(groupSnapshotSchedule, policyType)
+	if groupSnapshotSchedule.Status.Items == nil {
+		groupSnapshotSchedule.Status.Items = make(map[stork_api.SchedulePolicyType][]*stork_api.ScheduledGroupVolumeSnapshotStatus)
+	}
+	if groupSnapshotSchedule.Status.Items[policyType] == nil {
+		groupSnapshotSchedule.Status.Items[policyType] = make([]*stork_api.ScheduledGroupVolumeSnapshotStatus, 0)
+	}
+	groupSnapshotSchedule.Status.Items[policyType] = append(groupSnapshotSchedule.Status.Items[policyType],
+		&stork_api.ScheduledGroupVolumeSnapshotStatus{
+			Name:              groupSnapshotName,
+			CreationTimestamp: metav1.NewTime(schedule.GetCurrentTime()),
+			Status:            stork_api.GroupSnapshotPending,
+		})
+	err := s.client.Update(context.TODO(), groupSnapshotSchedule)
+	if err != nil {
+		return err
+	}
+
+	groupSnapshot := &stork_api.GroupVolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        groupSnapshotName,
+			Namespace:   groupSnapshotSchedule.Namespace,
+			Annotations: make(map[string]string),
+			Labels:      groupSnapshotSchedule.Labels,
+		},
+		Spec: *groupSnapshotSchedule.Spec.Template.Spec.DeepCopy(),
+	}
+	for k, v := range groupSnapshotSchedule.Annotations {
+		groupSnapshot.Annotations[k] = v
+	}
+	groupSnapshot.Annotations[GroupSnapshotScheduleNameAnnotation] = groupSnapshotSchedule.Name
+	groupSnapshot.Annotations[GroupSnapshotSchedulePolicyTypeAnnotation] = string(policyType)
+
+	// The options from the policy are passed to the driver along with the
+	// options from the template
+	options, err := schedule.GetOptions(groupSnapshotSchedule.Spec.SchedulePolicyName, groupSnapshotSchedule.Namespace, policyType)
+	if err != nil {
+		return err
+	}
+	if groupSnapshot.Spec.Options == nil {
+		groupSnapshot.Spec.Options = make(map[string]string)
+	}
+	for k, v := range options {
+		groupSnapshot.Spec.Options[k] = v
+	}
+
+	log.GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Infof("Starting group snapshot %v", groupSnapshotName)
+	// If reclaim policy is set to Delete, this will delete the group
+	// snapshots created by this schedule when the schedule object is deleted
+	if groupSnapshotSchedule.Spec.ReclaimPolicy == stork_api.ReclaimPolicyDelete {
+		groupSnapshot.OwnerReferences = []metav1.OwnerReference{
+			{
+				Name:       groupSnapshotSchedule.Name,
+				UID:        groupSnapshotSchedule.UID,
+				Kind:       groupSnapshotSchedule.GetObjectKind().GroupVersionKind().Kind,
+				APIVersion: groupSnapshotSchedule.GetObjectKind().GroupVersionKind().GroupVersion().String(),
+			},
+		}
+	}
+	_, err = storkops.Instance().CreateGroupSnapshot(groupSnapshot)
+	return err
+}
+
+// pruneGroupSnapshots deletes the group snapshots that aren't retained by the
+// schedule policy. The snapshots in the driver are cleaned up by the group
+// snapshot controller when the group snapshot object is removed.
+func (s *GroupSnapshotScheduleController) pruneGroupSnapshots(groupSnapshotSchedule *stork_api.GroupVolumeSnapshotSchedule) error {
+	for policyType, policyGroupSnapshot := range groupSnapshotSchedule.Status.Items {
+		numGroupSnapshots := len(policyGroupSnapshot)
+		deleteBefore := 0
+		retainNum, err := schedule.GetRetain(groupSnapshotSchedule.Spec.SchedulePolicyName, groupSnapshotSchedule.Namespace, policyType)
+		if err != nil {
+			return err
+		}
+		numReady := 0
+
+		// Keep up to retainNum successful group snapshot statuses and all
+		// failed group snapshots until there is a successful one
+		if numGroupSnapshots > int(retainNum) {
+			// Start from the end and find the retainNum successful group snapshots
+			for i := range policyGroupSnapshot {
+				if policyGroupSnapshot[(numGroupSnapshots-1-i)].Status == stork_api.GroupSnapshotSuccessful {
+					numReady++
+					if numReady > int(retainNum) {
+						deleteBefore = numGroupSnapshots - i
+						break
+					}
+				}
+			}
+			failedDeletes := make([]*stork_api.ScheduledGroupVolumeSnapshotStatus, 0)
+			if numReady > int(retainNum) {
+				for i := 0; i < deleteBefore; i++ {
+					err := storkops.Instance().DeleteGroupSnapshot(policyGroupSnapshot[i].Name, groupSnapshotSchedule.Namespace)
+					if err != nil && !errors.IsNotFound(err) {
+						log.GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Warnf("Error deleting %v: %v", policyGroupSnapshot[i].Name, err)
+						// Keep a track of the failed deletes
+						failedDeletes = append(failedDeletes, policyGroupSnapshot[i])
+					}
+				}
+			}
+			// Remove all the ones we tried to delete above
+			groupSnapshotSchedule.Status.Items[policyType] = policyGroupSnapshot[deleteBefore:]
+			// And re-add the ones that failed so that we don't lose track
+			// of them
+			groupSnapshotSchedule.Status.Items[policyType] = append(failedDeletes, groupSnapshotSchedule.Status.Items[policyType]...)
+		}
+	}
+	return s.client.Update(context.TODO(), groupSnapshotSchedule)
+}
+
+func (s *GroupSnapshotScheduleController) createCRD(config *rest.Config) error {
+	resource := apiextensions.CustomResource{
+		Name:    stork_api.GroupVolumeSnapshotScheduleResourceName,
+		Plural:  stork_api.GroupVolumeSnapshotScheduleResourcePlural,
+		Group:   stork_api.SchemeGroupVersion.Group,
+		Version: stork_api.SchemeGroupVersion.Version,
+		Scope:   apiextensionsv1beta1.NamespaceScoped,
+		Kind:    reflect.TypeOf(stork_api.GroupVolumeSnapshotSchedule{}).Name(),
+	}
+	return k8sutils.CreateCRD(config, resource)
+}
@@ -17,16 +17,27 @@ import (
 type GroupSnapshot struct {
 	Driver   volume.Driver
 	Recorder record.EventRecorder
+	// StatusPollJitter is the jitter factor for the interval at which the
+	// status of group snapshots in progress is checked
+	StatusPollJitter float64
+	// AdminNamespace is the namespace in which group snapshots can select
+	// PVCs from other namespaces
+	AdminNamespace string
 }
 
 // Init init
 func (m *GroupSnapshot) Init(mgr manager.Manager) error {
-	r := controllers.NewGroupSnapshot(mgr, m.Driver, m.Recorder)
+	r := controllers.NewGroupSnapshot(mgr, m.Driver, m.Recorder, m.StatusPollJitter)
 
-	if err := r.Init(mgr); err != nil {
+	if err := r.Init(mgr, m.AdminNamespace); err != nil {
 		return fmt.Errorf("initializing groupSnapshot controller: %v", err)
 	}
 
+	scheduleController := controllers.NewGroupSnapshotScheduleController(mgr, m.Recorder)
+	if err := scheduleController.Init(mgr); err != nil {
+		return fmt.Errorf("initializing groupSnapshot schedule controller: %v", err)
+	}
+
 	if err := m.performRuleRecovery(); err != nil {
 		return fmt.Errorf("error doing recovery on pending group snapshot rules: %v", err)
 	}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"time"
 
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
 	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
 	v1 "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
@@ -13,39 +16,82 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
 )
 
 const (
 	crdTimeout    = 1 * time.Minute
 	retryInterval = 5 * time.Second
+
+	crdV1beta1GroupVersion = "apiextensions.k8s.io/v1beta1"
 )
 
-// GetPVCsForGroupSnapshot returns all PVCs in given namespace that match the given matchLabels. All PVCs need to be bound.
-func GetPVCsForGroupSnapshot(namespace string, matchLabels map[string]string) ([]v1.PersistentVolumeClaim, error) {
-	pvcList, err := core.Instance().GetPersistentVolumeClaims(namespace, matchLabels)
-	if err != nil {
+// Namespace designated by the admin, BackupLocations from here can be used by
+// objects in all namespaces
+var adminNamespace string
+
+// SetAdminNamespace sets the namespace designated by the admin that is used as
+// a fallback when looking up BackupLocations
+func SetAdminNamespace(namespace string) {
+	adminNamespace = namespace
+}
+
+// GetBackupLocation returns the BackupLocation with the given name from the
+// namespace. If it doesn't exist there the BackupLocation with the same name
+// from the admin namespace is returned instead.
+func GetBackupLocation(name string, namespace string) (*storkapi.BackupLocation, error) {
+	backupLocation, err := storkops.Instance().GetBackupLocation(name, namespace)
+	if err == nil || !errors.IsNotFound(err) || adminNamespace == "" || adminNamespace == namespace {
+		return backupLocation, err
+	}
+	adminBackupLocation, adminErr := storkops.Instance().GetBackupLocation(name, adminNamespace)
+	if adminErr != nil {
+		// Return the original error since the location wasn't found in
+		// either namespace
 		return nil, err
 	}
+	return adminBackupLocation, nil
+}
+
+// GetGroupSnapshotNamespaces returns the namespaces in which PVCs are selected
+// for the group snapshot. Defaults to the namespace of the group snapshot.
+func GetGroupSnapshotNamespaces(groupSnap *storkapi.GroupVolumeSnapshot) []string {
+	if len(groupSnap.Spec.Namespaces) == 0 {
+		return []string{groupSnap.Namespace}
+	}
+	return groupSnap.Spec.Namespaces
+}
+
+// GetPVCsForGroupSnapshot returns all PVCs in given namespaces that match the given matchLabels. All PVCs need to be bound.
+func GetPVCsForGroupSnapshot(namespaces []string, matchLabels map[string]string) ([]v1.PersistentVolumeClaim, error) {
+	pvcs := make([]v1.PersistentVolumeClaim, 0)
+	for _, namespace := range namespaces {
+		pvcList, err := core.Instance().GetPersistentVolumeClaims(namespace, matchLabels)
+		if err != nil {
+			return nil, err
+		}
+		pvcs = append(pvcs, pvcList.Items...)
+	}
 
-	if len(pvcList.Items) == 0 {
+	if len(pvcs) == 0 {
 		return nil, fmt.Errorf("found no PVCs for group snapshot with given label selectors: %v", matchLabels)
 	}
 
 	// Check if no PVCs are in pending state
-	for _, pvc := range pvcList.Items {
+	for _, pvc := range pvcs {
 		if pvc.Status.Phase == v1.ClaimPending {
 			return nil, fmt.Errorf("PVC: [%s] %s is still in %s phase. Group snapshot will trigger after all PVCs are bound",
 				pvc.Namespace, pvc.Name, pvc.Status.Phase)
 		}
 	}
 
-	return pvcList.Items, nil
+	return pvcs, nil
 }
 
-// GetVolumeNamesFromLabelSelector returns PV names for all PVCs in given namespace that match the given
+// GetVolumeNamesFromLabelSelector returns PV names for all PVCs in given namespaces that match the given
 // labels
-func GetVolumeNamesFromLabelSelector(namespace string, labels map[string]string) ([]string, error) {
-	pvcs, err := GetPVCsForGroupSnapshot(namespace, labels)
+func GetVolumeNamesFromLabelSelector(namespaces []string, labels map[string]string) ([]string, error) {
+	pvcs, err := GetPVCsForGroupSnapshot(namespaces, labels)
 	if err != nil {
 		return nil, err
 	}
@@ -112,3 +158,58 @@ func ValidateCRDV1(client *clientset.Clientset, crdName string) error {
 		return false, nil
 	})
 }
+
+// CreateCRD creates the CRD for the given resource and waits for it to be
+// registered. v1 CRDs are created if the cluster doesn't serve v1beta1 CRDs.
+func CreateCRD(config *rest.Config, resource apiextensions.CustomResource) error {
+	client, err := clientset.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.Discovery().ServerResourcesForGroupVersion(crdV1beta1GroupVersion); err == nil {
+		err := apiextensions.Instance().CreateCRD(resource)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return apiextensions.Instance().ValidateCRD(resource, crdTimeout, retryInterval)
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("error checking for %v support: %v", crdV1beta1GroupVersion, err)
+	}
+
+	crdName := fmt.Sprintf("%s.%s", resource.Plural, resource.Group)
+	preserveUnknownFields := true
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: crdName,
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: resource.Group,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{
+					Name:    resource.Version,
+					Served:  true,
+					Storage: true,
+					Schema: &apiextensionsv1.CustomResourceValidation{
+						OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+							Type:                   "object",
+							XPreserveUnknownFields: &preserveUnknownFields,
+						},
+					},
+				},
+			},
+			Scope: apiextensionsv1.ResourceScope(resource.Scope),
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Singular:   resource.Name,
+				Plural:     resource.Plural,
+				Kind:       resource.Kind,
+				ShortNames: resource.ShortNames,
+			},
+		},
+	}
+	_, err = client.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return ValidateCRDV1(client, crdName)
+}
@@ -0,0 +1,193 @@
+package k8sutils
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/util/slice"
+)
+
+const (
+	// PodSecurityEnforceLabel is the namespace label with the Pod Security
+	// Standard level enforced by admission
+	PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+	// PodSecurityLevelPrivileged doesn't restrict pods
+	PodSecurityLevelPrivileged = "privileged"
+	// PodSecurityLevelBaseline prevents known privilege escalations
+	PodSecurityLevelBaseline = "baseline"
+	// PodSecurityLevelRestricted enforces pod hardening best practices
+	PodSecurityLevelRestricted = "restricted"
+)
+
+// Capabilities that can be added at the baseline level
+var baselineCapabilities = []string{
+	"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+	"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT",
+}
+
+// Sysctls that can be set at the baseline level
+var baselineSysctls = []string{
+	"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_unprivileged_port_start", "net.ipv4.tcp_syncookies",
+	"net.ipv4.ping_group_range",
+}
+
+// GetPodSecurityViolations returns the reasons that a pod spec would be
+// rejected by Pod Security admission at the given level. This covers the
+// checks for the pod and container fields, but not the AppArmor annotations.
+func GetPodSecurityViolations(level string, spec *v1.PodSpec) []string {
+	violations := make([]string, 0)
+	if level != PodSecurityLevelBaseline && level != PodSecurityLevelRestricted {
+		return violations
+	}
+
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "host namespaces are not allowed")
+	}
+	for _, sysctl := range getPodSysctls(spec) {
+		if !slice.ContainsString(baselineSysctls, sysctl, nil) {
+			violations = append(violations, fmt.Sprintf("sysctl %v is not allowed", sysctl))
+		}
+	}
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("hostPath volume %v is not allowed", volume.Name))
+		} else if level == PodSecurityLevelRestricted && !isRestrictedVolume(volume) {
+			violations = append(violations, fmt.Sprintf("volume %v has a type that is not allowed", volume.Name))
+		}
+	}
+
+	podRunAsNonRoot := false
+	podSeccompSet := false
+	if spec.SecurityContext != nil {
+		if spec.SecurityContext.RunAsNonRoot != nil {
+			podRunAsNonRoot = *spec.SecurityContext.RunAsNonRoot
+		}
+		if spec.SecurityContext.RunAsUser != nil && *spec.SecurityContext.RunAsUser == 0 && level == PodSecurityLevelRestricted {
+			violations = append(violations, "running as user 0 is not allowed")
+		}
+		if violation := checkSeccompProfile(spec.SecurityContext.SeccompProfile, "pod"); violation != "" {
+			violations = append(violations, violation)
+		}
+		podSeccompSet = spec.SecurityContext.SeccompProfile != nil
+	}
+
+	containers := make([]v1.Container, 0)
+	containers = append(containers, spec.InitContainers...)
+	containers = append(containers, spec.Containers...)
+	for _, container := range containers {
+		violations = append(violations, getContainerViolations(level, &container, podRunAsNonRoot, podSeccompSet)...)
+	}
+	for _, container := range spec.EphemeralContainers {
+		c := v1.Container(container.EphemeralContainerCommon)
+		violations = append(violations, getContainerViolations(level, &c, podRunAsNonRoot, podSeccompSet)...)
+	}
+	return violations
+}
+
+func getContainerViolations(
+	level string,
+	container *v1.Container,
+	podRunAsNonRoot bool,
+	podSeccompSet bool,
+) []string {
+	violations := make([]string, 0)
+	for _, port := range container.Ports {
+		if port.HostPort != 0 {
+			violations = append(violations, fmt.Sprintf("container %v uses hostPort %v", container.Name, port.HostPort))
+		}
+	}
+
+	securityContext := container.SecurityContext
+	if securityContext == nil {
+		securityContext = &v1.SecurityContext{}
+	}
+	if securityContext.Privileged != nil && *securityContext.Privileged {
+		violations = append(violations, fmt.Sprintf("container %v is privileged", container.Name))
+	}
+	if securityContext.ProcMount != nil && *securityContext.ProcMount != v1.DefaultProcMount {
+		violations = append(violations, fmt.Sprintf("container %v uses a non default procMount", container.Name))
+	}
+	if securityContext.SELinuxOptions != nil && securityContext.SELinuxOptions.Type != "" &&
+		!slice.ContainsString([]string{"container_t", "container_init_t", "container_kvm_t"}, securityContext.SELinuxOptions.Type, nil) {
+		violations = append(violations, fmt.Sprintf("container %v uses SELinux type %v", container.Name, securityContext.SELinuxOptions.Type))
+	}
+	if violation := checkSeccompProfile(securityContext.SeccompProfile, "container "+container.Name); violation != "" {
+		violations = append(violations, violation)
+	}
+	if securityContext.Capabilities != nil {
+		for _, capability := range securityContext.Capabilities.Add {
+			allowed := slice.ContainsString(baselineCapabilities, string(capability), nil)
+			if level == PodSecurityLevelRestricted {
+				allowed = capability == "NET_BIND_SERVICE"
+			}
+			if !allowed {
+				violations = append(violations, fmt.Sprintf("container %v adds capability %v", container.Name, capability))
+			}
+		}
+	}
+	if level != PodSecurityLevelRestricted {
+		return violations
+	}
+
+	if securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation {
+		violations = append(violations, fmt.Sprintf("container %v doesn't set allowPrivilegeEscalation to false", container.Name))
+	}
+	runAsNonRoot := podRunAsNonRoot
+	if securityContext.RunAsNonRoot != nil {
+		runAsNonRoot = *securityContext.RunAsNonRoot
+	}
+	if !runAsNonRoot {
+		violations = append(violations, fmt.Sprintf("container %v doesn't set runAsNonRoot to true", container.Name))
+	}
+	if securityContext.RunAsUser != nil && *securityContext.RunAsUser == 0 {
+		violations = append(violations, fmt.Sprintf("container %v runs as user 0", container.Name))
+	}
+	if !podSeccompSet && securityContext.SeccompProfile == nil {
+		violations = append(violations, fmt.Sprintf("container %v doesn't set a seccomp profile", container.Name))
+	}
+	dropsAll := false
+	if securityContext.Capabilities != nil {
+		for _, capability := range securityContext.Capabilities.Drop {
+			if strings.ToUpper(string(capability)) == "ALL" {
+				dropsAll = true
+			}
+		}
+	}
+	if !dropsAll {
+		violations = append(violations, fmt.Sprintf("container %v doesn't drop ALL capabilities", container.Name))
+	}
+	return violations
+}
+
+func checkSeccompProfile(profile *v1.SeccompProfile, owner string) string {
+	if profile == nil {
+		return ""
+	}
+	if profile.Type == v1.SeccompProfileTypeUnconfined {
+		return fmt.Sprintf("%v uses the Unconfined seccomp profile", owner)
+	}
+	return ""
+}
+
+func getPodSysctls(spec *v1.PodSpec) []string {
+	sysctls := make([]string, 0)
+	if spec.SecurityContext == nil {
+		return sysctls
+	}
+	for _, sysctl := range spec.SecurityContext.Sysctls {
+		sysctls = append(sysctls, sysctl.Name)
+	}
+	return sysctls
+}
+
+// isRestrictedVolume checks if the volume type is allowed at the restricted
+// level
+func isRestrictedVolume(volume v1.Volume) bool {
+	source := volume.VolumeSource
+	return source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil ||
+		source.EmptyDir != nil || source.Ephemeral != nil ||
+		source.PersistentVolumeClaim != nil || source.Projected != nil || source.Secret != nil
+}
@@ -0,0 +1,141 @@
+// +build unittest
+
+package k8sutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func getRestrictedPodSpec() *v1.PodSpec {
+	return &v1.PodSpec{
+		SecurityContext: &v1.PodSecurityContext{
+			RunAsNonRoot:   boolPtr(true),
+			SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []v1.Container{{
+			Name: "app",
+			SecurityContext: &v1.SecurityContext{
+				AllowPrivilegeEscalation: boolPtr(false),
+				Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+			},
+		}},
+		Volumes: []v1.Volume{{
+			Name:         "data",
+			VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc"}},
+		}},
+	}
+}
+
+func TestGetPodSecurityViolations(t *testing.T) {
+	tests := []struct {
+		name       string
+		update     func(spec *v1.PodSpec)
+		baseline   int
+		restricted int
+	}{
+		{name: "restricted pod", update: func(spec *v1.PodSpec) {}},
+		{
+			name:     "host network",
+			update:   func(spec *v1.PodSpec) { spec.HostNetwork = true },
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "host path",
+			update: func(spec *v1.PodSpec) {
+				spec.Volumes[0].VolumeSource = v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/"}}
+			},
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "privileged container",
+			update: func(spec *v1.PodSpec) {
+				spec.Containers[0].SecurityContext.Privileged = boolPtr(true)
+			},
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "host port",
+			update: func(spec *v1.PodSpec) {
+				spec.Containers[0].Ports = []v1.ContainerPort{{HostPort: 8080}}
+			},
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "unsafe sysctl",
+			update: func(spec *v1.PodSpec) {
+				spec.SecurityContext.Sysctls = []v1.Sysctl{{Name: "kernel.msgmax"}, {Name: "net.ipv4.tcp_syncookies"}}
+			},
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "baseline capability",
+			update: func(spec *v1.PodSpec) {
+				spec.Containers[0].SecurityContext.Capabilities.Add = []v1.Capability{"CHOWN"}
+			},
+			restricted: 1,
+		},
+		{
+			name: "unsafe capability",
+			update: func(spec *v1.PodSpec) {
+				spec.Containers[0].SecurityContext.Capabilities.Add = []v1.Capability{"SYS_ADMIN"}
+			},
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "unconfined seccomp",
+			update: func(spec *v1.PodSpec) {
+				spec.SecurityContext.SeccompProfile.Type = v1.SeccompProfileTypeUnconfined
+			},
+			baseline: 1, restricted: 1,
+		},
+		{
+			name: "root user",
+			update: func(spec *v1.PodSpec) {
+				spec.Containers[0].SecurityContext.RunAsUser = int64Ptr(0)
+			},
+			restricted: 1,
+		},
+		{
+			name: "missing restricted settings",
+			update: func(spec *v1.PodSpec) {
+				spec.SecurityContext = nil
+				spec.Containers[0].SecurityContext = nil
+			},
+			restricted: 4,
+		},
+		{
+			name: "emptyDir and secret volumes",
+			update: func(spec *v1.PodSpec) {
+				spec.Volumes = append(spec.Volumes,
+					v1.Volume{Name: "tmp", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+					v1.Volume{Name: "secret", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{}}})
+			},
+		},
+		{
+			name: "restricted volume type",
+			update: func(spec *v1.PodSpec) {
+				spec.Volumes[0].VolumeSource = v1.VolumeSource{NFS: &v1.NFSVolumeSource{Server: "nfs"}}
+			},
+			restricted: 1,
+		},
+	}
+	for _, test := range tests {
+		spec := getRestrictedPodSpec()
+		test.update(spec)
+		require.Len(t, GetPodSecurityViolations(PodSecurityLevelPrivileged, spec), 0, test.name)
+		require.Len(t, GetPodSecurityViolations("", spec), 0, test.name)
+		require.Len(t, GetPodSecurityViolations(PodSecurityLevelBaseline, spec), test.baseline, test.name)
+		require.Len(t, GetPodSecurityViolations(PodSecurityLevelRestricted, spec), test.restricted, test.name)
+	}
+}
@@ -120,6 +120,18 @@ func VolumeSnapshotScheduleLog(snapshotSchedule *storkv1.VolumeSnapshotSchedule)
 	return logrus.WithFields(logrus.Fields{})
 }
 
+// GroupVolumeSnapshotScheduleLog formats a log message with group volumesnapshot schedule information
+func GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule *storkv1.GroupVolumeSnapshotSchedule) *logrus.Entry {
+	if groupSnapshotSchedule != nil {
+		return logrus.WithFields(logrus.Fields{
+			"GroupVolumeSnapshotScheduleName": groupSnapshotSchedule.Name,
+			"Namespace":                       groupSnapshotSchedule.Namespace,
+		})
+	}
+
+	return logrus.WithFields(logrus.Fields{})
+}
+
 // RuleLog formats a log message with Rule information
 func RuleLog(
 	rule *storkv1.Rule,
@@ -20,6 +20,7 @@ func TestLog(t *testing.T) {
 	t.Run("statefulsetLogTest", statefulsetLogTest)
 	t.Run("snapshotLogTest", snapshotLogTest)
 	t.Run("snapshotScheduleLogTest", snapshotScheduleLogTest)
+	t.Run("groupSnapshotScheduleLogTest", groupSnapshotScheduleLogTest)
 	t.Run("migrationLogTest", migrationLogTest)
 	t.Run("migrationScheduleLogTest", migrationScheduleLogTest)
 	t.Run("ruleLogTest", ruleLogTest)
@@ -126,6 +127,18 @@ func snapshotScheduleLogTest(t *testing.T) {
 	VolumeSnapshotScheduleLog(nil).Infof("snapshot schedule nil log")
 }
 
+func groupSnapshotScheduleLogTest(t *testing.T) {
+	metadata := metav1.ObjectMeta{
+		Name:      "testgroupsnapshotschedule",
+		Namespace: "testnamespace",
+	}
+	groupSnapshotSchedule := &storkv1.GroupVolumeSnapshotSchedule{
+		ObjectMeta: metadata,
+	}
+	GroupVolumeSnapshotScheduleLog(groupSnapshotSchedule).Infof("group snapshot schedule log")
+	GroupVolumeSnapshotScheduleLog(nil).Infof("group snapshot schedule nil log")
+}
+
 func migrationLogTest(t *testing.T) {
 	metadata := metav1.ObjectMeta{
 		Name:      "testmigration",
@@ -34,21 +34,26 @@ var (
 var (
 	// restoreStatus map of application restore status to enum
 	restoreStatus = map[stork_api.ApplicationRestoreStatusType]float64{
-		stork_api.ApplicationRestoreStatusInitial:        0,
-		stork_api.ApplicationRestoreStatusPending:        1,
-		stork_api.ApplicationRestoreStatusInProgress:     2,
-		stork_api.ApplicationRestoreStatusFailed:         3,
-		stork_api.ApplicationRestoreStatusPartialSuccess: 4,
-		stork_api.ApplicationRestoreStatusRetained:       5,
-		stork_api.ApplicationRestoreStatusSuccessful:     6,
+		stork_api.ApplicationRestoreStatusInitial:         0,
+		stork_api.ApplicationRestoreStatusPending:         1,
+		stork_api.ApplicationRestoreStatusInProgress:      2,
+		stork_api.ApplicationRestoreStatusFailed:          3,
+		stork_api.ApplicationRestoreStatusPartialSuccess:  4,
+		stork_api.ApplicationRestoreStatusRetained:        5,
+		stork_api.ApplicationRestoreStatusSuccessful:      6,
+		stork_api.ApplicationRestoreStatusConflict:        7,
+		stork_api.ApplicationRestoreStatusStaged:          8,
+		stork_api.ApplicationRestoreStatusVolumesRestored: 9,
 	}
 
 	// restoreStage map of application restore stage to enum
 	restoreStage = map[stork_api.ApplicationRestoreStageType]float64{
-		stork_api.ApplicationRestoreStageInitial:      0,
-		stork_api.ApplicationRestoreStageVolumes:      1,
-		stork_api.ApplicationRestoreStageApplications: 2,
-		stork_api.ApplicationRestoreStageFinal:        3,
+		stork_api.ApplicationRestoreStageInitial:         0,
+		stork_api.ApplicationRestoreStageVolumes:         1,
+		stork_api.ApplicationRestoreStageApplications:    2,
+		stork_api.ApplicationRestoreStageFinal:           3,
+		stork_api.ApplicationRestoreStageFinalizeVolumes: 4,
+		stork_api.ApplicationRestoreStageSettle:          5,
 	}
 )
 
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// metricOperation for stork prometheus metrics
+	metricOperation = "operation"
+	// metricProvider for stork prometheus metrics
+	metricProvider = "provider"
+)
+
+var (
+	// transferredBytesCounter for bytes transferred to and from backup
+	// locations, the name and namespace are for the BackupLocation
+	transferredBytesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stork_objectstore_transferred_bytes",
+		Help: "Bytes transferred to and from backup locations",
+	}, []string{metricName, metricNamespace, metricOperation, metricProvider})
+)
+
+func observeTransfer(backupLocation *stork_api.BackupLocation, operation string, bytes int) {
+	labels := make(prometheus.Labels)
+	labels[metricName] = backupLocation.Name
+	labels[metricNamespace] = backupLocation.Namespace
+	labels[metricOperation] = operation
+	labels[metricProvider] = string(backupLocation.Location.Type)
+	transferredBytesCounter.With(labels).Add(float64(bytes))
+}
+
+func init() {
+	prometheus.MustRegister(transferredBytesCounter)
+	objectstore.SetTransferObserver(observeTransfer)
+}
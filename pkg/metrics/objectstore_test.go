@@ -0,0 +1,35 @@
+// +build unittest
+
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectstoreTransferMetrics(t *testing.T) {
+	backupLocation := &storkv1.BackupLocation{
+		Location: storkv1.BackupLocationItem{Type: storkv1.BackupLocationS3},
+	}
+	backupLocation.Name = "location"
+	backupLocation.Namespace = "transfer"
+	backupCounter := transferredBytesCounter.WithLabelValues("location", "transfer", objectstore.OperationBackup, "s3")
+	restoreCounter := transferredBytesCounter.WithLabelValues("location", "transfer", objectstore.OperationRestore, "s3")
+
+	var buf bytes.Buffer
+	_, err := objectstore.NewCountingWriter(&buf, backupLocation, objectstore.OperationBackup).Write([]byte("0123456789"))
+	require.NoError(t, err)
+	require.Equal(t, float64(10), testutil.ToFloat64(backupCounter), "stork_objectstore_transferred_bytes does not matched")
+
+	data, err := ioutil.ReadAll(objectstore.NewCountingReader(&buf, backupLocation, objectstore.OperationRestore))
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(data))
+	require.Equal(t, float64(10), testutil.ToFloat64(restoreCounter), "stork_objectstore_transferred_bytes does not matched")
+	require.Equal(t, float64(10), testutil.ToFloat64(backupCounter), "stork_objectstore_transferred_bytes does not matched")
+}
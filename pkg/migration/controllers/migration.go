@@ -413,7 +413,7 @@ func (m *MigrationController) purgeMigratedResources(migration *stork_api.Migrat
 	if err != nil {
 		return err
 	}
-	err = m.resourceCollector.DeleteResources(dynamicInterface, toBeDeleted)
+	err = m.resourceCollector.DeleteResources(dynamicInterface, toBeDeleted, nil)
 	if err != nil {
 		return err
 	}
@@ -868,6 +868,9 @@ func (m *MigrationController) prepareResources(
 		if err != nil {
 			return err
 		}
+		if err := resourcecollector.RemoveLastModifiedAnnotation(o); err != nil {
+			return err
+		}
 		resource := o.GetObjectKind().GroupVersionKind()
 		switch resource.Kind {
 		case "PersistentVolume":
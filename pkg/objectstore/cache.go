@@ -0,0 +1,104 @@
+package objectstore
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"gocloud.dev/blob"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Time after which the BackupLocation for a cached bucket is looked up again
+// to check if its config or credentials have changed
+const bucketCacheTimeout = 2 * time.Minute
+
+type cachedBucket struct {
+	bucket         *blob.Bucket
+	backupLocation *stork_api.BackupLocation
+	fingerprint    string
+	refreshTime    time.Time
+}
+
+var (
+	bucketCache     = make(map[string]*cachedBucket)
+	bucketCacheLock sync.Mutex
+
+	// Replaced in tests
+	getBackupLocation = k8sutils.GetBackupLocation
+	openBucket        = GetBucket
+)
+
+// GetCachedBucket returns a bucket handle for the BackupLocation along with
+// the BackupLocation itself. The handle is shared between callers, so it
+// shouldn't be closed. Once the cached entry is older than the cache timeout
+// the BackupLocation is looked up again, and a new handle is opened if its
+// config or credentials have changed. The returned BackupLocation is a copy
+// that can be modified by the caller.
+func GetCachedBucket(name string, namespace string) (*blob.Bucket, *stork_api.BackupLocation, error) {
+	key := namespace + "/" + name
+	bucketCacheLock.Lock()
+	if cached, ok := bucketCache[key]; ok && time.Since(cached.refreshTime) < bucketCacheTimeout {
+		bucket, backupLocation := cached.bucket, cached.backupLocation.DeepCopy()
+		bucketCacheLock.Unlock()
+		return bucket, backupLocation, nil
+	}
+	bucketCacheLock.Unlock()
+
+	backupLocation, err := getBackupLocation(name, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			InvalidateCachedBucket(name, namespace)
+		}
+		return nil, nil, err
+	}
+	fingerprint, err := getLocationFingerprint(backupLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bucketCacheLock.Lock()
+	defer bucketCacheLock.Unlock()
+	// Keep using the same handle if nothing changed. The handles don't hold
+	// any resources that need to be released, so replaced handles are left to
+	// be garbage collected once they aren't in use anymore.
+	if cached, ok := bucketCache[key]; ok && cached.fingerprint == fingerprint {
+		cached.backupLocation = backupLocation
+		cached.refreshTime = time.Now()
+		return cached.bucket, backupLocation.DeepCopy(), nil
+	}
+	bucket, err := openBucket(backupLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+	bucketCache[key] = &cachedBucket{
+		bucket:         bucket,
+		backupLocation: backupLocation,
+		fingerprint:    fingerprint,
+		refreshTime:    time.Now(),
+	}
+	return bucket, backupLocation.DeepCopy(), nil
+}
+
+// InvalidateCachedBucket removes the cached bucket handle for the
+// BackupLocation. Should be called when an operation with the handle fails so
+// that the next call to GetCachedBucket picks up any changed credentials.
+func InvalidateCachedBucket(name string, namespace string) {
+	bucketCacheLock.Lock()
+	defer bucketCacheLock.Unlock()
+	delete(bucketCache, namespace+"/"+name)
+}
+
+// getLocationFingerprint returns a hash of the location config, which includes
+// the credentials merged from the secret for the BackupLocation
+func getLocationFingerprint(backupLocation *stork_api.BackupLocation) (string, error) {
+	data, err := json.Marshal(backupLocation.Location)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
@@ -0,0 +1,131 @@
+// +build unittest
+
+package objectstore
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeLocations struct {
+	sync.Mutex
+	locations map[string]*stork_api.BackupLocation
+	lookups   int
+	opens     int
+}
+
+func (f *fakeLocations) get(name string, namespace string) (*stork_api.BackupLocation, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.lookups++
+	location, ok := f.locations[namespace+"/"+name]
+	if !ok {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "backuplocations"}, name)
+	}
+	return location.DeepCopy(), nil
+}
+
+func (f *fakeLocations) open(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
+	f.Lock()
+	defer f.Unlock()
+	f.opens++
+	return &blob.Bucket{}, nil
+}
+
+func setupCacheTest(t *testing.T) *fakeLocations {
+	f := &fakeLocations{locations: make(map[string]*stork_api.BackupLocation)}
+	location := &stork_api.BackupLocation{
+		Location: stork_api.BackupLocationItem{
+			Type: stork_api.BackupLocationS3,
+			Path: "bucket",
+		},
+	}
+	location.Name = "location"
+	location.Namespace = "ns"
+	f.locations["ns/location"] = location
+
+	bucketCache = make(map[string]*cachedBucket)
+	getBackupLocation = f.get
+	openBucket = f.open
+	return f
+}
+
+func TestCachedBucketExpiry(t *testing.T) {
+	f := setupCacheTest(t)
+
+	bucket, location, err := GetCachedBucket("location", "ns")
+	require.NoError(t, err, "Error getting cached bucket")
+	require.Equal(t, "bucket", location.Location.Path)
+
+	// Changes by the caller shouldn't affect the cached BackupLocation
+	location.Location.Path = "changed"
+	cachedBucket, location, err := GetCachedBucket("location", "ns")
+	require.NoError(t, err, "Error getting cached bucket")
+	require.True(t, bucket == cachedBucket, "Bucket wasn't cached")
+	require.Equal(t, "bucket", location.Location.Path)
+	require.Equal(t, 1, f.lookups)
+
+	// The same handle is used after the entry expires if the config didn't
+	// change
+	bucketCache["ns/location"].refreshTime = time.Now().Add(-bucketCacheTimeout)
+	cachedBucket, _, err = GetCachedBucket("location", "ns")
+	require.NoError(t, err, "Error getting cached bucket")
+	require.True(t, bucket == cachedBucket, "Bucket handle was replaced")
+	require.Equal(t, 2, f.lookups)
+	require.Equal(t, 1, f.opens)
+
+	// A new handle is opened once the config has changed
+	f.locations["ns/location"].Location.Path = "new-bucket"
+	bucketCache["ns/location"].refreshTime = time.Now().Add(-bucketCacheTimeout)
+	cachedBucket, location, err = GetCachedBucket("location", "ns")
+	require.NoError(t, err, "Error getting cached bucket")
+	require.False(t, bucket == cachedBucket, "Bucket handle wasn't replaced")
+	require.Equal(t, "new-bucket", location.Location.Path)
+	require.Equal(t, 2, f.opens)
+}
+
+func TestCachedBucketInvalidate(t *testing.T) {
+	f := setupCacheTest(t)
+
+	_, _, err := GetCachedBucket("location", "ns")
+	require.NoError(t, err, "Error getting cached bucket")
+	InvalidateCachedBucket("location", "ns")
+	require.NotContains(t, bucketCache, "ns/location")
+
+	// The entry is removed if the BackupLocation has been deleted
+	_, _, err = GetCachedBucket("location", "ns")
+	require.NoError(t, err, "Error getting cached bucket")
+	delete(f.locations, "ns/location")
+	bucketCache["ns/location"].refreshTime = time.Now().Add(-bucketCacheTimeout)
+	_, _, err = GetCachedBucket("location", "ns")
+	require.True(t, errors.IsNotFound(err), "Expected NotFound error, got %v", err)
+	require.NotContains(t, bucketCache, "ns/location")
+}
+
+func TestCachedBucketConcurrentAccess(t *testing.T) {
+	setupCacheTest(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, location, err := GetCachedBucket("location", "ns")
+				require.NoError(t, err, "Error getting cached bucket")
+				location.Location.Path = "changed"
+				if j%10 == i%10 {
+					InvalidateCachedBucket("location", "ns")
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
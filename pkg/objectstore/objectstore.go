@@ -1,15 +1,187 @@
 package objectstore
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
 
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/objectstore/azure"
 	"github.com/libopenstorage/stork/pkg/objectstore/google"
 	"github.com/libopenstorage/stork/pkg/objectstore/s3"
 	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
 )
 
+const (
+	validateTimeout = 30 * time.Second
+
+	defaultExistsTimeout = 30 * time.Second
+	defaultReadTimeout   = 30 * time.Minute
+	defaultWriteTimeout  = 30 * time.Minute
+)
+
+// RequestType is the type of request made to the objectstore, used to pick the
+// timeout for the request
+type RequestType string
+
+const (
+	// RequestExists checks if an object exists
+	RequestExists RequestType = "Exists"
+	// RequestRead downloads an object
+	RequestRead RequestType = "Read"
+	// RequestWrite uploads or deletes an object
+	RequestWrite RequestType = "Write"
+)
+
+// ValidationErrorType is the type of error returned when validating a backup
+// location
+type ValidationErrorType string
+
+const (
+	// ValidationErrorAuth is returned when the credentials for the location
+	// are invalid or don't have access to the bucket
+	ValidationErrorAuth ValidationErrorType = "Auth"
+	// ValidationErrorNetwork is returned when the objectstore couldn't be
+	// reached
+	ValidationErrorNetwork ValidationErrorType = "Network"
+	// ValidationErrorNotFound is returned when the bucket doesn't exist
+	ValidationErrorNotFound ValidationErrorType = "NotFound"
+	// ValidationErrorUnknown is returned for all other errors
+	ValidationErrorUnknown ValidationErrorType = "Unknown"
+)
+
+// Error codes returned by the objectstores for invalid credentials that aren't
+// mapped to PermissionDenied
+var authErrorCodes = []string{
+	"AccessDenied",
+	"InvalidAccessKeyId",
+	"SignatureDoesNotMatch",
+	"AuthenticationFailed",
+	"AuthorizationFailure",
+	"invalid_grant",
+}
+
+// ValidationError is returned by Validate when a backup location can't be
+// accessed
+type ValidationError struct {
+	// Type of the failure
+	Type ValidationErrorType
+	// Location that failed validation
+	Location string
+	// Cause is the error returned by the objectstore
+	Cause error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("error accessing backup location %v (%v): %v", e.Location, e.Type, e.Cause)
+}
+
+// Validate checks that the bucket for the backup location can be accessed
+// with the configured credentials. The check is non-destructive, it only
+// lists objects in the bucket.
+func Validate(backupLocation *stork_api.BackupLocation) error {
+	if backupLocation == nil {
+		return fmt.Errorf("nil backupLocation")
+	}
+	bucket, err := GetBucket(backupLocation)
+	if err != nil {
+		return newValidationError(backupLocation, err)
+	}
+	defer func() {
+		_ = bucket.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+	defer cancel()
+	iterator := bucket.List(&blob.ListOptions{
+		Prefix:    backupLocation.Namespace + "/",
+		Delimiter: "/",
+	})
+	if _, err := iterator.Next(ctx); err != nil && err != io.EOF {
+		return newValidationError(backupLocation, err)
+	}
+	return nil
+}
+
+// CheckEncryption returns an error if the backup location requires encryption
+// but doesn't have an encryption key configured
+func CheckEncryption(backupLocation *stork_api.BackupLocation) error {
+	if backupLocation.Location.EncryptionRequired && backupLocation.Location.EncryptionKey == "" {
+		return fmt.Errorf("backup location %v requires encryption but no encryption key is configured for it", backupLocation.Name)
+	}
+	return nil
+}
+
+func newValidationError(backupLocation *stork_api.BackupLocation, err error) *ValidationError {
+	return &ValidationError{
+		Type:     getValidationErrorType(err),
+		Location: backupLocation.Namespace + "/" + backupLocation.Name,
+		Cause:    err,
+	}
+}
+
+func getValidationErrorType(err error) ValidationErrorType {
+	switch gcerrors.Code(err) {
+	case gcerrors.PermissionDenied:
+		return ValidationErrorAuth
+	case gcerrors.NotFound:
+		return ValidationErrorNotFound
+	case gcerrors.DeadlineExceeded:
+		return ValidationErrorNetwork
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ValidationErrorNetwork
+	}
+	for _, code := range authErrorCodes {
+		if strings.Contains(err.Error(), code) {
+			return ValidationErrorAuth
+		}
+	}
+	return ValidationErrorUnknown
+}
+
+// GetTimeout returns the timeout configured in the backup location for the
+// request type, or the default timeout for it if one isn't configured
+func GetTimeout(backupLocation *stork_api.BackupLocation, requestType RequestType) time.Duration {
+	var timeout time.Duration
+	var defaultTimeout time.Duration
+	timeouts := backupLocation.Location.Timeouts
+	switch requestType {
+	case RequestExists:
+		defaultTimeout = defaultExistsTimeout
+		if timeouts != nil {
+			timeout = timeouts.Exists.Duration
+		}
+	case RequestRead:
+		defaultTimeout = defaultReadTimeout
+		if timeouts != nil {
+			timeout = timeouts.Read.Duration
+		}
+	default:
+		defaultTimeout = defaultWriteTimeout
+		if timeouts != nil {
+			timeout = timeouts.Write.Duration
+		}
+	}
+	if timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// GetContext returns a context for a request to the objectstore of the backup
+// location that is cancelled after the timeout for the request type. The cancel
+// function must be called once the request completes.
+func GetContext(backupLocation *stork_api.BackupLocation, requestType RequestType) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), GetTimeout(backupLocation, requestType))
+}
+
 // GetBucket gets the bucket handle for the given backup location
 func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 	if backupLocation == nil {
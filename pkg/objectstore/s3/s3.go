@@ -2,17 +2,34 @@ package s3
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/sirupsen/logrus"
 	"gocloud.dev/blob"
 	"gocloud.dev/blob/s3blob"
 )
 
+const (
+	// Region used to look up the region of a bucket if one isn't configured
+	defaultRegion = "us-east-1"
+	// Timeout for looking up the region of a bucket
+	bucketRegionTimeout = 30 * time.Second
+)
+
+var (
+	// Regions of the buckets that have been looked up, keyed by bucket name
+	bucketRegions     = make(map[string]string)
+	bucketRegionsLock sync.Mutex
+)
+
 func getSession(backupLocation *stork_api.BackupLocation) (*session.Session, error) {
 	// AWS SDK fetches the correct endpoint based on region provided if endpoint is passed empty
 	var endpoint string
@@ -21,14 +38,62 @@ func getSession(backupLocation *stork_api.BackupLocation) (*session.Session, err
 	} else {
 		endpoint = backupLocation.Location.S3Config.Endpoint
 	}
-	return session.NewSession(&aws.Config{
+	config := &aws.Config{
 		Endpoint: aws.String(endpoint),
 		Credentials: credentials.NewStaticCredentials(backupLocation.Location.S3Config.AccessKeyID,
 			backupLocation.Location.S3Config.SecretAccessKey, ""),
 		Region:           aws.String(backupLocation.Location.S3Config.Region),
 		DisableSSL:       aws.Bool(backupLocation.Location.S3Config.DisableSSL),
 		S3ForcePathStyle: aws.Bool(true),
-	})
+	}
+	sess, err := session.NewSession(config)
+	if err != nil || endpoint != "" {
+		return sess, err
+	}
+
+	// Requests to AWS fail if the region doesn't match the region of the
+	// bucket, so use the region that the bucket is actually in
+	region := getBucketRegion(sess, backupLocation)
+	if region == "" || region == backupLocation.Location.S3Config.Region {
+		return sess, nil
+	}
+	config.Region = aws.String(region)
+	return session.NewSession(config)
+}
+
+// getBucketRegion returns the region of the bucket for the backup location.
+// Returns an empty string if it couldn't be found, for example if the bucket
+// doesn't exist yet.
+func getBucketRegion(sess *session.Session, backupLocation *stork_api.BackupLocation) string {
+	bucket := backupLocation.Location.Path
+	bucketRegionsLock.Lock()
+	region, ok := bucketRegions[bucket]
+	bucketRegionsLock.Unlock()
+	if ok {
+		return region
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), bucketRegionTimeout)
+	defer cancel()
+	configured := backupLocation.Location.S3Config.Region
+	hint := configured
+	if hint == "" {
+		hint = defaultRegion
+	}
+	region, err := s3manager.GetBucketRegion(ctx, sess, bucket, hint)
+	if err != nil {
+		logrus.Debugf("Error getting region for bucket %v: %v", bucket, err)
+		return ""
+	}
+	if region != configured {
+		logrus.Warnf("Bucket %v for BackupLocation %v/%v is in region %v instead of the configured region %q, using %v",
+			bucket, backupLocation.Namespace, backupLocation.Name, region, configured, region)
+	}
+
+	bucketRegionsLock.Lock()
+	bucketRegions[bucket] = region
+	bucketRegionsLock.Unlock()
+	return region
 }
 
 // GetBucket gets a reference to the bucket for that backup location
@@ -0,0 +1,93 @@
+package objectstore
+
+import (
+	"io"
+	"sync"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+)
+
+const (
+	// OperationBackup is the operation for objects uploaded for backups
+	OperationBackup = "backup"
+	// OperationRestore is the operation for objects downloaded for restores
+	OperationRestore = "restore"
+)
+
+// TransferObserver is called with the number of bytes transferred to or from
+// a backup location for an operation
+type TransferObserver func(backupLocation *stork_api.BackupLocation, operation string, bytes int)
+
+var (
+	transferObserverLock sync.RWMutex
+	transferObserver     TransferObserver
+)
+
+// SetTransferObserver sets the observer that is notified about the bytes
+// transferred to and from backup locations
+func SetTransferObserver(observer TransferObserver) {
+	transferObserverLock.Lock()
+	defer transferObserverLock.Unlock()
+	transferObserver = observer
+}
+
+func observeTransfer(backupLocation *stork_api.BackupLocation, operation string, bytes int) {
+	transferObserverLock.RLock()
+	observer := transferObserver
+	transferObserverLock.RUnlock()
+	if observer != nil && bytes > 0 {
+		observer(backupLocation, operation, bytes)
+	}
+}
+
+type countingWriter struct {
+	writer         io.Writer
+	backupLocation *stork_api.BackupLocation
+	operation      string
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	observeTransfer(w.backupLocation, w.operation, n)
+	return n, err
+}
+
+type countingReader struct {
+	reader         io.Reader
+	backupLocation *stork_api.BackupLocation
+	operation      string
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	observeTransfer(r.backupLocation, r.operation, n)
+	return n, err
+}
+
+// NewCountingWriter returns a writer that reports the bytes written to the
+// backup location for the given operation to the transfer observer
+func NewCountingWriter(
+	writer io.Writer,
+	backupLocation *stork_api.BackupLocation,
+	operation string,
+) io.Writer {
+	return &countingWriter{
+		writer:         writer,
+		backupLocation: backupLocation,
+		operation:      operation,
+	}
+}
+
+// NewCountingReader returns a reader that reports the bytes read from the
+// backup location for the given operation to the transfer observer
+func NewCountingReader(
+	reader io.Reader,
+	backupLocation *stork_api.BackupLocation,
+	operation string,
+) io.Reader {
+	return &countingReader{
+		reader:         reader,
+		backupLocation: backupLocation,
+		operation:      operation,
+	}
+}
@@ -0,0 +1,56 @@
+package resourcecollector
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// APIServices are cluster scoped, so they are only collected if they are
+// served by a service in the namespace. APIServices for the built-in groups
+// don't have a service and are never collected. APIService is an optional
+// resource kind, so this is only called if it was requested.
+func (r *ResourceCollector) apiServiceToBeCollected(
+	object runtime.Unstructured,
+	namespace string,
+) (bool, error) {
+	serviceNamespace, _, err := unstructured.NestedString(object.UnstructuredContent(), "spec", "service", "namespace")
+	if err != nil {
+		return false, err
+	}
+	return serviceNamespace == namespace, nil
+}
+
+// prepareAPIServiceForApply updates the namespace of the service serving the
+// APIService based on the namespace mapping. Returns true if the service isn't
+// in one of the mapped namespaces, in which case the APIService should be
+// skipped.
+func (r *ResourceCollector) prepareAPIServiceForApply(
+	object runtime.Unstructured,
+	namespaceMappings map[string]string,
+) (bool, error) {
+	content := object.UnstructuredContent()
+	serviceNamespace, found, err := unstructured.NestedString(content, "spec", "service", "namespace")
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return true, nil
+	}
+	destNamespace, ok := namespaceMappings[serviceNamespace]
+	if !ok {
+		return true, nil
+	}
+	return false, unstructured.SetNestedField(content, destNamespace, "spec", "service", "namespace")
+}
+
+// IsAPIService returns if the object is an APIService. APIServices that
+// already exist on the destination are never replaced, since the API server
+// stops serving the group while it is recreated.
+func IsAPIService(object runtime.Unstructured) bool {
+	objectType, err := meta.TypeAccessor(object)
+	if err != nil {
+		return false
+	}
+	return objectType.GetKind() == "APIService"
+}
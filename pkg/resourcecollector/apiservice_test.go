@@ -0,0 +1,100 @@
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newAPIService(service map[string]interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"group":    "metrics.example.com",
+		"version":  "v1",
+		"caBundle": "Y2E=",
+	}
+	if service != nil {
+		spec["service"] = service
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	object.SetAPIVersion("apiregistration.k8s.io/v1")
+	object.SetKind("APIService")
+	object.SetName("v1.metrics.example.com")
+	return object
+}
+
+func TestAPIServiceToBeCollected(t *testing.T) {
+	r := &ResourceCollector{}
+
+	collect, err := r.apiServiceToBeCollected(newAPIService(map[string]interface{}{"namespace": "src", "name": "metrics"}), "src")
+	require.NoError(t, err)
+	require.True(t, collect, "APIService served from the namespace should be collected")
+
+	collect, err = r.apiServiceToBeCollected(newAPIService(map[string]interface{}{"namespace": "other", "name": "metrics"}), "src")
+	require.NoError(t, err)
+	require.False(t, collect, "APIService served from another namespace shouldn't be collected")
+
+	collect, err = r.apiServiceToBeCollected(newAPIService(nil), "src")
+	require.NoError(t, err)
+	require.False(t, collect, "Local APIService shouldn't be collected")
+}
+
+func TestAPIServiceIsOptional(t *testing.T) {
+	optional, included := isOptionalResourceKind("APIService", nil)
+	require.True(t, optional)
+	require.False(t, included, "APIService should only be included when requested")
+
+	optional, included = isOptionalResourceKind("APIService", []string{"apiservices"})
+	require.True(t, optional)
+	require.True(t, included)
+}
+
+func TestPrepareAPIServiceForApply(t *testing.T) {
+	r := &ResourceCollector{}
+	namespaceMappings := map[string]string{"src": "dest"}
+
+	tests := []struct {
+		name      string
+		service   map[string]interface{}
+		skip      bool
+		namespace string
+	}{
+		{
+			name:      "mapped namespace",
+			service:   map[string]interface{}{"namespace": "src", "name": "metrics"},
+			namespace: "dest",
+		},
+		{
+			name:    "unmapped namespace",
+			service: map[string]interface{}{"namespace": "other", "name": "metrics"},
+			skip:    true,
+		},
+		{
+			name: "no service",
+			skip: true,
+		},
+	}
+
+	for _, test := range tests {
+		object := newAPIService(test.service)
+		skip, err := r.prepareAPIServiceForApply(object, namespaceMappings)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.skip, skip, test.name)
+		if test.skip {
+			continue
+		}
+		namespace, _, err := unstructured.NestedString(object.Object, "spec", "service", "namespace")
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.namespace, namespace, test.name)
+		name, _, err := unstructured.NestedString(object.Object, "spec", "service", "name")
+		require.NoError(t, err, test.name)
+		require.Equal(t, "metrics", name, test.name)
+	}
+}
+
+func TestIsAPIService(t *testing.T) {
+	require.True(t, IsAPIService(newAPIService(nil)))
+	require.False(t, IsAPIService(newDiffConfigMap("config", nil)))
+}
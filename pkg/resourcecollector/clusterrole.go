@@ -4,10 +4,11 @@ import (
 	"strings"
 
 	rbacv1 "k8s.io/api/rbac/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/client-go/dynamic"
 )
 
 // Checks if the subject is in the specified namespace
@@ -180,20 +181,31 @@ func (r *ResourceCollector) updateSubjects(
 }
 
 func (r *ResourceCollector) mergeAndUpdateClusterRoleBinding(
+	dynamicClient dynamic.ResourceInterface,
 	object runtime.Unstructured,
 ) error {
 	var newCRB rbacv1.ClusterRoleBinding
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &newCRB); err != nil {
 		return err
 	}
-
-	currentCRB, err := r.rbacOps.GetClusterRoleBinding(newCRB.Name)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			_, err = r.rbacOps.CreateClusterRoleBinding(&newCRB)
+	return updateMergedResource(dynamicClient, object, func(current *unstructured.Unstructured) error {
+		var currentCRB rbacv1.ClusterRoleBinding
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.UnstructuredContent(), &currentCRB); err != nil {
+			return err
 		}
-		return err
-	}
+		mergeClusterRoleBinding(&currentCRB, &newCRB)
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&currentCRB)
+		if err != nil {
+			return err
+		}
+		current.SetUnstructuredContent(content)
+		return nil
+	})
+}
+
+// mergeClusterRoleBinding adds the subjects from the new ClusterRoleBinding to
+// the current one
+func mergeClusterRoleBinding(currentCRB *rbacv1.ClusterRoleBinding, newCRB *rbacv1.ClusterRoleBinding) {
 
 	// Map which will help eliminate duplicate subjects since the subject string
 	// will be unique for different subjects
@@ -213,7 +225,4 @@ func (r *ResourceCollector) mergeAndUpdateClusterRoleBinding(
 	for _, subject := range updatedSubjects {
 		currentCRB.Subjects = append(currentCRB.Subjects, subject)
 	}
-
-	_, err = r.rbacOps.UpdateClusterRoleBinding(currentCRB)
-	return err
 }
@@ -0,0 +1,265 @@
+package resourcecollector
+
+import (
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// Groups that kinds were moved to after being deprecated in their original
+// group
+var kindGroupMigrations = map[string][]string{
+	"Ingress":           {"networking.k8s.io"},
+	"NetworkPolicy":     {"networking.k8s.io"},
+	"Deployment":        {"apps"},
+	"DaemonSet":         {"apps"},
+	"ReplicaSet":        {"apps"},
+	"StatefulSet":       {"apps"},
+	"PodSecurityPolicy": {"policy"},
+}
+
+// versionConverter updates the content of an object that has already had its
+// apiVersion changed to the target version
+type versionConverter func(object runtime.Unstructured, from schema.GroupVersion) error
+
+// Converters for kinds whose spec changed between versions. Kinds that aren't
+// listed here only need their apiVersion to be updated.
+var versionConverters = map[schema.GroupVersionKind]versionConverter{
+	networkingv1.SchemeGroupVersion.WithKind("Ingress"): convertIngressToV1,
+	{Group: "apps", Version: "v1", Kind: "Deployment"}:  setWorkloadSelector,
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"}:   setWorkloadSelector,
+	{Group: "apps", Version: "v1", Kind: "ReplicaSet"}:  setWorkloadSelector,
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"}: setWorkloadSelector,
+}
+
+// UnsupportedResource is an object whose apiVersion isn't served by the
+// cluster and couldn't be converted to one that is
+type UnsupportedResource struct {
+	Object runtime.Unstructured
+	Reason string
+}
+
+// ConvertResourcesToSupportedVersion converts objects whose apiVersion isn't
+// served by the cluster to a version of the same kind that is. Objects that
+// can't be converted are returned separately so that they can be reported.
+func (r *ResourceCollector) ConvertResourcesToSupportedVersion(
+	objects []runtime.Unstructured,
+) ([]runtime.Unstructured, []*UnsupportedResource, error) {
+	if r.discoveryClient == nil {
+		return objects, nil, nil
+	}
+	if err := r.discoveryHelper.Refresh(); err != nil {
+		return nil, nil, err
+	}
+
+	// Cache of the kinds served for each group version
+	servedKinds := make(map[string]map[string]bool)
+	supported := make([]runtime.Unstructured, 0, len(objects))
+	unsupported := make([]*UnsupportedResource, 0)
+	for _, o := range objects {
+		gvk := o.GetObjectKind().GroupVersionKind()
+		gv := gvk.GroupVersion().String()
+		kinds, ok := servedKinds[gv]
+		if !ok {
+			kinds = make(map[string]bool)
+			resources, err := r.discoveryClient.ServerResourcesForGroupVersion(gv)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return nil, nil, err
+			}
+			if resources != nil {
+				for _, resource := range resources.APIResources {
+					kinds[resource.Kind] = true
+				}
+			}
+			servedKinds[gv] = kinds
+		}
+		if kinds[gvk.Kind] {
+			supported = append(supported, o)
+			continue
+		}
+
+		target, err := r.getSupportedVersion(gvk)
+		if err != nil {
+			return nil, nil, err
+		}
+		if target == nil {
+			unsupported = append(unsupported, &UnsupportedResource{
+				Object: o,
+				Reason: fmt.Sprintf("%v is not supported by the cluster and no other version of %v was found", gv, gvk.Kind),
+			})
+			continue
+		}
+		if err := convertResourceVersion(o, *target); err != nil {
+			unsupported = append(unsupported, &UnsupportedResource{
+				Object: o,
+				Reason: fmt.Sprintf("error converting from %v to %v: %v", gv, target.GroupVersion().String(), err),
+			})
+			continue
+		}
+		supported = append(supported, o)
+	}
+	return supported, unsupported, nil
+}
+
+// getSupportedVersion returns the preferred version of the kind served by the
+// cluster, either in the same group or in the group that the kind was moved
+// to. Returns nil if the kind isn't served.
+func (r *ResourceCollector) getSupportedVersion(
+	gvk schema.GroupVersionKind,
+) (*schema.GroupVersionKind, error) {
+	groups := append([]string{gvk.Group}, kindGroupMigrations[gvk.Kind]...)
+	for _, group := range groups {
+		for _, resourceList := range r.discoveryHelper.Resources() {
+			gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+			if err != nil {
+				return nil, err
+			}
+			if gv.Group != group {
+				continue
+			}
+			for _, resource := range resourceList.APIResources {
+				if resource.Kind == gvk.Kind {
+					target := gv.WithKind(gvk.Kind)
+					return &target, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func convertResourceVersion(
+	object runtime.Unstructured,
+	target schema.GroupVersionKind,
+) error {
+	from := object.GetObjectKind().GroupVersionKind().GroupVersion()
+	object.GetObjectKind().SetGroupVersionKind(target)
+	if converter, ok := versionConverters[target]; ok {
+		return converter(object, from)
+	}
+	return nil
+}
+
+// convertIngressToV1 converts an Ingress from extensions/v1beta1 or
+// networking.k8s.io/v1beta1 to networking.k8s.io/v1
+func convertIngressToV1(
+	object runtime.Unstructured,
+	from schema.GroupVersion,
+) error {
+	if from.Version != "v1beta1" {
+		return fmt.Errorf("conversion from %v is not supported", from.String())
+	}
+	var oldIngress networkingv1beta1.Ingress
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &oldIngress); err != nil {
+		return err
+	}
+
+	ingress := networkingv1.Ingress{
+		TypeMeta:   oldIngress.TypeMeta,
+		ObjectMeta: oldIngress.ObjectMeta,
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: oldIngress.Spec.IngressClassName,
+		},
+	}
+	ingress.APIVersion = networkingv1.SchemeGroupVersion.String()
+	if oldIngress.Spec.Backend != nil {
+		ingress.Spec.DefaultBackend = convertIngressBackendToV1(oldIngress.Spec.Backend)
+	}
+	for _, tls := range oldIngress.Spec.TLS {
+		ingress.Spec.TLS = append(ingress.Spec.TLS, networkingv1.IngressTLS{
+			Hosts:      tls.Hosts,
+			SecretName: tls.SecretName,
+		})
+	}
+	for _, oldRule := range oldIngress.Spec.Rules {
+		rule := networkingv1.IngressRule{
+			Host: oldRule.Host,
+		}
+		if oldRule.HTTP != nil {
+			rule.HTTP = &networkingv1.HTTPIngressRuleValue{}
+			for _, oldPath := range oldRule.HTTP.Paths {
+				// pathType is required in v1, v1beta1 defaulted it to
+				// ImplementationSpecific
+				pathType := networkingv1.PathTypeImplementationSpecific
+				if oldPath.PathType != nil {
+					pathType = networkingv1.PathType(*oldPath.PathType)
+				}
+				rule.HTTP.Paths = append(rule.HTTP.Paths, networkingv1.HTTPIngressPath{
+					Path:     oldPath.Path,
+					PathType: &pathType,
+					Backend:  *convertIngressBackendToV1(&oldPath.Backend),
+				})
+			}
+		}
+		ingress.Spec.Rules = append(ingress.Spec.Rules, rule)
+	}
+
+	o, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&ingress)
+	if err != nil {
+		return err
+	}
+	// Status is updated by the ingress controller once the object is created
+	delete(o, "status")
+	object.SetUnstructuredContent(o)
+	return nil
+}
+
+func convertIngressBackendToV1(
+	backend *networkingv1beta1.IngressBackend,
+) *networkingv1.IngressBackend {
+	newBackend := &networkingv1.IngressBackend{
+		Resource: backend.Resource,
+	}
+	if backend.ServiceName != "" {
+		newBackend.Service = &networkingv1.IngressServiceBackend{
+			Name: backend.ServiceName,
+		}
+		if backend.ServicePort.Type == intstr.String {
+			newBackend.Service.Port.Name = backend.ServicePort.StrVal
+		} else {
+			newBackend.Service.Port.Number = backend.ServicePort.IntVal
+		}
+	}
+	return newBackend
+}
+
+// setWorkloadSelector sets the selector for workloads converted from
+// extensions/v1beta1 or apps/v1beta1 since they defaulted it to the labels of
+// the pod template, but it is required in apps/v1
+func setWorkloadSelector(
+	object runtime.Unstructured,
+	from schema.GroupVersion,
+) error {
+	content := object.UnstructuredContent()
+	// Fields that were removed in apps/v1
+	unstructured.RemoveNestedField(content, "spec", "rollbackTo")
+	unstructured.RemoveNestedField(content, "spec", "templateGeneration")
+
+	_, found, err := unstructured.NestedFieldNoCopy(content, "spec", "selector")
+	if err != nil || found {
+		return err
+	}
+	templateLabels, found, err := unstructured.NestedStringMap(content, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return err
+	}
+	if !found || len(templateLabels) == 0 {
+		return fmt.Errorf("selector is required for %v and pod template doesn't have any labels", object.GetObjectKind().GroupVersionKind().Kind)
+	}
+	selector, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&metav1.LabelSelector{MatchLabels: templateLabels})
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedMap(content, selector, "spec", "selector"); err != nil {
+		return err
+	}
+	object.SetUnstructuredContent(content)
+	return nil
+}
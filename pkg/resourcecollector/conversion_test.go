@@ -0,0 +1,144 @@
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func newConversionObject(apiVersion, kind string, spec map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetName("test")
+	object.SetNamespace("ns")
+	return object
+}
+
+func TestConvertIngressToV1(t *testing.T) {
+	tests := []struct {
+		name        string
+		apiVersion  string
+		spec        map[string]interface{}
+		expectError bool
+		verify      func(ingress *networkingv1.Ingress)
+	}{
+		{
+			name:       "rules with numbered port",
+			apiVersion: "extensions/v1beta1",
+			spec: map[string]interface{}{
+				"rules": []interface{}{map[string]interface{}{
+					"host": "example.com",
+					"http": map[string]interface{}{"paths": []interface{}{map[string]interface{}{
+						"path":    "/",
+						"backend": map[string]interface{}{"serviceName": "svc", "servicePort": int64(80)},
+					}}},
+				}},
+			},
+			verify: func(ingress *networkingv1.Ingress) {
+				path := ingress.Spec.Rules[0].HTTP.Paths[0]
+				require.Equal(t, "svc", path.Backend.Service.Name)
+				require.Equal(t, int32(80), path.Backend.Service.Port.Number)
+				require.Equal(t, networkingv1.PathTypeImplementationSpecific, *path.PathType)
+			},
+		},
+		{
+			name:       "default backend with named port and tls",
+			apiVersion: "networking.k8s.io/v1beta1",
+			spec: map[string]interface{}{
+				"backend": map[string]interface{}{"serviceName": "svc", "servicePort": "http"},
+				"tls":     []interface{}{map[string]interface{}{"hosts": []interface{}{"example.com"}, "secretName": "tls"}},
+			},
+			verify: func(ingress *networkingv1.Ingress) {
+				require.Equal(t, "svc", ingress.Spec.DefaultBackend.Service.Name)
+				require.Equal(t, "http", ingress.Spec.DefaultBackend.Service.Port.Name)
+				require.Equal(t, "tls", ingress.Spec.TLS[0].SecretName)
+			},
+		},
+		{
+			name:        "unsupported version",
+			apiVersion:  "networking.k8s.io/v2alpha1",
+			spec:        map[string]interface{}{},
+			expectError: true,
+		},
+	}
+	target := networkingv1.SchemeGroupVersion.WithKind("Ingress")
+	for _, test := range tests {
+		object := newConversionObject(test.apiVersion, "Ingress", test.spec)
+		err := convertResourceVersion(object, target)
+		if test.expectError {
+			require.Error(t, err, test.name)
+			continue
+		}
+		require.NoError(t, err, test.name)
+		require.Equal(t, target, object.GroupVersionKind(), test.name)
+		require.Equal(t, "test", object.GetName(), test.name)
+		var ingress networkingv1.Ingress
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &ingress), test.name)
+		test.verify(&ingress)
+	}
+}
+
+func TestSetWorkloadSelector(t *testing.T) {
+	templateLabels := map[string]interface{}{"app": "test"}
+	tests := []struct {
+		name        string
+		spec        map[string]interface{}
+		expectError bool
+		selector    map[string]interface{}
+	}{
+		{
+			name: "selector from template labels",
+			spec: map[string]interface{}{
+				"rollbackTo": map[string]interface{}{"revision": int64(1)},
+				"template":   map[string]interface{}{"metadata": map[string]interface{}{"labels": templateLabels}},
+			},
+			selector: map[string]interface{}{"matchLabels": templateLabels},
+		},
+		{
+			name: "existing selector is kept",
+			spec: map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"other": "label"}},
+				"template": map[string]interface{}{"metadata": map[string]interface{}{"labels": templateLabels}},
+			},
+			selector: map[string]interface{}{"matchLabels": map[string]interface{}{"other": "label"}},
+		},
+		{
+			name:        "no template labels",
+			spec:        map[string]interface{}{"template": map[string]interface{}{}},
+			expectError: true,
+		},
+	}
+	for _, kind := range []string{"Deployment", "DaemonSet", "ReplicaSet", "StatefulSet"} {
+		target := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: kind}
+		for _, test := range tests {
+			object := newConversionObject("extensions/v1beta1", kind, test.spec).DeepCopy()
+			err := convertResourceVersion(object, target)
+			if test.expectError {
+				require.Error(t, err, "%v: %v", kind, test.name)
+				continue
+			}
+			require.NoError(t, err, "%v: %v", kind, test.name)
+			require.Equal(t, target, object.GroupVersionKind())
+			selector, _, _ := unstructured.NestedMap(object.Object, "spec", "selector")
+			require.Equal(t, test.selector, selector, "%v: %v", kind, test.name)
+			_, found, _ := unstructured.NestedFieldNoCopy(object.Object, "spec", "rollbackTo")
+			require.False(t, found, "%v: %v", kind, test.name)
+		}
+	}
+}
+
+func TestConvertResourceVersionWithoutConverter(t *testing.T) {
+	object := newConversionObject("extensions/v1beta1", "NetworkPolicy", map[string]interface{}{"podSelector": map[string]interface{}{}})
+	target := schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}
+	require.NoError(t, convertResourceVersion(object, target))
+	require.Equal(t, target, object.GroupVersionKind())
+	_, found, _ := unstructured.NestedMap(object.Object, "spec", "podSelector")
+	require.True(t, found)
+}
@@ -0,0 +1,114 @@
+package resourcecollector
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+// Fields that are set by the apiserver or controllers and shouldn't be
+// compared
+var diffIgnoredFields = []string{
+	"status",
+	"metadata.uid",
+	"metadata.resourceVersion",
+	"metadata.generation",
+	"metadata.creationTimestamp",
+	"metadata.deletionTimestamp",
+	"metadata.deletionGracePeriodSeconds",
+	"metadata.managedFields",
+	"metadata.selfLink",
+	"metadata.ownerReferences",
+}
+
+// DiffResource compares an object that has been prepared for apply with the
+// object in the cluster. Maps are compared field by field, while lists are
+// compared as a whole. Fields that are defaulted by the apiserver will show up
+// as removed.
+func (r *ResourceCollector) DiffResource(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) (*stork_api.ApplicationRestoreResourceDiff, error) {
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	diff := &stork_api.ApplicationRestoreResourceDiff{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Changed: make([]string, 0),
+	}
+	live, err := dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return diff, nil
+		}
+		return nil, err
+	}
+	diff.Exists = true
+
+	backupFields := make(map[string]interface{})
+	flattenFields("", object.UnstructuredContent(), backupFields)
+	liveFields := make(map[string]interface{})
+	flattenFields("", live.UnstructuredContent(), liveFields)
+	for path, value := range backupFields {
+		if diffFieldIgnored(path) {
+			continue
+		}
+		liveValue, ok := liveFields[path]
+		if !ok {
+			diff.Added = append(diff.Added, path)
+		} else if !reflect.DeepEqual(value, liveValue) {
+			diff.Changed = append(diff.Changed, path)
+		}
+	}
+	for path := range liveFields {
+		if diffFieldIgnored(path) {
+			continue
+		}
+		if _, ok := backupFields[path]; !ok {
+			diff.Removed = append(diff.Removed, path)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff, nil
+}
+
+// flattenFields adds the leaf values of the content to fields, keyed by their
+// path. Lists are treated as leaf values.
+func flattenFields(prefix string, content map[string]interface{}, fields map[string]interface{}) {
+	for key, value := range content {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) != 0 {
+			flattenFields(path, nested, fields)
+			continue
+		}
+		fields[path] = value
+	}
+}
+
+func diffFieldIgnored(path string) bool {
+	for _, ignored := range diffIgnoredFields {
+		if path == ignored || strings.HasPrefix(path, ignored+".") {
+			return true
+		}
+	}
+	return false
+}
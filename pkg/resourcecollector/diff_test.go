@@ -0,0 +1,113 @@
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newDiffConfigMap(name string, data map[string]interface{}) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{"data": data}}
+	object.SetAPIVersion("v1")
+	object.SetKind("ConfigMap")
+	object.SetNamespace("ns")
+	object.SetName(name)
+	return object
+}
+
+func TestDiffResource(t *testing.T) {
+	live := newDiffConfigMap("config", map[string]interface{}{
+		"same":    "value",
+		"changed": "old",
+		"removed": "value",
+		"list":    []interface{}{"a", "b"},
+	})
+	live.SetUID("uid")
+	live.SetResourceVersion("1")
+	live.SetLabels(map[string]string{"app": "live"})
+	r := &ResourceCollector{}
+	dynamicInterface := fake.NewSimpleDynamicClient(runtime.NewScheme(), live)
+
+	tests := []struct {
+		name    string
+		object  *unstructured.Unstructured
+		exists  bool
+		added   []string
+		removed []string
+		changed []string
+	}{
+		{
+			name:    "missing object",
+			object:  newDiffConfigMap("missing", map[string]interface{}{"key": "value"}),
+			added:   []string{},
+			removed: []string{},
+			changed: []string{},
+		},
+		{
+			name: "unchanged object",
+			object: func() *unstructured.Unstructured {
+				object := newDiffConfigMap("config", map[string]interface{}{
+					"same":    "value",
+					"changed": "old",
+					"removed": "value",
+					"list":    []interface{}{"a", "b"},
+				})
+				object.SetLabels(map[string]string{"app": "live"})
+				return object
+			}(),
+			exists:  true,
+			added:   []string{},
+			removed: []string{},
+			changed: []string{},
+		},
+		{
+			name: "changed object",
+			object: func() *unstructured.Unstructured {
+				object := newDiffConfigMap("config", map[string]interface{}{
+					"same":    "value",
+					"changed": "new",
+					"added":   "value",
+					"list":    []interface{}{"a"},
+				})
+				object.SetLabels(map[string]string{"app": "backup", "new": "label"})
+				object.SetUID("other-uid")
+				return object
+			}(),
+			exists:  true,
+			added:   []string{"data.added", "metadata.labels.new"},
+			removed: []string{"data.removed"},
+			changed: []string{"data.changed", "data.list", "metadata.labels.app"},
+		},
+	}
+	for _, test := range tests {
+		diff, err := r.DiffResource(dynamicInterface, test.object)
+		require.NoError(t, err, test.name)
+		require.Equal(t, test.exists, diff.Exists, test.name)
+		require.Equal(t, test.added, diff.Added, test.name)
+		require.Equal(t, test.removed, diff.Removed, test.name)
+		require.Equal(t, test.changed, diff.Changed, test.name)
+	}
+}
+
+func TestDiffFieldIgnored(t *testing.T) {
+	tests := []struct {
+		path    string
+		ignored bool
+	}{
+		{"status", true},
+		{"status.phase", true},
+		{"metadata.uid", true},
+		{"metadata.managedFields", true},
+		{"metadata.labels.app", false},
+		{"metadata.uidLabel", false},
+		{"spec.status", false},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.ignored, diffFieldIgnored(test.path), test.path)
+	}
+}
@@ -0,0 +1,71 @@
+// +build unittest
+
+package resourcecollector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newServiceAccount(imagePullSecrets ...string) *unstructured.Unstructured {
+	secrets := make([]interface{}, 0)
+	for _, secret := range imagePullSecrets {
+		secrets = append(secrets, map[string]interface{}{"name": secret})
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{"imagePullSecrets": secrets}}
+	object.SetAPIVersion("v1")
+	object.SetKind("ServiceAccount")
+	object.SetNamespace("ns")
+	object.SetName("sa")
+	return object
+}
+
+func newClusterRoleBinding(subjects ...string) *unstructured.Unstructured {
+	items := make([]interface{}, 0)
+	for _, subject := range subjects {
+		items = append(items, map[string]interface{}{"kind": "ServiceAccount", "name": subject, "namespace": "ns"})
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"roleRef":  map[string]interface{}{"apiGroup": "rbac.authorization.k8s.io", "kind": "ClusterRole", "name": "role"},
+		"subjects": items,
+	}}
+	object.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	object.SetKind("ClusterRoleBinding")
+	object.SetName("crb")
+	return object
+}
+
+// The existing objects are merged on the cluster of the client that they are
+// applied with, which isn't the local cluster for restores to a ClusterPair
+func TestApplyResourceMergeRemote(t *testing.T) {
+	remote := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newServiceAccount("existing"),
+		newClusterRoleBinding("existing"))
+	r := &ResourceCollector{}
+
+	require.NoError(t, r.ApplyResource(remote, newServiceAccount("restored")))
+	sa, err := remote.Resource(schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}).
+		Namespace("ns").Get(context.TODO(), "sa", metav1.GetOptions{})
+	require.NoError(t, err)
+	secrets, _, err := unstructured.NestedSlice(sa.Object, "imagePullSecrets")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"name": "existing"},
+		map[string]interface{}{"name": "restored"},
+	}, secrets)
+
+	require.NoError(t, r.ApplyResource(remote, newClusterRoleBinding("restored")))
+	crb, err := remote.Resource(schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}).
+		Get(context.TODO(), "crb", metav1.GetOptions{})
+	require.NoError(t, err)
+	subjects, _, err := unstructured.NestedSlice(crb.Object, "subjects")
+	require.NoError(t, err)
+	require.Len(t, subjects, 2)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
@@ -87,10 +88,27 @@ func (r *ResourceCollector) pvToBeCollected(
 	return false, nil
 }
 
+// PVSourceUIDAnnotation is added to PVs when they are backed up with the UID
+// of the PV. The UID isn't kept when the PV is restored, so this is used to
+// find the original PV.
+const PVSourceUIDAnnotation = "stork.libopenstorage.org/source-pv-uid"
+
 func (r *ResourceCollector) preparePVResourceForCollection(
 	object runtime.Unstructured,
 ) error {
-	err := unstructured.SetNestedField(object.UnstructuredContent(), nil, "spec", "claimRef")
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	if metadata.GetUID() != "" {
+		annotations := metadata.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[PVSourceUIDAnnotation] = string(metadata.GetUID())
+		metadata.SetAnnotations(annotations)
+	}
+	err = unstructured.SetNestedField(object.UnstructuredContent(), nil, "spec", "claimRef")
 	if err != nil {
 		return err
 	}
@@ -77,3 +77,57 @@ func (r *ResourceCollector) preparePVCResourceForApply(
 	object.SetUnstructuredContent(o)
 	return false, nil
 }
+
+// PVCMappingKey returns the key for a PVC in the mapping returned by
+// BuildPVCToPVMapping
+func PVCMappingKey(namespace string, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// BuildPVCToPVMapping returns a mapping from PVCs to the PVs that they are
+// bound to, for the PVCs and PVs in the given objects. The mapping is keyed by
+// PVCMappingKey. PVCs that aren't bound, or whose PV isn't in the objects,
+// aren't included. Returns an error if any of the PVC or PV objects can't be
+// parsed.
+func BuildPVCToPVMapping(objects []runtime.Unstructured) (map[string]*v1.PersistentVolume, error) {
+	pvNameToPVCKey := make(map[string]string)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolumeClaim" {
+			continue
+		}
+		var pvc v1.PersistentVolumeClaim
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pvc); err != nil {
+			return nil, fmt.Errorf("error converting %v to persistent volume claim: %v", getUnstructuredName(o), err)
+		}
+		if pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pvNameToPVCKey[pvc.Spec.VolumeName] = PVCMappingKey(pvc.Namespace, pvc.Name)
+	}
+
+	pvcToPV := make(map[string]*v1.PersistentVolume)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolume" {
+			continue
+		}
+		pv := &v1.PersistentVolume{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), pv); err != nil {
+			return nil, fmt.Errorf("error converting %v to persistent volume: %v", getUnstructuredName(o), err)
+		}
+		if key, ok := pvNameToPVCKey[pv.Name]; ok {
+			pvcToPV[key] = pv
+		}
+	}
+	return pvcToPV, nil
+}
+
+func getUnstructuredName(object runtime.Unstructured) string {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return "object"
+	}
+	if metadata.GetNamespace() == "" {
+		return metadata.GetName()
+	}
+	return PVCMappingKey(metadata.GetNamespace(), metadata.GetName())
+}
@@ -0,0 +1,148 @@
+package resourcecollector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ReferencedResourceAnnotation is added to cluster scoped resources that were
+// collected because they are referenced by namespaced resources. They are
+// shared with other namespaces, so they are only created when restored and
+// are never replaced or deleted.
+const ReferencedResourceAnnotation = "stork.libopenstorage.org/referenced-resource"
+
+// clusterReference is a cluster scoped object referenced by an object
+type clusterReference struct {
+	resource schema.GroupVersionResource
+	kind     string
+	name     string
+}
+
+func (c *clusterReference) key() string {
+	return c.resource.Group + "/" + c.kind + "/" + c.name
+}
+
+var (
+	storageClassGVR  = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}
+	clusterRoleGVR   = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}
+	ingressClassGVR  = schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"}
+	priorityClassGVR = schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+)
+
+// Prefix of the PriorityClasses that exist on every cluster
+const systemPriorityClassPrefix = "system-"
+
+// IsReferencedResource returns if the object was collected because it is
+// referenced by a namespaced resource
+func IsReferencedResource(object runtime.Unstructured) bool {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false
+	}
+	return metadata.GetAnnotations()[ReferencedResourceAnnotation] == "true"
+}
+
+// GetReferencedResources returns the cluster scoped resources that are
+// referenced by the given objects and aren't in them already. The
+// StorageClasses of PVCs, the ClusterRoles of RoleBindings, the IngressClasses
+// of Ingresses and the PriorityClasses of the pod templates of workloads are
+// collected. References to resources that don't exist, and the default
+// ClusterRoles and PriorityClasses, are skipped.
+func (r *ResourceCollector) GetReferencedResources(
+	objects []runtime.Unstructured,
+) ([]runtime.Unstructured, error) {
+	collected := make(map[string]bool)
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		if metadata.GetNamespace() == "" {
+			gvk := o.GetObjectKind().GroupVersionKind()
+			collected[gvk.Group+"/"+gvk.Kind+"/"+metadata.GetName()] = true
+		}
+	}
+
+	referenced := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		reference, err := getClusterReference(o)
+		if err != nil {
+			return nil, err
+		}
+		if reference == nil || collected[reference.key()] {
+			continue
+		}
+		collected[reference.key()] = true
+
+		object, err := r.dynamicInterface.Resource(reference.resource).Get(context.TODO(), reference.name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting %v %v referenced by %v: %v", reference.kind, reference.name, getUnstructuredName(o), err)
+		}
+		// The default ClusterRoles exist on every cluster
+		if object.GetLabels()["kubernetes.io/bootstrapping"] == "rbac-defaults" {
+			continue
+		}
+		annotations := object.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[ReferencedResourceAnnotation] = "true"
+		object.SetAnnotations(annotations)
+		referenced = append(referenced, object)
+	}
+
+	if err := r.prepareResourcesForCollection(referenced, nil); err != nil {
+		return nil, err
+	}
+	return referenced, nil
+}
+
+// getClusterReference returns the cluster scoped object referenced by an
+// object, or nil if it doesn't reference one
+func getClusterReference(object runtime.Unstructured) (*clusterReference, error) {
+	content := object.UnstructuredContent()
+	var reference *clusterReference
+	var err error
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "PersistentVolumeClaim":
+		reference = &clusterReference{resource: storageClassGVR, kind: "StorageClass"}
+		reference.name, _, err = unstructured.NestedString(content, "spec", "storageClassName")
+	case "RoleBinding":
+		var kind string
+		kind, _, err = unstructured.NestedString(content, "roleRef", "kind")
+		if err != nil || kind != "ClusterRole" {
+			return nil, err
+		}
+		reference = &clusterReference{resource: clusterRoleGVR, kind: "ClusterRole"}
+		reference.name, _, err = unstructured.NestedString(content, "roleRef", "name")
+	case "Ingress":
+		reference = &clusterReference{resource: ingressClassGVR, kind: "IngressClass"}
+		reference.name, _, err = unstructured.NestedString(content, "spec", "ingressClassName")
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "DeploymentConfig", "Job":
+		reference = &clusterReference{resource: priorityClassGVR, kind: "PriorityClass"}
+		reference.name, _, err = unstructured.NestedString(content, "spec", "template", "spec", "priorityClassName")
+	case "CronJob":
+		reference = &clusterReference{resource: priorityClassGVR, kind: "PriorityClass"}
+		reference.name, _, err = unstructured.NestedString(content, "spec", "jobTemplate", "spec", "template", "spec", "priorityClassName")
+	default:
+		return nil, nil
+	}
+	if reference.kind == "PriorityClass" && strings.HasPrefix(reference.name, systemPriorityClassPrefix) {
+		return nil, err
+	}
+	if err != nil || reference.name == "" {
+		return nil, err
+	}
+	return reference, nil
+}
@@ -2,9 +2,11 @@ package resourcecollector
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-openapi/inflect"
@@ -25,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	k8sdiscovery "k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/kubernetes/pkg/registry/core/service/portallocator"
@@ -38,12 +41,52 @@ const (
 	skipOwnerRefCheckAnnotation      = "stork.libopenstorage.org/skip-owner-ref-check"
 	deletedMaxRetries                = 12
 	deletedRetryInterval             = 10 * time.Second
+	deleteMaxRetries                 = 5
+	deleteRetryInterval              = 2 * time.Second
+	defaultDeleteConcurrency         = 10
+	defaultDeleteBatchSize           = 100
+	// Field manager used for server-side apply when merging resources
+	mergeFieldManager = "stork"
 )
 
+// Kinds that are only collected and applied if they are included in the
+// optional resource types. APIServices are cluster scoped and replacing one
+// breaks discovery for its group, so they have to be requested explicitly.
+var optionalResourceKinds = []string{"Job", "APIService"}
+
+// SetOptionalResourceKinds sets the kinds that are only collected and applied
+// if they are included in the optional resource types for a backup, restore
+// or migration
+func SetOptionalResourceKinds(kinds []string) {
+	optionalResourceKinds = kinds
+}
+
+// isOptionalResourceKind returns if the kind is optional. If it is, also
+// returns if it was included in the optional resource types. Types can be
+// specified using either the singular or plural name of the kind.
+func isOptionalResourceKind(kind string, optionalResourceTypes []string) (bool, bool) {
+	if !slice.ContainsString(optionalResourceKinds, kind, strings.ToLower) {
+		return false, false
+	}
+	plural := inflect.Pluralize(strings.ToLower(kind))
+	return true, slice.ContainsString(optionalResourceTypes, kind, strings.ToLower) ||
+		slice.ContainsString(optionalResourceTypes, plural, strings.ToLower)
+}
+
+// LastModifiedAnnotation is added to collected resources with the time they
+// were last modified in the source
+const LastModifiedAnnotation = "stork.libopenstorage.org/last-modified"
+
+// OriginalCreationTimestampAnnotation is added to collected resources with the
+// time they were created in the source. It is kept on the resources when they
+// are applied since their creationTimestamp is reset.
+const OriginalCreationTimestampAnnotation = "stork.libopenstorage.org/original-creation-timestamp"
+
 // ResourceCollector is used to collect and process unstructured objects in namespaces and using label selectors
 type ResourceCollector struct {
 	Driver           volume.Driver
 	discoveryHelper  discovery.Helper
+	discoveryClient  k8sdiscovery.DiscoveryInterface
 	dynamicInterface dynamic.Interface
 	coreOps          core.Ops
 	rbacOps          rbac.Ops
@@ -72,8 +115,8 @@ func (r *ResourceCollector) Init(config *restclient.Config) error {
 		return fmt.Errorf("error getting apiextension client, %v", err)
 	}
 
-	discoveryClient := aeclient.Discovery()
-	r.discoveryHelper, err = discovery.NewHelper(discoveryClient, logrus.New())
+	r.discoveryClient = aeclient.Discovery()
+	r.discoveryHelper, err = discovery.NewHelper(r.discoveryClient, logrus.New())
 	if err != nil {
 		return err
 	}
@@ -105,6 +148,10 @@ func resourceToBeCollected(resource metav1.APIResource, grp schema.GroupVersion,
 		return false
 	}
 
+	if optional, included := isOptionalResourceKind(resource.Kind, optionalResourceTypes); optional {
+		return included
+	}
+
 	// Include all namespaced CRDs
 	for _, res := range crdKinds {
 		if res.Kind == resource.Kind &&
@@ -134,11 +181,13 @@ func resourceToBeCollected(resource metav1.APIResource, grp schema.GroupVersion,
 		"CronJob",
 		"ResourceQuota",
 		"ReplicaSet",
-		"LimitRange":
+		"LimitRange",
+		"HorizontalPodAutoscaler",
+		"ValidatingWebhookConfiguration",
+		"MutatingWebhookConfiguration",
+		"APIService",
+		"Job":
 		return true
-	case "Job":
-		return slice.ContainsString(optionalResourceTypes, "job", strings.ToLower) ||
-			slice.ContainsString(optionalResourceTypes, "jobs", strings.ToLower)
 	default:
 		return false
 	}
@@ -468,6 +517,10 @@ func (r *ResourceCollector) objectToBeCollected(
 		return r.ingressToBeCollected(object)
 	case "ConfigMap":
 		return r.configmapToBeCollected(object)
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		return r.webhookConfigurationToBeCollected(object, namespace)
+	case "APIService":
+		return r.apiServiceToBeCollected(object, namespace)
 	}
 
 	return true, nil
@@ -570,6 +623,9 @@ func (r *ResourceCollector) prepareResourcesForCollection(
 			}
 		}
 
+		setLastModifiedAnnotation(metadata)
+		setOriginalCreationTimestampAnnotation(metadata)
+
 		content := o.UnstructuredContent()
 		if crdList != nil {
 			resourceKind := o.GetObjectKind().GroupVersionKind()
@@ -600,6 +656,80 @@ func (r *ResourceCollector) prepareResourcesForCollection(
 	return nil
 }
 
+// setLastModifiedAnnotation records the last time the object was modified in
+// an annotation. This is the latest time from the managed fields, or the
+// creation time if there aren't any.
+func setLastModifiedAnnotation(metadata metav1.Object) {
+	lastModified := metadata.GetCreationTimestamp()
+	for _, field := range metadata.GetManagedFields() {
+		if field.Time != nil && lastModified.Before(field.Time) {
+			lastModified = *field.Time
+		}
+	}
+	if lastModified.IsZero() {
+		return
+	}
+	annotations := metadata.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[LastModifiedAnnotation] = lastModified.UTC().Format(time.RFC3339)
+	metadata.SetAnnotations(annotations)
+}
+
+// setOriginalCreationTimestampAnnotation records the time the object was
+// created in an annotation. Objects that were restored from an earlier backup
+// already have the annotation, so it isn't updated for them.
+func setOriginalCreationTimestampAnnotation(metadata metav1.Object) {
+	created := metadata.GetCreationTimestamp()
+	if created.IsZero() {
+		return
+	}
+	annotations := metadata.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if _, ok := annotations[OriginalCreationTimestampAnnotation]; ok {
+		return
+	}
+	annotations[OriginalCreationTimestampAnnotation] = created.UTC().Format(time.RFC3339)
+	metadata.SetAnnotations(annotations)
+}
+
+// ModifiedSince returns whether the object was modified in the source after
+// the given time. Objects without a recorded modification time are always
+// considered modified.
+func ModifiedSince(object runtime.Unstructured, since metav1.Time) (bool, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	value, ok := metadata.GetAnnotations()[LastModifiedAnnotation]
+	if !ok {
+		return true, nil
+	}
+	lastModified, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false, fmt.Errorf("error parsing last modified time for %v: %v", metadata.GetName(), err)
+	}
+	return lastModified.After(since.Time), nil
+}
+
+// RemoveLastModifiedAnnotation removes the annotation added during collection
+// with the time the object was last modified
+func RemoveLastModifiedAnnotation(object runtime.Unstructured) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	annotations := metadata.GetAnnotations()
+	if _, ok := annotations[LastModifiedAnnotation]; ok {
+		delete(annotations, LastModifiedAnnotation)
+		metadata.SetAnnotations(annotations)
+	}
+	return nil
+}
+
 // includeObject determines whether to include an object or not
 // based on the object kind
 func (r *ResourceCollector) includeObject(
@@ -670,6 +800,10 @@ func (r *ResourceCollector) PrepareResourceForApply(
 		return true, nil
 	}
 
+	if err := RemoveLastModifiedAnnotation(object); err != nil {
+		return false, err
+	}
+
 	if metadata.GetNamespace() != "" {
 		var val string
 		var present bool
@@ -681,13 +815,11 @@ func (r *ResourceCollector) PrepareResourceForApply(
 		metadata.SetNamespace(val)
 	}
 
-	switch objectType.GetKind() {
-	case "Job":
-		if slice.ContainsString(optionalResourceTypes, "job", strings.ToLower) ||
-			slice.ContainsString(optionalResourceTypes, "jobs", strings.ToLower) {
-			return false, nil
-		}
+	if optional, included := isOptionalResourceKind(objectType.GetKind(), optionalResourceTypes); optional && !included {
 		return true, nil
+	}
+
+	switch objectType.GetKind() {
 	case "PersistentVolume":
 		return r.preparePVResourceForApply(object, pvNameMappings)
 	case "PersistentVolumeClaim":
@@ -696,6 +828,10 @@ func (r *ResourceCollector) PrepareResourceForApply(
 		return false, r.prepareClusterRoleBindingForApply(object, namespaceMappings)
 	case "RoleBinding":
 		return false, r.prepareRoleBindingForApply(object, namespaceMappings)
+	case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+		return r.prepareWebhookConfigurationForApply(object, namespaceMappings)
+	case "APIService":
+		return r.prepareAPIServiceForApply(object, namespaceMappings)
 	}
 	return false, nil
 }
@@ -715,7 +851,10 @@ func (r *ResourceCollector) mergeSupportedForResource(
 	return false
 }
 
+// mergeAndUpdateResource merges the object with the existing one using the
+// client for the cluster that it is being applied to
 func (r *ResourceCollector) mergeAndUpdateResource(
+	dynamicClient dynamic.ResourceInterface,
 	object runtime.Unstructured,
 ) error {
 	objectType, err := meta.TypeAccessor(object)
@@ -725,13 +864,39 @@ func (r *ResourceCollector) mergeAndUpdateResource(
 
 	switch objectType.GetKind() {
 	case "ClusterRoleBinding":
-		return r.mergeAndUpdateClusterRoleBinding(object)
+		return r.mergeAndUpdateClusterRoleBinding(dynamicClient, object)
 	case "ServiceAccount":
-		return r.mergeAndUpdateServiceAccount(object)
+		return r.mergeAndUpdateServiceAccount(dynamicClient, object)
 	}
 	return nil
 }
 
+// updateMergedResource creates the object if it doesn't exist. Otherwise the
+// merge function is called with the existing object and its result is
+// updated.
+func updateMergedResource(
+	dynamicClient dynamic.ResourceInterface,
+	object runtime.Unstructured,
+	merge func(current *unstructured.Unstructured) error,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	current, err := dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			_, err = dynamicClient.Create(context.TODO(), object.(*unstructured.Unstructured), metav1.CreateOptions{})
+		}
+		return err
+	}
+	if err := merge(current); err != nil {
+		return err
+	}
+	_, err = dynamicClient.Update(context.TODO(), current, metav1.UpdateOptions{})
+	return err
+}
+
 // ApplyResource applies a given resource using the provided client interface
 func (r *ResourceCollector) ApplyResource(
 	dynamicInterface dynamic.Interface,
@@ -745,7 +910,7 @@ func (r *ResourceCollector) ApplyResource(
 	if err != nil {
 		if apierrors.IsAlreadyExists(err) || strings.Contains(err.Error(), portallocator.ErrAllocated.Error()) {
 			if r.mergeSupportedForResource(object) {
-				return r.mergeAndUpdateResource(object)
+				return r.mergeAndUpdateResource(dynamicClient, object)
 			} else if strings.Contains(err.Error(), portallocator.ErrAllocated.Error()) {
 				err = r.updateService(object)
 				if err != nil {
@@ -762,69 +927,309 @@ func (r *ResourceCollector) ApplyResource(
 	return err
 }
 
-// DeleteResources deletes given resources using the provided client interface
+// ResourceExists checks if the object exists on the cluster for the provided
+// client interface
+func (r *ResourceCollector) ResourceExists(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) (bool, error) {
+	if _, err := r.GetResource(dynamicInterface, object); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetResource gets the current version of the object from the cluster for the
+// provided client interface
+func (r *ResourceCollector) GetResource(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) (*unstructured.Unstructured, error) {
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
+}
+
+// UpdateResource updates an existing resource
+func (r *ResourceCollector) UpdateResource(
+	dynamicInterface dynamic.Interface,
+	object *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicClient.Update(context.TODO(), object, metav1.UpdateOptions{})
+}
+
+// CreateResourceWithName creates a resource with the given name instead of
+// the name of the given object. Unlike ApplyResource, existing resources
+// aren't updated.
+func (r *ResourceCollector) CreateResourceWithName(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+	name string,
+) error {
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return err
+	}
+	renamed := object.(*unstructured.Unstructured).DeepCopy()
+	renamed.SetName(name)
+	_, err = dynamicClient.Create(context.TODO(), renamed, metav1.CreateOptions{})
+	return err
+}
+
+// MergeResource updates an existing resource with the fields from the given
+// object using server-side apply. Fields that aren't set in the object, like
+// the ones managed by controllers in the cluster, are left as is. Fields that
+// were set by a previous merge but have since been removed from the object
+// are removed from the resource. Fields that are managed by others, like
+// replicas set by an autoscaler, aren't taken over. A Conflict error listing
+// the fields is returned instead.
+func (r *ResourceCollector) MergeResource(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) error {
+	return r.serverSideApply(dynamicInterface, object, nil)
+}
+
+// DryRunApplyResource applies the object using a server-side apply dry run.
+// The object goes through validation and admission in the apiserver, but
+// isn't persisted. Returns the error from the apiserver if it was rejected.
+func (r *ResourceCollector) DryRunApplyResource(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) error {
+	return r.serverSideApply(dynamicInterface, object, []string{metav1.DryRunAll})
+}
+
+func (r *ResourceCollector) serverSideApply(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+	dryRun []string,
+) error {
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return err
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
+	// Server-side apply doesn't allow these to be set in the applied object
+	content := runtime.DeepCopyJSON(object.UnstructuredContent())
+	unstructured.RemoveNestedField(content, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(content, "metadata", "uid")
+	unstructured.RemoveNestedField(content, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(content, "metadata", "managedFields")
+	unstructured.RemoveNestedField(content, "status")
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	_, err = dynamicClient.Patch(context.TODO(), metadata.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: mergeFieldManager,
+		DryRun:       dryRun,
+	})
+	return err
+}
+
+// DeleteOptions are the options used when deleting resources
+type DeleteOptions struct {
+	// Concurrency is the number of objects that are deleted in parallel
+	Concurrency int
+	// BatchSize is the number of objects that are deleted before waiting for
+	// them to be removed
+	BatchSize int
+	// GracePeriodSeconds is the grace period used when deleting objects. The
+	// default for each object is used if it isn't set. If it is set, the wait
+	// for objects to be removed is extended by the grace period and an error
+	// is returned for objects that are still present after that.
+	GracePeriodSeconds *int64
+}
+
+// getDeleteOrder returns the order in which objects of a kind should be
+// deleted. Objects using other resources are deleted first so that they don't
+// hold on to, or re-create, the resources they depend on.
+func getDeleteOrder(object runtime.Unstructured) int {
+	objectType, err := meta.TypeAccessor(object)
+	if err != nil {
+		return 0
+	}
+	switch objectType.GetKind() {
+	case "PersistentVolumeClaim",
+		"ConfigMap",
+		"Secret",
+		"Role",
+		"RoleBinding":
+		return 1
+	case "PersistentVolume",
+		"ClusterRole",
+		"CustomResourceDefinition":
+		return 2
+	}
+	return 0
+}
+
+func isTransientDeleteError(err error) bool {
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsServiceUnavailable(err)
+}
+
+// DeleteResources deletes given resources using the provided client interface.
+// Objects are deleted in order of their dependencies, in batches, with up to
+// opts.Concurrency objects being deleted in parallel. Default options are used
+// if opts is nil.
 func (r *ResourceCollector) DeleteResources(
 	dynamicInterface dynamic.Interface,
 	objects []runtime.Unstructured,
+	opts *DeleteOptions,
 ) error {
-	// First delete all the objects
-	deleteStart := metav1.Now()
+	concurrency := defaultDeleteConcurrency
+	batchSize := defaultDeleteBatchSize
+	var gracePeriodSeconds *int64
+	if opts != nil {
+		if opts.Concurrency > 0 {
+			concurrency = opts.Concurrency
+		}
+		if opts.BatchSize > 0 {
+			batchSize = opts.BatchSize
+		}
+		gracePeriodSeconds = opts.GracePeriodSeconds
+	}
+
+	// Group the objects so that each group can be deleted after the previous
+	// one has been removed. Objects that support merging aren't deleted.
+	var groups [][]runtime.Unstructured
 	for _, object := range objects {
-		// Don't delete objects that support merging
 		if r.mergeSupportedForResource(object) {
 			continue
 		}
-
-		metadata, err := meta.Accessor(object)
-		if err != nil {
-			return err
-		}
-
-		dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
-		if err != nil {
-			return err
+		order := getDeleteOrder(object)
+		for len(groups) <= order {
+			groups = append(groups, nil)
 		}
+		groups[order] = append(groups[order], object)
+	}
 
-		// Delete the resource if it already exists on the destination
-		// cluster and try creating again
-		err = dynamicClient.Delete(context.TODO(), metadata.GetName(), metav1.DeleteOptions{})
-		if err != nil && !apierrors.IsNotFound(err) {
-			return err
+	for _, group := range groups {
+		for start := 0; start < len(group); start += batchSize {
+			end := start + batchSize
+			if end > len(group) {
+				end = len(group)
+			}
+			if err := r.deleteBatch(dynamicInterface, group[start:end], concurrency, gracePeriodSeconds); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
+
+// deleteBatch deletes the objects in parallel and waits for them to be
+// removed
+func (r *ResourceCollector) deleteBatch(
+	dynamicInterface dynamic.Interface,
+	objects []runtime.Unstructured,
+	concurrency int,
+	gracePeriodSeconds *int64,
+) error {
+	deleteStart := metav1.Now()
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var lastError error
+	workers := make(chan struct{}, concurrency)
 
-	// Then wait for them to actually be deleted
 	for _, object := range objects {
-		// Objects that support merging aren't deleted
-		if r.mergeSupportedForResource(object) {
-			continue
-		}
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(object runtime.Unstructured) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			if err := r.deleteResource(dynamicInterface, object, deleteStart, gracePeriodSeconds); err != nil {
+				lock.Lock()
+				lastError = err
+				lock.Unlock()
+			}
+		}(object)
+	}
+	wg.Wait()
+	return lastError
+}
 
-		metadata, err := meta.Accessor(object)
-		if err != nil {
-			return err
-		}
+func (r *ResourceCollector) deleteResource(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+	deleteStart metav1.Time,
+	gracePeriodSeconds *int64,
+) error {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return err
+	}
 
-		dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
-		if err != nil {
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return err
+	}
+
+	// Delete the resource if it already exists on the destination
+	// cluster and try creating again. Retry on transient errors from the
+	// apiserver.
+	for i := 0; ; i++ {
+		err = dynamicClient.Delete(context.TODO(), metadata.GetName(), metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriodSeconds,
+		})
+		if err == nil || apierrors.IsNotFound(err) {
+			break
+		}
+		if !isTransientDeleteError(err) || i >= deleteMaxRetries {
 			return err
 		}
+		logrus.Warnf("Error deleting object %v, retrying in %v: %v", metadata.GetName(), deleteRetryInterval, err)
+		time.Sleep(deleteRetryInterval)
+	}
 
-		// Wait for up to 2 minutes for the object to be deleted
-		for i := 0; i < deletedMaxRetries; i++ {
-			obj, err := dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
-			if err != nil && apierrors.IsNotFound(err) {
-				break
-			}
+	// Wait for up to 2 minutes, along with the grace period if one was
+	// given, for the object to be deleted
+	waitTimeout := deletedMaxRetries * deletedRetryInterval
+	if gracePeriodSeconds != nil {
+		waitTimeout += time.Duration(*gracePeriodSeconds) * time.Second
+	}
+	for deadline := time.Now().Add(waitTimeout); time.Now().Before(deadline); {
+		obj, err := dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
+		if err != nil && apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err == nil {
 			createTime := obj.GetCreationTimestamp()
 			if deleteStart.Before(&createTime) {
 				logrus.Warnf("Object[%v] got re-created after deletion. So, Ignore wait. deleteStart time:[%v], create time:[%v]",
 					obj.GetName(), deleteStart, createTime)
-				break
+				return nil
 			}
-			logrus.Warnf("Object %v still present, retrying in %v", metadata.GetName(), deletedRetryInterval)
-			time.Sleep(deletedRetryInterval)
 		}
+		logrus.Warnf("Object %v still present, retrying in %v", metadata.GetName(), deletedRetryInterval)
+		time.Sleep(deletedRetryInterval)
+	}
+	// Creating the object again would fail while it is still being deleted
+	if gracePeriodSeconds != nil {
+		return fmt.Errorf("timed out waiting for %v to be deleted", metadata.GetName())
 	}
 	return nil
 }
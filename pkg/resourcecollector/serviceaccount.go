@@ -5,10 +5,11 @@ import (
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/dynamic"
 )
 
 func (r *ResourceCollector) serviceAccountToBeCollected(
@@ -39,20 +40,31 @@ func (r *ResourceCollector) serviceAccountToBeCollected(
 }
 
 func (r *ResourceCollector) mergeAndUpdateServiceAccount(
+	dynamicClient dynamic.ResourceInterface,
 	object runtime.Unstructured,
 ) error {
 	var newSA v1.ServiceAccount
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &newSA); err != nil {
 		return err
 	}
-
-	currentSA, err := r.coreOps.GetServiceAccount(newSA.Name, newSA.Namespace)
-	if err != nil {
-		if apierrors.IsNotFound(err) {
-			_, err = r.coreOps.CreateServiceAccount(&newSA)
+	return updateMergedResource(dynamicClient, object, func(current *unstructured.Unstructured) error {
+		var currentSA v1.ServiceAccount
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.UnstructuredContent(), &currentSA); err != nil {
+			return err
 		}
-		return err
-	}
+		mergeServiceAccount(&currentSA, &newSA)
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&currentSA)
+		if err != nil {
+			return err
+		}
+		current.SetUnstructuredContent(content)
+		return nil
+	})
+}
+
+// mergeServiceAccount adds the secrets from the new ServiceAccount to the
+// current one
+func mergeServiceAccount(currentSA *v1.ServiceAccount, newSA *v1.ServiceAccount) {
 	imagePullSecrets := sets.NewString()
 	for _, secret := range currentSA.ImagePullSecrets {
 		imagePullSecrets.Insert(secret.Name)
@@ -72,6 +84,4 @@ func (r *ResourceCollector) mergeAndUpdateServiceAccount(
 			currentSA.Secrets = append(currentSA.Secrets, secret)
 		}
 	}
-	_, err = r.coreOps.UpdateServiceAccount(currentSA)
-	return err
 }
@@ -0,0 +1,109 @@
+package resourcecollector
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// IsWebhookConfiguration returns if the kind is a validating or mutating
+// admission webhook configuration
+func IsWebhookConfiguration(kind string) bool {
+	return kind == "ValidatingWebhookConfiguration" || kind == "MutatingWebhookConfiguration"
+}
+
+// GetWebhookServices returns the services, as namespace/name, that the
+// webhooks in a webhook configuration call. Webhooks that call a URL are
+// skipped.
+func GetWebhookServices(object runtime.Unstructured) ([]string, error) {
+	webhooks, _, err := unstructured.NestedSlice(object.UnstructuredContent(), "webhooks")
+	if err != nil {
+		return nil, err
+	}
+	services := make([]string, 0)
+	for _, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid webhook in %v", object.GetObjectKind().GroupVersionKind().Kind)
+		}
+		namespace, found, err := unstructured.NestedString(webhookMap, "clientConfig", "service", "namespace")
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		name, _, err := unstructured.NestedString(webhookMap, "clientConfig", "service", "name")
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, fmt.Sprintf("%v/%v", namespace, name))
+	}
+	return services, nil
+}
+
+// Webhook configurations are cluster scoped, so they are only collected if
+// one of their webhooks calls a service in the namespace
+func (r *ResourceCollector) webhookConfigurationToBeCollected(
+	object runtime.Unstructured,
+	namespace string,
+) (bool, error) {
+	webhooks, _, err := unstructured.NestedSlice(object.UnstructuredContent(), "webhooks")
+	if err != nil {
+		return false, err
+	}
+	for _, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		serviceNamespace, _, err := unstructured.NestedString(webhookMap, "clientConfig", "service", "namespace")
+		if err != nil {
+			return false, err
+		}
+		if serviceNamespace == namespace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// prepareWebhookConfigurationForApply updates the namespace of the services
+// called by the webhooks based on the namespace mapping. Returns true if none
+// of the services are in the mapped namespaces, in which case the
+// configuration should be skipped.
+func (r *ResourceCollector) prepareWebhookConfigurationForApply(
+	object runtime.Unstructured,
+	namespaceMappings map[string]string,
+) (bool, error) {
+	content := object.UnstructuredContent()
+	webhooks, _, err := unstructured.NestedSlice(content, "webhooks")
+	if err != nil {
+		return false, err
+	}
+	skip := true
+	for _, webhook := range webhooks {
+		webhookMap, ok := webhook.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		serviceNamespace, found, err := unstructured.NestedString(webhookMap, "clientConfig", "service", "namespace")
+		if err != nil {
+			return false, err
+		}
+		if !found {
+			continue
+		}
+		if destNamespace, ok := namespaceMappings[serviceNamespace]; ok {
+			skip = false
+			if err := unstructured.SetNestedField(webhookMap, destNamespace, "clientConfig", "service", "namespace"); err != nil {
+				return false, err
+			}
+		}
+	}
+	if skip {
+		return true, nil
+	}
+	return false, unstructured.SetNestedSlice(content, webhooks, "webhooks")
+}
@@ -91,6 +91,16 @@ var ownerAPICallBackoff = wait.Backoff{
 	Steps:    20,
 }
 
+// Source used for events created by the rule executor
+var eventSource = v1.EventSource{
+	Component: "stork",
+}
+
+// SetEventSource sets the source used for events created by the rule executor
+func SetEventSource(source v1.EventSource) {
+	eventSource = source
+}
+
 // Init initializes the rule executor
 func Init() error {
 	storkRuleResource := apiextensions.CustomResource{
@@ -191,9 +201,7 @@ func PerformRuleRecovery(
 					},
 					Reason:  "FailedToGetPod",
 					Message: err.Error(),
-					Source: v1.EventSource{
-						Component: "stork",
-					},
+					Source:  eventSource,
 				}
 				if _, err = core.Instance().CreateEvent(ev); err != nil {
 					log.RuleLog(nil, owner).Warnf("failed to create event for missing pod err: %v", err)
@@ -221,6 +229,43 @@ func ExecuteRule(
 	rType Type,
 	owner runtime.Object,
 	podNamespace string,
+) (chan bool, error) {
+	return executeRule(rule, rType, owner, podNamespace, nil)
+}
+
+// ExecuteRuleForPVCs executes rules for the given owner only on the pods that
+// use one of the given PVCs
+func ExecuteRuleForPVCs(
+	rule *stork_api.Rule,
+	rType Type,
+	owner runtime.Object,
+	podNamespace string,
+	pvcNames []string,
+) (chan bool, error) {
+	return executeRule(rule, rType, owner, podNamespace, pvcNames)
+}
+
+// podUsesPVCs checks if a pod has a volume for any of the given PVCs
+func podUsesPVCs(pod *v1.Pod, pvcNames []string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		for _, pvcName := range pvcNames {
+			if volume.PersistentVolumeClaim.ClaimName == pvcName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func executeRule(
+	rule *stork_api.Rule,
+	rType Type,
+	owner runtime.Object,
+	podNamespace string,
+	pvcNames []string,
 ) (chan bool, error) {
 	// Validate the rule. Don't depend on callers to invoke this
 	if err := ValidateRule(rule, rType); err != nil {
@@ -241,7 +286,11 @@ func ExecuteRule(
 			return nil, err
 		}
 
-		pods = append(pods, p.Items...)
+		for _, pod := range p.Items {
+			if pvcNames == nil || podUsesPVCs(&pod, pvcNames) {
+				pods = append(pods, pod)
+			}
+		}
 	}
 
 	if len(pods) > 0 {
@@ -499,9 +548,7 @@ func runCommandOnPods(pods []v1.Pod, container string, cmd string, numRetries in
 						},
 						Reason:  "FailedToGetPod",
 						Message: err.Error(),
-						Source: v1.EventSource{
-							Component: "stork",
-						},
+						Source:  eventSource,
 					}
 					if _, err = core.Instance().CreateEvent(ev); err != nil {
 						logrus.Warnf("failed to create event for missing pod err: %v", err)
@@ -0,0 +1,42 @@
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestPodUsesPVCs(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{
+					Name:         "config",
+					VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{}},
+				},
+				{
+					Name: "data",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "db-1"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		pvcNames []string
+		uses     bool
+	}{
+		{pvcNames: []string{"db-1"}, uses: true},
+		{pvcNames: []string{"db-2", "db-1"}, uses: true},
+		{pvcNames: []string{"db-2"}, uses: false},
+		{pvcNames: []string{}, uses: false},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.uses, podUsesPVCs(pod, test.pvcNames), "Unexpected result for %v", test.pvcNames)
+	}
+}
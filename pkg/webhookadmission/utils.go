@@ -2,6 +2,7 @@ package webhookadmission
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -9,9 +10,12 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"math/big"
 	"time"
 
+	"github.com/libopenstorage/stork/pkg/apis/stork"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s/admissionregistration"
 	"github.com/portworx/sched-ops/k8s/core"
 	log "github.com/sirupsen/logrus"
@@ -19,13 +23,16 @@ import (
 	v1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 const (
-	webhookName       = "webhook.stork.libopenstorage.org"
-	storkService      = "stork-service"
-	storkNamespaceEnv = "STORK-NAMESPACE"
-	defaultNamespace  = "kube-system"
+	webhookName         = "webhook.stork.libopenstorage.org"
+	validateWebhookName = "validate.webhook.stork.libopenstorage.org"
+	storkService        = "stork-service"
+	storkNamespaceEnv   = "STORK-NAMESPACE"
+	defaultNamespace    = "kube-system"
 )
 
 // CreateMutateWebhook create new webhookconfig for stork if not exist already
@@ -53,6 +60,14 @@ func CreateMutateWebhook(caBundle []byte, ns string) error {
 					Resources:   []string{"deployments", "statefulsets", "pods"},
 				},
 			},
+			{
+				Operations: []admissionv1beta1.OperationType{admissionv1beta1.Create},
+				Rule: admissionv1beta1.Rule{
+					APIGroups:   []string{stork.GroupName},
+					APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+					Resources:   []string{stork_api.ApplicationRestoreResourcePlural, stork_api.ApplicationBackupResourcePlural},
+				},
+			},
 		},
 		SideEffects: &sideEffect,
 	}
@@ -79,6 +94,82 @@ func CreateMutateWebhook(caBundle []byte, ns string) error {
 	return nil
 }
 
+// CreateValidateWebhook create new validating webhookconfig for stork if not
+// exist already
+func CreateValidateWebhook(caBundle []byte, ns string) error {
+	client, err := getKubernetesClient()
+	if err != nil {
+		return err
+	}
+	path := validateWebHook
+	sideEffect := admissionv1beta1.SideEffectClassNone
+	webhook := admissionv1beta1.ValidatingWebhook{
+		Name: validateWebhookName,
+		ClientConfig: admissionv1beta1.WebhookClientConfig{
+			Service: &admissionv1beta1.ServiceReference{
+				Name:      storkService,
+				Namespace: ns,
+				Path:      &path,
+			},
+			CABundle: caBundle,
+		},
+		Rules: []admissionv1beta1.RuleWithOperations{
+			{
+				Operations: []admissionv1beta1.OperationType{admissionv1beta1.Create, admissionv1beta1.Update},
+				Rule: admissionv1beta1.Rule{
+					APIGroups:   []string{stork.GroupName},
+					APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+					Resources:   []string{stork_api.GroupVolumeSnapshotResourcePlural},
+				},
+			},
+		},
+		SideEffects: &sideEffect,
+	}
+	req := &admissionv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: storkAdmissionController,
+		},
+		Webhooks: []admissionv1beta1.ValidatingWebhook{webhook},
+	}
+
+	webhookClient := client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations()
+	resp, err := webhookClient.Get(context.TODO(), storkAdmissionController, metav1.GetOptions{})
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			_, err = webhookClient.Create(context.TODO(), req, metav1.CreateOptions{})
+		}
+		return err
+	}
+	req.ResourceVersion = resp.ResourceVersion
+	if _, err := webhookClient.Update(context.TODO(), req, metav1.UpdateOptions{}); err != nil {
+		log.Errorf("unable to update validating webhook configuration: %v", err)
+		return err
+	}
+	log.Debugf("stork validating webhook configured: %v", validateWebhookName)
+	return nil
+}
+
+// DeleteValidateWebhook deletes the validating webhookconfig for stork
+func DeleteValidateWebhook() error {
+	client, err := getKubernetesClient()
+	if err != nil {
+		return err
+	}
+	err = client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Delete(context.TODO(), storkAdmissionController, metav1.DeleteOptions{})
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func getKubernetesClient() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster config: %v", err)
+	}
+	return kubernetes.NewForConfig(config)
+}
+
 // GenerateCertificate Self Signed certificate using given CN, returns x509 cert
 // and priv key in PEM format
 func GenerateCertificate(cn string) ([]byte, []byte, error) {
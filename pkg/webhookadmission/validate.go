@@ -0,0 +1,100 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	admissionv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *Controller) processValidateRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	webhookConfig := &admissionv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: storkAdmissionController,
+		},
+	}
+
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		c.Recorder.Event(webhookConfig, v1.EventTypeWarning, "invalid admission review request", err.Error())
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Result: &metav1.Status{
+			Message: "Successful",
+		},
+		Allowed: true,
+	}
+	switch arReq.Kind.Kind {
+	case "GroupVolumeSnapshot":
+		var groupSnap stork_api.GroupVolumeSnapshot
+		if err := json.Unmarshal(arReq.Object.Raw, &groupSnap); err != nil {
+			log.Errorf("Could not unmarshal admission review object: %v", err)
+			c.Recorder.Event(webhookConfig, v1.EventTypeWarning, "could not unmarshal ar object", err.Error())
+			http.Error(w, "Decode error", http.StatusBadRequest)
+			return
+		}
+		log.Debugf("Received admission review request for group snapshot %s,%s", groupSnap.GetName(), arReq.Namespace)
+		if err := validateGroupVolumeSnapshot(&groupSnap, arReq.Namespace); err != nil {
+			admissionResponse = &v1beta1.AdmissionResponse{
+				Result: &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Reason:  metav1.StatusReasonInvalid,
+					Message: err.Error(),
+				},
+				Allowed: false,
+			}
+		}
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// validateGroupVolumeSnapshot checks the spec for a group snapshot so that
+// invalid objects are rejected when they are created instead of failing
+// during reconcile
+func validateGroupVolumeSnapshot(groupSnap *stork_api.GroupVolumeSnapshot, namespace string) error {
+	if len(groupSnap.Spec.PVCSelector.MatchExpressions) > 0 {
+		return fmt.Errorf("matchExpressions are currently not supported in the spec. Use matchLabels")
+	}
+	if len(groupSnap.Spec.PVCSelector.MatchLabels) == 0 {
+		return fmt.Errorf("matchLabels are required for group snapshots. Refer to spec examples")
+	}
+	if groupSnap.Spec.MaxRetries < 0 {
+		return fmt.Errorf("maxRetries should be greater than or equal to 0")
+	}
+	for _, ruleName := range []string{groupSnap.Spec.PreExecRule, groupSnap.Spec.PostExecRule} {
+		if ruleName == "" {
+			continue
+		}
+		if _, err := storkops.Instance().GetRule(ruleName, namespace); err != nil {
+			return fmt.Errorf("error getting rule %v: %v", ruleName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// +build unittest
+
+package webhookadmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newValidateGroupSnapshot(update func(*stork_api.GroupVolumeSnapshot)) *stork_api.GroupVolumeSnapshot {
+	groupSnap := &stork_api.GroupVolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "ns"},
+		Spec: stork_api.GroupVolumeSnapshotSpec{
+			PVCSelector: stork_api.PVCSelectorSpec{
+				LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+			},
+		},
+	}
+	update(groupSnap)
+	return groupSnap
+}
+
+func setupValidateTest(t *testing.T) {
+	rule := &stork_api.Rule{ObjectMeta: metav1.ObjectMeta{Name: "rule", Namespace: "ns"}}
+	storkops.SetInstance(storkops.New(kubernetes.NewSimpleClientset(), fakeclient.NewSimpleClientset(rule), nil))
+}
+
+func TestValidateGroupVolumeSnapshot(t *testing.T) {
+	setupValidateTest(t)
+	tests := []struct {
+		name    string
+		update  func(*stork_api.GroupVolumeSnapshot)
+		allowed bool
+	}{
+		{name: "valid", update: func(g *stork_api.GroupVolumeSnapshot) {}, allowed: true},
+		{
+			name: "match expressions",
+			update: func(g *stork_api.GroupVolumeSnapshot) {
+				g.Spec.PVCSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "app", Operator: metav1.LabelSelectorOpExists}}
+			},
+		},
+		{
+			name:   "no match labels",
+			update: func(g *stork_api.GroupVolumeSnapshot) { g.Spec.PVCSelector.MatchLabels = nil },
+		},
+		{
+			name:   "negative retries",
+			update: func(g *stork_api.GroupVolumeSnapshot) { g.Spec.MaxRetries = -1 },
+		},
+		{
+			name:    "existing rules",
+			update:  func(g *stork_api.GroupVolumeSnapshot) { g.Spec.PreExecRule = "rule"; g.Spec.PostExecRule = "rule" },
+			allowed: true,
+		},
+		{
+			name:   "missing rule",
+			update: func(g *stork_api.GroupVolumeSnapshot) { g.Spec.PostExecRule = "missing" },
+		},
+	}
+	for _, test := range tests {
+		err := validateGroupVolumeSnapshot(newValidateGroupSnapshot(test.update), "ns")
+		if test.allowed {
+			require.NoError(t, err, test.name)
+		} else {
+			require.Error(t, err, test.name)
+		}
+	}
+}
+
+func TestProcessValidateRequest(t *testing.T) {
+	setupValidateTest(t)
+	c := &Controller{Recorder: record.NewFakeRecorder(10)}
+	tests := []struct {
+		name     string
+		kind     string
+		object   interface{}
+		allowed  bool
+		httpCode int
+	}{
+		{
+			name:     "valid group snapshot",
+			kind:     "GroupVolumeSnapshot",
+			object:   newValidateGroupSnapshot(func(g *stork_api.GroupVolumeSnapshot) {}),
+			allowed:  true,
+			httpCode: http.StatusOK,
+		},
+		{
+			name:     "invalid group snapshot",
+			kind:     "GroupVolumeSnapshot",
+			object:   newValidateGroupSnapshot(func(g *stork_api.GroupVolumeSnapshot) { g.Spec.MaxRetries = -1 }),
+			httpCode: http.StatusOK,
+		},
+		{
+			name:     "other kinds are allowed",
+			kind:     "ApplicationBackup",
+			object:   &stork_api.ApplicationBackup{},
+			allowed:  true,
+			httpCode: http.StatusOK,
+		},
+		{
+			name:     "invalid object",
+			kind:     "GroupVolumeSnapshot",
+			object:   []string{"invalid"},
+			httpCode: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		raw, err := json.Marshal(test.object)
+		require.NoError(t, err, test.name)
+		review := v1beta1.AdmissionReview{
+			Request: &v1beta1.AdmissionRequest{
+				UID:       "uid",
+				Kind:      metav1.GroupVersionKind{Group: "stork.libopenstorage.org", Version: "v1alpha1", Kind: test.kind},
+				Namespace: "ns",
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		}
+		body, err := json.Marshal(review)
+		require.NoError(t, err, test.name)
+
+		recorder := httptest.NewRecorder()
+		c.processValidateRequest(recorder, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+		require.Equal(t, test.httpCode, recorder.Code, test.name)
+		if test.httpCode != http.StatusOK {
+			continue
+		}
+		var response v1beta1.AdmissionReview
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response), test.name)
+		require.Equal(t, test.allowed, response.Response.Allowed, test.name)
+		require.Equal(t, review.Request.UID, response.Response.UID, test.name)
+	}
+
+	// Requests that can't be decoded are rejected
+	recorder := httptest.NewRecorder()
+	c.processValidateRequest(recorder, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader([]byte("invalid"))))
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/portworx/sched-ops/k8s/admissionregistration"
 	"github.com/portworx/sched-ops/k8s/core"
 	log "github.com/sirupsen/logrus"
@@ -54,6 +55,8 @@ type Controller struct {
 func (c *Controller) serveHTTP(w http.ResponseWriter, req *http.Request) {
 	if strings.Contains(req.URL.Path, mutateWebHook) {
 		c.processMutateRequest(w, req)
+	} else if strings.Contains(req.URL.Path, validateWebHook) {
+		c.processValidateRequest(w, req)
 	} else {
 		http.Error(w, "Unsupported request", http.StatusNotFound)
 	}
@@ -63,6 +66,7 @@ func (c *Controller) processMutateRequest(w http.ResponseWriter, req *http.Reque
 	var admissionResponse *v1beta1.AdmissionResponse
 	var err error
 	var schedPath string
+	var patch []byte
 	admissionReview := v1beta1.AdmissionReview{}
 	isStorkResource := false
 	skipHookAnnotation := defaultSkipAnnotation
@@ -148,9 +152,33 @@ func (c *Controller) processMutateRequest(w http.ResponseWriter, req *http.Reque
 			}
 			schedPath = podSpecSchedPath
 		}
+	case "ApplicationRestore":
+		var restore stork_api.ApplicationRestore
+		if err := json.Unmarshal(arReq.Object.Raw, &restore); err != nil {
+			log.Errorf("Could not unmarshal admission review object: %v", err)
+			c.Recorder.Event(webhookConfig, v1.EventTypeWarning, "could not unmarshal ar object", err.Error())
+			http.Error(w, "Decode error", http.StatusBadRequest)
+			return
+		}
+		log.Debugf("Received admission review request for restore %s,%s", restore.GetName(), arReq.Namespace)
+		patch = createCreatedByPatch(restore.Annotations, arReq.UserInfo.Username)
+	case "ApplicationBackup":
+		var backup stork_api.ApplicationBackup
+		if err := json.Unmarshal(arReq.Object.Raw, &backup); err != nil {
+			log.Errorf("Could not unmarshal admission review object: %v", err)
+			c.Recorder.Event(webhookConfig, v1.EventTypeWarning, "could not unmarshal ar object", err.Error())
+			http.Error(w, "Decode error", http.StatusBadRequest)
+			return
+		}
+		log.Debugf("Received admission review request for backup %s,%s", backup.GetName(), arReq.Namespace)
+		patch = createCreatedByPatch(backup.Annotations, arReq.UserInfo.Username)
 	}
 
-	if !isStorkResource {
+	if isStorkResource {
+		log.Debugf("Updating scheduler to stork for Resource:%s, Name: %s, Namespace:%s", arReq.Kind.Kind, resourceName, arReq.Namespace)
+		patch = createPatch(schedPath)
+	}
+	if patch == nil {
 		// ignore for non driver application + resources other than depoy/ss
 		admissionResponse = &v1beta1.AdmissionResponse{
 			Result: &metav1.Status{
@@ -159,9 +187,6 @@ func (c *Controller) processMutateRequest(w http.ResponseWriter, req *http.Reque
 			Allowed: true,
 		}
 	} else {
-		// create patch
-		log.Debugf("Updating scheduler to stork for Resource:%s, Name: %s, Namespace:%s", arReq.Kind.Kind, resourceName, arReq.Namespace)
-		patch := createPatch(schedPath)
 		admissionResponse = &v1beta1.AdmissionResponse{
 			Result: &metav1.Status{
 				Message: "Successful",
@@ -259,7 +284,8 @@ func (c *Controller) Start() error {
 	c.server = &http.Server{Addr: ":443",
 		TLSConfig: &tls.Config{Certificates: []tls.Certificate{tlsCert}}}
 
-	http.HandleFunc("/mutate", c.serveHTTP)
+	http.HandleFunc(mutateWebHook, c.serveHTTP)
+	http.HandleFunc(validateWebHook, c.serveHTTP)
 	go func() {
 		if err := c.server.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
 			log.Errorf("Error starting webhook server: %v", err)
@@ -267,7 +293,10 @@ func (c *Controller) Start() error {
 	}()
 	c.started = true
 	log.Debugf("Webhook server started")
-	return CreateMutateWebhook(caBundle, ns)
+	if err := CreateMutateWebhook(caBundle, ns); err != nil {
+		return err
+	}
+	return CreateValidateWebhook(caBundle, ns)
 }
 
 // Stop Stops the webhook server
@@ -282,6 +311,10 @@ func (c *Controller) Stop() error {
 		log.Errorf("unable to delete webhook configuration, %v", err)
 		return err
 	}
+	if err := DeleteValidateWebhook(); err != nil {
+		log.Errorf("unable to delete validating webhook configuration, %v", err)
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -308,6 +341,31 @@ func createPatch(schedpath string) []byte {
 	return b
 }
 
+// createCreatedByPatch creates a json patch to set the annotation with the
+// user that created the object. Overwrites any existing value so that it
+// can't be set by the user.
+func createCreatedByPatch(annotations map[string]string, username string) []byte {
+	p := []map[string]interface{}{}
+	if annotations == nil {
+		p = append(p, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations",
+			"value": map[string]string{stork_api.CreatedByAnnotation: username},
+		})
+	} else {
+		p = append(p, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations/" + strings.Replace(stork_api.CreatedByAnnotation, "/", "~1", -1),
+			"value": username,
+		})
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		log.Errorf("could not marshal patch: %v", err)
+	}
+	return b
+}
+
 func skipSchedulerUpdate(skipHookAnnotation string, annotations map[string]string) bool {
 	if annotations != nil {
 		if value, ok := annotations[skipHookAnnotation]; ok {
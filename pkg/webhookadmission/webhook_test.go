@@ -0,0 +1,79 @@
+// +build unittest
+
+package webhookadmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMutateCreatedBy(t *testing.T) {
+	c := &Controller{Recorder: record.NewFakeRecorder(10)}
+	tests := []struct {
+		kind        string
+		object      interface{}
+		annotations bool
+	}{
+		{
+			kind:   "ApplicationBackup",
+			object: &stork_api.ApplicationBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"}},
+		},
+		{
+			kind: "ApplicationBackup",
+			object: &stork_api.ApplicationBackup{ObjectMeta: metav1.ObjectMeta{
+				Name:        "backup",
+				Namespace:   "ns",
+				Annotations: map[string]string{stork_api.CreatedByAnnotation: "spoofed"},
+			}},
+			annotations: true,
+		},
+		{
+			kind:   "ApplicationRestore",
+			object: &stork_api.ApplicationRestore{ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "ns"}},
+		},
+	}
+	for _, test := range tests {
+		raw, err := json.Marshal(test.object)
+		require.NoError(t, err)
+		review := v1beta1.AdmissionReview{
+			Request: &v1beta1.AdmissionRequest{
+				UID:       "uid",
+				Kind:      metav1.GroupVersionKind{Group: "stork.libopenstorage.org", Version: "v1alpha1", Kind: test.kind},
+				Namespace: "ns",
+				Object:    runtime.RawExtension{Raw: raw},
+				UserInfo:  authenticationv1.UserInfo{Username: "user"},
+			},
+		}
+		body, err := json.Marshal(review)
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		c.processMutateRequest(recorder, httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, recorder.Code, test.kind)
+		var response v1beta1.AdmissionReview
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response), test.kind)
+		require.True(t, response.Response.Allowed, test.kind)
+
+		var patch []map[string]interface{}
+		require.NoError(t, json.Unmarshal(response.Response.Patch, &patch), test.kind)
+		require.Len(t, patch, 1, test.kind)
+		if test.annotations {
+			require.Equal(t, "/metadata/annotations/stork.libopenstorage.org~1created-by", patch[0]["path"], test.kind)
+			require.Equal(t, "user", patch[0]["value"], test.kind)
+		} else {
+			require.Equal(t, "/metadata/annotations", patch[0]["path"], test.kind)
+			require.Equal(t, map[string]interface{}{stork_api.CreatedByAnnotation: "user"}, patch[0]["value"], test.kind)
+		}
+	}
+}